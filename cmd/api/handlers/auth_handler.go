@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 
+	"agro-mas-backend/internal/auth"
+	"agro-mas-backend/internal/marketplace/products"
 	"agro-mas-backend/internal/marketplace/users"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -46,6 +50,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		case users.ErrInvalidRole:
 			status = http.StatusBadRequest
 			code = "INVALID_ROLE"
+		default:
+			if errors.Is(err, products.ErrCoordinatesOutOfRange) {
+				status = http.StatusBadRequest
+				code = "COORDINATES_OUT_OF_RANGE"
+			}
 		}
 
 		c.JSON(status, gin.H{
@@ -115,6 +124,14 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 
 	user, err := h.userService.GetUserByID(c.Request.Context(), userID.(uuid.UUID))
 	if err != nil {
+		if err == users.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+				"code":  "USER_NOT_FOUND",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get user profile",
 			"code":  "PROFILE_FETCH_FAILED",
@@ -150,6 +167,14 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 
 	user, err := h.userService.UpdateUser(c.Request.Context(), userID.(uuid.UUID), &req)
 	if err != nil {
+		if errors.Is(err, products.ErrCoordinatesOutOfRange) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "COORDINATES_OUT_OF_RANGE",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update profile",
 			"code":  "PROFILE_UPDATE_FAILED",
@@ -242,22 +267,523 @@ func (h *AuthHandler) GetPublicProfile(c *gin.Context) {
 	})
 }
 
-// Logout handles user logout (client-side token invalidation)
+// RefreshToken exchanges a refresh token for a new token pair, rotating the refresh token
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tokenResponse, user, err := h.userService.RefreshAccessToken(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if err == users.ErrInvalidRefreshToken {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_REFRESH_TOKEN",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to refresh token",
+			"code":  "TOKEN_REFRESH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":  user.ToResponse(),
+		"token": tokenResponse,
+	})
+}
+
+// DeleteAccount deletes the current user's account once they confirm their password
+func (h *AuthHandler) DeleteAccount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req users.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.userService.DeleteAccount(c.Request.Context(), userID.(uuid.UUID), &req)
+	if err != nil {
+		switch err {
+		case users.ErrInvalidPassword:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_PASSWORD",
+			})
+		case users.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+				"code":  "USER_NOT_FOUND",
+			})
+		case users.ErrActiveTransactions:
+			c.JSON(http.StatusConflict, gin.H{
+				"error":               err.Error(),
+				"code":                "ACTIVE_TRANSACTIONS",
+				"active_transactions": result.BlockingTransactions,
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to delete account",
+				"code":  "ACCOUNT_DELETION_FAILED",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account deleted successfully",
+		"result":  result,
+	})
+}
+
+// CreateWebhook registers an endpoint for the current user's transaction
+// events. The signing secret is only shown in this response.
+func (h *AuthHandler) CreateWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req users.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	webhook, err := h.userService.CreateWebhook(c.Request.Context(), userID.(uuid.UUID), &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "WEBHOOK_CREATE_FAILED"
+
+		switch err {
+		case users.ErrInvalidWebhookURL:
+			status = http.StatusBadRequest
+			code = "INVALID_WEBHOOK_URL"
+		case users.ErrWebhookLimitReached:
+			status = http.StatusConflict
+			code = "WEBHOOK_LIMIT_REACHED"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook created successfully",
+		"webhook": webhook,
+	})
+}
+
+// ListWebhooks returns the current user's webhooks
+func (h *AuthHandler) ListWebhooks(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	webhooks, err := h.userService.ListWebhooks(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list webhooks",
+			"code":  "WEBHOOKS_FETCH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": webhooks,
+	})
+}
+
+// DeleteWebhook removes one of the current user's webhooks
+func (h *AuthHandler) DeleteWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid webhook ID format",
+			"code":  "INVALID_WEBHOOK_ID",
+		})
+		return
+	}
+
+	if err := h.userService.DeleteWebhook(c.Request.Context(), userID.(uuid.UUID), webhookID); err != nil {
+		if err == users.ErrWebhookNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+				"code":  "WEBHOOK_NOT_FOUND",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete webhook",
+			"code":  "WEBHOOK_DELETE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// Logout handles user logout. The bearer access token, when sent, is revoked
+// until it expires, and so is the refresh token so the session cannot be renewed.
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// Since we're using stateless JWT, logout is primarily client-side
-	// In a production system, you might want to implement token blacklisting
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if accessToken, err := auth.ExtractTokenFromHeader(c.GetHeader("Authorization")); err == nil {
+		if err := h.userService.RevokeAccessToken(c.Request.Context(), accessToken); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to revoke access token",
+				"code":  "LOGOUT_FAILED",
+			})
+			return
+		}
+	}
+
+	if req.RefreshToken != "" {
+		if err := h.userService.RevokeRefreshToken(c.Request.Context(), req.RefreshToken); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to revoke refresh token",
+				"code":  "LOGOUT_FAILED",
+			})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Logged out successfully",
 	})
 }
 
+// VerifyEmail consumes an email verification token
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.userService.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		status := http.StatusInternalServerError
+		code := "EMAIL_VERIFICATION_FAILED"
+
+		switch err {
+		case users.ErrInvalidVerificationToken:
+			status = http.StatusBadRequest
+			code = "INVALID_VERIFICATION_TOKEN"
+		case users.ErrVerificationTokenExpired:
+			status = http.StatusGone
+			code = "VERIFICATION_TOKEN_EXPIRED"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email verified successfully",
+	})
+}
+
+// ResendVerification sends a new email verification link. The response is the
+// same whether the email is unknown, pending or already verified.
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.userService.ResendEmailVerification(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to send verification email",
+			"code":  "VERIFICATION_RESEND_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If the email is registered, a verification link has been sent",
+	})
+}
+
+// ForgotPassword sends a password reset link. It always answers 200 before
+// the link is sent, so neither the response nor its timing tells which
+// emails are registered.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	h.userService.RequestPasswordReset(c.Request.Context(), req.Email)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If the email is registered, a password reset link has been sent",
+	})
+}
+
+// ResetPassword sets a new password using a reset token
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=8"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.userService.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		status := http.StatusInternalServerError
+		code := "PASSWORD_RESET_FAILED"
+
+		switch {
+		case err == users.ErrInvalidResetToken:
+			status = http.StatusBadRequest
+			code = "INVALID_RESET_TOKEN"
+		case errors.Is(err, users.ErrWeakPassword):
+			status = http.StatusBadRequest
+			code = "WEAK_PASSWORD"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password reset successfully",
+	})
+}
+
+// GetNotificationPreferences returns the current user's notification settings per event and channel
+func (h *AuthHandler) GetNotificationPreferences(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	preferences, err := h.userService.GetNotificationPreferences(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "NOTIFICATION_PREFERENCES_FETCH_FAILED"
+
+		if err == users.ErrUserNotFound {
+			status = http.StatusNotFound
+			code = "USER_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdateNotificationPreferences toggles notification channels per event for the current user
+func (h *AuthHandler) UpdateNotificationPreferences(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req users.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	preferences, err := h.userService.UpdateNotificationPreferences(c.Request.Context(), userID.(uuid.UUID), &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "NOTIFICATION_PREFERENCES_UPDATE_FAILED"
+
+		switch err {
+		case users.ErrInvalidNotificationSetting:
+			status = http.StatusBadRequest
+			code = "INVALID_NOTIFICATION_SETTING"
+		case users.ErrUserNotFound:
+			status = http.StatusNotFound
+			code = "USER_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Notification preferences updated successfully",
+		"settings": preferences.Settings,
+	})
+}
+
+// UpdatePreferences updates the current user's general preferences
+func (h *AuthHandler) UpdatePreferences(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	var req users.UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	preferences, err := h.userService.UpdatePreferences(c.Request.Context(), userID.(uuid.UUID), &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "PREFERENCES_UPDATE_FAILED"
+
+		if err == users.ErrUserNotFound {
+			status = http.StatusNotFound
+			code = "USER_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Preferences updated successfully",
+		"preferences": preferences,
+	})
+}
+
 // RegisterRoutes registers authentication routes
 func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
 	auth := router.Group("/auth")
 	{
 		auth.POST("/register", h.Register)
 		auth.POST("/login", h.Login)
+		auth.POST("/refresh", h.RefreshToken)
 		auth.POST("/logout", h.Logout)
+		auth.POST("/verify-email", h.VerifyEmail)
+		auth.POST("/resend-verification", h.ResendVerification)
+		auth.POST("/forgot-password", h.ForgotPassword)
+		auth.POST("/reset-password", h.ResetPassword)
 		
 		// Protected routes
 		protected := auth.Group("/")
@@ -273,5 +799,20 @@ func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin
 	users := router.Group("/users")
 	{
 		users.GET("/:id", h.GetPublicProfile)
+
+		// Current user routes
+		me := users.Group("/me")
+		me.Use(authMiddleware)
+		{
+			me.GET("", h.GetProfile)
+			me.PUT("", h.UpdateProfile)
+			me.DELETE("", h.DeleteAccount)
+			me.GET("/webhooks", h.ListWebhooks)
+			me.POST("/webhooks", h.CreateWebhook)
+			me.DELETE("/webhooks/:id", h.DeleteWebhook)
+			me.PUT("/preferences", h.UpdatePreferences)
+			me.GET("/notification-preferences", h.GetNotificationPreferences)
+			me.PUT("/notification-preferences", h.UpdateNotificationPreferences)
+		}
 	}
 }
\ No newline at end of file
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"agro-mas-backend/internal/marketplace/users"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type DocumentsHandler struct {
+	documentService *users.DocumentService
+}
+
+func NewDocumentsHandler(documentService *users.DocumentService) *DocumentsHandler {
+	return &DocumentsHandler{
+		documentService: documentService,
+	}
+}
+
+// UploadDocument stores a private verification document for the current user
+func (h *DocumentsHandler) UploadDocument(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No document file provided",
+			"code":  "NO_DOCUMENT_FILE",
+		})
+		return
+	}
+	defer file.Close()
+
+	document, err := h.documentService.UploadDocument(c.Request.Context(), userID.(uuid.UUID), c.Param("type"), file, header)
+	if err != nil {
+		respondDocumentError(c, err, "DOCUMENT_UPLOAD_FAILED")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Document uploaded successfully",
+		"document": document,
+	})
+}
+
+// GetDocumentURL returns a short-lived signed URL for one of the current
+// user's verification documents
+func (h *DocumentsHandler) GetDocumentURL(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	h.respondDocumentURL(c, userID.(uuid.UUID))
+}
+
+// GetUserDocumentURL lets an admin fetch a signed URL for any user's document
+func (h *DocumentsHandler) GetUserDocumentURL(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	h.respondDocumentURL(c, userID)
+}
+
+func (h *DocumentsHandler) respondDocumentURL(c *gin.Context, ownerID uuid.UUID) {
+	requesterID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+	role, _ := c.Get("user_role")
+	requesterRole, _ := role.(string)
+
+	response, err := h.documentService.GetDocumentURL(c.Request.Context(), requesterID.(uuid.UUID), requesterRole, ownerID, c.Param("type"))
+	if err != nil {
+		respondDocumentError(c, err, "DOCUMENT_URL_FAILED")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetVerificationQueue lists users with verification documents awaiting review
+func (h *DocumentsHandler) GetVerificationQueue(c *gin.Context) {
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+	response, err := h.documentService.GetVerificationQueue(c.Request.Context(), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+			"code":  "VERIFICATION_QUEUE_FETCH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ReviewDocument approves or rejects one of a user's verification documents
+func (h *DocumentsHandler) ReviewDocument(c *gin.Context) {
+	reviewerID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	var req users.ReviewDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.documentService.ReviewDocument(c.Request.Context(), reviewerID.(uuid.UUID), userID, c.Param("type"), &req)
+	if err != nil {
+		respondDocumentError(c, err, "DOCUMENT_REVIEW_FAILED")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func respondDocumentError(c *gin.Context, err error, defaultCode string) {
+	status := http.StatusInternalServerError
+	code := defaultCode
+
+	switch {
+	case errors.Is(err, users.ErrInvalidDocumentType):
+		status = http.StatusBadRequest
+		code = "INVALID_DOCUMENT_TYPE"
+	case errors.Is(err, users.ErrInvalidReviewStatus):
+		status = http.StatusBadRequest
+		code = "INVALID_REVIEW_STATUS"
+	case errors.Is(err, users.ErrInvalidDocumentFile):
+		status = http.StatusBadRequest
+		code = "INVALID_DOCUMENT_FILE"
+	case errors.Is(err, users.ErrDocumentAccessDenied):
+		status = http.StatusForbidden
+		code = "DOCUMENT_ACCESS_DENIED"
+	case errors.Is(err, users.ErrUserNotFound):
+		status = http.StatusNotFound
+		code = "USER_NOT_FOUND"
+	case errors.Is(err, users.ErrDocumentNotFound):
+		status = http.StatusNotFound
+		code = "DOCUMENT_NOT_FOUND"
+	case errors.Is(err, users.ErrDocumentStorageMissing):
+		status = http.StatusServiceUnavailable
+		code = "STORAGE_UNAVAILABLE"
+	}
+
+	c.JSON(status, gin.H{
+		"error": err.Error(),
+		"code":  code,
+	})
+}
+
+// RegisterRoutes registers verification document routes
+func (h *DocumentsHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware, adminMiddleware gin.HandlerFunc) {
+	me := router.Group("/users/me/documents")
+	me.Use(authMiddleware)
+	{
+		me.POST("/:type", h.UploadDocument)
+		me.GET("/:type/url", h.GetDocumentURL)
+	}
+
+	admin := router.Group("/admin")
+	admin.Use(authMiddleware, adminMiddleware)
+	{
+		admin.GET("/verification-queue", h.GetVerificationQueue)
+		admin.GET("/users/:id/documents/:type/url", h.GetUserDocumentURL)
+		admin.POST("/users/:id/documents/:type/review", h.ReviewDocument)
+	}
+}
@@ -1,10 +1,17 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"agro-mas-backend/internal/marketplace/products"
+	"agro-mas-backend/internal/marketplace/users"
+	"agro-mas-backend/pkg/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -12,12 +19,27 @@ import (
 type ProductsHandler struct {
 	productService *products.Service
 	imageService   *products.ImageService
+	userService    *users.Service
+
+	// Multipart bytes kept in memory when parsing image uploads
+	maxMultipartMemory int64
 }
 
-func NewProductsHandler(productService *products.Service, imageService *products.ImageService) *ProductsHandler {
+func NewProductsHandler(productService *products.Service, imageService *products.ImageService, userService *users.Service) *ProductsHandler {
 	return &ProductsHandler{
 		productService: productService,
 		imageService:   imageService,
+		userService:    userService,
+
+		maxMultipartMemory: 64 << 20,
+	}
+}
+
+// SetMaxMultipartMemory configures how much of an image upload form is kept
+// in memory before spilling to temporary files
+func (h *ProductsHandler) SetMaxMultipartMemory(n int64) {
+	if n > 0 {
+		h.maxMultipartMemory = n
 	}
 }
 
@@ -42,16 +64,12 @@ func (h *ProductsHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	// Get seller information from context
-	sellerInfo := products.SellerInfo{
-		Name:              "Seller Name", // This would come from user service
-		Phone:             "Seller Phone",
-		Rating:            4.5,
-		VerificationLevel: 2,
-	}
-
-	product, err := h.productService.CreateProduct(c.Request.Context(), userID.(uuid.UUID), &req, sellerInfo)
+	product, err := h.productService.CreateProduct(c.Request.Context(), userID.(uuid.UUID), &req)
 	if err != nil {
+		if respondProductLimit(c, err) {
+			return
+		}
+
 		status := http.StatusInternalServerError
 		code := "PRODUCT_CREATION_FAILED"
 
@@ -62,6 +80,20 @@ func (h *ProductsHandler) CreateProduct(c *gin.Context) {
 		case products.ErrInvalidPriceType:
 			status = http.StatusBadRequest
 			code = "INVALID_PRICE_TYPE"
+		case products.ErrInvalidMinOrderQuantity:
+			status = http.StatusBadRequest
+			code = "INVALID_MIN_ORDER_QUANTITY"
+		case products.ErrInvalidExpiry:
+			status = http.StatusBadRequest
+			code = "INVALID_EXPIRY"
+		case products.ErrSellerNotFound:
+			status = http.StatusForbidden
+			code = "SELLER_NOT_FOUND"
+		default:
+			if errors.Is(err, products.ErrCoordinatesOutOfRange) {
+				status = http.StatusBadRequest
+				code = "COORDINATES_OUT_OF_RANGE"
+			}
 		}
 
 		c.JSON(status, gin.H{
@@ -71,13 +103,38 @@ func (h *ProductsHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	message := "Product created successfully"
+	if product.Status == products.ProductStatusDraft {
+		message = "Product saved as draft"
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Product created successfully",
+		"message": message,
 		"product": product,
 	})
 }
 
-// GetProduct retrieves a product by ID
+// respondProductLimit answers 403 with the seller's active count and cap when
+// err is a ProductLimitError, reporting whether it did
+func respondProductLimit(c *gin.Context, err error) bool {
+	var limitErr *products.ProductLimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":              err.Error(),
+		"code":               "PRODUCT_LIMIT_REACHED",
+		"active_products":    limitErr.ActiveProducts,
+		"limit":              limitErr.Limit,
+		"verification_level": limitErr.VerificationLevel,
+	})
+	return true
+}
+
+// GetProduct retrieves a product by ID. Responses carry an ETag and a
+// matching If-None-Match gets a 304; the view is still counted in that case,
+// since the client is displaying the listing either way.
 func (h *ProductsHandler) GetProduct(c *gin.Context) {
 	productIDStr := c.Param("id")
 	productID, err := uuid.Parse(productIDStr)
@@ -107,15 +164,87 @@ func (h *ProductsHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
+	h.enrichSellers(c, []*products.Product{product})
+
+	// The ETag covers exactly what is sent, seller details included
+	body, err := json.Marshal(gin.H{"product": product})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to encode product",
+			"code":  "PRODUCT_ENCODE_FAILED",
+		})
+		return
+	}
+
+	if notModified(c, products.BodyETag(body)) {
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// GetProductImages lists a product's images, honouring If-None-Match
+func (h *ProductsHandler) GetProductImages(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	if _, err := h.productService.GetProductByID(c.Request.Context(), productID, false); err != nil {
+		status := http.StatusNotFound
+		if err != products.ErrProductNotFound {
+			status = http.StatusInternalServerError
+		}
+
+		c.JSON(status, gin.H{
+			"error": "Product not found",
+			"code":  "PRODUCT_NOT_FOUND",
+		})
+		return
+	}
+
+	images, err := h.imageService.GetProductImages(c.Request.Context(), productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get product images",
+			"code":  "IMAGES_FETCH_FAILED",
+		})
+		return
+	}
+
+	if notModified(c, products.ImagesETag(productID, images)) {
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"product": product,
+		"images": images,
 	})
 }
 
+// notModified sets the ETag header and answers 304 when the request's
+// If-None-Match already names it. Comparison is weak, as for GET in RFC 9110.
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
 // SearchProducts handles product search
 func (h *ProductsHandler) SearchProducts(c *gin.Context) {
 	req := &products.ProductSearchRequest{
 		Query:             c.Query("query"),
+		Language:          c.Query("language"),
 		Category:          c.Query("category"),
 		Subcategory:       c.Query("subcategory"),
 		Province:          c.Query("province"),
@@ -156,6 +285,103 @@ func (h *ProductsHandler) SearchProducts(c *gin.Context) {
 		}
 	}
 
+	// Category-specific boolean filters (livestock / transport only)
+	if organicStr := c.Query("is_organic"); organicStr != "" {
+		if organic, err := strconv.ParseBool(organicStr); err == nil {
+			req.IsOrganic = &organic
+		}
+	}
+
+	if pregnantStr := c.Query("is_pregnant"); pregnantStr != "" {
+		if pregnant, err := strconv.ParseBool(pregnantStr); err == nil {
+			req.IsPregnant = &pregnant
+		}
+	}
+
+	if refrigerationStr := c.Query("has_refrigeration"); refrigerationStr != "" {
+		if refrigeration, err := strconv.ParseBool(refrigerationStr); err == nil {
+			req.HasRefrigeration = &refrigeration
+		}
+	}
+
+	if equipmentStr := c.Query("has_livestock_equipment"); equipmentStr != "" {
+		if equipment, err := strconv.ParseBool(equipmentStr); err == nil {
+			req.HasLivestockEquipment = &equipment
+		}
+	}
+
+	// Livestock range filters
+	if minAgeStr := c.Query("min_age_months"); minAgeStr != "" {
+		if minAge, err := strconv.Atoi(minAgeStr); err == nil {
+			req.MinAgeMonths = &minAge
+		}
+	}
+
+	if maxAgeStr := c.Query("max_age_months"); maxAgeStr != "" {
+		if maxAge, err := strconv.Atoi(maxAgeStr); err == nil {
+			req.MaxAgeMonths = &maxAge
+		}
+	}
+
+	if minWeightStr := c.Query("min_weight_kg"); minWeightStr != "" {
+		if minWeight, err := strconv.ParseFloat(minWeightStr, 64); err == nil {
+			req.MinWeightKg = &minWeight
+		}
+	}
+
+	if maxWeightStr := c.Query("max_weight_kg"); maxWeightStr != "" {
+		if maxWeight, err := strconv.ParseFloat(maxWeightStr, 64); err == nil {
+			req.MaxWeightKg = &maxWeight
+		}
+	}
+
+	// Transport capacity and route filters
+	if minTonsStr := c.Query("min_capacity_tons"); minTonsStr != "" {
+		if minTons, err := strconv.ParseFloat(minTonsStr, 64); err == nil {
+			req.MinCapacityTons = &minTons
+		}
+	}
+
+	if minVolumeStr := c.Query("min_capacity_cubic_meters"); minVolumeStr != "" {
+		if minVolume, err := strconv.ParseFloat(minVolumeStr, 64); err == nil {
+			req.MinCapacityCubicMeters = &minVolume
+		}
+	}
+
+	req.ServiceProvince = c.Query("service_province")
+
+	// Buyer location for delivery reach and distances
+	if buyerLatStr := c.Query("buyer_lat"); buyerLatStr != "" {
+		if buyerLat, err := strconv.ParseFloat(buyerLatStr, 64); err == nil {
+			req.BuyerLat = &buyerLat
+		}
+	}
+
+	if buyerLngStr := c.Query("buyer_lng"); buyerLngStr != "" {
+		if buyerLng, err := strconv.ParseFloat(buyerLngStr, 64); err == nil {
+			req.BuyerLng = &buyerLng
+		}
+	}
+
+	if availableOnStr := c.Query("available_on"); availableOnStr != "" {
+		if availableOn, err := time.Parse("2006-01-02", availableOnStr); err == nil {
+			req.AvailableOn = &availableOn
+		}
+	}
+
+	// Supplies shelf-life filters
+	if expiringAfterStr := c.Query("expiring_after"); expiringAfterStr != "" {
+		if expiringAfter, err := time.Parse("2006-01-02", expiringAfterStr); err == nil {
+			req.ExpiringAfter = &expiringAfter
+		}
+	}
+
+	if expiringBeforeStr := c.Query("expiring_before"); expiringBeforeStr != "" {
+		if expiringBefore, err := time.Parse("2006-01-02", expiringBeforeStr); err == nil {
+			req.ExpiringBefore = &expiringBefore
+		}
+	}
+
 	// Parse pagination
 	if pageStr := c.Query("page"); pageStr != "" {
 		if page, err := strconv.Atoi(pageStr); err == nil {
@@ -169,13 +395,65 @@ func (h *ProductsHandler) SearchProducts(c *gin.Context) {
 		}
 	}
 
+	// Repeated category params search several categories at once
+	if categories := c.QueryArray("category"); len(categories) > 1 {
+		req.Categories = categories
+	}
+
 	// Parse tags
 	if tags := c.QueryArray("tags"); len(tags) > 0 {
 		req.Tags = tags
 	}
+	req.TagMatch = c.Query("tag_match")
+	req.Cursor = c.Query("cursor")
 
 	response, err := h.productService.SearchProducts(c.Request.Context(), req)
 	if err != nil {
+		switch err {
+		case products.ErrInvalidSearchRange:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_SEARCH_RANGE",
+			})
+			return
+		case products.ErrInvalidTagMatch:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_TAG_MATCH",
+			})
+			return
+		case products.ErrInvalidCursor:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_CURSOR",
+			})
+			return
+		case products.ErrInvalidSearchLanguage:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_SEARCH_LANGUAGE",
+			})
+			return
+		case products.ErrIncompleteBuyerLocation:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "INCOMPLETE_BUYER_LOCATION",
+			})
+			return
+		case products.ErrInvalidQuery:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_QUERY",
+			})
+			return
+		}
+		if errors.Is(err, products.ErrCoordinatesOutOfRange) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "COORDINATES_OUT_OF_RANGE",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to search products",
 			"code":  "SEARCH_FAILED",
@@ -184,9 +462,49 @@ func (h *ProductsHandler) SearchProducts(c *gin.Context) {
 		return
 	}
 
+	results := make([]*products.Product, len(response.Products))
+	for i := range response.Products {
+		results[i] = &response.Products[i]
+	}
+	h.enrichSellers(c, results)
+
 	c.JSON(http.StatusOK, response)
 }
 
+// enrichSellers replaces the seller information denormalized on the products
+// with the sellers' current profiles, loaded in a single query. On failure the
+// stored values are kept.
+func (h *ProductsHandler) enrichSellers(c *gin.Context, items []*products.Product) {
+	if len(items) == 0 {
+		return
+	}
+
+	sellerIDs := make([]uuid.UUID, 0, len(items))
+	for _, product := range items {
+		sellerIDs = append(sellerIDs, product.UserID)
+	}
+
+	sellers, err := h.userService.GetUsersByIDs(c.Request.Context(), sellerIDs)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Warn("failed to load sellers", "error", err)
+		return
+	}
+
+	for _, product := range items {
+		seller, ok := sellers[product.UserID]
+		if !ok {
+			continue
+		}
+		name := seller.DisplayName()
+		rating := seller.Rating
+		level := seller.VerificationLevel
+		product.SellerName = &name
+		product.SellerPhone = seller.Phone
+		product.SellerRating = &rating
+		product.SellerVerificationLevel = &level
+	}
+}
+
 // UpdateProduct handles product updates
 func (h *ProductsHandler) UpdateProduct(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -220,6 +538,10 @@ func (h *ProductsHandler) UpdateProduct(c *gin.Context) {
 
 	product, err := h.productService.UpdateProduct(c.Request.Context(), userID.(uuid.UUID), productID, &req)
 	if err != nil {
+		if respondProductLimit(c, err) {
+			return
+		}
+
 		status := http.StatusInternalServerError
 		code := "PRODUCT_UPDATE_FAILED"
 
@@ -230,6 +552,23 @@ func (h *ProductsHandler) UpdateProduct(c *gin.Context) {
 		case products.ErrProductNotOwnedByUser:
 			status = http.StatusForbidden
 			code = "NOT_PRODUCT_OWNER"
+		case products.ErrInvalidPriceType:
+			status = http.StatusBadRequest
+			code = "INVALID_PRICE_TYPE"
+		case products.ErrInvalidMinOrderQuantity:
+			status = http.StatusBadRequest
+			code = "INVALID_MIN_ORDER_QUANTITY"
+		case products.ErrInvalidExpiry:
+			status = http.StatusBadRequest
+			code = "INVALID_EXPIRY"
+		case products.ErrDetailsCategoryMismatch:
+			status = http.StatusBadRequest
+			code = "DETAILS_CATEGORY_MISMATCH"
+		default:
+			if errors.Is(err, products.ErrCoordinatesOutOfRange) {
+				status = http.StatusBadRequest
+				code = "COORDINATES_OUT_OF_RANGE"
+			}
 		}
 
 		c.JSON(status, gin.H{
@@ -245,8 +584,8 @@ func (h *ProductsHandler) UpdateProduct(c *gin.Context) {
 	})
 }
 
-// PublishProduct publishes a product
-func (h *ProductsHandler) PublishProduct(c *gin.Context) {
+// UpdateProductDetails replaces the category-specific details of a product
+func (h *ProductsHandler) UpdateProductDetails(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -256,8 +595,7 @@ func (h *ProductsHandler) PublishProduct(c *gin.Context) {
 		return
 	}
 
-	productIDStr := c.Param("id")
-	productID, err := uuid.Parse(productIDStr)
+	productID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid product ID format",
@@ -266,10 +604,20 @@ func (h *ProductsHandler) PublishProduct(c *gin.Context) {
 		return
 	}
 
-	err = h.productService.PublishProduct(c.Request.Context(), userID.(uuid.UUID), productID)
+	var req products.UpdateProductDetailsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	product, err := h.productService.UpdateProductDetails(c.Request.Context(), userID.(uuid.UUID), productID, &req)
 	if err != nil {
 		status := http.StatusInternalServerError
-		code := "PUBLISH_FAILED"
+		code := "PRODUCT_UPDATE_FAILED"
 
 		switch err {
 		case products.ErrProductNotFound:
@@ -278,6 +626,12 @@ func (h *ProductsHandler) PublishProduct(c *gin.Context) {
 		case products.ErrProductNotOwnedByUser:
 			status = http.StatusForbidden
 			code = "NOT_PRODUCT_OWNER"
+		case products.ErrDetailsRequired:
+			status = http.StatusBadRequest
+			code = "DETAILS_REQUIRED"
+		case products.ErrDetailsCategoryMismatch:
+			status = http.StatusBadRequest
+			code = "DETAILS_CATEGORY_MISMATCH"
 		}
 
 		c.JSON(status, gin.H{
@@ -288,12 +642,13 @@ func (h *ProductsHandler) PublishProduct(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Product published successfully",
+		"message": "Product details updated successfully",
+		"product": product,
 	})
 }
 
-// UnpublishProduct unpublishes a product
-func (h *ProductsHandler) UnpublishProduct(c *gin.Context) {
+// PublishProduct publishes a product
+func (h *ProductsHandler) PublishProduct(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -313,18 +668,36 @@ func (h *ProductsHandler) UnpublishProduct(c *gin.Context) {
 		return
 	}
 
-	err = h.productService.UnpublishProduct(c.Request.Context(), userID.(uuid.UUID), productID)
+	err = h.productService.PublishProduct(c.Request.Context(), userID.(uuid.UUID), productID)
 	if err != nil {
 		status := http.StatusInternalServerError
-		code := "UNPUBLISH_FAILED"
+		code := "PUBLISH_FAILED"
 
-		switch err {
-		case products.ErrProductNotFound:
+		switch {
+		case err == products.ErrProductNotFound:
 			status = http.StatusNotFound
 			code = "PRODUCT_NOT_FOUND"
-		case products.ErrProductNotOwnedByUser:
+		case err == products.ErrProductNotOwnedByUser:
 			status = http.StatusForbidden
 			code = "NOT_PRODUCT_OWNER"
+		case errors.Is(err, products.ErrDraftIncomplete):
+			status = http.StatusBadRequest
+			code = "DRAFT_INCOMPLETE"
+		case err == products.ErrInvalidCategory:
+			status = http.StatusBadRequest
+			code = "INVALID_CATEGORY"
+		case err == products.ErrInvalidPriceType:
+			status = http.StatusBadRequest
+			code = "INVALID_PRICE_TYPE"
+		case err == products.ErrInvalidMinOrderQuantity:
+			status = http.StatusBadRequest
+			code = "INVALID_MIN_ORDER_QUANTITY"
+		case err == products.ErrInvalidExpiry:
+			status = http.StatusBadRequest
+			code = "INVALID_EXPIRY"
+		case errors.Is(err, products.ErrCoordinatesOutOfRange):
+			status = http.StatusBadRequest
+			code = "COORDINATES_OUT_OF_RANGE"
 		}
 
 		c.JSON(status, gin.H{
@@ -335,12 +708,12 @@ func (h *ProductsHandler) UnpublishProduct(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Product unpublished successfully",
+		"message": "Product published successfully",
 	})
 }
 
-// DeleteProduct handles product deletion
-func (h *ProductsHandler) DeleteProduct(c *gin.Context) {
+// UnpublishProduct unpublishes a product
+func (h *ProductsHandler) UnpublishProduct(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -360,10 +733,10 @@ func (h *ProductsHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	err = h.productService.DeleteProduct(c.Request.Context(), userID.(uuid.UUID), productID)
+	err = h.productService.UnpublishProduct(c.Request.Context(), userID.(uuid.UUID), productID)
 	if err != nil {
 		status := http.StatusInternalServerError
-		code := "DELETE_FAILED"
+		code := "UNPUBLISH_FAILED"
 
 		switch err {
 		case products.ErrProductNotFound:
@@ -382,12 +755,21 @@ func (h *ProductsHandler) DeleteProduct(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Product deleted successfully",
+		"message": "Product unpublished successfully",
 	})
 }
 
-// GetUserProducts retrieves products belonging to a user
-func (h *ProductsHandler) GetUserProducts(c *gin.Context) {
+// BulkPublishProducts publishes several of the seller's products at once
+func (h *ProductsHandler) BulkPublishProducts(c *gin.Context) {
+	h.bulkSetPublished(c, true)
+}
+
+// BulkUnpublishProducts unpublishes several of the seller's products at once
+func (h *ProductsHandler) BulkUnpublishProducts(c *gin.Context) {
+	h.bulkSetPublished(c, false)
+}
+
+func (h *ProductsHandler) bulkSetPublished(c *gin.Context, publish bool) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -397,27 +779,29 @@ func (h *ProductsHandler) GetUserProducts(c *gin.Context) {
 		return
 	}
 
-	page := 1
-	pageSize := 20
-
-	if pageStr := c.Query("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil {
-			page = p
-		}
+	var req products.BulkPublishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
 	}
 
-	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
-		if ps, err := strconv.Atoi(pageSizeStr); err == nil {
-			pageSize = ps
-		}
+	var response *products.BulkPublishResponse
+	var err error
+	code := "PUBLISH_FAILED"
+	if publish {
+		response, err = h.productService.PublishProducts(c.Request.Context(), userID.(uuid.UUID), req.ProductIDs)
+	} else {
+		code = "UNPUBLISH_FAILED"
+		response, err = h.productService.UnpublishProducts(c.Request.Context(), userID.(uuid.UUID), req.ProductIDs)
 	}
-
-	response, err := h.productService.GetUserProducts(c.Request.Context(), userID.(uuid.UUID), page, pageSize)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get user products",
-			"code":  "USER_PRODUCTS_FETCH_FAILED",
-			"details": err.Error(),
+			"error": err.Error(),
+			"code":  code,
 		})
 		return
 	}
@@ -425,8 +809,46 @@ func (h *ProductsHandler) GetUserProducts(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// UploadProductImage handles product image uploads
-func (h *ProductsHandler) UploadProductImage(c *gin.Context) {
+// PreviewImport validates a product CSV and reports per-row results without creating anything
+func (h *ProductsHandler) PreviewImport(c *gin.Context) {
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No import file provided",
+			"code":  "NO_IMPORT_FILE",
+		})
+		return
+	}
+	defer file.Close()
+
+	preview, err := h.productService.PreviewImport(c.Request.Context(), file)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "IMPORT_PREVIEW_FAILED"
+
+		switch {
+		case errors.Is(err, products.ErrInvalidImportFile):
+			status = http.StatusBadRequest
+			code = "INVALID_IMPORT_FILE"
+		case errors.Is(err, products.ErrImportTooLarge):
+			status = http.StatusRequestEntityTooLarge
+			code = "IMPORT_TOO_LARGE"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// ImportProducts creates products in bulk from a CSV file upload or a JSON
+// array body, reporting per-row results. Valid rows are created even when
+// others are rejected.
+func (h *ProductsHandler) ImportProducts(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -436,66 +858,1400 @@ func (h *ProductsHandler) UploadProductImage(c *gin.Context) {
 		return
 	}
 
-	// Parse multipart form
-	if err := c.Request.ParseMultipartForm(10 << 20); err != nil { // 10MB max
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Failed to parse multipart form",
-			"code":  "INVALID_FORM",
-		})
-		return
+	var body io.Reader = c.Request.Body
+	parse := products.ParseProductJSON
+	if c.ContentType() != "application/json" {
+		file, _, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "No import file provided",
+				"code":  "NO_IMPORT_FILE",
+			})
+			return
+		}
+		defer file.Close()
+		body, parse = file, products.ParseProductCSV
 	}
 
-	// Get file from form
-	file, header, err := c.Request.FormFile("image")
+	result, err := h.productService.ImportProducts(c.Request.Context(), userID.(uuid.UUID), body, parse)
+	if err != nil {
+		if respondProductLimit(c, err) {
+			return
+		}
+
+		status := http.StatusInternalServerError
+		code := "IMPORT_FAILED"
+
+		switch {
+		case errors.Is(err, products.ErrInvalidImportFile):
+			status = http.StatusBadRequest
+			code = "INVALID_IMPORT_FILE"
+		case errors.Is(err, products.ErrImportTooLarge):
+			status = http.StatusRequestEntityTooLarge
+			code = "IMPORT_TOO_LARGE"
+		case err == products.ErrSellerNotFound:
+			status = http.StatusForbidden
+			code = "SELLER_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// AddFavorite saves a product to the user's favorites
+func (h *ProductsHandler) AddFavorite(c *gin.Context) {
+	h.setFavorite(c, true)
+}
+
+// RemoveFavorite removes a product from the user's favorites
+func (h *ProductsHandler) RemoveFavorite(c *gin.Context) {
+	h.setFavorite(c, false)
+}
+
+func (h *ProductsHandler) setFavorite(c *gin.Context, favorite bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	if favorite {
+		err = h.productService.AddFavorite(c.Request.Context(), userID.(uuid.UUID), productID)
+	} else {
+		err = h.productService.RemoveFavorite(c.Request.Context(), userID.(uuid.UUID), productID)
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "FAVORITE_UPDATE_FAILED"
+
+		if err == products.ErrProductNotFound {
+			status = http.StatusNotFound
+			code = "PRODUCT_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"product_id": productID,
+		"favorite":   favorite,
+	})
+}
+
+// GetFavorites lists the user's favorite products
+func (h *ProductsHandler) GetFavorites(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+	response, err := h.productService.ListFavorites(c.Request.Context(), userID.(uuid.UUID), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get favorites",
+			"code":  "FAVORITES_FETCH_FAILED",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Autocomplete suggests product titles for the search box
+func (h *ProductsHandler) Autocomplete(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	suggestions, err := h.productService.AutocompleteTitles(c.Request.Context(), c.Query("q"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get suggestions",
+			"code":  "AUTOCOMPLETE_FAILED",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"suggestions": suggestions,
+	})
+}
+
+// GetSimilarProducts returns "you might also like" recommendations for a product
+func (h *ProductsHandler) GetSimilarProducts(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	similar, err := h.productService.GetSimilarProducts(c.Request.Context(), productID, limit)
+	if err != nil {
+		if err == products.ErrProductNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+				"code":  "PRODUCT_NOT_FOUND",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get similar products",
+			"code":  "SIMILAR_FETCH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products": similar,
+	})
+}
+
+// EstimateTotal returns an all-in cost estimate for a product, quantity and buyer location
+func (h *ProductsHandler) EstimateTotal(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	var req products.EstimateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	estimate, err := h.productService.EstimateTotal(c.Request.Context(), productID, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "ESTIMATE_FAILED"
+
+		switch err {
+		case products.ErrProductNotFound:
+			status = http.StatusNotFound
+			code = "PRODUCT_NOT_FOUND"
+		case products.ErrQuantityUnavailable:
+			status = http.StatusBadRequest
+			code = "QUANTITY_UNAVAILABLE"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, estimate)
+}
+
+// DeleteProduct handles product deletion
+func (h *ProductsHandler) DeleteProduct(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	productIDStr := c.Param("id")
+	productID, err := uuid.Parse(productIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	err = h.productService.DeleteProduct(c.Request.Context(), userID.(uuid.UUID), productID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "DELETE_FAILED"
+
+		switch err {
+		case products.ErrProductNotFound:
+			status = http.StatusNotFound
+			code = "PRODUCT_NOT_FOUND"
+		case products.ErrProductNotOwnedByUser:
+			status = http.StatusForbidden
+			code = "NOT_PRODUCT_OWNER"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Product deleted successfully",
+	})
+}
+
+// GetExpiringSupplies lists the seller's supplies expiring within the next days days
+func (h *ProductsHandler) GetExpiringSupplies(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	days := 30
+	if daysStr := c.Query("days"); daysStr != "" {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid days parameter",
+				"code":  "INVALID_EXPIRY_WINDOW",
+			})
+			return
+		}
+		days = d
+	}
+
+	page := 1
+	pageSize := 20
+
+	if pageStr := c.Query("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil {
+			page = p
+		}
+	}
+
+	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil {
+			pageSize = ps
+		}
+	}
+
+	response, err := h.productService.GetExpiringSupplies(c.Request.Context(), userID.(uuid.UUID), days, page, pageSize)
+	if err != nil {
+		if err == products.ErrInvalidExpiryWindow {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_EXPIRY_WINDOW",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get expiring supplies",
+			"code":  "EXPIRING_SUPPLIES_FETCH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTransportCompliance lists the seller's transport products with expired or
+// soon-to-expire license or insurance
+func (h *ProductsHandler) GetTransportCompliance(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	days := 30
+	if daysStr := c.Query("days"); daysStr != "" {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid days parameter",
+				"code":  "INVALID_EXPIRY_WINDOW",
+			})
+			return
+		}
+		days = d
+	}
+
+	listings, err := h.productService.GetTransportCompliance(c.Request.Context(), userID.(uuid.UUID), days)
+	if err != nil {
+		if err == products.ErrInvalidExpiryWindow {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_EXPIRY_WINDOW",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get transport compliance",
+			"code":  "COMPLIANCE_FETCH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products": listings,
+		"total":    len(listings),
+		"days":     days,
+	})
+}
+
+// RestoreProduct undoes the soft delete of one of the seller's products
+func (h *ProductsHandler) RestoreProduct(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	err = h.productService.RestoreProduct(c.Request.Context(), userID.(uuid.UUID), productID)
+	if err != nil {
+		if respondProductLimit(c, err) {
+			return
+		}
+
+		status := http.StatusInternalServerError
+		code := "RESTORE_FAILED"
+
+		switch err {
+		case products.ErrProductNotFound:
+			status = http.StatusNotFound
+			code = "PRODUCT_NOT_FOUND"
+		case products.ErrProductNotOwnedByUser:
+			status = http.StatusForbidden
+			code = "NOT_PRODUCT_OWNER"
+		case products.ErrProductNotDeleted:
+			status = http.StatusConflict
+			code = "PRODUCT_NOT_DELETED"
+		case products.ErrRestoreWindowExpired:
+			status = http.StatusGone
+			code = "RESTORE_WINDOW_EXPIRED"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Product restored successfully",
+	})
+}
+
+// GetDeletedProducts lists the user's soft-deleted products that can still be restored
+func (h *ProductsHandler) GetDeletedProducts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	deleted, err := h.productService.GetDeletedProducts(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get deleted products",
+			"code":  "DELETED_PRODUCTS_FETCH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products": deleted,
+		"total":    len(deleted),
+	})
+}
+
+// GetUserProducts retrieves products belonging to a user
+func (h *ProductsHandler) GetUserProducts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	page := 1
+	pageSize := 20
+
+	if pageStr := c.Query("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil {
+			page = p
+		}
+	}
+
+	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil {
+			pageSize = ps
+		}
+	}
+
+	response, err := h.productService.GetUserProducts(c.Request.Context(), userID.(uuid.UUID), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user products",
+			"code":  "USER_PRODUCTS_FETCH_FAILED",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UploadProductImage handles product image uploads
+func (h *ProductsHandler) UploadProductImage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	// Parse multipart form
+	if err := c.Request.ParseMultipartForm(h.maxMultipartMemory); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to parse multipart form",
+			"code":  "INVALID_FORM",
+		})
+		return
+	}
+
+	// Get file from form
+	file, header, err := c.Request.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No image file provided",
+			"code":  "NO_IMAGE_FILE",
+		})
+		return
+	}
+	defer file.Close()
+
+	// Parse form data
+	var req products.UploadImageRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid form data",
+			"code":  "INVALID_FORM_DATA",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	image, err := h.imageService.UploadProductImage(c.Request.Context(), userID.(uuid.UUID), file, header, req)
+	if err != nil {
+		var limitErr *products.TooManyImagesError
+		if errors.As(err, &limitErr) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+				"code":  "TOO_MANY_IMAGES",
+				"count": limitErr.Count,
+				"limit": limitErr.Limit,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to upload image",
+			"code":  "IMAGE_UPLOAD_FAILED",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Image uploaded successfully",
+		"image":   image,
+	})
+}
+
+// UploadProductImages adds several images to a product at once from the
+// "images" form field. Either every image is stored or none is; on failure
+// the response lists the outcome of each file.
+func (h *ProductsHandler) UploadProductImages(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	if err := c.Request.ParseMultipartForm(h.maxMultipartMemory); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to parse multipart form",
+			"code":  "INVALID_FORM",
+		})
+		return
+	}
+
+	headers := c.Request.MultipartForm.File["images"]
+	if len(headers) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No image files provided",
+			"code":  "NO_IMAGE_FILE",
+		})
+		return
+	}
+
+	images, err := h.imageService.UploadProductImages(c.Request.Context(), userID.(uuid.UUID), productID, headers)
+	if err != nil {
+		var limitErr *products.TooManyImagesError
+		if errors.As(err, &limitErr) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+				"code":  "TOO_MANY_IMAGES",
+				"count": limitErr.Count,
+				"limit": limitErr.Limit,
+			})
+			return
+		}
+
+		var batchErr *products.ImageBatchError
+		if errors.As(err, &batchErr) {
+			status := http.StatusInternalServerError
+			code := "IMAGE_UPLOAD_FAILED"
+			if errors.Is(err, products.ErrInvalidImages) {
+				status = http.StatusBadRequest
+				code = "INVALID_IMAGES"
+			}
+			c.JSON(status, gin.H{
+				"error":   err.Error(),
+				"code":    code,
+				"results": batchErr.Results,
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to upload images",
+			"code":  "IMAGE_UPLOAD_FAILED",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Images uploaded successfully",
+		"images":  images,
+	})
+}
+
+// ReorderProductImages sets the gallery order and cover image of a product
+func (h *ProductsHandler) ReorderProductImages(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	var req products.ReorderImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	images, err := h.imageService.ReorderProductImages(c.Request.Context(), userID.(uuid.UUID), productID, req.Images)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "IMAGE_REORDER_FAILED"
+
+		switch err {
+		case products.ErrProductNotFound:
+			status = http.StatusNotFound
+			code = "PRODUCT_NOT_FOUND"
+		case products.ErrProductNotOwnedByUser:
+			status = http.StatusForbidden
+			code = "NOT_PRODUCT_OWNER"
+		case products.ErrInvalidImageOrder:
+			status = http.StatusBadRequest
+			code = "INVALID_IMAGE_ORDER"
+		case products.ErrInvalidPrimaryImage:
+			status = http.StatusBadRequest
+			code = "INVALID_PRIMARY_IMAGE"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Images reordered successfully",
+		"images":  images,
+	})
+}
+
+// UploadProductVideo handles the optional product video upload
+func (h *ProductsHandler) UploadProductVideo(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	productIDStr := c.Param("id")
+	productID, err := uuid.Parse(productIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	// Parse multipart form
+	if err := c.Request.ParseMultipartForm(h.maxMultipartMemory); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to parse multipart form",
+			"code":  "INVALID_FORM",
+		})
+		return
+	}
+
+	// Get file from form
+	file, header, err := c.Request.FormFile("video")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No video file provided",
+			"code":  "NO_VIDEO_FILE",
+		})
+		return
+	}
+	defer file.Close()
+
+	// Poster frame is optional
+	poster, posterHeader, _ := c.Request.FormFile("poster")
+	if poster != nil {
+		defer poster.Close()
+	}
+
+	video, err := h.imageService.UploadProductVideo(c.Request.Context(), userID.(uuid.UUID), productID, file, header, poster, posterHeader)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to upload video",
+			"code":  "VIDEO_UPLOAD_FAILED",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Video uploaded successfully",
+		"video":   video,
+	})
+}
+
+// DeleteProductVideo handles removing the product video
+func (h *ProductsHandler) DeleteProductVideo(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	productIDStr := c.Param("id")
+	productID, err := uuid.Parse(productIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	if err := h.imageService.DeleteProductVideo(c.Request.Context(), userID.(uuid.UUID), productID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete video",
+			"code":  "VIDEO_DELETE_FAILED",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Video deleted successfully",
+	})
+}
+
+// GetAvailability lists the availability calendar of a product
+func (h *ProductsHandler) GetAvailability(c *gin.Context) {
+	productIDStr := c.Param("id")
+	productID, err := uuid.Parse(productIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	slots, err := h.productService.GetAvailabilitySlots(c.Request.Context(), productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get availability",
+			"code":  "AVAILABILITY_FETCH_FAILED",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"slots": slots,
+	})
+}
+
+// GetPriceHistory returns the chronological price list of a product. Drafts
+// and unlisted products are only visible to their owner.
+func (h *ProductsHandler) GetPriceHistory(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	var viewerID *uuid.UUID
+	if userID, exists := c.Get("user_id"); exists {
+		id := userID.(uuid.UUID)
+		viewerID = &id
+	}
+
+	history, err := h.productService.GetPriceHistory(c.Request.Context(), productID, viewerID)
+	if err != nil {
+		if err == products.ErrProductNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+				"code":  "PRODUCT_NOT_FOUND",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get price history",
+			"code":  "PRICE_HISTORY_FETCH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"product_id": productID,
+		"history":    history,
+	})
+}
+
+// GetComparables returns similar active listings and their price range. When
+// the caller is signed in, their own listings are left out.
+func (h *ProductsHandler) GetComparables(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	var viewerID *uuid.UUID
+	if userID, exists := c.Get("user_id"); exists {
+		id := userID.(uuid.UUID)
+		viewerID = &id
+	}
+
+	comparables, err := h.productService.GetComparableProducts(c.Request.Context(), productID, viewerID)
+	if err != nil {
+		if err == products.ErrProductNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+				"code":  "PRODUCT_NOT_FOUND",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get comparable products",
+			"code":  "COMPARABLES_FETCH_FAILED",
+		})
+		return
+	}
+
+	h.enrichSellers(c, comparables.Products)
+
+	c.JSON(http.StatusOK, comparables)
+}
+
+// AddAvailabilitySlot blocks, books or opens a date range on a transport product
+func (h *ProductsHandler) AddAvailabilitySlot(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	productIDStr := c.Param("id")
+	productID, err := uuid.Parse(productIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	var req products.CreateAvailabilitySlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	slot, err := h.productService.AddAvailabilitySlot(c.Request.Context(), userID.(uuid.UUID), productID, &req)
+	if err != nil {
+		h.respondAvailabilityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Availability slot added successfully",
+		"slot":    slot,
+	})
+}
+
+// DeleteAvailabilitySlot removes a slot from a transport product
+func (h *ProductsHandler) DeleteAvailabilitySlot(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	slotID, err := uuid.Parse(c.Param("slot_id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "No image file provided",
-			"code":  "NO_IMAGE_FILE",
+			"error": "Invalid slot ID format",
+			"code":  "INVALID_SLOT_ID",
 		})
 		return
 	}
-	defer file.Close()
 
-	// Parse form data
-	var req products.UploadImageRequest
-	if err := c.ShouldBind(&req); err != nil {
+	if err := h.productService.DeleteAvailabilitySlot(c.Request.Context(), userID.(uuid.UUID), productID, slotID); err != nil {
+		h.respondAvailabilityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Availability slot deleted successfully",
+	})
+}
+
+func (h *ProductsHandler) respondAvailabilityError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	code := "AVAILABILITY_UPDATE_FAILED"
+
+	switch err {
+	case products.ErrProductNotFound:
+		status = http.StatusNotFound
+		code = "PRODUCT_NOT_FOUND"
+	case products.ErrProductNotOwnedByUser:
+		status = http.StatusForbidden
+		code = "NOT_PRODUCT_OWNER"
+	case products.ErrNotBookable:
+		status = http.StatusBadRequest
+		code = "PRODUCT_NOT_BOOKABLE"
+	case products.ErrInvalidSlotRange:
+		status = http.StatusBadRequest
+		code = "INVALID_SLOT_RANGE"
+	case products.ErrSlotNotFound:
+		status = http.StatusNotFound
+		code = "SLOT_NOT_FOUND"
+	}
+
+	c.JSON(status, gin.H{
+		"error": err.Error(),
+		"code":  code,
+	})
+}
+
+// PurgeDeletedProducts permanently removes the seller's soft-deleted products
+func (h *ProductsHandler) PurgeDeletedProducts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	result, err := h.productService.PurgeDeletedProducts(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to purge products",
+			"code":  "PRODUCT_PURGE_FAILED",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Free storage once the rows are gone
+	h.imageService.DeleteStoredFiles(c.Request.Context(), result.StoragePaths)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Deleted products purged successfully",
+		"result":  result,
+	})
+}
+
+// SuggestPrice returns a recommended price range for a new listing
+func (h *ProductsHandler) SuggestPrice(c *gin.Context) {
+	var req products.PriceSuggestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid form data",
-			"code":  "INVALID_FORM_DATA",
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	image, err := h.imageService.UploadProductImage(c.Request.Context(), userID.(uuid.UUID), file, header, req)
+	suggestion, err := h.productService.SuggestPrice(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to upload image",
-			"code":  "IMAGE_UPLOAD_FAILED",
+		status := http.StatusInternalServerError
+		code := "PRICE_SUGGESTION_FAILED"
+
+		switch err {
+		case products.ErrInvalidCategory:
+			status = http.StatusBadRequest
+			code = "INVALID_CATEGORY"
+		case products.ErrInvalidPriceType:
+			status = http.StatusBadRequest
+			code = "INVALID_PRICE_TYPE"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// CreateDraft starts a draft product for the listing wizard
+func (h *ProductsHandler) CreateDraft(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	// Drafts are partial, so decode without running binding validation
+	var req products.CreateProductRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
 			"details": err.Error(),
 		})
 		return
 	}
 
+	draft, err := h.productService.CreateDraft(c.Request.Context(), userID.(uuid.UUID), &req)
+	if err != nil {
+		if respondProductLimit(c, err) {
+			return
+		}
+
+		status := http.StatusInternalServerError
+		code := "DRAFT_CREATION_FAILED"
+
+		switch err {
+		case products.ErrInvalidCategory:
+			status = http.StatusBadRequest
+			code = "INVALID_CATEGORY"
+		case products.ErrInvalidPriceType:
+			status = http.StatusBadRequest
+			code = "INVALID_PRICE_TYPE"
+		case products.ErrInvalidMinOrderQuantity:
+			status = http.StatusBadRequest
+			code = "INVALID_MIN_ORDER_QUANTITY"
+		case products.ErrInvalidExpiry:
+			status = http.StatusBadRequest
+			code = "INVALID_EXPIRY"
+		case products.ErrSellerNotFound:
+			status = http.StatusForbidden
+			code = "SELLER_NOT_FOUND"
+		default:
+			if errors.Is(err, products.ErrCoordinatesOutOfRange) {
+				status = http.StatusBadRequest
+				code = "COORDINATES_OUT_OF_RANGE"
+			}
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Image uploaded successfully",
-		"image":   image,
+		"message": "Draft created successfully",
+		"draft":   draft,
+	})
+}
+
+// SaveDraft auto-saves the wizard progress onto the caller's draft product
+func (h *ProductsHandler) SaveDraft(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	productIDStr := c.Param("id")
+	productID, err := uuid.Parse(productIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	// Drafts are partial, so decode without running binding validation
+	var req products.UpdateProductRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	draft, err := h.productService.SaveDraft(c.Request.Context(), userID.(uuid.UUID), productID, &req)
+	if err != nil {
+		if respondProductLimit(c, err) {
+			return
+		}
+
+		status := http.StatusInternalServerError
+		code := "DRAFT_SAVE_FAILED"
+
+		switch err {
+		case products.ErrDraftNotFound:
+			status = http.StatusNotFound
+			code = "DRAFT_NOT_FOUND"
+		case products.ErrProductNotOwnedByUser:
+			status = http.StatusForbidden
+			code = "NOT_PRODUCT_OWNER"
+		case products.ErrDraftAlreadyPublished:
+			status = http.StatusConflict
+			code = "DRAFT_ALREADY_PUBLISHED"
+		case products.ErrInvalidPriceType:
+			status = http.StatusBadRequest
+			code = "INVALID_PRICE_TYPE"
+		case products.ErrInvalidMinOrderQuantity:
+			status = http.StatusBadRequest
+			code = "INVALID_MIN_ORDER_QUANTITY"
+		case products.ErrInvalidExpiry:
+			status = http.StatusBadRequest
+			code = "INVALID_EXPIRY"
+		case products.ErrDetailsCategoryMismatch:
+			status = http.StatusBadRequest
+			code = "DETAILS_CATEGORY_MISMATCH"
+		default:
+			if errors.Is(err, products.ErrCoordinatesOutOfRange) {
+				status = http.StatusBadRequest
+				code = "COORDINATES_OUT_OF_RANGE"
+			}
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Draft saved successfully",
+		"draft":   draft,
+	})
+}
+
+// GetDraft returns the caller's draft product so the wizard can resume
+func (h *ProductsHandler) GetDraft(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	productIDStr := c.Param("id")
+	productID, err := uuid.Parse(productIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	draft, err := h.productService.GetDraft(c.Request.Context(), userID.(uuid.UUID), productID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "DRAFT_FETCH_FAILED"
+
+		switch err {
+		case products.ErrDraftNotFound:
+			status = http.StatusNotFound
+			code = "DRAFT_NOT_FOUND"
+		case products.ErrProductNotOwnedByUser:
+			status = http.StatusForbidden
+			code = "NOT_PRODUCT_OWNER"
+		case products.ErrDraftAlreadyPublished:
+			status = http.StatusConflict
+			code = "DRAFT_ALREADY_PUBLISHED"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"draft": draft,
+	})
+}
+
+// PublishDraft validates the caller's draft product and publishes it
+func (h *ProductsHandler) PublishDraft(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "AUTH_REQUIRED",
+		})
+		return
+	}
+
+	productIDStr := c.Param("id")
+	productID, err := uuid.Parse(productIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+			"code":  "INVALID_PRODUCT_ID",
+		})
+		return
+	}
+
+	product, err := h.productService.PublishDraft(c.Request.Context(), userID.(uuid.UUID), productID)
+	if err != nil {
+		if respondProductLimit(c, err) {
+			return
+		}
+
+		status := http.StatusInternalServerError
+		code := "DRAFT_PUBLISH_FAILED"
+
+		switch {
+		case err == products.ErrDraftNotFound:
+			status = http.StatusNotFound
+			code = "DRAFT_NOT_FOUND"
+		case err == products.ErrProductNotOwnedByUser:
+			status = http.StatusForbidden
+			code = "NOT_PRODUCT_OWNER"
+		case err == products.ErrDraftAlreadyPublished:
+			status = http.StatusConflict
+			code = "DRAFT_ALREADY_PUBLISHED"
+		case errors.Is(err, products.ErrDraftIncomplete):
+			status = http.StatusBadRequest
+			code = "DRAFT_INCOMPLETE"
+		case err == products.ErrInvalidCategory:
+			status = http.StatusBadRequest
+			code = "INVALID_CATEGORY"
+		case err == products.ErrInvalidPriceType:
+			status = http.StatusBadRequest
+			code = "INVALID_PRICE_TYPE"
+		case err == products.ErrInvalidMinOrderQuantity:
+			status = http.StatusBadRequest
+			code = "INVALID_MIN_ORDER_QUANTITY"
+		case err == products.ErrInvalidExpiry:
+			status = http.StatusBadRequest
+			code = "INVALID_EXPIRY"
+		case err == products.ErrSellerNotFound:
+			status = http.StatusForbidden
+			code = "SELLER_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Product created successfully",
+		"product": product,
 	})
 }
 
-// RegisterRoutes registers product routes
-func (h *ProductsHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware, sellerMiddleware gin.HandlerFunc) {
+// RegisterRoutes registers product routes. optionalAuthMiddleware identifies
+// the caller on public routes that tailor their results to signed-in users.
+func (h *ProductsHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware, sellerMiddleware, optionalAuthMiddleware gin.HandlerFunc) {
 	products := router.Group("/products")
 	{
 		// Public routes
 		products.GET("/search", h.SearchProducts)
+		products.GET("/autocomplete", h.Autocomplete)
 		products.GET("/:id", h.GetProduct)
+		products.GET("/:id/images", h.GetProductImages)
+		products.GET("/:id/availability", h.GetAvailability)
+		products.GET("/:id/price-history", optionalAuthMiddleware, h.GetPriceHistory)
+		products.GET("/:id/comparables", optionalAuthMiddleware, h.GetComparables)
+		products.GET("/:id/similar", h.GetSimilarProducts)
+		products.POST("/:id/estimate", h.EstimateTotal)
 
 		// Protected routes
 		protected := products.Group("/")
 		protected.Use(authMiddleware)
 		{
 			protected.GET("/my", h.GetUserProducts)
+			protected.GET("/my/deleted", h.GetDeletedProducts)
+			protected.GET("/my/expiring", h.GetExpiringSupplies)
+			protected.GET("/my/compliance", h.GetTransportCompliance)
+			protected.GET("/favorites", h.GetFavorites)
+			protected.POST("/:id/favorite", h.AddFavorite)
+			protected.DELETE("/:id/favorite", h.RemoveFavorite)
 			
 			// Seller-only routes
 			seller := protected.Group("/")
@@ -503,10 +2259,30 @@ func (h *ProductsHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware
 			{
 				seller.POST("/", h.CreateProduct)
 				seller.PUT("/:id", h.UpdateProduct)
+				seller.PATCH("/:id/details", h.UpdateProductDetails)
+				seller.DELETE("/purge", h.PurgeDeletedProducts)
 				seller.DELETE("/:id", h.DeleteProduct)
+				seller.POST("/:id/restore", h.RestoreProduct)
 				seller.POST("/:id/publish", h.PublishProduct)
 				seller.POST("/:id/unpublish", h.UnpublishProduct)
+				seller.POST("/bulk-publish", h.BulkPublishProducts)
+				seller.POST("/bulk-unpublish", h.BulkUnpublishProducts)
+				seller.POST("/import/preview", h.PreviewImport)
+				seller.POST("/import", h.ImportProducts)
 				seller.POST("/images", h.UploadProductImage)
+				seller.POST("/:id/images/batch", h.UploadProductImages)
+				seller.PUT("/:id/images/order", h.ReorderProductImages)
+				seller.POST("/price-suggestion", h.SuggestPrice)
+				seller.POST("/:id/video", h.UploadProductVideo)
+				seller.DELETE("/:id/video", h.DeleteProductVideo)
+				seller.POST("/:id/availability", h.AddAvailabilitySlot)
+				seller.DELETE("/:id/availability/:slot_id", h.DeleteAvailabilitySlot)
+
+				// Draft auto-save for the listing wizard
+				seller.POST("/drafts", h.CreateDraft)
+				seller.PUT("/:id/draft", h.SaveDraft)
+				seller.GET("/:id/draft", h.GetDraft)
+				seller.POST("/:id/draft/publish", h.PublishDraft)
 			}
 		}
 	}
@@ -2,14 +2,19 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"agro-mas-backend/cmd/api/handlers"
+	"agro-mas-backend/internal/audit"
 	"agro-mas-backend/internal/auth"
 	"agro-mas-backend/internal/config"
 	"agro-mas-backend/internal/marketplace/products"
@@ -17,33 +22,44 @@ import (
 	"agro-mas-backend/internal/marketplace/users"
 	"agro-mas-backend/internal/storage"
 	"agro-mas-backend/pkg/gcloud"
+	"agro-mas-backend/pkg/logger"
+	"agro-mas-backend/pkg/mailer"
 	"agro-mas-backend/pkg/middleware"
 	"agro-mas-backend/pkg/whatsapp"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
 )
 
 func main() {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		logger.Fatal("failed to load configuration", "error", err)
 	}
 
+	// Structured JSON logs at the configured level
+	logger.Init(cfg.Server.LogLevel)
+
 	// Set Gin mode
 	gin.SetMode(cfg.Server.GinMode)
 
 	// Initialize database
-	db, err := storage.NewDatabase(cfg.GetDatabaseURL())
+	db, err := storage.NewDatabase(cfg.GetDatabaseURL(), storage.PoolConfig{
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+	})
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		logger.Fatal("failed to connect to database", "error", err)
 	}
 	defer db.Close()
 
 	// Run migrations
 	if err := db.RunMigrations("./migrations"); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+		logger.Fatal("failed to run migrations", "error", err)
 	}
 
 	// Initialize Google Cloud Storage (only in production)
@@ -53,19 +69,30 @@ func main() {
 		var err error
 		storageClient, err = gcloud.NewStorageClient(ctx, cfg.GoogleCloud.ProjectID, cfg.GoogleCloud.CredentialsFile, cfg.GoogleCloud.StorageBucket)
 		if err != nil {
-			log.Fatalf("Failed to initialize Google Cloud Storage: %v", err)
+			logger.Fatal("failed to initialize Google Cloud Storage", "error", err)
 		}
 		defer storageClient.Close()
 	} else {
-		log.Println("⚠️  Running in development mode - Google Cloud Storage disabled")
+		slog.Warn("running in development mode, Google Cloud Storage disabled")
 	}
 
 	// Initialize WhatsApp client
 	whatsappClient := whatsapp.NewClient(cfg.WhatsApp.APIUrl, cfg.WhatsApp.BusinessNumber)
+	whatsappClient.SetProvinceNumbers(cfg.WhatsApp.ProvinceNumbers)
 
 	// Initialize authentication components
-	passwordManager := auth.NewPasswordManager(nil)
-	jwtManager := auth.NewJWTManager(cfg.JWT.Secret, cfg.JWT.ExpirationHours)
+	passwordManager := auth.NewPasswordManager(&auth.PasswordConfig{
+		Memory:      uint32(cfg.Password.MemoryKB),
+		Iterations:  uint32(cfg.Password.Iterations),
+		Parallelism: uint8(cfg.Password.Parallelism),
+	})
+	jwtManager := auth.NewJWTManager(cfg.JWT.Secret, cfg.JWT.ExpirationHours, cfg.JWT.RefreshTokenTTL)
+	jwtManager.SetIssuerAudience(cfg.JWT.Issuer, cfg.JWT.Audience)
+	if cfg.JWT.RevocationStore == "memory" || (cfg.JWT.RevocationStore == "" && cfg.IsDevelopment()) {
+		jwtManager.SetRevocationStore(auth.NewMemoryRevocationStore())
+	} else {
+		jwtManager.SetRevocationStore(auth.NewDBRevocationStore(db.GetDB()))
+	}
 
 	// Initialize repositories
 	userRepo := users.NewRepository(db.GetDB())
@@ -73,45 +100,137 @@ func main() {
 	transactionRepo := transactions.NewRepository(db.GetDB())
 
 	// Initialize services
+	auditRecorder := audit.NewRecorder(db.GetDB())
 	userService := users.NewService(userRepo, passwordManager, jwtManager)
+	userService.SetAuditRecorder(auditRecorder)
+	var mailSender mailer.Mailer
+	if cfg.Mail.SMTPHost != "" {
+		mailSender = mailer.NewSMTPMailer(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From)
+	} else {
+		slog.Warn("SMTP not configured, email links will be logged instead of sent")
+	}
+	userService.SetMailer(mailSender, cfg.Mail.AppBaseURL)
+	userService.SetEmailVerificationTTL(cfg.Mail.EmailVerificationTTL)
+	userService.SetPasswordResetTTL(cfg.Mail.PasswordResetTTL)
 	productService := products.NewService(productRepo)
+	productService.SetSellerStaleCheck(cfg.Products.SellerStaleCheck, cfg.Products.SellerRatingTolerance)
+	productService.SetDeletedRetention(cfg.Products.DeletedRetention)
+	productService.SetProductLimits(cfg.Products.LimitsByVerificationLevel)
+	productService.SetAutoUnpublishExpiredInsurance(cfg.Products.AutoUnpublishExpiredInsurance)
+	viewCounter := products.NewViewCounter(productRepo, cfg.Products.ViewFlushThreshold)
+	productService.SetViewCounter(viewCounter)
+	productService.SetNotifier(users.NotificationChannels, func(ctx context.Context, userID uuid.UUID, channels []string) ([]string, error) {
+		return userService.AcceptedChannels(ctx, userID, users.NotificationEventNewMatch, channels)
+	})
+	tierThresholds := users.SellerTierThresholds(cfg.SellerTier)
+	userService.SetSellerTierThresholds(tierThresholds)
+	productService.SetSellerTierFunc(tierThresholds.Tier)
 	imageService := products.NewImageService(db.GetDB(), storageClient)
+	imageService.SetImageLimits(cfg.Uploads.MaxImageSize, cfg.Uploads.MaxImagesPerProduct)
+	imageService.SetVideoLimit(cfg.Uploads.MaxVideoSize)
+	geoService := products.NewGeospatialService(db.GetDB())
+	geoService.SetSearchPreferencesFunc(func(ctx context.Context, userID uuid.UUID) (*products.SearchPreferences, error) {
+		user, err := userService.GetUserByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if user.Preferences == nil {
+			return nil, nil
+		}
+		return &products.SearchPreferences{
+			RadiusKm:   float64(user.Preferences.SearchRadius),
+			Categories: user.Preferences.PreferredCategories,
+		}, nil
+	})
+	documentService := users.NewDocumentService(userRepo, storageClient)
+	userService.SetStorageClient(storageClient)
+	documentService.SetAuditRecorder(auditRecorder)
 	transactionService := transactions.NewService(transactionRepo)
+	transactionService.SetAuditRecorder(auditRecorder)
+	transactionService.SetIdempotencyKeyTTL(cfg.Transactions.IdempotencyKeyTTL)
+	transactionService.SetUnpublishSoldOut(cfg.Transactions.UnpublishSoldOut)
+	webhookDispatcher := transactions.NewWebhookDispatcher(transactionRepo, cfg.Webhooks.Timeout, cfg.Webhooks.MaxAttempts)
+	receiptService := transactions.NewReceiptService(transactionRepo, storageClient)
+	if cfg.Receipts.LogoPath != "" {
+		logo, err := os.ReadFile(cfg.Receipts.LogoPath)
+		if err != nil {
+			logger.Fatal("failed to read receipt logo", "path", cfg.Receipts.LogoPath, "error", err)
+		}
+		receiptService.SetLogo(logo)
+	}
 	whatsappService := whatsapp.NewService(whatsappClient, db.GetDB())
+	whatsappService.SetWebhookCredentials(cfg.WhatsApp.VerifyToken, cfg.WhatsApp.WebhookSecret)
+	whatsappService.SetClickHashSecret(cfg.WhatsApp.ClickHashSecret)
+	whatsappService.SetAuditRecorder(auditRecorder)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(userService)
-	productsHandler := handlers.NewProductsHandler(productService, imageService)
+	productsHandler := handlers.NewProductsHandler(productService, imageService, userService)
+	productsHandler.SetMaxMultipartMemory(cfg.Uploads.MaxMultipartMemory)
+	documentsHandler := handlers.NewDocumentsHandler(documentService)
 
 	// Initialize Gin router
 	router := gin.New()
+	router.MaxMultipartMemory = cfg.Uploads.MaxMultipartMemory
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logger.Fatal("invalid trusted proxies", "proxies", cfg.Server.TrustedProxies, "error", err)
+	}
 
 	// Add middleware
 	router.Use(middleware.LoggerMiddleware())
 	router.Use(middleware.ErrorHandler())
 	router.Use(middleware.CORSMiddleware())
 	router.Use(middleware.SecurityHeadersMiddleware())
+	router.Use(middleware.RateLimitErrorHandler())
+	router.Use(middleware.RateLimitMiddleware(cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.Burst,
+		middleware.RouteRateLimit{
+			Path:  "/api/v1/auth/login",
+			Limit: middleware.PerMinute(cfg.RateLimit.LoginPerMinute),
+			Burst: cfg.RateLimit.LoginBurst,
+		},
+		middleware.RouteRateLimit{
+			Path:  "/api/v1/auth/resend-verification",
+			Limit: middleware.PerMinute(cfg.RateLimit.LoginPerMinute),
+			Burst: cfg.RateLimit.LoginBurst,
+		},
+		middleware.RouteRateLimit{
+			Path:  "/api/v1/auth/forgot-password",
+			Limit: middleware.PerMinute(cfg.RateLimit.LoginPerMinute),
+			Burst: cfg.RateLimit.LoginBurst,
+		},
+		middleware.RouteRateLimit{
+			Path:  "/api/v1/whatsapp/track/:id",
+			Limit: middleware.PerMinute(cfg.RateLimit.ClickTrackPerMinute),
+			Burst: cfg.RateLimit.ClickTrackBurst,
+		},
+	))
 	router.Use(middleware.APIVersionMiddleware("v1"))
 	router.Use(middleware.ContentTypeMiddleware())
-
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		// Check database connection
-		if err := db.HealthCheck(); err != nil {
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"status":   "unhealthy",
-				"database": "down",
-				"error":    err.Error(),
-			})
-			return
-		}
-
+	router.Use(middleware.QueryConcurrencyMiddleware(cfg.Database.MaxConcurrentQueriesPerRequest))
+	router.Use(middleware.TimeoutMiddleware(cfg.Timeouts.Default,
+		middleware.RouteTimeout{Method: http.MethodPost, Path: "/api/v1/products/images", Timeout: cfg.Timeouts.Upload},
+		middleware.RouteTimeout{Method: http.MethodPost, Path: "/api/v1/products/:id/images/batch", Timeout: cfg.Timeouts.Upload},
+		middleware.RouteTimeout{Method: http.MethodPost, Path: "/api/v1/products/:id/video", Timeout: cfg.Timeouts.Upload},
+		middleware.RouteTimeout{Method: http.MethodPost, Path: "/api/v1/products/import", Timeout: cfg.Timeouts.Upload},
+		middleware.RouteTimeout{Method: http.MethodPost, Path: "/api/v1/products/import/preview", Timeout: cfg.Timeouts.Upload},
+		middleware.RouteTimeout{Method: http.MethodPost, Path: "/api/v1/users/me/documents/:type", Timeout: cfg.Timeouts.Upload},
+		middleware.RouteTimeout{Method: http.MethodGet, Timeout: cfg.Timeouts.Read},
+	))
+
+	// Health check endpoints. Liveness only says the process is serving, so a
+	// dependency outage doesn't get the instance restarted; readiness checks
+	// the dependencies so traffic stops routing to a broken instance.
+	var storageCheck func(context.Context) error
+	if storageClient != nil && cfg.Health.CheckStorage {
+		storageCheck = storageClient.HealthCheck
+	}
+	readiness := healthCheck(cfg, db.HealthCheck, storageCheck)
+	router.GET("/health", readiness)
+	router.GET("/health/ready", readiness)
+	router.GET("/health/live", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
-			"status":      "healthy",
-			"timestamp":   time.Now(),
-			"version":     "v1",
-			"database":    "up",
-			"environment": cfg.Environment,
+			"status":    "alive",
+			"timestamp": time.Now(),
 		})
 	})
 
@@ -154,28 +273,44 @@ func main() {
 
 	// Register routes
 	authHandler.RegisterRoutes(api, authMiddleware)
-	productsHandler.RegisterRoutes(api, authMiddleware, sellerMiddleware)
+	productsHandler.RegisterRoutes(api, authMiddleware, sellerMiddleware, middleware.OptionalAuthMiddleware(jwtManager))
+	documentsHandler.RegisterRoutes(api, authMiddleware, adminMiddleware)
+	geoService.RegisterRoutes(api, middleware.OptionalAuthMiddleware(jwtManager))
 
 	// Additional API endpoints
-	registerAdditionalRoutes(api, authMiddleware, adminMiddleware, userService, transactionService, whatsappService)
+	registerAdditionalRoutes(api, authMiddleware, adminMiddleware, userService, productService, transactionService, receiptService, whatsappService, auditRecorder, db)
 
 	// Create HTTP server
 	server := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
 		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		// Uploads may take up to the upload timeout, which the request
+		// middleware enforces per route
+		ReadHeaderTimeout: 15 * time.Second,
+		ReadTimeout:       cfg.Timeouts.Upload + 5*time.Second,
+		WriteTimeout:      cfg.Timeouts.Upload + 5*time.Second,
+		IdleTimeout:       60 * time.Second,
 	}
 
+	// Background jobs stop when the server shuts down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go runProductExpiry(jobsCtx, productService, cfg.Products.ExpiryCheckInterval)
+	go runSavedSearchMatching(jobsCtx, productService, cfg.Products.SavedSearchMatchInterval)
+	go runWebhookDispatch(jobsCtx, webhookDispatcher, cfg.Webhooks.DispatchInterval)
+	go runIdempotencyKeyPurge(jobsCtx, transactionService, cfg.Transactions.IdempotencyKeyPurgeInterval)
+	go viewCounter.Run(jobsCtx, cfg.Products.ViewFlushInterval)
+
 	// Start server in a goroutine
 	go func() {
-		log.Printf("🌾 Agro Mas API server starting on port %s", cfg.Server.Port)
-		log.Printf("📍 Environment: %s", cfg.Environment)
-		log.Printf("🔗 Health check: http://localhost:%s/health", cfg.Server.Port)
+		slog.Info("Agro Mas API server starting",
+			"port", cfg.Server.Port,
+			"environment", cfg.Environment,
+			"health_check", "http://localhost:"+cfg.Server.Port+"/health",
+		)
 		
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			logger.Fatal("failed to start server", "error", err)
 		}
 	}()
 
@@ -184,7 +319,8 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("🛑 Shutting down server...")
+	slog.Info("shutting down server")
+	stopJobs()
 
 	// Give outstanding requests a deadline for completion
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -192,9 +328,190 @@ func main() {
 
 	// Attempt graceful shutdown
 	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("⚠️  Server forced to shutdown: %v", err)
+		slog.Error("server forced to shutdown", "error", err)
 	} else {
-		log.Println("✅ Server shutdown complete")
+		slog.Info("server shutdown complete")
+	}
+
+	// Write the views counted since the last flush
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := viewCounter.Flush(flushCtx); err != nil {
+		slog.Error("failed to flush product views", "error", err)
+	}
+}
+
+// healthCheck reports the status of each dependency, checked concurrently
+// within cfg.Health.Timeout. A nil storage check is reported as disabled.
+func healthCheck(cfg *config.Config, databaseCheck, storageCheck func(context.Context) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Health.Timeout)
+		defer cancel()
+
+		checks := map[string]func(context.Context) error{
+			"database": databaseCheck,
+			"storage":  storageCheck,
+		}
+
+		var mu sync.Mutex
+		statuses := make(map[string]string, len(checks))
+		failures := make(map[string]string)
+		var wg sync.WaitGroup
+		for name, check := range checks {
+			if check == nil {
+				statuses[name] = "disabled"
+				continue
+			}
+			wg.Add(1)
+			go func(name string, check func(context.Context) error) {
+				defer wg.Done()
+				err := check(ctx)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					statuses[name] = "down"
+					failures[name] = err.Error()
+					return
+				}
+				statuses[name] = "up"
+			}(name, check)
+		}
+		wg.Wait()
+
+		response := gin.H{
+			"status":      "healthy",
+			"timestamp":   time.Now(),
+			"version":     "v1",
+			"environment": cfg.Environment,
+			"database":    statuses["database"],
+			"storage":     statuses["storage"],
+		}
+		if len(failures) > 0 {
+			response["status"] = "unhealthy"
+			response["errors"] = failures
+			c.JSON(http.StatusServiceUnavailable, response)
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// runProductExpiry periodically deactivates expired products, and unpublishes
+// transport listings with expired insurance when enabled, until ctx is cancelled
+func runProductExpiry(ctx context.Context, productService *products.Service, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := productService.ExpireProducts(ctx)
+			if err != nil {
+				slog.Error("failed to expire products", "error", err)
+				continue
+			}
+			if expired > 0 {
+				slog.Info("expired products", "count", expired)
+			}
+
+			unpublished, err := productService.UnpublishExpiredInsurance(ctx)
+			if err != nil {
+				slog.Error("failed to unpublish transport with expired insurance", "error", err)
+				continue
+			}
+			if unpublished > 0 {
+				slog.Info("unpublished transport with expired insurance", "count", unpublished)
+			}
+		}
+	}
+}
+
+// runSavedSearchMatching periodically records alerts for newly published
+// products matching saved searches until ctx is cancelled
+func runSavedSearchMatching(ctx context.Context, productService *products.Service, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			recorded, err := productService.MatchSavedSearches(ctx)
+			if err != nil {
+				slog.Error("failed to match saved searches", "error", err)
+				continue
+			}
+			if recorded > 0 {
+				slog.Info("recorded saved search alerts", "count", recorded)
+			}
+		}
+	}
+}
+
+// runWebhookDispatch periodically delivers pending transaction events to
+// user webhooks until ctx is cancelled
+func runWebhookDispatch(ctx context.Context, dispatcher *transactions.WebhookDispatcher, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			delivered, err := dispatcher.DispatchPending(ctx)
+			if err != nil {
+				slog.Error("failed to dispatch webhooks", "error", err)
+				continue
+			}
+			if delivered > 0 {
+				slog.Info("delivered webhooks", "count", delivered)
+			}
+		}
+	}
+}
+
+// runIdempotencyKeyPurge periodically deletes expired transaction
+// idempotency keys until ctx is cancelled
+func runIdempotencyKeyPurge(ctx context.Context, transactionService *transactions.Service, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := transactionService.PurgeExpiredIdempotencyKeys(ctx)
+			if err != nil {
+				slog.Error("failed to purge idempotency keys", "error", err)
+				continue
+			}
+			if purged > 0 {
+				slog.Info("purged idempotency keys", "count", purged)
+			}
+		}
 	}
 }
 
@@ -203,44 +520,91 @@ func registerAdditionalRoutes(
 	api *gin.RouterGroup,
 	authMiddleware, adminMiddleware gin.HandlerFunc,
 	userService *users.Service,
+	productService *products.Service,
 	transactionService *transactions.Service,
+	receiptService *transactions.ReceiptService,
 	whatsappService *whatsapp.Service,
+	auditRecorder *audit.Recorder,
+	db *storage.Database,
 ) {
 	// Transaction routes
 	transactions := api.Group("/transactions")
 	transactions.Use(authMiddleware)
 	{
 		transactions.GET("/", getTransactions(transactionService))
+		transactions.GET("/active", getActiveTransactions(transactionService))
+		transactions.GET("/stats", getTransactionStats(transactionService))
+		transactions.GET("/by-ref/:ref", getTransactionByReference(transactionService))
 		transactions.GET("/:id", getTransaction(transactionService))
-		transactions.POST("/", createTransaction(transactionService))
+		transactions.POST("/", createTransaction(transactionService, productService, userService))
 		transactions.PUT("/:id", updateTransaction(transactionService))
 		transactions.POST("/:id/review", addTransactionReview(transactionService))
+		transactions.POST("/:id/dispute", openTransactionDispute(transactionService))
+		transactions.POST("/:id/cancel", cancelTransaction(transactionService))
+		transactions.GET("/:id/receipt", getTransactionReceipt(receiptService))
+		transactions.GET("/:id/messages", getTransactionMessages(transactionService))
+		transactions.POST("/:id/messages", addTransactionMessage(transactionService))
 	}
 
 	// Inquiry routes
 	inquiries := api.Group("/inquiries")
 	inquiries.Use(authMiddleware)
 	{
-		inquiries.POST("/", createInquiry(transactionService))
+		inquiries.GET("/", getInquiries(transactionService))
+		inquiries.GET("/:id", getInquiry(transactionService))
+		inquiries.POST("/", createInquiry(transactionService, productService))
 		inquiries.POST("/:id/respond", respondToInquiry(transactionService))
+		inquiries.DELETE("/:id", deleteInquiry(transactionService))
+	}
+
+	// Saved search routes
+	searches := api.Group("/searches")
+	searches.Use(authMiddleware)
+	{
+		searches.POST("/", createSavedSearch(productService))
+		searches.GET("/", getSavedSearches(productService))
+		searches.DELETE("/:id", deleteSavedSearch(productService))
 	}
 
+	// WhatsApp webhook, authenticated by Meta's signature instead of a user token
+	api.GET("/whatsapp/webhook", verifyWhatsAppWebhook(whatsappService))
+	api.POST("/whatsapp/webhook", receiveWhatsAppWebhook(whatsappService))
+
 	// WhatsApp routes
 	whatsappGroup := api.Group("/whatsapp")
 	whatsappGroup.Use(authMiddleware)
 	{
 		whatsappGroup.POST("/link", createWhatsAppLink(whatsappService))
+		whatsappGroup.POST("/preview", previewWhatsAppLink(whatsappService))
 		whatsappGroup.GET("/links", getUserWhatsAppLinks(whatsappService))
+		whatsappGroup.POST("/links/bulk", createBulkWhatsAppLinks(whatsappService))
 		whatsappGroup.POST("/track/:id", trackWhatsAppClick(whatsappService))
 	}
 
+	// Seller analytics routes
+	sellers := api.Group("/sellers")
+	sellers.Use(authMiddleware)
+	{
+		sellers.GET("/me/whatsapp-leaderboard", getWhatsAppLeaderboard(whatsappService))
+	}
+
 	// Admin routes
 	admin := api.Group("/admin")
-	admin.Use(adminMiddleware)
+	admin.Use(authMiddleware, adminMiddleware)
 	{
 		admin.GET("/users", getUsers(userService))
+		admin.POST("/users/merge", mergeUsers(userService))
 		admin.PUT("/users/:id/verification", updateUserVerification(userService))
-		admin.GET("/stats", getSystemStats(userService, transactionService))
+		admin.GET("/transactions", getAllTransactions(transactionService))
+		admin.GET("/transactions/stats", getPlatformTransactionStats(transactionService))
+		admin.POST("/transactions/:id/resolve-dispute", resolveTransactionDispute(transactionService))
+		admin.GET("/stats", getSystemStats(userService, productService, transactionService, db))
+		admin.GET("/products/:id/audit", getProductAudit(productService))
+		admin.GET("/audit", getAuditLog(auditRecorder))
+		admin.GET("/whatsapp/templates", getWhatsAppTemplates(whatsappService))
+		admin.POST("/whatsapp/templates", createWhatsAppTemplate(whatsappService))
+		admin.PUT("/whatsapp/templates/:id", updateWhatsAppTemplate(whatsappService))
+		admin.DELETE("/whatsapp/templates/:id", deleteWhatsAppTemplate(whatsappService))
 	}
 }
 
@@ -266,6 +630,90 @@ func getTransactions(service *transactions.Service) gin.HandlerFunc {
 	}
 }
 
+func getActiveTransactions(service *transactions.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+
+		activeTransactions, err := service.ListActiveTransactions(c.Request.Context(), userID.(uuid.UUID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Per-user view, safe for short private caching only
+		c.Header("Cache-Control", "private, max-age=30")
+		c.JSON(http.StatusOK, gin.H{"transactions": activeTransactions})
+	}
+}
+
+// getTransactionStats returns the dashboard numbers for transactions the user is a party to
+func getTransactionStats(service *transactions.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+
+		dateFrom, dateTo, ok := parseStatsDateRange(c)
+		if !ok {
+			return
+		}
+
+		id := userID.(uuid.UUID)
+		stats, err := service.GetTransactionStats(c.Request.Context(), &id, dateFrom, dateTo)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, stats)
+	}
+}
+
+// getPlatformTransactionStats returns platform-wide transaction numbers for admins
+func getPlatformTransactionStats(service *transactions.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dateFrom, dateTo, ok := parseStatsDateRange(c)
+		if !ok {
+			return
+		}
+
+		stats, err := service.GetTransactionStats(c.Request.Context(), nil, dateFrom, dateTo)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, stats)
+	}
+}
+
+// parseStatsDateRange reads the optional date_from/date_to (YYYY-MM-DD) query
+// params, writing a 400 response and returning false when they are invalid
+func parseStatsDateRange(c *gin.Context) (*time.Time, *time.Time, bool) {
+	var dateFrom, dateTo *time.Time
+	if from := c.Query("date_from"); from != "" {
+		parsed, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date_from, expected YYYY-MM-DD"})
+			return nil, nil, false
+		}
+		dateFrom = &parsed
+	}
+	if to := c.Query("date_to"); to != "" {
+		parsed, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date_to, expected YYYY-MM-DD"})
+			return nil, nil, false
+		}
+		// Include the whole end day
+		endOfDay := parsed.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		dateTo = &endOfDay
+	}
+	if dateFrom != nil && dateTo != nil && dateFrom.After(*dateTo) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date_from must not be after date_to"})
+		return nil, nil, false
+	}
+	return dateFrom, dateTo, true
+}
+
 func getTransaction(service *transactions.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, _ := c.Get("user_id")
@@ -285,7 +733,28 @@ func getTransaction(service *transactions.Service) gin.HandlerFunc {
 	}
 }
 
-func createTransaction(service *transactions.Service) gin.HandlerFunc {
+func getTransactionByReference(service *transactions.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+
+		transaction, err := service.GetTransactionByReference(c.Request.Context(), userID.(uuid.UUID), c.Param("ref"))
+		if err != nil {
+			switch err {
+			case transactions.ErrTransactionNotFound:
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case transactions.ErrTransactionNotAuthorized:
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"transaction": transaction})
+	}
+}
+
+func createTransaction(service *transactions.Service, productService *products.Service, userService *users.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, _ := c.Get("user_id")
 		
@@ -295,21 +764,116 @@ func createTransaction(service *transactions.Service) gin.HandlerFunc {
 			return
 		}
 
-		// This would need product info and user info from other services
-		productInfo := transactions.ProductInfo{} // Would be fetched
-		sellerInfo := transactions.SellerInfo{}   // Would be fetched
-		buyerInfo := transactions.BuyerInfo{}     // Would be fetched
+		// Lookup failures are server errors; everything else the service rejects is a bad request
+		status := http.StatusBadRequest
+		create := func(ctx context.Context) (*transactions.Transaction, error) {
+			product, err := productService.GetProductByID(ctx, req.ProductID, false)
+			if err != nil {
+				status = http.StatusInternalServerError
+				if err == products.ErrProductNotFound {
+					status = http.StatusNotFound
+				}
+				return nil, err
+			}
+
+			// A carrier is hired for the pickup, or the delivery when no pickup is set
+			date := req.PickupDate
+			if date == nil {
+				date = req.DeliveryDate
+			}
+			if date != nil {
+				if err := productService.CheckBookable(ctx, product, *date); err != nil {
+					status = http.StatusInternalServerError
+					if err == products.ErrCarrierBooked {
+						status = http.StatusConflict
+					}
+					return nil, err
+				}
+			}
+
+			seller, err := userService.GetUserByID(ctx, product.UserID)
+			if err != nil {
+				status = http.StatusInternalServerError
+				return nil, err
+			}
+			buyer, err := userService.GetUserByID(ctx, userID.(uuid.UUID))
+			if err != nil {
+				status = http.StatusInternalServerError
+				return nil, err
+			}
+
+			productInfo := transactionProductInfo(product)
+			sellerInfo := transactionPartyInfo(seller)
+			buyerInfo := transactions.BuyerInfo(transactionPartyInfo(buyer))
+
+			return service.CreateTransaction(ctx, userID.(uuid.UUID), &req, productInfo, sellerInfo, buyerInfo)
+		}
 
-		transaction, err := service.CreateTransaction(c.Request.Context(), userID.(uuid.UUID), &req, productInfo, sellerInfo, buyerInfo)
+		// With an Idempotency-Key a retried request returns the original transaction
+		var transaction *transactions.Transaction
+		var replayed bool
+		var err error
+		if key := c.GetHeader("Idempotency-Key"); key != "" {
+			transaction, replayed, err = service.CreateTransactionIdempotent(c.Request.Context(), userID.(uuid.UUID), key, &req, create)
+		} else {
+			transaction, err = create(c.Request.Context())
+		}
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			switch err {
+			case transactions.ErrTransactionAlreadyExists:
+				status = http.StatusConflict
+			case transactions.ErrIdempotencyKeyReused:
+				status = http.StatusUnprocessableEntity
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
 			return
 		}
 
+		if replayed {
+			c.JSON(http.StatusOK, gin.H{"transaction": transaction})
+			return
+		}
 		c.JSON(http.StatusCreated, gin.H{"transaction": transaction})
 	}
 }
 
+// transactionProductInfo snapshots the product fields a transaction needs
+func transactionProductInfo(product *products.Product) transactions.ProductInfo {
+	now := time.Now()
+	isAvailable := product.PublishedAt != nil &&
+		(product.AvailableFrom == nil || !product.AvailableFrom.After(now)) &&
+		(product.AvailableUntil == nil || product.AvailableUntil.After(now)) &&
+		(product.Quantity == nil || *product.Quantity > 0)
+
+	return transactions.ProductInfo{
+		ID:               product.ID,
+		Title:            product.Title,
+		Category:         product.Category,
+		Price:            product.Price,
+		PriceType:        product.PriceType,
+		Currency:         product.Currency,
+		Unit:             product.Unit,
+		Quantity:         product.Quantity,
+		MinOrderQuantity: product.MinOrderQuantity,
+		IsActive:         product.IsActive,
+		IsAvailable:      isAvailable,
+		SellerID:         product.UserID,
+	}
+}
+
+// transactionPartyInfo snapshots a user's contact details for transaction metadata
+func transactionPartyInfo(user *users.User) transactions.SellerInfo {
+	info := transactions.SellerInfo{
+		Name:              user.DisplayName(),
+		Email:             user.Email,
+		VerificationLevel: user.VerificationLevel,
+	}
+	if user.Phone != nil {
+		info.Phone = *user.Phone
+	}
+	return info
+}
+
 func updateTransaction(service *transactions.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, _ := c.Get("user_id")
@@ -360,127 +924,893 @@ func addTransactionReview(service *transactions.Service) gin.HandlerFunc {
 	}
 }
 
-// Inquiry handlers
-func createInquiry(service *transactions.Service) gin.HandlerFunc {
+func openTransactionDispute(service *transactions.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, _ := c.Get("user_id")
-		
-		var req transactions.CreateInquiryRequest
+		transactionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+			return
+		}
+
+		var req transactions.OpenDisputeRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		// Would need to get seller ID from product
-		sellerID := uuid.New() // This would be fetched from product service
-
-		inquiry, err := service.CreateInquiry(c.Request.Context(), userID.(uuid.UUID), &req, sellerID)
+		err = service.OpenDispute(c.Request.Context(), userID.(uuid.UUID), transactionID, req.Reason)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			status := http.StatusInternalServerError
+			switch err {
+			case transactions.ErrTransactionNotFound:
+				status = http.StatusNotFound
+			case transactions.ErrTransactionNotAuthorized:
+				status = http.StatusForbidden
+			case transactions.ErrDisputeReasonRequired:
+				status = http.StatusBadRequest
+			case transactions.ErrDisputeNotAllowed:
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusCreated, gin.H{"inquiry": inquiry})
+		c.JSON(http.StatusOK, gin.H{"message": "Dispute opened successfully"})
 	}
 }
 
-func respondToInquiry(service *transactions.Service) gin.HandlerFunc {
+func cancelTransaction(service *transactions.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, _ := c.Get("user_id")
-		inquiryID, err := uuid.Parse(c.Param("id"))
+		transactionID, err := uuid.Parse(c.Param("id"))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inquiry ID"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
 			return
 		}
 
-		var req transactions.RespondToInquiryRequest
+		var req transactions.CancelTransactionRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		err = service.RespondToInquiry(c.Request.Context(), userID.(uuid.UUID), inquiryID, &req)
+		err = service.CancelTransaction(c.Request.Context(), userID.(uuid.UUID), transactionID, req.Reason)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			status := http.StatusInternalServerError
+			switch err {
+			case transactions.ErrTransactionNotFound:
+				status = http.StatusNotFound
+			case transactions.ErrTransactionNotAuthorized:
+				status = http.StatusForbidden
+			case transactions.ErrCancelReasonRequired:
+				status = http.StatusBadRequest
+			case transactions.ErrCancelNotAllowed:
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Response sent successfully"})
+		c.JSON(http.StatusOK, gin.H{"message": "Transaction cancelled successfully"})
 	}
 }
 
-// WhatsApp handlers
-func createWhatsAppLink(service *whatsapp.Service) gin.HandlerFunc {
+func getTransactionReceipt(service *transactions.ReceiptService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, _ := c.Get("user_id")
-		
-		var req whatsapp.CreateLinkRequest
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		role, _ := c.Get("user_role")
+		requesterRole, _ := role.(string)
+		transactionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
 			return
 		}
 
-		link, err := service.CreateWhatsAppLink(c.Request.Context(), userID.(uuid.UUID), req)
+		receipt, err := service.GetReceipt(c.Request.Context(), userID.(uuid.UUID), requesterRole, transactionID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			status := http.StatusInternalServerError
+			switch err {
+			case transactions.ErrTransactionNotFound:
+				status = http.StatusNotFound
+			case transactions.ErrTransactionNotAuthorized:
+				status = http.StatusForbidden
+			case transactions.ErrReceiptNotAvailable:
+				status = http.StatusConflict
+			case transactions.ErrReceiptStorageMissing:
+				status = http.StatusServiceUnavailable
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusCreated, gin.H{"link": link})
+		c.JSON(http.StatusOK, gin.H{"receipt": receipt})
 	}
 }
 
-func getUserWhatsAppLinks(service *whatsapp.Service) gin.HandlerFunc {
+func getTransactionMessages(service *transactions.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, _ := c.Get("user_id")
-		linkType := c.Query("type")
-		
-		links, err := service.GetUserWhatsAppLinks(c.Request.Context(), userID.(uuid.UUID), linkType, 50)
+		transactionID, err := uuid.Parse(c.Param("id"))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"links": links})
+		messages, err := service.GetCommunicationMessages(c.Request.Context(), userID.(uuid.UUID), transactionID)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch err {
+			case transactions.ErrTransactionNotFound:
+				status = http.StatusNotFound
+			case transactions.ErrTransactionNotAuthorized:
+				status = http.StatusForbidden
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"messages": messages})
 	}
 }
 
-func trackWhatsAppClick(service *whatsapp.Service) gin.HandlerFunc {
+func addTransactionMessage(service *transactions.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		linkID, err := uuid.Parse(c.Param("id"))
+		userID, _ := c.Get("user_id")
+		transactionID, err := uuid.Parse(c.Param("id"))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
 			return
 		}
 
-		err = service.TrackLinkClick(c.Request.Context(), linkID)
-		if err != nil {
+		var req transactions.AddCommunicationMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Click tracked"})
+		message, err := service.AddCommunicationMessage(c.Request.Context(), userID.(uuid.UUID), transactionID, transactions.CommunicationMessage{
+			Channel:     req.Channel,
+			MessageType: req.MessageType,
+			Content:     req.Content,
+			Metadata:    req.Metadata,
+		})
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch err {
+			case transactions.ErrTransactionNotFound:
+				status = http.StatusNotFound
+			case transactions.ErrTransactionNotAuthorized:
+				status = http.StatusForbidden
+			case transactions.ErrInvalidMessageChannel, transactions.ErrInvalidMessageType:
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": message})
 	}
 }
 
-// Admin handlers (simplified)
-func getUsers(service *users.Service) gin.HandlerFunc {
+// Inquiry handlers
+func createInquiry(service *transactions.Service, productService *products.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Implementation would go here
-		c.JSON(http.StatusOK, gin.H{"message": "Users endpoint"})
+		userID, _ := c.Get("user_id")
+		
+		var req transactions.CreateInquiryRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Inquiries go to the product's owner
+		product, err := productService.GetProductByID(c.Request.Context(), req.ProductID, false)
+		if err != nil {
+			if err == products.ErrProductNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		inquiry, err := service.CreateInquiry(c.Request.Context(), userID.(uuid.UUID), &req, product.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"inquiry": inquiry})
 	}
 }
 
-func updateUserVerification(service *users.Service) gin.HandlerFunc {
+// getInquiries lists the user's inquiries as buyer and seller
+func getInquiries(service *transactions.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Implementation would go here
-		c.JSON(http.StatusOK, gin.H{"message": "User verification update endpoint"})
+		userID, _ := c.Get("user_id")
+
+		req := &transactions.InquiryListRequest{}
+		if err := c.ShouldBindQuery(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		response, err := service.ListInquiries(c.Request.Context(), userID.(uuid.UUID), req)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if err == transactions.ErrInvalidInquiryRole || err == transactions.ErrInvalidInquiryType {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
 	}
 }
 
-func getSystemStats(userService *users.Service, transactionService *transactions.Service) gin.HandlerFunc {
+func getInquiry(service *transactions.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Implementation would go here
-		c.JSON(http.StatusOK, gin.H{"message": "System stats endpoint"})
+		userID, _ := c.Get("user_id")
+		inquiryID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inquiry ID"})
+			return
+		}
+
+		inquiry, err := service.GetInquiry(c.Request.Context(), userID.(uuid.UUID), inquiryID)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch err {
+			case transactions.ErrInquiryNotFound:
+				status = http.StatusNotFound
+			case transactions.ErrInquiryNotAuthorized:
+				status = http.StatusForbidden
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"inquiry": inquiry})
+	}
+}
+
+func respondToInquiry(service *transactions.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		inquiryID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inquiry ID"})
+			return
+		}
+
+		var req transactions.RespondToInquiryRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err = service.RespondToInquiry(c.Request.Context(), userID.(uuid.UUID), inquiryID, &req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Response sent successfully"})
+	}
+}
+
+func deleteInquiry(service *transactions.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		inquiryID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inquiry ID"})
+			return
+		}
+
+		err = service.DeleteInquiry(c.Request.Context(), userID.(uuid.UUID), inquiryID)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch err {
+			case transactions.ErrInquiryNotFound:
+				status = http.StatusNotFound
+			case transactions.ErrInquiryNotAuthorized:
+				status = http.StatusForbidden
+			case transactions.ErrInquiryAlreadyResponded:
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Inquiry deleted successfully"})
+	}
+}
+
+// Saved search handlers
+func createSavedSearch(service *products.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+
+		var req products.CreateSavedSearchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		search, err := service.CreateSavedSearch(c.Request.Context(), userID.(uuid.UUID), &req)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch err {
+			case products.ErrTooManySavedSearches:
+				status = http.StatusConflict
+			case products.ErrInvalidTagMatch, products.ErrInvalidSearchRange, products.ErrInvalidSearchLanguage, products.ErrInvalidQuery:
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"search": search})
+	}
+}
+
+func getSavedSearches(service *products.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+
+		searches, err := service.GetSavedSearches(c.Request.Context(), userID.(uuid.UUID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"searches": searches})
+	}
+}
+
+func deleteSavedSearch(service *products.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		searchID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid saved search ID"})
+			return
+		}
+
+		err = service.DeleteSavedSearch(c.Request.Context(), userID.(uuid.UUID), searchID)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if err == products.ErrSavedSearchNotFound {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Saved search deleted successfully"})
+	}
+}
+
+// WhatsApp handlers
+func createWhatsAppLink(service *whatsapp.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		
+		var req whatsapp.CreateLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		link, err := service.CreateWhatsAppLink(c.Request.Context(), userID.(uuid.UUID), req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"link": link})
+	}
+}
+
+func createBulkWhatsAppLinks(service *whatsapp.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+
+		var req whatsapp.BulkCreateLinksRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		links, err := service.CreateBulkWhatsAppLinks(c.Request.Context(), userID.(uuid.UUID), req)
+		if err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, whatsapp.ErrProductNotOwned) {
+				status = http.StatusForbidden
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"links": links, "count": len(links)})
+	}
+}
+
+func previewWhatsAppLink(service *whatsapp.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req whatsapp.CreateLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		preview, err := service.PreviewLink(c.Request.Context(), req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"preview": preview})
+	}
+}
+
+// WhatsApp template admin handlers
+func getWhatsAppTemplates(service *whatsapp.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		templates, err := service.ListTemplates(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"templates": templates})
+	}
+}
+
+func createWhatsAppTemplate(service *whatsapp.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req whatsapp.CreateTemplateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		adminID, _ := c.Get("user_id")
+		template, err := service.CreateTemplate(c.Request.Context(), adminID.(uuid.UUID), req)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, whatsapp.ErrInvalidTemplate) {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"template": template})
+	}
+}
+
+func updateWhatsAppTemplate(service *whatsapp.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		templateID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+			return
+		}
+
+		var req whatsapp.UpdateTemplateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		adminID, _ := c.Get("user_id")
+		template, err := service.UpdateTemplate(c.Request.Context(), adminID.(uuid.UUID), templateID, req)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case err == whatsapp.ErrTemplateNotFound:
+				status = http.StatusNotFound
+			case errors.Is(err, whatsapp.ErrInvalidTemplate):
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"template": template})
+	}
+}
+
+func deleteWhatsAppTemplate(service *whatsapp.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		templateID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+			return
+		}
+
+		adminID, _ := c.Get("user_id")
+		if err := service.DeleteTemplate(c.Request.Context(), adminID.(uuid.UUID), templateID); err != nil {
+			status := http.StatusInternalServerError
+			if err == whatsapp.ErrTemplateNotFound {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Template deleted successfully"})
+	}
+}
+
+func getUserWhatsAppLinks(service *whatsapp.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		linkType := c.Query("type")
+		
+		links, err := service.GetUserWhatsAppLinks(c.Request.Context(), userID.(uuid.UUID), linkType, 50)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"links": links})
+	}
+}
+
+func trackWhatsAppClick(service *whatsapp.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		linkID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
+			return
+		}
+
+		err = service.TrackLinkClick(c.Request.Context(), linkID, whatsapp.ClickInfo{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Click tracked"})
+	}
+}
+
+func verifyWhatsAppWebhook(service *whatsapp.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !service.VerifySubscription(c.Query("hub.mode"), c.Query("hub.verify_token")) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid verify token"})
+			return
+		}
+
+		c.String(http.StatusOK, c.Query("hub.challenge"))
+	}
+}
+
+func receiveWhatsAppWebhook(service *whatsapp.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := c.GetRawData()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+
+		// Reject unsigned or forged callbacks before touching the payload
+		if !service.VerifySignature(body, c.GetHeader("X-Hub-Signature-256")) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid signature"})
+			return
+		}
+
+		processed, err := service.ProcessWebhook(c.Request.Context(), body)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, whatsapp.ErrInvalidWebhookPayload) {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"processed": processed})
+	}
+}
+
+func getWhatsAppLeaderboard(service *whatsapp.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+
+		// Default to the last 30 days
+		toDate := time.Now()
+		fromDate := toDate.AddDate(0, 0, -30)
+		if from := c.Query("from"); from != "" {
+			parsed, err := time.Parse("2006-01-02", from)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+				return
+			}
+			fromDate = parsed
+		}
+		if to := c.Query("to"); to != "" {
+			parsed, err := time.Parse("2006-01-02", to)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+				return
+			}
+			// Include the whole end day
+			toDate = parsed.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		if fromDate.After(toDate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+			return
+		}
+
+		limit, _ := strconv.Atoi(c.Query("limit"))
+		if limit < 1 || limit > 100 {
+			limit = 20
+		}
+
+		leaderboard, err := service.GetSellerLinkLeaderboard(c.Request.Context(), userID.(uuid.UUID), fromDate, toDate, c.Query("sort_by"), limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"from":        fromDate,
+			"to":          toDate,
+			"leaderboard": leaderboard,
+		})
+	}
+}
+
+// Admin handlers (simplified)
+func getUsers(service *users.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filters := users.UserFilters{
+			Role:     c.Query("role"),
+			Province: c.Query("province"),
+			Query:    strings.TrimSpace(c.Query("q")),
+		}
+
+		if level := c.Query("verification_level"); level != "" {
+			parsed, err := strconv.Atoi(level)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification_level"})
+				return
+			}
+			filters.VerificationLevel = parsed
+		}
+
+		if verified := c.Query("is_verified"); verified != "" {
+			parsed, err := strconv.ParseBool(verified)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid is_verified"})
+				return
+			}
+			filters.IsVerified = &parsed
+		}
+
+		page, _ := strconv.Atoi(c.Query("page"))
+		pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+		response, err := service.ListUsers(c.Request.Context(), filters, page, pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func resolveTransactionDispute(service *transactions.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, _ := c.Get("user_id")
+		transactionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+			return
+		}
+
+		var req transactions.ResolveDisputeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err = service.ResolveDispute(c.Request.Context(), adminID.(uuid.UUID), transactionID, req.Resolution, req.FinalStatus)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch err {
+			case transactions.ErrTransactionNotFound:
+				status = http.StatusNotFound
+			case transactions.ErrInvalidDisputeResolution:
+				status = http.StatusBadRequest
+			case transactions.ErrTransactionNotDisputed:
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Dispute resolved successfully"})
+	}
+}
+
+func getAuditLog(recorder *audit.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := &audit.ListRequest{}
+		if err := c.ShouldBindQuery(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		dateFrom, dateTo, ok := parseStatsDateRange(c)
+		if !ok {
+			return
+		}
+		req.DateFrom = dateFrom
+		req.DateTo = dateTo
+
+		response, err := recorder.List(c.Request.Context(), req)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if err == audit.ErrInvalidFilter {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func getAllTransactions(service *transactions.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := &transactions.TransactionListRequest{}
+		if err := c.ShouldBindQuery(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		response, err := service.ListTransactions(c.Request.Context(), nil, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func updateUserVerification(service *users.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, _ := c.Get("user_id")
+
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		var req users.UpdateVerificationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := service.UpdateVerificationLevel(c.Request.Context(), adminID.(uuid.UUID), userID, *req.VerificationLevel, *req.IsVerified)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch err {
+			case users.ErrUserNotFound:
+				status = http.StatusNotFound
+			case users.ErrInvalidVerificationLevel:
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"verification": result})
+	}
+}
+
+func mergeUsers(service *users.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, _ := c.Get("user_id")
+
+		var req users.MergeUsersRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := service.MergeUsers(c.Request.Context(), adminID.(uuid.UUID), &req)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch err {
+			case users.ErrUserNotFound:
+				status = http.StatusNotFound
+			case users.ErrMergeSameUser:
+				status = http.StatusBadRequest
+			case users.ErrMergeConflict:
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"merge": result})
+	}
+}
+
+func getProductAudit(service *products.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+
+		audit, err := service.GetProductAudit(c.Request.Context(), productID)
+		if err != nil {
+			if err == products.ErrProductNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, audit)
+	}
+}
+
+// SystemStatsResponse is the admin dashboard overview. TotalGMV is the value
+// of completed transactions.
+type SystemStatsResponse struct {
+	Users        *users.UserStats                       `json:"users"`
+	Products     *products.ProductStats                 `json:"products"`
+	Transactions *transactions.TransactionStatsResponse `json:"transactions"`
+	TotalGMV     float64                                `json:"total_gmv"`
+	Database     *storage.PoolStats                     `json:"database"`
+	GeneratedAt  time.Time                              `json:"generated_at"`
+}
+
+func getSystemStats(userService *users.Service, productService *products.Service, transactionService *transactions.Service, db *storage.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response := SystemStatsResponse{GeneratedAt: time.Now(), Database: db.PoolStats()}
+
+		// The aggregates hit independent tables, so run them concurrently
+		g, ctx := errgroup.WithContext(c.Request.Context())
+		g.Go(func() (err error) {
+			response.Users, err = userService.GetUserStats(ctx)
+			return err
+		})
+		g.Go(func() (err error) {
+			response.Products, err = productService.GetProductStats(ctx)
+			return err
+		})
+		g.Go(func() (err error) {
+			response.Transactions, err = transactionService.GetTransactionStats(ctx, nil, nil, nil)
+			return err
+		})
+
+		if err := g.Wait(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		response.TotalGMV = response.Transactions.TotalRevenue
+		c.JSON(http.StatusOK, response)
 	}
 }
\ No newline at end of file
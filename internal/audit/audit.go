@@ -0,0 +1,207 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"agro-mas-backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidFilter = errors.New("actor_id and entity_id must be valid UUIDs")
+
+// Audited admin actions
+const (
+	ActionVerificationUpdated = "user.verification_updated"
+	ActionUsersMerged         = "user.merged"
+	ActionDocumentReviewed    = "user.document_reviewed"
+	ActionDisputeResolved     = "transaction.dispute_resolved"
+	ActionTemplateCreated     = "whatsapp_template.created"
+	ActionTemplateUpdated     = "whatsapp_template.updated"
+	ActionTemplateDeleted     = "whatsapp_template.deleted"
+)
+
+// Entity types actions apply to
+const (
+	EntityUser             = "user"
+	EntityTransaction      = "transaction"
+	EntityWhatsAppTemplate = "whatsapp_template"
+)
+
+type Entry struct {
+	ID         uuid.UUID       `json:"id"`
+	ActorID    uuid.UUID       `json:"actor_id"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   uuid.UUID       `json:"entity_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type ListRequest struct {
+	ActorID    string     `json:"actor_id,omitempty" form:"actor_id"`
+	EntityType string     `json:"entity_type,omitempty" form:"entity_type"`
+	EntityID   string     `json:"entity_id,omitempty" form:"entity_id"`
+	DateFrom   *time.Time `json:"date_from,omitempty" form:"-"`
+	DateTo     *time.Time `json:"date_to,omitempty" form:"-"`
+	Page       int        `json:"page,omitempty" form:"page"`
+	PageSize   int        `json:"page_size,omitempty" form:"page_size"`
+}
+
+type ListResponse struct {
+	Entries    []*Entry `json:"entries"`
+	TotalCount int      `json:"total_count"`
+	Page       int      `json:"page"`
+	PageSize   int      `json:"page_size"`
+	TotalPages int      `json:"total_pages"`
+}
+
+// Recorder writes the audit log. A nil Recorder records nothing, so services
+// can hold one without every caller configuring it.
+type Recorder struct {
+	db *sql.DB
+}
+
+func NewRecorder(db *sql.DB) *Recorder {
+	return &Recorder{db: db}
+}
+
+// Record logs an admin action on an entity with JSON snapshots of it before
+// and after; either may be nil. It is called once the action succeeded and
+// never fails it: write errors are only logged.
+func (r *Recorder) Record(ctx context.Context, actorID uuid.UUID, action, entityType string, entityID uuid.UUID, before, after interface{}) {
+	if r == nil {
+		return
+	}
+
+	beforeJSON, err := snapshot(before)
+	if err == nil {
+		var afterJSON []byte
+		afterJSON, err = snapshot(after)
+		if err == nil {
+			_, err = r.db.ExecContext(ctx, `
+				INSERT INTO audit_log (actor_id, action, entity_type, entity_id, before, after)
+				VALUES ($1, $2, $3, $4, $5, $6)`,
+				actorID, action, entityType, entityID, beforeJSON, afterJSON)
+		}
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to record audit entry",
+			"action", action, "entity_type", entityType, "entity_id", entityID, "actor_id", actorID, "error", err)
+	}
+}
+
+// snapshot marshals v for a JSONB column, keeping nil as NULL
+func snapshot(v interface{}) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// List returns audit entries matching the filters, newest first
+func (r *Recorder) List(ctx context.Context, req *ListRequest) (*ListResponse, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 50
+	}
+
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+	argIndex := 1
+
+	if req.ActorID != "" {
+		actorID, err := uuid.Parse(req.ActorID)
+		if err != nil {
+			return nil, ErrInvalidFilter
+		}
+		conditions = append(conditions, fmt.Sprintf("actor_id = $%d", argIndex))
+		args = append(args, actorID)
+		argIndex++
+	}
+
+	if req.EntityType != "" {
+		conditions = append(conditions, fmt.Sprintf("entity_type = $%d", argIndex))
+		args = append(args, req.EntityType)
+		argIndex++
+	}
+
+	if req.EntityID != "" {
+		entityID, err := uuid.Parse(req.EntityID)
+		if err != nil {
+			return nil, ErrInvalidFilter
+		}
+		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", argIndex))
+		args = append(args, entityID)
+		argIndex++
+	}
+
+	if req.DateFrom != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argIndex))
+		args = append(args, *req.DateFrom)
+		argIndex++
+	}
+
+	if req.DateTo != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", argIndex))
+		args = append(args, *req.DateTo)
+		argIndex++
+	}
+
+	whereClause := strings.Join(conditions, " AND ")
+
+	var totalCount int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log WHERE `+whereClause, args...).Scan(&totalCount); err != nil {
+		return nil, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, actor_id, action, entity_type, entity_id, before, after, created_at
+		FROM audit_log
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, whereClause, argIndex, argIndex+1)
+	args = append(args, req.PageSize, (req.Page-1)*req.PageSize)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*Entry{}
+	for rows.Next() {
+		entry := &Entry{}
+		var before, after []byte
+		if err := rows.Scan(&entry.ID, &entry.ActorID, &entry.Action, &entry.EntityType,
+			&entry.EntityID, &before, &after, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entry.Before = before
+		entry.After = after
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &ListResponse{
+		Entries:    entries,
+		TotalCount: totalCount,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: (totalCount + req.PageSize - 1) / req.PageSize,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrInvalidCBUFormat   = errors.New("CBU must be 22 digits")
+	ErrInvalidCBUChecksum = errors.New("invalid CBU check digit")
+)
+
+// CBU check digit weights. The first block (bank and branch) has 7 digits
+// plus a check digit, the second (account) 13 digits plus a check digit.
+var (
+	cbuBankWeights    = []int{7, 1, 3, 9, 7, 1, 3}
+	cbuAccountWeights = []int{3, 9, 7, 1, 3, 9, 7, 1, 3, 9, 7, 1, 3}
+)
+
+// ValidateCBU validates an Argentine CBU (Clave Bancaria Uniforme) against
+// the BCRA check digits of both blocks. Whitespace is ignored.
+func ValidateCBU(cbu string) error {
+	clean := StripCBU(cbu)
+	if len(clean) != 22 {
+		return ErrInvalidCBUFormat
+	}
+
+	digits := make([]int, 22)
+	for i, char := range clean {
+		if char < '0' || char > '9' {
+			return ErrInvalidCBUFormat
+		}
+		digits[i] = int(char - '0')
+	}
+
+	if cbuCheckDigit(digits[:7], cbuBankWeights) != digits[7] ||
+		cbuCheckDigit(digits[8:21], cbuAccountWeights) != digits[21] {
+		return ErrInvalidCBUChecksum
+	}
+
+	return nil
+}
+
+// StripCBU removes whitespace from a CBU as typed or pasted by the user
+func StripCBU(cbu string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, cbu)
+}
+
+// cbuCheckDigit returns the digit that brings the weighted sum of a block to
+// a multiple of 10
+func cbuCheckDigit(digits, weights []int) int {
+	sum := 0
+	for i, digit := range digits {
+		sum += digit * weights[i]
+	}
+	return (10 - sum%10) % 10
+}
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateCBU(t *testing.T) {
+	tests := []struct {
+		name    string
+		cbu     string
+		wantErr error
+	}{
+		{"valid", "2850590940090418135201", nil},
+		{"valid other bank", "0170099220000067797370", nil},
+		{"valid with spaces", " 28505909 40090418135201 ", nil},
+		{"bad bank check digit", "2850590840090418135201", ErrInvalidCBUChecksum},
+		{"bad account check digit", "2850590940090418135202", ErrInvalidCBUChecksum},
+		{"transposed account digits", "2850590940090418153201", ErrInvalidCBUChecksum},
+		{"too short", "285059094009041813520", ErrInvalidCBUFormat},
+		{"too long", "28505909400904181352010", ErrInvalidCBUFormat},
+		{"non digits", "2850590940090418I35201", ErrInvalidCBUFormat},
+		{"dashes", "28505909-40090418135201", ErrInvalidCBUFormat},
+		{"empty", "", ErrInvalidCBUFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCBU(tt.cbu); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateCBU(%q) = %v, want %v", tt.cbu, err, tt.wantErr)
+			}
+		})
+	}
+}
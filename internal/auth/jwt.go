@@ -1,7 +1,13 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -9,14 +15,29 @@ import (
 )
 
 var (
-	ErrInvalidToken = errors.New("invalid token")
-	ErrExpiredToken = errors.New("token has expired")
-	ErrInvalidClaims = errors.New("invalid token claims")
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrExpiredToken     = errors.New("token has expired")
+	ErrInvalidClaims    = errors.New("invalid token claims")
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+	ErrInvalidIssuer    = errors.New("token issuer mismatch")
+	ErrInvalidAudience  = errors.New("token audience mismatch")
+)
+
+// Issuer and audience stamped on tokens unless configured otherwise
+const (
+	DefaultTokenIssuer   = "agro-mas-backend"
+	DefaultTokenAudience = "agro-mas-frontend"
 )
 
 type JWTManager struct {
-	secretKey     string
-	tokenDuration time.Duration
+	secretKey       string
+	tokenDuration   time.Duration
+	refreshDuration time.Duration
+	issuer          string
+	audience        string
+	revocations     RevocationStore
 }
 
 type UserClaims struct {
@@ -31,16 +52,32 @@ type UserClaims struct {
 }
 
 type TokenResponse struct {
-	AccessToken  string    `json:"access_token"`
-	RefreshToken string    `json:"refresh_token"`
-	ExpiresAt    time.Time `json:"expires_at"`
-	TokenType    string    `json:"token_type"`
+	AccessToken           string    `json:"access_token"`
+	RefreshToken          string    `json:"refresh_token"`
+	ExpiresAt             time.Time `json:"expires_at"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	TokenType             string    `json:"token_type"`
 }
 
-func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
+func NewJWTManager(secretKey string, tokenDuration, refreshDuration time.Duration) *JWTManager {
 	return &JWTManager{
-		secretKey:     secretKey,
-		tokenDuration: tokenDuration,
+		secretKey:       secretKey,
+		tokenDuration:   tokenDuration,
+		refreshDuration: refreshDuration,
+		issuer:          DefaultTokenIssuer,
+		audience:        DefaultTokenAudience,
+	}
+}
+
+// SetIssuerAudience sets the iss and aud claims tokens are minted with and
+// must carry to verify, so tokens from another environment are rejected.
+// Empty values keep the current ones.
+func (manager *JWTManager) SetIssuerAudience(issuer, audience string) {
+	if issuer != "" {
+		manager.issuer = issuer
+	}
+	if audience != "" {
+		manager.audience = audience
 	}
 }
 
@@ -70,8 +107,8 @@ func (manager *JWTManager) GenerateToken(
 			NotBefore: jwt.NewNumericDate(now),
 			ID:        uuid.New().String(),
 			Subject:   userID.String(),
-			Issuer:    "agro-mas-backend",
-			Audience:  jwt.ClaimStrings{"agro-mas-frontend"},
+			Issuer:    manager.issuer,
+			Audience:  jwt.ClaimStrings{manager.audience},
 		},
 	}
 
@@ -81,65 +118,76 @@ func (manager *JWTManager) GenerateToken(
 		return nil, err
 	}
 
-	// Generate refresh token with longer expiration
-	refreshExpiresAt := now.Add(time.Hour * 24 * 7) // 7 days
-	refreshClaims := jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(refreshExpiresAt),
-		IssuedAt:  jwt.NewNumericDate(now),
-		NotBefore: jwt.NewNumericDate(now),
-		ID:        uuid.New().String(),
-		Subject:   userID.String(),
-		Issuer:    "agro-mas-backend",
-		Audience:  jwt.ClaimStrings{"agro-mas-refresh"},
-	}
-
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString([]byte(manager.secretKey))
-	if err != nil {
+	// The refresh token is an opaque random value; callers persist its hash
+	// so it can be rotated and revoked server-side
+	refreshBytes := make([]byte, 32)
+	if _, err := rand.Read(refreshBytes); err != nil {
 		return nil, err
 	}
 
 	return &TokenResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshTokenString,
-		ExpiresAt:    expiresAt,
-		TokenType:    "Bearer",
+		AccessToken:           accessToken,
+		RefreshToken:          base64.RawURLEncoding.EncodeToString(refreshBytes),
+		ExpiresAt:             expiresAt,
+		RefreshTokenExpiresAt: now.Add(manager.refreshDuration),
+		TokenType:             "Bearer",
 	}, nil
 }
 
-func (manager *JWTManager) VerifyToken(tokenString string) (*UserClaims, error) {
-	token, err := jwt.ParseWithClaims(
-		tokenString,
-		&UserClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			_, ok := token.Method.(*jwt.SigningMethodHMAC)
-			if !ok {
-				return nil, ErrInvalidToken
-			}
-			return []byte(manager.secretKey), nil
-		},
-	)
+// SetRevocationStore enables revoking access tokens before they expire.
+// Without a store revocation is a no-op and tokens live until expiry.
+func (manager *JWTManager) SetRevocationStore(store RevocationStore) {
+	manager.revocations = store
+}
 
-	if err != nil {
-		return nil, ErrInvalidToken
+// RevokeToken revokes a single access token, e.g. on logout
+func (manager *JWTManager) RevokeToken(ctx context.Context, claims *UserClaims) error {
+	if manager.revocations == nil || claims.ID == "" {
+		return nil
 	}
+	return manager.revocations.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time)
+}
 
-	claims, ok := token.Claims.(*UserClaims)
-	if !ok {
-		return nil, ErrInvalidClaims
+// RevokeUserTokens revokes every access token issued to the user so far.
+// Tokens minted later, e.g. after reactivation, are unaffected. The iat claim
+// only has second precision, so the cutoff is truncated to match it and
+// tokens issued within the same second are revoked too.
+func (manager *JWTManager) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	if manager.revocations == nil {
+		return nil
 	}
+	now := time.Now()
+	return manager.revocations.RevokeUserTokens(ctx, userID, now.Truncate(time.Second), now.Add(manager.tokenDuration))
+}
 
-	if time.Now().After(claims.ExpiresAt.Time) {
-		return nil, ErrExpiredToken
+// Authenticate verifies an access token and rejects it with ErrRevokedToken
+// if it has been revoked, or ErrRevocationUnavailable if the store failed.
+func (manager *JWTManager) Authenticate(ctx context.Context, tokenString string) (*UserClaims, error) {
+	claims, err := manager.VerifyToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if manager.revocations == nil {
+		return claims, nil
 	}
 
+	revoked, err := manager.revocations.IsRevoked(ctx, claims.ID, claims.UserID, claims.IssuedAt.Time)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRevocationUnavailable, err)
+	}
+	if revoked {
+		return nil, ErrRevokedToken
+	}
 	return claims, nil
 }
 
-func (manager *JWTManager) RefreshToken(refreshTokenString string) (*TokenResponse, error) {
+// VerifyToken checks the signature, expiry, not-before, issuer and audience of
+// an access token. Each failure has its own error for logging; none of them
+// should be shown to clients.
+func (manager *JWTManager) VerifyToken(tokenString string) (*UserClaims, error) {
 	token, err := jwt.ParseWithClaims(
-		refreshTokenString,
-		&jwt.RegisteredClaims{},
+		tokenString,
+		&UserClaims{},
 		func(token *jwt.Token) (interface{}, error) {
 			_, ok := token.Method.(*jwt.SigningMethodHMAC)
 			if !ok {
@@ -147,50 +195,69 @@ func (manager *JWTManager) RefreshToken(refreshTokenString string) (*TokenRespon
 			}
 			return []byte(manager.secretKey), nil
 		},
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(manager.issuer),
+		jwt.WithAudience(manager.audience),
 	)
 
 	if err != nil {
-		return nil, ErrInvalidToken
+		return nil, verificationError(err)
 	}
 
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	claims, ok := token.Claims.(*UserClaims)
 	if !ok {
 		return nil, ErrInvalidClaims
 	}
 
-	if time.Now().After(claims.ExpiresAt.Time) {
-		return nil, ErrExpiredToken
+	// Every token minted here carries nbf, iat and jti
+	if claims.NotBefore == nil || claims.IssuedAt == nil || claims.ID == "" {
+		return nil, ErrInvalidClaims
 	}
 
-	// Validate audience for refresh token
-	validAudience := false
-	for _, aud := range claims.Audience {
-		if aud == "agro-mas-refresh" {
-			validAudience = true
-			break
-		}
-	}
-	if !validAudience {
-		return nil, ErrInvalidToken
+	return claims, nil
+}
+
+// verificationError maps a jwt parse failure to the reason it was rejected
+func verificationError(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return ErrMalformedToken
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return ErrInvalidSignature
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ErrExpiredToken
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return ErrTokenNotYetValid
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return ErrInvalidIssuer
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return ErrInvalidAudience
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return ErrInvalidClaims
 	}
+	return ErrInvalidToken
+}
 
-	userID, err := uuid.Parse(claims.Subject)
-	if err != nil {
-		return nil, ErrInvalidClaims
+// HashRefreshToken returns the digest under which a refresh token is stored,
+// so a leaked table cannot be replayed as tokens
+func HashRefreshToken(refreshToken string) string {
+	return HashToken(refreshToken)
+}
+
+// NewOpaqueToken returns a random URL-safe token for one-time links such as
+// email verification. Like refresh tokens, only its HashToken digest is stored.
+func NewOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	// Here you would typically fetch the user from database to get updated information
-	// For now, we'll return a placeholder response
-	// In a real implementation, you'd call a user service to get current user data
-	return manager.GenerateToken(
-		userID,
-		"", // These would be fetched from database
-		"",
-		nil,
-		nil,
-		0,
-		false,
-	)
+// HashToken returns the SHA-256 digest under which an opaque token is stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 // ExtractTokenFromHeader extracts JWT token from Authorization header
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRevokeUserTokensMatchesIssuedAtPrecision(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	manager := NewJWTManager("test-secret", time.Hour, 24*time.Hour)
+	manager.SetRevocationStore(store)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	tokens, err := manager.GenerateToken(userID, "seller@test.local", "seller", nil, nil, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.RevokeUserTokens(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	revocation := store.users[userID]
+	if !revocation.issuedBefore.Equal(revocation.issuedBefore.Truncate(time.Second)) {
+		t.Errorf("expected a whole-second cutoff like iat, got %v", revocation.issuedBefore)
+	}
+	if _, err := manager.Authenticate(ctx, tokens.AccessToken); err != ErrRevokedToken {
+		t.Errorf("expected the earlier token to be revoked, got %v", err)
+	}
+}
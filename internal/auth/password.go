@@ -36,12 +36,32 @@ type PasswordManager struct {
 	config *PasswordConfig
 }
 
+// NewPasswordManager hashes with the given parameters; nil or zero fields fall
+// back to DefaultPasswordConfig
 func NewPasswordManager(config *PasswordConfig) *PasswordManager {
 	if config == nil {
 		config = DefaultPasswordConfig
 	}
+
+	resolved := *config
+	if resolved.Memory == 0 {
+		resolved.Memory = DefaultPasswordConfig.Memory
+	}
+	if resolved.Iterations == 0 {
+		resolved.Iterations = DefaultPasswordConfig.Iterations
+	}
+	if resolved.Parallelism == 0 {
+		resolved.Parallelism = DefaultPasswordConfig.Parallelism
+	}
+	if resolved.SaltLength == 0 {
+		resolved.SaltLength = DefaultPasswordConfig.SaltLength
+	}
+	if resolved.KeyLength == 0 {
+		resolved.KeyLength = DefaultPasswordConfig.KeyLength
+	}
+
 	return &PasswordManager{
-		config: config,
+		config: &resolved,
 	}
 }
 
@@ -75,6 +95,41 @@ func (pm *PasswordManager) ComparePasswordAndHash(password, encodedHash string)
 	return false, nil
 }
 
+// NeedsRehash reports whether an encoded hash was made with weaker parameters
+// than the current config. Hashes that cannot be decoded always need one.
+func (pm *PasswordManager) NeedsRehash(encodedHash string) bool {
+	config, _, _, err := decodeHash(encodedHash)
+	if err != nil {
+		return true
+	}
+
+	return config.Memory < pm.config.Memory ||
+		config.Iterations < pm.config.Iterations ||
+		config.Parallelism < pm.config.Parallelism ||
+		config.SaltLength < pm.config.SaltLength ||
+		config.KeyLength < pm.config.KeyLength
+}
+
+// ComparePasswordAndRehash verifies the password and, when it matches a hash
+// made with weaker parameters, returns a new hash using the current ones.
+// upgradedHash is empty when the password is wrong or the hash is up to date.
+func (pm *PasswordManager) ComparePasswordAndRehash(password, encodedHash string) (valid bool, upgradedHash string, err error) {
+	valid, err = pm.ComparePasswordAndHash(password, encodedHash)
+	if err != nil || !valid {
+		return valid, "", err
+	}
+
+	if !pm.NeedsRehash(encodedHash) {
+		return true, "", nil
+	}
+
+	upgradedHash, err = pm.HashPassword(password)
+	if err != nil {
+		return true, "", err
+	}
+	return true, upgradedHash, nil
+}
+
 func generateRandomBytes(n uint32) ([]byte, error) {
 	b := make([]byte, n)
 	_, err := rand.Read(b)
@@ -0,0 +1,51 @@
+package auth
+
+import "testing"
+
+func TestComparePasswordAndRehashUpgradesWeakHash(t *testing.T) {
+	weak := NewPasswordManager(&PasswordConfig{Memory: 8 * 1024, Iterations: 1, Parallelism: 1})
+	current := NewPasswordManager(&PasswordConfig{Memory: 16 * 1024, Iterations: 2, Parallelism: 1})
+
+	oldHash, err := weak.HashPassword("Secret#123")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if !current.NeedsRehash(oldHash) {
+		t.Fatal("expected low cost hash to need a rehash")
+	}
+
+	valid, upgraded, err := current.ComparePasswordAndRehash("Secret#123", oldHash)
+	if err != nil || !valid {
+		t.Fatalf("expected password to verify, got valid=%v err=%v", valid, err)
+	}
+	if upgraded == "" {
+		t.Fatal("expected an upgraded hash")
+	}
+	if current.NeedsRehash(upgraded) {
+		t.Errorf("upgraded hash %q still uses weak parameters", upgraded)
+	}
+
+	// The next login verifies against the upgraded hash without rehashing again
+	valid, again, err := current.ComparePasswordAndRehash("Secret#123", upgraded)
+	if err != nil || !valid {
+		t.Fatalf("expected upgraded hash to verify, got valid=%v err=%v", valid, err)
+	}
+	if again != "" {
+		t.Error("expected no rehash for an up to date hash")
+	}
+}
+
+func TestComparePasswordAndRehashWrongPassword(t *testing.T) {
+	weak := NewPasswordManager(&PasswordConfig{Memory: 8 * 1024, Iterations: 1, Parallelism: 1})
+	current := NewPasswordManager(&PasswordConfig{Memory: 16 * 1024, Iterations: 2, Parallelism: 1})
+
+	oldHash, err := weak.HashPassword("Secret#123")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+
+	valid, upgraded, err := current.ComparePasswordAndRehash("Wrong#123", oldHash)
+	if err != nil || valid || upgraded != "" {
+		t.Errorf("expected rejection without rehash, got valid=%v upgraded=%q err=%v", valid, upgraded, err)
+	}
+}
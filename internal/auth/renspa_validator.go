@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidRENSPA = errors.New("invalid RENSPA")
+
+// renspaSegments describes the canonical RENSPA layout, PP.DDD.T.EEEEE/DD:
+// province, department, type and establishment separated by dots, then the
+// unit digits after a slash
+var renspaSegments = []struct {
+	name   string
+	digits int
+}{
+	{"province", 2},
+	{"department", 3},
+	{"type", 1},
+	{"establishment", 5},
+	{"digit", 2},
+}
+
+// ValidateRENSPA checks that a SENASA farm registration number (RENSPA)
+// follows the canonical format. The returned error wraps ErrInvalidRENSPA and
+// names the first malformed segment.
+func ValidateRENSPA(renspa string) error {
+	renspa = strings.TrimSpace(renspa)
+	if renspa == "" {
+		return fmt.Errorf("%w: RENSPA is required", ErrInvalidRENSPA)
+	}
+
+	registration, unit, found := strings.Cut(renspa, "/")
+	if !found {
+		return fmt.Errorf("%w: missing '/' before the digit segment (expected PP.DDD.T.EEEEE/DD)", ErrInvalidRENSPA)
+	}
+
+	parts := append(strings.Split(registration, "."), unit)
+	if len(parts) != len(renspaSegments) {
+		return fmt.Errorf("%w: expected format PP.DDD.T.EEEEE/DD", ErrInvalidRENSPA)
+	}
+
+	for i, segment := range renspaSegments {
+		if !isDigits(parts[i], segment.digits) {
+			return fmt.Errorf("%w: %s segment %q must be %d digits", ErrInvalidRENSPA, segment.name, parts[i], segment.digits)
+		}
+	}
+
+	// SENASA numbers the 24 jurisdictions from 01
+	if province, _ := strconv.Atoi(parts[0]); province < 1 || province > 24 {
+		return fmt.Errorf("%w: province segment %q is not a valid province code", ErrInvalidRENSPA, parts[0])
+	}
+
+	return nil
+}
+
+func isDigits(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, char := range s {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return true
+}
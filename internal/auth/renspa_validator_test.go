@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateRENSPA(t *testing.T) {
+	tests := []struct {
+		name    string
+		renspa  string
+		wantErr error
+	}{
+		{"valid", "01.001.0.00123/00", nil},
+		{"valid last province", "24.123.4.56789/01", nil},
+		{"valid with spaces", " 12.345.6.78901/23 ", nil},
+		{"empty", "", ErrInvalidRENSPA},
+		{"blank", "   ", ErrInvalidRENSPA},
+		{"missing slash", "01.001.0.00123.00", ErrInvalidRENSPA},
+		{"missing segment", "01.001.00123/00", ErrInvalidRENSPA},
+		{"extra segment", "01.001.0.0.00123/00", ErrInvalidRENSPA},
+		{"short establishment", "01.001.0.0123/00", ErrInvalidRENSPA},
+		{"long digit segment", "01.001.0.00123/000", ErrInvalidRENSPA},
+		{"non digits", "01.0A1.0.00123/00", ErrInvalidRENSPA},
+		{"province zero", "00.001.0.00123/00", ErrInvalidRENSPA},
+		{"province out of range", "25.001.0.00123/00", ErrInvalidRENSPA},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRENSPA(tt.renspa); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateRENSPA(%q) = %v, want %v", tt.renspa, err, tt.wantErr)
+			}
+		})
+	}
+}
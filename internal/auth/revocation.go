@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRevokedToken          = errors.New("token has been revoked")
+	ErrRevocationUnavailable = errors.New("token revocation store unavailable")
+)
+
+// RevocationStore records revoked access tokens. Entries only need to outlive
+// the tokens they revoke, so each carries the time after which it can be dropped.
+type RevocationStore interface {
+	// RevokeToken revokes the single token with the given jti
+	RevokeToken(ctx context.Context, jti string, until time.Time) error
+	// RevokeUserTokens revokes every token issued to the user up to issuedBefore
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID, issuedBefore, until time.Time) error
+	// IsRevoked reports whether a token was revoked by jti or by its user
+	IsRevoked(ctx context.Context, jti string, userID uuid.UUID, issuedAt time.Time) (bool, error)
+}
+
+// MemoryRevocationStore keeps revocations in process. It suits development
+// and single-instance deployments; revocations are lost on restart.
+type MemoryRevocationStore struct {
+	mu     sync.Mutex
+	tokens map[string]time.Time
+	users  map[uuid.UUID]userRevocation
+	now    func() time.Time
+}
+
+type userRevocation struct {
+	issuedBefore time.Time
+	until        time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		tokens: make(map[string]time.Time),
+		users:  make(map[uuid.UUID]userRevocation),
+		now:    time.Now,
+	}
+}
+
+func (s *MemoryRevocationStore) RevokeToken(ctx context.Context, jti string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	s.tokens[jti] = until
+	return nil
+}
+
+func (s *MemoryRevocationStore) RevokeUserTokens(ctx context.Context, userID uuid.UUID, issuedBefore, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	s.users[userID] = userRevocation{issuedBefore: issuedBefore, until: until}
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, jti string, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if until, ok := s.tokens[jti]; ok && now.Before(until) {
+		return true, nil
+	}
+	if revocation, ok := s.users[userID]; ok && now.Before(revocation.until) && !issuedAt.After(revocation.issuedBefore) {
+		return true, nil
+	}
+	return false, nil
+}
+
+// sweep drops entries whose tokens have expired anyway
+func (s *MemoryRevocationStore) sweep() {
+	now := s.now()
+	for jti, until := range s.tokens {
+		if !now.Before(until) {
+			delete(s.tokens, jti)
+		}
+	}
+	for userID, revocation := range s.users {
+		if !now.Before(revocation.until) {
+			delete(s.users, userID)
+		}
+	}
+}
+
+// DBRevocationStore keeps revocations in Postgres so every instance sees them
+type DBRevocationStore struct {
+	db *sql.DB
+}
+
+func NewDBRevocationStore(db *sql.DB) *DBRevocationStore {
+	return &DBRevocationStore{db: db}
+}
+
+func (s *DBRevocationStore) RevokeToken(ctx context.Context, jti string, until time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING`, jti, until)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	// Expired entries no longer matter
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= NOW()`); err != nil {
+		return fmt.Errorf("failed to prune revoked tokens: %w", err)
+	}
+	return nil
+}
+
+func (s *DBRevocationStore) RevokeUserTokens(ctx context.Context, userID uuid.UUID, issuedBefore, until time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_token_revocations (user_id, issued_before, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			issued_before = EXCLUDED.issued_before,
+			expires_at = EXCLUDED.expires_at`, userID, issuedBefore, until)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+	return nil
+}
+
+func (s *DBRevocationStore) IsRevoked(ctx context.Context, jti string, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1 AND expires_at > NOW())
+			OR EXISTS (
+				SELECT 1 FROM user_token_revocations
+				WHERE user_id = $2 AND expires_at > NOW() AND issued_before >= $3
+			)`, jti, userID, issuedAt).Scan(&revoked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return revoked, nil
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -15,6 +17,9 @@ type Config struct {
 	// JWT configuration
 	JWT JWTConfig
 
+	// Password hashing configuration
+	Password PasswordConfig
+
 	// Server configuration
 	Server ServerConfig
 
@@ -24,6 +29,36 @@ type Config struct {
 	// WhatsApp configuration
 	WhatsApp WhatsAppConfig
 
+	// Products configuration
+	Products ProductsConfig
+
+	// Transactions configuration
+	Transactions TransactionsConfig
+
+	// Seller tier thresholds
+	SellerTier SellerTierConfig
+
+	// Rate limiting configuration
+	RateLimit RateLimitConfig
+
+	// Outgoing email configuration
+	Mail MailConfig
+
+	// Health check configuration
+	Health HealthConfig
+
+	// Request timeout configuration
+	Timeouts TimeoutConfig
+
+	// File upload limits
+	Uploads UploadConfig
+
+	// Transaction webhook delivery
+	Webhooks WebhookConfig
+
+	// Transaction receipt PDFs
+	Receipts ReceiptConfig
+
 	// Environment
 	Environment string
 }
@@ -35,6 +70,15 @@ type DatabaseConfig struct {
 	Password string
 	Name     string
 	SSLMode  string
+
+	// Maximum queries a single request may run concurrently
+	MaxConcurrentQueriesPerRequest int
+
+	// Connection pool, per instance
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 }
 
 type JWTConfig struct {
@@ -42,11 +86,34 @@ type JWTConfig struct {
 	ExpirationHours  time.Duration
 	RefreshTokenTTL  time.Duration
 	AccessTokenTTL   time.Duration
+	// Issuer and Audience differ per environment so tokens don't cross over
+	Issuer           string
+	Audience         string
+	// RevocationStore is "memory" or "database"; empty picks memory in
+	// development and the database elsewhere
+	RevocationStore  string
+}
+
+// PasswordConfig holds the Argon2id cost parameters. Raising them upgrades
+// existing hashes as users log in.
+type PasswordConfig struct {
+	MemoryKB    int // memory in KiB
+	Iterations  int
+	Parallelism int
 }
 
 type ServerConfig struct {
 	Port    string
 	GinMode string
+
+	// Minimum log level: debug, info, warn or error
+	LogLevel string
+
+	// Proxies (IPs or CIDRs) allowed to set X-Forwarded-For. The client IP
+	// used for rate limiting is the rightmost address not in this list, so
+	// clients cannot pick their own. Cloud Run's front end connects from the
+	// link-local range.
+	TrustedProxies []string
 }
 
 type GoogleCloudConfig struct {
@@ -57,9 +124,96 @@ type GoogleCloudConfig struct {
 }
 
 type WhatsAppConfig struct {
-	APIUrl         string
-	BusinessNumber string
-	WebhookSecret  string
+	APIUrl          string
+	BusinessNumber  string
+	ProvinceNumbers map[string]string // province -> business number, falls back to BusinessNumber
+	WebhookSecret   string // app secret Meta signs webhook callbacks with
+	VerifyToken     string // token echoed back when subscribing the webhook
+	ClickHashSecret string // keys the hashes of click IPs, defaults to the JWT secret
+}
+
+type ProductsConfig struct {
+	SellerStaleCheck      bool          // flag products whose denormalized seller info drifted
+	SellerRatingTolerance float64       // rating difference tolerated before flagging as stale
+	ExpiryCheckInterval   time.Duration // how often expired products are deactivated
+	DeletedRetention      time.Duration // how long soft-deleted products stay restorable
+
+	AutoUnpublishExpiredInsurance bool          // unpublish transport listings once their insurance expires
+	SavedSearchMatchInterval      time.Duration // how often saved searches are matched against new listings
+
+	LimitsByVerificationLevel []int // active listing cap per verification level, 0 = unlimited
+
+	ViewFlushInterval  time.Duration // how often batched product views are written
+	ViewFlushThreshold int           // pending views that trigger an early write
+}
+
+// MailConfig configures the SMTP relay. Without a host no mail is sent and
+// verification and reset links are logged instead.
+type MailConfig struct {
+	SMTPHost             string
+	SMTPPort             int
+	SMTPUsername         string
+	SMTPPassword         string
+	From                 string
+	AppBaseURL           string        // frontend base URL links in emails point at
+	EmailVerificationTTL time.Duration // how long email verification links stay valid
+	PasswordResetTTL     time.Duration // how long password reset links stay valid
+}
+
+type HealthConfig struct {
+	CheckStorage bool          // include the storage bucket in readiness checks
+	Timeout      time.Duration // budget for all dependency checks of one probe
+}
+
+// TimeoutConfig bounds how long a request may run. Reads are GET requests;
+// uploads are the file upload and import routes.
+type TimeoutConfig struct {
+	Default time.Duration
+	Read    time.Duration
+	Upload  time.Duration
+}
+
+type UploadConfig struct {
+	MaxImageSize        int64 // largest product image accepted, in bytes
+	MaxImagesPerProduct int   // images a product's gallery may hold
+	MaxVideoSize        int64 // largest product video accepted, in bytes
+	MaxMultipartMemory  int64 // multipart form bytes kept in memory before spilling to disk
+}
+
+type WebhookConfig struct {
+	DispatchInterval time.Duration // how often pending transaction events are sent
+	Timeout          time.Duration // per delivery request
+	MaxAttempts      int           // deliveries are abandoned after this many failures
+}
+
+type ReceiptConfig struct {
+	LogoPath string // JPEG printed in the receipt header; the platform name is printed when empty
+}
+
+type TransactionsConfig struct {
+	IdempotencyKeyTTL           time.Duration // how long an Idempotency-Key replays its transaction
+	IdempotencyKeyPurgeInterval time.Duration // how often expired idempotency keys are deleted
+	UnpublishSoldOut            bool          // unpublish products once transactions use up their stock
+}
+
+type RateLimitConfig struct {
+	RequestsPerSecond int // sustained requests per second per client IP
+	Burst             int // requests a client may send at once
+	LoginPerMinute    int // stricter limit for login attempts per client IP
+	LoginBurst        int
+
+	ClickTrackPerMinute int // WhatsApp link click tracking per client IP
+	ClickTrackBurst     int
+}
+
+type SellerTierConfig struct {
+	EstablishedMinSales     int
+	EstablishedMinRating    float64
+	EstablishedMinTenure    time.Duration
+	TopMinSales             int
+	TopMinRating            float64
+	TopMinVerificationLevel int
+	TopMinTenure            time.Duration
 }
 
 func Load() (*Config, error) {
@@ -74,16 +228,33 @@ func Load() (*Config, error) {
 			Password: getEnv("DB_PASSWORD", ""),
 			Name:     getEnv("DB_NAME", "agro_mas_dev"),
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+
+			MaxConcurrentQueriesPerRequest: getEnvAsInt("DB_MAX_CONCURRENT_QUERIES_PER_REQUEST", 4),
+
+			MaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 10),
+			MaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 2),
+			ConnMaxLifetime: time.Duration(getEnvAsInt("DB_CONN_MAX_LIFETIME_MINUTES", 30)) * time.Minute,
+			ConnMaxIdleTime: time.Duration(getEnvAsInt("DB_CONN_MAX_IDLE_TIME_MINUTES", 5)) * time.Minute,
 		},
 		JWT: JWTConfig{
 			Secret:          getEnv("JWT_SECRET", "your-secret-key"),
 			ExpirationHours: time.Duration(getEnvAsInt("JWT_EXPIRATION_HOURS", 24)) * time.Hour,
 			RefreshTokenTTL: time.Duration(getEnvAsInt("JWT_REFRESH_TOKEN_TTL_DAYS", 7)) * 24 * time.Hour,
 			AccessTokenTTL:  time.Duration(getEnvAsInt("JWT_ACCESS_TOKEN_TTL_MINUTES", 15)) * time.Minute,
+			Issuer:          getEnv("JWT_ISSUER", "agro-mas-backend"),
+			Audience:        getEnv("JWT_AUDIENCE", "agro-mas-frontend"),
+			RevocationStore: getEnv("JWT_REVOCATION_STORE", ""),
+		},
+		Password: PasswordConfig{
+			MemoryKB:    getEnvAsInt("PASSWORD_ARGON2_MEMORY_KB", 64*1024),
+			Iterations:  getEnvAsInt("PASSWORD_ARGON2_ITERATIONS", 3),
+			Parallelism: getEnvAsInt("PASSWORD_ARGON2_PARALLELISM", 2),
 		},
 		Server: ServerConfig{
 			Port:    getEnv("PORT", "8080"),
 			GinMode: getEnv("GIN_MODE", "debug"),
+
+			TrustedProxies: getEnvAsSlice("TRUSTED_PROXIES", []string{"169.254.0.0/16"}),
 		},
 		GoogleCloud: GoogleCloudConfig{
 			ProjectID:         getEnv("GOOGLE_CLOUD_PROJECT", ""),
@@ -92,16 +263,124 @@ func Load() (*Config, error) {
 			CloudSQLInstance:  getEnv("CLOUD_SQL_INSTANCE", ""),
 		},
 		WhatsApp: WhatsAppConfig{
-			APIUrl:         getEnv("WHATSAPP_API_URL", "https://api.whatsapp.com/send"),
-			BusinessNumber: getEnv("WHATSAPP_BUSINESS_NUMBER", ""),
-			WebhookSecret:  getEnv("WHATSAPP_WEBHOOK_SECRET", ""),
+			APIUrl:          getEnv("WHATSAPP_API_URL", "https://api.whatsapp.com/send"),
+			BusinessNumber:  getEnv("WHATSAPP_BUSINESS_NUMBER", ""),
+			ProvinceNumbers: getEnvAsMap("WHATSAPP_PROVINCE_NUMBERS"),
+			WebhookSecret:   getEnv("WHATSAPP_WEBHOOK_SECRET", ""),
+			VerifyToken:     getEnv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", ""),
+			ClickHashSecret: getEnv("WHATSAPP_CLICK_HASH_SECRET", ""),
+		},
+		Products: ProductsConfig{
+			SellerStaleCheck:      getEnvAsBool("PRODUCTS_SELLER_STALE_CHECK", true),
+			SellerRatingTolerance: getEnvAsFloat("PRODUCTS_SELLER_RATING_TOLERANCE", 0.1),
+			ExpiryCheckInterval:   time.Duration(getEnvAsInt("PRODUCTS_EXPIRY_CHECK_INTERVAL_MINUTES", 15)) * time.Minute,
+			DeletedRetention:      time.Duration(getEnvAsInt("PRODUCTS_DELETED_RETENTION_DAYS", 30)) * 24 * time.Hour,
+
+			AutoUnpublishExpiredInsurance: getEnvAsBool("PRODUCTS_AUTO_UNPUBLISH_EXPIRED_INSURANCE", false),
+			SavedSearchMatchInterval:      time.Duration(getEnvAsInt("PRODUCTS_SAVED_SEARCH_MATCH_INTERVAL_MINUTES", 10)) * time.Minute,
+
+			LimitsByVerificationLevel: getEnvAsIntSlice("PRODUCTS_LIMITS_BY_VERIFICATION_LEVEL", []int{5, 20, 100, 500, 0}),
+
+			ViewFlushInterval:  time.Duration(getEnvAsInt("PRODUCTS_VIEW_FLUSH_INTERVAL_SECONDS", 10)) * time.Second,
+			ViewFlushThreshold: getEnvAsInt("PRODUCTS_VIEW_FLUSH_THRESHOLD", 1000),
+		},
+		RateLimit: RateLimitConfig{
+			RequestsPerSecond: getEnvAsInt("RATE_LIMIT_RPS", 10),
+			Burst:             getEnvAsInt("RATE_LIMIT_BURST", 20),
+			LoginPerMinute:    getEnvAsInt("RATE_LIMIT_LOGIN_PER_MINUTE", 5),
+			LoginBurst:        getEnvAsInt("RATE_LIMIT_LOGIN_BURST", 5),
+
+			ClickTrackPerMinute: getEnvAsInt("RATE_LIMIT_CLICK_TRACK_PER_MINUTE", 30),
+			ClickTrackBurst:     getEnvAsInt("RATE_LIMIT_CLICK_TRACK_BURST", 10),
+		},
+		Transactions: TransactionsConfig{
+			IdempotencyKeyTTL:           time.Duration(getEnvAsInt("TRANSACTIONS_IDEMPOTENCY_KEY_TTL_HOURS", 24)) * time.Hour,
+			IdempotencyKeyPurgeInterval: time.Duration(getEnvAsInt("TRANSACTIONS_IDEMPOTENCY_KEY_PURGE_INTERVAL_MINUTES", 60)) * time.Minute,
+			UnpublishSoldOut:            getEnvAsBool("TRANSACTIONS_UNPUBLISH_SOLD_OUT", false),
+		},
+		SellerTier: SellerTierConfig{
+			EstablishedMinSales:     getEnvAsInt("SELLER_TIER_ESTABLISHED_MIN_SALES", 5),
+			EstablishedMinRating:    getEnvAsFloat("SELLER_TIER_ESTABLISHED_MIN_RATING", 3.5),
+			EstablishedMinTenure:    time.Duration(getEnvAsInt("SELLER_TIER_ESTABLISHED_MIN_TENURE_DAYS", 90)) * 24 * time.Hour,
+			TopMinSales:             getEnvAsInt("SELLER_TIER_TOP_MIN_SALES", 50),
+			TopMinRating:            getEnvAsFloat("SELLER_TIER_TOP_MIN_RATING", 4.5),
+			TopMinVerificationLevel: getEnvAsInt("SELLER_TIER_TOP_MIN_VERIFICATION_LEVEL", 2),
+			TopMinTenure:            time.Duration(getEnvAsInt("SELLER_TIER_TOP_MIN_TENURE_DAYS", 365)) * 24 * time.Hour,
+		},
+		Mail: MailConfig{
+			SMTPHost:             getEnv("SMTP_HOST", ""),
+			SMTPPort:             getEnvAsInt("SMTP_PORT", 587),
+			SMTPUsername:         getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+			From:                 getEnv("MAIL_FROM", "Agro Mas <no-reply@agromas.com.ar>"),
+			AppBaseURL:           getEnv("APP_BASE_URL", "http://localhost:3000"),
+			EmailVerificationTTL: time.Duration(getEnvAsInt("EMAIL_VERIFICATION_TTL_HOURS", 24)) * time.Hour,
+			PasswordResetTTL:     time.Duration(getEnvAsInt("PASSWORD_RESET_TTL_MINUTES", 60)) * time.Minute,
+		},
+		Health: HealthConfig{
+			CheckStorage: getEnvAsBool("HEALTH_CHECK_STORAGE", true),
+			Timeout:      time.Duration(getEnvAsInt("HEALTH_CHECK_TIMEOUT_MS", 2000)) * time.Millisecond,
+		},
+		Timeouts: TimeoutConfig{
+			Default: time.Duration(getEnvAsInt("REQUEST_TIMEOUT_SECONDS", 30)) * time.Second,
+			Read:    time.Duration(getEnvAsInt("REQUEST_READ_TIMEOUT_SECONDS", 10)) * time.Second,
+			Upload:  time.Duration(getEnvAsInt("REQUEST_UPLOAD_TIMEOUT_SECONDS", 120)) * time.Second,
+		},
+		Uploads: UploadConfig{
+			MaxImageSize:        int64(getEnvAsInt("UPLOAD_MAX_IMAGE_SIZE_MB", 10)) << 20,
+			MaxImagesPerProduct: getEnvAsInt("UPLOAD_MAX_IMAGES_PER_PRODUCT", 10),
+			MaxVideoSize:        int64(getEnvAsInt("UPLOAD_MAX_VIDEO_SIZE_MB", 50)) << 20,
+			MaxMultipartMemory:  int64(getEnvAsInt("UPLOAD_MAX_MULTIPART_MEMORY_MB", 64)) << 20,
+		},
+		Webhooks: WebhookConfig{
+			DispatchInterval: time.Duration(getEnvAsInt("WEBHOOK_DISPATCH_INTERVAL_SECONDS", 30)) * time.Second,
+			Timeout:          time.Duration(getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second,
+			MaxAttempts:      getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 10),
+		},
+		Receipts: ReceiptConfig{
+			LogoPath: getEnv("RECEIPT_LOGO_PATH", ""),
 		},
 		Environment: getEnv("ENVIRONMENT", "development"),
 	}
 
+	if config.WhatsApp.ClickHashSecret == "" {
+		config.WhatsApp.ClickHashSecret = config.JWT.Secret
+	}
+
+	if err := config.RateLimit.validate(); err != nil {
+		return nil, err
+	}
+
+	// Verbose logs in development, info and above everywhere else
+	config.Server.LogLevel = getEnv("LOG_LEVEL", "info")
+	if _, set := os.LookupEnv("LOG_LEVEL"); !set && config.IsDevelopment() {
+		config.Server.LogLevel = "debug"
+	}
+
 	return config, nil
 }
 
+// validate rejects per-minute limits that would divide a minute by zero and
+// bursts below 1, with which a limiter refuses every request
+func (c RateLimitConfig) validate() error {
+	if c.LoginPerMinute < 1 {
+		return fmt.Errorf("RATE_LIMIT_LOGIN_PER_MINUTE must be at least 1, got %d", c.LoginPerMinute)
+	}
+	if c.ClickTrackPerMinute < 1 {
+		return fmt.Errorf("RATE_LIMIT_CLICK_TRACK_PER_MINUTE must be at least 1, got %d", c.ClickTrackPerMinute)
+	}
+	if c.Burst < 1 {
+		return fmt.Errorf("RATE_LIMIT_BURST must be at least 1, got %d", c.Burst)
+	}
+	if c.LoginBurst < 1 {
+		return fmt.Errorf("RATE_LIMIT_LOGIN_BURST must be at least 1, got %d", c.LoginBurst)
+	}
+	if c.ClickTrackBurst < 1 {
+		return fmt.Errorf("RATE_LIMIT_CLICK_TRACK_BURST must be at least 1, got %d", c.ClickTrackBurst)
+	}
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -117,6 +396,79 @@ func getEnvAsInt(name string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsBool(name string, defaultValue bool) bool {
+	valueStr := getEnv(name, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvAsFloat(name string, defaultValue float64) float64 {
+	valueStr := getEnv(name, "")
+	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsIntSlice parses a comma separated list of integers, e.g. "5,20,100",
+// falling back to the default when unset or malformed
+func getEnvAsIntSlice(name string, defaultValue []int) []int {
+	valueStr := getEnv(name, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	var result []int
+	for _, item := range strings.Split(valueStr, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil {
+			return defaultValue
+		}
+		result = append(result, value)
+	}
+	return result
+}
+
+// getEnvAsSlice parses a comma separated list of strings, e.g. "10.0.0.0/8,127.0.0.1".
+// Set it to "none" for an empty list.
+func getEnvAsSlice(name string, defaultValue []string) []string {
+	valueStr := getEnv(name, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+	if valueStr == "none" {
+		return []string{}
+	}
+
+	var result []string
+	for _, item := range strings.Split(valueStr, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getEnvAsMap parses a comma separated list of key=value pairs,
+// e.g. "Buenos Aires=5491100000000,Córdoba=5493510000000"
+func getEnvAsMap(name string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(getEnv(name, ""), ",") {
+		key, value, found := strings.Cut(pair, "=")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if key != "" && value != "" {
+			result[key] = value
+		}
+	}
+	return result
+}
+
 func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
 }
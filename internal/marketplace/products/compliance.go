@@ -0,0 +1,125 @@
+package products
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"agro-mas-backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// Transport document states reported by the compliance check
+const (
+	DocumentStatusValid    = "valid"
+	DocumentStatusExpiring = "expiring"
+	DocumentStatusExpired  = "expired"
+	DocumentStatusMissing  = "missing"
+)
+
+// Documents expiring within this window are flagged when a listing is saved
+const complianceWarningDays = 30
+
+// TransportCompliance reports the license and insurance state of one of the
+// seller's transport listings
+type TransportCompliance struct {
+	ProductID       uuid.UUID  `json:"product_id"`
+	Title           string     `json:"title"`
+	IsActive        bool       `json:"is_active"`
+	PublishedAt     *time.Time `json:"published_at,omitempty"`
+	LicensePlate    *string    `json:"license_plate,omitempty"`
+	LicenseExpiry   *time.Time `json:"license_expiry,omitempty"`
+	LicenseStatus   string     `json:"license_status"`
+	InsuranceExpiry *time.Time `json:"insurance_expiry,omitempty"`
+	InsuranceStatus string     `json:"insurance_status"`
+}
+
+// SetAutoUnpublishExpiredInsurance enables unpublishing transport listings
+// whose insurance has expired
+func (s *Service) SetAutoUnpublishExpiredInsurance(enabled bool) {
+	s.autoUnpublishExpiredInsurance = enabled
+}
+
+// GetTransportCompliance lists the seller's transport products whose license
+// or insurance has expired or expires within the next days days
+func (s *Service) GetTransportCompliance(ctx context.Context, userID uuid.UUID, days int) ([]TransportCompliance, error) {
+	if days < 1 || days > 365 {
+		return nil, ErrInvalidExpiryWindow
+	}
+
+	today := startOfDay(time.Now())
+	listings, err := s.repo.GetTransportDocuments(ctx, userID, today.AddDate(0, 0, days))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range listings {
+		listings[i].LicenseStatus = documentStatus(listings[i].LicenseExpiry, today, days)
+		listings[i].InsuranceStatus = documentStatus(listings[i].InsuranceExpiry, today, days)
+	}
+	return listings, nil
+}
+
+// UnpublishExpiredInsurance hides transport listings whose insurance has
+// expired. It does nothing unless enabled in the configuration.
+func (s *Service) UnpublishExpiredInsurance(ctx context.Context) (int64, error) {
+	if !s.autoUnpublishExpiredInsurance {
+		return 0, nil
+	}
+
+	ids, err := s.repo.UnpublishExpiredInsurance(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		logger.FromContext(ctx).Info("unpublished transport listing with expired insurance", "product_id", id)
+	}
+	return int64(len(ids)), nil
+}
+
+// transportComplianceWarnings flags expired and soon-to-expire documents of a
+// transport listing without blocking the save
+func transportComplianceWarnings(details *TransportDetails, now time.Time) []string {
+	if details == nil {
+		return nil
+	}
+
+	today := startOfDay(now)
+	var warnings []string
+	documents := []struct {
+		name   string
+		expiry *time.Time
+	}{
+		{"license", details.LicenseExpiry},
+		{"insurance", details.InsuranceExpiry},
+	}
+	for _, document := range documents {
+		switch documentStatus(document.expiry, today, complianceWarningDays) {
+		case DocumentStatusExpired:
+			warnings = append(warnings, fmt.Sprintf("%s expired on %s", document.name, document.expiry.Format("2006-01-02")))
+		case DocumentStatusExpiring:
+			warnings = append(warnings, fmt.Sprintf("%s expires on %s", document.name, document.expiry.Format("2006-01-02")))
+		}
+	}
+	return warnings
+}
+
+// documentStatus classifies an expiry date against today; documents are valid
+// through their expiry day
+func documentStatus(expiry *time.Time, today time.Time, warningDays int) string {
+	switch {
+	case expiry == nil:
+		return DocumentStatusMissing
+	case expiry.Before(today):
+		return DocumentStatusExpired
+	case expiry.Before(today.AddDate(0, 0, warningDays)):
+		return DocumentStatusExpiring
+	default:
+		return DocumentStatusValid
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,41 @@
+package products
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+// BodyETag returns a weak ETag for a serialized response. Product reads hash
+// the body they send, since sellers, videos and details change it without
+// touching the product's updated_at.
+func BodyETag(body []byte) string {
+	h := sha256.New()
+	h.Write(body)
+	return weakETag(h)
+}
+
+// ImagesETag returns a weak ETag for a product's image list
+func ImagesETag(productID uuid.UUID, images []ProductImage) string {
+	h := sha256.New()
+	io.WriteString(h, productID.String())
+	writeImages(h, images)
+	return weakETag(h)
+}
+
+func writeImages(h hash.Hash, images []ProductImage) {
+	for _, img := range images {
+		fmt.Fprintf(h, "|%s:%d:%t:%d", img.ID, img.DisplayOrder, img.IsPrimary, img.UploadedAt.UnixNano())
+		if img.AltText != nil {
+			io.WriteString(h, ":"+*img.AltText)
+		}
+	}
+}
+
+func weakETag(h hash.Hash) string {
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
@@ -3,35 +3,86 @@ package products
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"math"
+	"strings"
 
+	"agro-mas-backend/pkg/logger"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// DefaultSearchRadiusKm is used for nearby searches that give no radius and
+// have no user preference to fall back on
+const DefaultSearchRadiusKm = 50
+
+// MaxSearchRadiusKm caps nearby searches, including preferred radii
+const MaxSearchRadiusKm = 500
+
+// Page size limits for nearby searches
+const (
+	DefaultNearbyResults = 50
+	MaxNearbyResults     = 100
 )
 
 type GeospatialService struct {
 	db *sql.DB
+
+	// Resolves the authenticated user's search defaults
+	searchPreferences SearchPreferencesFunc
+}
+
+// SearchPreferences are a user's saved defaults for nearby searches
+type SearchPreferences struct {
+	RadiusKm   float64
+	Categories []string
 }
 
+// SearchPreferencesFunc loads a user's search defaults, returning nil when
+// the user has none
+type SearchPreferencesFunc func(ctx context.Context, userID uuid.UUID) (*SearchPreferences, error)
+
 type NearbySearchRequest struct {
 	Latitude     float64  `json:"latitude" binding:"required"`
 	Longitude    float64  `json:"longitude" binding:"required"`
-	RadiusKm     float64  `json:"radius_km" binding:"required,min=1,max=500"`
+	RadiusKm     float64  `json:"radius_km,omitempty" binding:"omitempty,min=1,max=500"`
 	Category     string   `json:"category,omitempty"`
+	Categories   []string `json:"-"` // preferred categories, used when Category is empty
 	Subcategory  string   `json:"subcategory,omitempty"`
 	MaxResults   int      `json:"max_results,omitempty"`
+	// Offset skips that many results; Page, when set, takes precedence and
+	// counts pages of MaxResults from 1
+	Offset       int      `json:"offset,omitempty" binding:"omitempty,min=0"`
+	Page         int      `json:"page,omitempty" binding:"omitempty,min=1"`
 	PriceRange   *PriceRange `json:"price_range,omitempty"`
 }
 
+// NearbySearchResponse is a page of nearby results, closest first
+type NearbySearchResponse struct {
+	Products   []*NearbyProduct `json:"products"`
+	TotalCount int              `json:"total_count"`
+	RadiusKm   float64          `json:"radius_km"`
+	Center     Point            `json:"center"`
+	Limit      int              `json:"limit"`
+	Offset     int              `json:"offset"`
+	HasMore    bool             `json:"has_more"`
+}
+
 type PriceRange struct {
 	Min float64 `json:"min"`
 	Max float64 `json:"max"`
 }
 
+// NearbyProduct is a geo search result. DistanceKm and BearingDeg are measured
+// from the search's reference point: the nearby search center, the route start
+// (distance is to the route itself) or the optional viewport center of a
+// bounds search. Both are omitted when there is no reference point.
 type NearbyProduct struct {
-	Product     *Product `json:"product"`
-	DistanceKm  float64  `json:"distance_km"`
-	BearingDeg  float64  `json:"bearing_deg,omitempty"`
+	Product    *Product `json:"product"`
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+	BearingDeg *float64 `json:"bearing_deg,omitempty"`
 }
 
 type LocationBounds struct {
@@ -52,8 +103,28 @@ func NewGeospatialService(db *sql.DB) *GeospatialService {
 	return &GeospatialService{db: db}
 }
 
-// FindNearbyProducts finds products within a specified radius
-func (g *GeospatialService) FindNearbyProducts(ctx context.Context, req *NearbySearchRequest) ([]*NearbyProduct, error) {
+// SetSearchPreferencesFunc lets authenticated nearby searches default their
+// radius and categories from the user's preferences
+func (g *GeospatialService) SetSearchPreferencesFunc(fn SearchPreferencesFunc) {
+	g.searchPreferences = fn
+}
+
+// FindNearbyProducts returns a page of products within the search radius,
+// ordered by distance with ties broken by product ID so pages stay stable
+func (g *GeospatialService) FindNearbyProducts(ctx context.Context, req *NearbySearchRequest) (*NearbySearchResponse, error) {
+	if err := ValidateCoordinates(req.Latitude, req.Longitude); err != nil {
+		return nil, err
+	}
+
+	limit, offset := req.pagination()
+	filter, args := nearbyFilter(req)
+
+	var totalCount int
+	countQuery := "SELECT COUNT(*) FROM products p WHERE " + filter
+	if err := g.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount); err != nil {
+		return nil, fmt.Errorf("failed to count nearby products: %w", err)
+	}
+
 	// Build the SQL query with PostGIS functions
 	query := `
 		SELECT 
@@ -73,56 +144,10 @@ func (g *GeospatialService) FindNearbyProducts(ctx context.Context, req *NearbyS
 				ST_GeogFromText(ST_AsText(p.location_coordinates))
 			) * 180 / PI() as bearing_deg
 		FROM products p
-		WHERE p.is_active = true 
-		AND p.published_at IS NOT NULL
-		AND p.location_coordinates IS NOT NULL
-		AND ST_DWithin(
-			ST_GeogFromText('POINT(' || $1 || ' ' || $2 || ')'),
-			ST_GeogFromText(ST_AsText(p.location_coordinates)),
-			$3
-		)`
+		WHERE ` + filter
 
-	args := []interface{}{req.Longitude, req.Latitude, req.RadiusKm * 1000} // Convert km to meters
-	argIndex := 4
-
-	// Add category filter
-	if req.Category != "" {
-		query += fmt.Sprintf(" AND p.category = $%d", argIndex)
-		args = append(args, req.Category)
-		argIndex++
-	}
-
-	// Add subcategory filter
-	if req.Subcategory != "" {
-		query += fmt.Sprintf(" AND p.subcategory = $%d", argIndex)
-		args = append(args, req.Subcategory)
-		argIndex++
-	}
-
-	// Add price range filter
-	if req.PriceRange != nil {
-		if req.PriceRange.Min > 0 {
-			query += fmt.Sprintf(" AND p.price >= $%d", argIndex)
-			args = append(args, req.PriceRange.Min)
-			argIndex++
-		}
-		if req.PriceRange.Max > 0 {
-			query += fmt.Sprintf(" AND p.price <= $%d", argIndex)
-			args = append(args, req.PriceRange.Max)
-			argIndex++
-		}
-	}
-
-	// Order by distance
-	query += " ORDER BY distance_km ASC"
-
-	// Limit results
-	maxResults := req.MaxResults
-	if maxResults <= 0 || maxResults > 100 {
-		maxResults = 50 // Default limit
-	}
-	query += fmt.Sprintf(" LIMIT $%d", argIndex)
-	args = append(args, maxResults)
+	query += fmt.Sprintf(" ORDER BY distance_km ASC, p.id ASC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
 
 	rows, err := g.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -130,7 +155,7 @@ func (g *GeospatialService) FindNearbyProducts(ctx context.Context, req *NearbyS
 	}
 	defer rows.Close()
 
-	var nearbyProducts []*NearbyProduct
+	nearbyProducts := make([]*NearbyProduct, 0)
 	for rows.Next() {
 		product := &Product{}
 		var lng, lat, distanceKm, bearingDeg sql.NullFloat64
@@ -157,11 +182,11 @@ func (g *GeospatialService) FindNearbyProducts(ctx context.Context, req *NearbyS
 
 		nearbyProduct := &NearbyProduct{
 			Product:    product,
-			DistanceKm: distanceKm.Float64,
+			DistanceKm: &distanceKm.Float64,
 		}
 
 		if bearingDeg.Valid {
-			nearbyProduct.BearingDeg = bearingDeg.Float64
+			nearbyProduct.BearingDeg = &bearingDeg.Float64
 		}
 
 		nearbyProducts = append(nearbyProducts, nearbyProduct)
@@ -171,11 +196,91 @@ func (g *GeospatialService) FindNearbyProducts(ctx context.Context, req *NearbyS
 		return nil, fmt.Errorf("failed to iterate nearby products: %w", err)
 	}
 
-	return nearbyProducts, nil
+	return &NearbySearchResponse{
+		Products:   nearbyProducts,
+		TotalCount: totalCount,
+		RadiusKm:   req.RadiusKm,
+		Center:     Point{Lat: req.Latitude, Lng: req.Longitude},
+		Limit:      limit,
+		Offset:     offset,
+		HasMore:    offset+len(nearbyProducts) < totalCount,
+	}, nil
+}
+
+// pagination resolves the page size and offset of a nearby search
+func (req *NearbySearchRequest) pagination() (limit, offset int) {
+	limit = req.MaxResults
+	if limit <= 0 || limit > MaxNearbyResults {
+		limit = DefaultNearbyResults
+	}
+
+	offset = req.Offset
+	if req.Page > 0 {
+		offset = (req.Page - 1) * limit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// nearbyFilter builds the WHERE clause shared by the nearby search and its
+// count, with the center and radius as $1-$3
+func nearbyFilter(req *NearbySearchRequest) (string, []interface{}) {
+	conditions := []string{
+		"p.is_active = true",
+		"p.published_at IS NOT NULL",
+		"p.location_coordinates IS NOT NULL",
+		`ST_DWithin(
+			ST_GeogFromText('POINT(' || $1 || ' ' || $2 || ')'),
+			ST_GeogFromText(ST_AsText(p.location_coordinates)),
+			$3
+		)`,
+	}
+	args := []interface{}{req.Longitude, req.Latitude, req.RadiusKm * 1000} // Convert km to meters
+	argIndex := 4
+
+	// Add category filter
+	if req.Category != "" {
+		conditions = append(conditions, fmt.Sprintf("p.category = $%d", argIndex))
+		args = append(args, req.Category)
+		argIndex++
+	} else if len(req.Categories) > 0 {
+		conditions = append(conditions, fmt.Sprintf("p.category = ANY($%d)", argIndex))
+		args = append(args, pq.Array(req.Categories))
+		argIndex++
+	}
+
+	// Add subcategory filter
+	if req.Subcategory != "" {
+		conditions = append(conditions, fmt.Sprintf("p.subcategory = $%d", argIndex))
+		args = append(args, req.Subcategory)
+		argIndex++
+	}
+
+	// Add price range filter
+	if req.PriceRange != nil {
+		if req.PriceRange.Min > 0 {
+			conditions = append(conditions, fmt.Sprintf("p.price >= $%d", argIndex))
+			args = append(args, req.PriceRange.Min)
+			argIndex++
+		}
+		if req.PriceRange.Max > 0 {
+			conditions = append(conditions, fmt.Sprintf("p.price <= $%d", argIndex))
+			args = append(args, req.PriceRange.Max)
+			argIndex++
+		}
+	}
+
+	return strings.Join(conditions, "\n\t\tAND "), args
 }
 
 // GetGeospatialStats returns statistics about products in a geographic area
 func (g *GeospatialService) GetGeospatialStats(ctx context.Context, req *NearbySearchRequest) (*GeospatialStats, error) {
+	if err := ValidateCoordinates(req.Latitude, req.Longitude); err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT 
 			COUNT(*) as total_count,
@@ -246,8 +351,25 @@ func (g *GeospatialService) GetGeospatialStats(ctx context.Context, req *NearbyS
 	return stats, nil
 }
 
-// GetProductsInBounds returns products within a bounding box
-func (g *GeospatialService) GetProductsInBounds(ctx context.Context, bounds LocationBounds, category string, limit int) ([]*Product, error) {
+// Largest map viewport, in degrees per side, a bounds search accepts
+const MaxBoundsSpanDegrees = 10.0
+
+// GetProductsInBounds returns products within a bounding box. When center is
+// given, each result carries its distance and bearing from it.
+func (g *GeospatialService) GetProductsInBounds(ctx context.Context, bounds LocationBounds, center *Point, category string, limit int) ([]*NearbyProduct, error) {
+	if err := validateBounds(bounds); err != nil {
+		return nil, err
+	}
+	if center != nil {
+		if err := ValidateCoordinates(center.Lat, center.Lng); err != nil {
+			return nil, err
+		}
+	}
+	if bounds.NorthEast.Lng-bounds.SouthWest.Lng > MaxBoundsSpanDegrees ||
+		bounds.NorthEast.Lat-bounds.SouthWest.Lat > MaxBoundsSpanDegrees {
+		return nil, ErrBoundsTooLarge
+	}
+
 	query := `
 		SELECT 
 			p.id, p.user_id, p.title, p.description, p.category, p.subcategory,
@@ -292,7 +414,7 @@ func (g *GeospatialService) GetProductsInBounds(ctx context.Context, bounds Loca
 	}
 	defer rows.Close()
 
-	var products []*Product
+	results := make([]*NearbyProduct, 0)
 	for rows.Next() {
 		product := &Product{}
 		var lng, lat sql.NullFloat64
@@ -308,17 +430,24 @@ func (g *GeospatialService) GetProductsInBounds(ctx context.Context, bounds Loca
 			return nil, fmt.Errorf("failed to scan product row: %w", err)
 		}
 
+		result := &NearbyProduct{Product: product}
 		if lng.Valid && lat.Valid {
 			product.LocationCoordinates = &Point{
 				Lng: lng.Float64,
 				Lat: lat.Float64,
 			}
+			if center != nil {
+				distance := haversineKm(*center, *product.LocationCoordinates)
+				bearing := initialBearingDeg(*center, *product.LocationCoordinates)
+				result.DistanceKm = &distance
+				result.BearingDeg = &bearing
+			}
 		}
 
-		products = append(products, product)
+		results = append(results, result)
 	}
 
-	return products, nil
+	return results, rows.Err()
 }
 
 // FindProductsAlongRoute finds products along a route between two points
@@ -394,21 +523,27 @@ func (g *GeospatialService) FindProductsAlongRoute(ctx context.Context, start, e
 			return nil, fmt.Errorf("failed to scan product row: %w", err)
 		}
 
+		nearbyProduct := &NearbyProduct{
+			Product:    product,
+			DistanceKm: &distanceToRoute.Float64,
+		}
+
 		if lng.Valid && lat.Valid {
 			product.LocationCoordinates = &Point{
 				Lng: lng.Float64,
 				Lat: lat.Float64,
 			}
-		}
-
-		nearbyProduct := &NearbyProduct{
-			Product:    product,
-			DistanceKm: distanceToRoute.Float64,
+			bearing := initialBearingDeg(start, *product.LocationCoordinates)
+			nearbyProduct.BearingDeg = &bearing
 		}
 
 		nearbyProducts = append(nearbyProducts, nearbyProduct)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate route products: %w", err)
+	}
+
 	return nearbyProducts, nil
 }
 
@@ -429,58 +564,158 @@ func (g *GeospatialService) CalculateDistance(ctx context.Context, point1, point
 	return distance, nil
 }
 
+// Density grid size limits; each side is split into gridSize cells
+const (
+	MinDensityGridSize = 2
+	MaxDensityGridSize = 50
+)
+
+// DensityGrid is a product count heatmap over a bounding box. Counts[i][j] is
+// the cell in row i (south to north) and column j (west to east); each cell
+// spans LngStep by LatStep degrees from the SouthWest corner.
+type DensityGrid struct {
+	Bounds   LocationBounds `json:"bounds"`
+	GridSize int            `json:"grid_size"`
+	LngStep  float64        `json:"lng_step"`
+	LatStep  float64        `json:"lat_step"`
+	Counts   [][]int        `json:"counts"`
+}
+
 // GetProductDensityGrid returns a grid showing product density in an area
 func (g *GeospatialService) GetProductDensityGrid(ctx context.Context, bounds LocationBounds, gridSize int, category string) ([][]int, error) {
-	// This is a simplified implementation - in production you might use more sophisticated gridding
-	lngStep := (bounds.NorthEast.Lng - bounds.SouthWest.Lng) / float64(gridSize)
-	latStep := (bounds.NorthEast.Lat - bounds.SouthWest.Lat) / float64(gridSize)
+	if gridSize < MinDensityGridSize || gridSize > MaxDensityGridSize {
+		return nil, ErrInvalidGridSize
+	}
+	if err := validateBounds(bounds); err != nil {
+		return nil, err
+	}
 
 	grid := make([][]int, gridSize)
 	for i := range grid {
 		grid[i] = make([]int, gridSize)
 	}
 
-	for i := 0; i < gridSize; i++ {
-		for j := 0; j < gridSize; j++ {
-			minLng := bounds.SouthWest.Lng + float64(j)*lngStep
-			maxLng := bounds.SouthWest.Lng + float64(j+1)*lngStep
-			minLat := bounds.SouthWest.Lat + float64(i)*latStep
-			maxLat := bounds.SouthWest.Lat + float64(i+1)*latStep
-
-			query := `
-				SELECT COUNT(*)
-				FROM products p
-				WHERE p.is_active = true 
-				AND p.published_at IS NOT NULL
-				AND p.location_coordinates IS NOT NULL
-				AND ST_Within(
-					p.location_coordinates,
-					ST_MakeEnvelope($1, $2, $3, $4, 4326)
-				)`
-
-			args := []interface{}{minLng, minLat, maxLng, maxLat}
-
-			if category != "" {
-				query += " AND p.category = $5"
-				args = append(args, category)
-			}
+	// Bucket every product in one pass instead of counting cell by cell
+	query := `
+		SELECT 
+			width_bucket(ST_Y(p.location_coordinates), $2, $4, $5) - 1 as row_idx,
+			width_bucket(ST_X(p.location_coordinates), $1, $3, $5) - 1 as col_idx,
+			COUNT(*)
+		FROM products p
+		WHERE p.is_active = true 
+		AND p.published_at IS NOT NULL
+		AND p.location_coordinates IS NOT NULL
+		AND ST_Intersects(
+			p.location_coordinates,
+			ST_MakeEnvelope($1, $2, $3, $4, 4326)
+		)`
 
-			var count int
-			err := g.db.QueryRowContext(ctx, query, args...).Scan(&count)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get grid cell count: %w", err)
-			}
+	args := []interface{}{
+		bounds.SouthWest.Lng, bounds.SouthWest.Lat,
+		bounds.NorthEast.Lng, bounds.NorthEast.Lat,
+		gridSize,
+	}
+
+	if category != "" {
+		query += " AND p.category = $6"
+		args = append(args, category)
+	}
 
-			grid[i][j] = count
+	query += " GROUP BY row_idx, col_idx"
+
+	rows, err := g.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get density grid: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row, col, count int
+		if err := rows.Scan(&row, &col, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan grid cell count: %w", err)
 		}
+
+		// Points on the north/east edge fall into the bucket past the last one
+		row = clampCell(row, gridSize)
+		col = clampCell(col, gridSize)
+		grid[row][col] += count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate grid cells: %w", err)
 	}
 
 	return grid, nil
 }
 
-// Helper function to create geospatial search handlers
-func (g *GeospatialService) RegisterRoutes(router *gin.RouterGroup) {
+// Rough bounding box of Argentina, used to catch swapped or mistyped coordinates
+const (
+	minArgentinaLat = -55.0
+	maxArgentinaLat = -21.0
+	minArgentinaLng = -74.0
+	maxArgentinaLng = -53.0
+)
+
+// ValidateCoordinates rejects points outside Argentina. The error wraps
+// ErrCoordinatesOutOfRange and names the offending values.
+func ValidateCoordinates(lat, lng float64) error {
+	if lat < minArgentinaLat || lat > maxArgentinaLat || lng < minArgentinaLng || lng > maxArgentinaLng {
+		return fmt.Errorf("%w: lat %.6f, lng %.6f (expected lat %.0f to %.0f, lng %.0f to %.0f)",
+			ErrCoordinatesOutOfRange, lat, lng, minArgentinaLat, maxArgentinaLat, minArgentinaLng, maxArgentinaLng)
+	}
+	return nil
+}
+
+// haversineKm returns the great-circle distance between two points in kilometers
+func haversineKm(a, b Point) float64 {
+	const earthRadiusKm = 6371.0
+
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// validateBounds rejects boxes whose north-east corner is not strictly north
+// and east of the south-west one, which also rules out zero-area boxes
+func validateBounds(bounds LocationBounds) error {
+	if bounds.NorthEast.Lng <= bounds.SouthWest.Lng || bounds.NorthEast.Lat <= bounds.SouthWest.Lat {
+		return ErrInvalidBounds
+	}
+	return nil
+}
+
+// initialBearingDeg returns the compass bearing from a to b in degrees,
+// clockwise from north in [0, 360), matching ST_Azimuth in nearby search
+func initialBearingDeg(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	y := math.Sin(dLng) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLng)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+func clampCell(index, gridSize int) int {
+	if index < 0 {
+		return 0
+	}
+	if index >= gridSize {
+		return gridSize - 1
+	}
+	return index
+}
+
+// Helper function to create geospatial search handlers. optionalAuthMiddleware
+// identifies the user, when there is one, so nearby searches can use their preferences.
+func (g *GeospatialService) RegisterRoutes(router *gin.RouterGroup, optionalAuthMiddleware gin.HandlerFunc) {
 	geo := router.Group("/geo")
+	geo.Use(optionalAuthMiddleware)
 	{
 		geo.POST("/nearby", g.handleNearbySearch)
 		geo.POST("/bounds", g.handleBoundsSearch)
@@ -491,6 +726,32 @@ func (g *GeospatialService) RegisterRoutes(router *gin.RouterGroup) {
 	}
 }
 
+// applySearchDefaults fills what a nearby search leaves out. Explicit request
+// values always win; otherwise the radius resolves to the authenticated user's
+// preferred radius (capped at MaxSearchRadiusKm), then DefaultSearchRadiusKm,
+// and an empty category to the user's preferred categories, then to none.
+// Failing to load preferences only logs and falls through to the defaults.
+func (g *GeospatialService) applySearchDefaults(c *gin.Context, req *NearbySearchRequest) {
+	var preferences *SearchPreferences
+	if userID, ok := c.Get("user_id"); ok && g.searchPreferences != nil && (req.RadiusKm == 0 || req.Category == "") {
+		var err error
+		preferences, err = g.searchPreferences(c.Request.Context(), userID.(uuid.UUID))
+		if err != nil {
+			logger.FromContext(c.Request.Context()).Warn("failed to load search preferences", "user_id", userID, "error", err)
+		}
+	}
+
+	if req.RadiusKm == 0 {
+		req.RadiusKm = DefaultSearchRadiusKm
+		if preferences != nil && preferences.RadiusKm > 0 {
+			req.RadiusKm = math.Min(preferences.RadiusKm, MaxSearchRadiusKm)
+		}
+	}
+	if req.Category == "" && preferences != nil {
+		req.Categories = preferences.Categories
+	}
+}
+
 // Handler implementations
 func (g *GeospatialService) handleNearbySearch(c *gin.Context) {
 	var req NearbySearchRequest
@@ -498,9 +759,14 @@ func (g *GeospatialService) handleNearbySearch(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Invalid request"})
 		return
 	}
+	g.applySearchDefaults(c, &req)
 	
 	results, err := g.FindNearbyProducts(c.Request.Context(), &req)
 	if err != nil {
+		if errors.Is(err, ErrCoordinatesOutOfRange) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(500, gin.H{"error": "Search failed"})
 		return
 	}
@@ -509,20 +775,35 @@ func (g *GeospatialService) handleNearbySearch(c *gin.Context) {
 }
 
 func (g *GeospatialService) handleBoundsSearch(c *gin.Context) {
-	// Simple bounds search using nearby search as base
-	var req NearbySearchRequest
+	type BoundsRequest struct {
+		Bounds   LocationBounds `json:"bounds" binding:"required"`
+		Center   *Point         `json:"center,omitempty"` // adds distance and bearing from the viewport center
+		Category string         `json:"category"`
+		Limit    int            `json:"limit"`
+	}
+
+	var req BoundsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": "Invalid request"})
 		return
 	}
-	
-	results, err := g.FindNearbyProducts(c.Request.Context(), &req)
+
+	results, err := g.GetProductsInBounds(c.Request.Context(), req.Bounds, req.Center, req.Category, req.Limit)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Search failed"})
+		switch {
+		case err == ErrInvalidBounds, err == ErrBoundsTooLarge, errors.Is(err, ErrCoordinatesOutOfRange):
+			c.JSON(400, gin.H{"error": err.Error()})
+		default:
+			c.JSON(500, gin.H{"error": "Search failed"})
+		}
 		return
 	}
-	
-	c.JSON(200, results)
+
+	c.JSON(200, gin.H{
+		"bounds":   req.Bounds,
+		"products": results,
+		"count":    len(results),
+	})
 }
 
 func (g *GeospatialService) handleRouteSearch(c *gin.Context) {
@@ -555,9 +836,14 @@ func (g *GeospatialService) handleGeospatialStats(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Invalid request"})
 		return
 	}
+	g.applySearchDefaults(c, &req)
 	
 	stats, err := g.GetGeospatialStats(c.Request.Context(), &req)
 	if err != nil {
+		if errors.Is(err, ErrCoordinatesOutOfRange) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(500, gin.H{"error": "Stats failed"})
 		return
 	}
@@ -587,13 +873,34 @@ func (g *GeospatialService) handleDistanceCalculation(c *gin.Context) {
 }
 
 func (g *GeospatialService) handleDensityGrid(c *gin.Context) {
-	// Simplified density response
-	var req NearbySearchRequest
+	type DensityRequest struct {
+		Bounds   LocationBounds `json:"bounds" binding:"required"`
+		GridSize int            `json:"grid_size" binding:"required"`
+		Category string         `json:"category"`
+	}
+
+	var req DensityRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": "Invalid request"})
 		return
 	}
-	
-	// Return a basic response for density grid
-	c.JSON(200, gin.H{"message": "Density grid not implemented"})
-}
\ No newline at end of file
+
+	counts, err := g.GetProductDensityGrid(c.Request.Context(), req.Bounds, req.GridSize, req.Category)
+	if err != nil {
+		switch err {
+		case ErrInvalidGridSize, ErrInvalidBounds:
+			c.JSON(400, gin.H{"error": err.Error()})
+		default:
+			c.JSON(500, gin.H{"error": "Density grid failed"})
+		}
+		return
+	}
+
+	c.JSON(200, DensityGrid{
+		Bounds:   req.Bounds,
+		GridSize: req.GridSize,
+		LngStep:  (req.Bounds.NorthEast.Lng - req.Bounds.SouthWest.Lng) / float64(req.GridSize),
+		LatStep:  (req.Bounds.NorthEast.Lat - req.Bounds.SouthWest.Lat) / float64(req.GridSize),
+		Counts:   counts,
+	})
+}
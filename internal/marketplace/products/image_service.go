@@ -3,19 +3,89 @@ package products
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math"
 	"mime/multipart"
 	"strings"
+	"time"
 
 	"agro-mas-backend/pkg/gcloud"
+	"agro-mas-backend/pkg/logger"
+	"agro-mas-backend/pkg/media"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type ImageService struct {
 	db            *sql.DB
 	storageClient *gcloud.StorageClient
+
+	// Upload limits, see SetImageLimits and SetVideoLimit
+	maxImageSize        int64
+	maxImagesPerProduct int
+	maxVideoSize        int64
+}
+
+var ErrTooManyImages = errors.New("product image limit reached")
+
+// DefaultMaxImagesPerProduct caps a product's gallery unless configured otherwise
+const DefaultMaxImagesPerProduct = 10
+
+// TooManyImagesError reports how many images the product has and the cap, so
+// clients can tell the seller to remove some first
+type TooManyImagesError struct {
+	Count int `json:"count"`
+	Limit int `json:"limit"`
+}
+
+func (e *TooManyImagesError) Error() string {
+	return fmt.Sprintf("%s: product has %d of %d images", ErrTooManyImages, e.Count, e.Limit)
+}
+
+func (e *TooManyImagesError) Is(target error) bool {
+	return target == ErrTooManyImages
+}
+
+var (
+	ErrInvalidImages     = errors.New("some images are invalid")
+	ErrImageUploadFailed = errors.New("image upload failed")
+)
+
+// ImageUploadResult is the outcome of one file of a multi-image upload
+type ImageUploadResult struct {
+	Index    int           `json:"index"`
+	Filename string        `json:"filename"`
+	Success  bool          `json:"success"`
+	Error    string        `json:"error,omitempty"`
+	Image    *ProductImage `json:"image,omitempty"`
+}
+
+// ImageBatchError reports a multi-image upload that stored nothing, with the
+// outcome of each file so the seller can see which ones failed and why. Err is
+// ErrInvalidImages or ErrImageUploadFailed.
+type ImageBatchError struct {
+	Err     error
+	Results []ImageUploadResult
 }
 
+func (e *ImageBatchError) Error() string {
+	failed := 0
+	for _, result := range e.Results {
+		if result.Error != "" && result.Error != imageRolledBack {
+			failed++
+		}
+	}
+	return fmt.Sprintf("%s: %d of %d images failed", e.Err, failed, len(e.Results))
+}
+
+func (e *ImageBatchError) Is(target error) bool {
+	return target == e.Err
+}
+
+// imageRolledBack marks files of a failed batch that had no problem themselves
+const imageRolledBack = "not stored because another image failed"
+
 type UploadImageRequest struct {
 	ProductID    uuid.UUID `form:"product_id" binding:"required"`
 	AltText      string    `form:"alt_text"`
@@ -23,21 +93,56 @@ type UploadImageRequest struct {
 	DisplayOrder int       `form:"display_order"`
 }
 
+// ImageOrder is the desired position and cover flag of one product image
+type ImageOrder struct {
+	ImageID      uuid.UUID `json:"image_id" binding:"required"`
+	DisplayOrder int       `json:"display_order" binding:"min=0"`
+	IsPrimary    bool      `json:"is_primary"`
+}
+
+type ReorderImagesRequest struct {
+	Images []ImageOrder `json:"images" binding:"required,min=1,dive"`
+}
+
 type UploadImageResponse struct {
 	Image ProductImage `json:"image"`
 }
 
+// MaxVideoDuration caps the length of the single product video
+const MaxVideoDuration = 120 * time.Second
+
 func NewImageService(db *sql.DB, storageClient *gcloud.StorageClient) *ImageService {
 	return &ImageService{
-		db:            db,
-		storageClient: storageClient,
+		db:                  db,
+		storageClient:       storageClient,
+		maxImageSize:        gcloud.DefaultMaxImageSize,
+		maxImagesPerProduct: DefaultMaxImagesPerProduct,
+		maxVideoSize:        gcloud.DefaultMaxVideoSize,
+	}
+}
+
+// SetImageLimits configures the largest accepted image in bytes and how many
+// images a product may have. Zero or negative values keep the defaults.
+func (s *ImageService) SetImageLimits(maxSize int64, maxPerProduct int) {
+	if maxSize > 0 {
+		s.maxImageSize = maxSize
+	}
+	if maxPerProduct > 0 {
+		s.maxImagesPerProduct = maxPerProduct
+	}
+}
+
+// SetVideoLimit overrides the maximum product video size in bytes
+func (s *ImageService) SetVideoLimit(maxSize int64) {
+	if maxSize > 0 {
+		s.maxVideoSize = maxSize
 	}
 }
 
 // UploadProductImage uploads an image for a product
 func (s *ImageService) UploadProductImage(ctx context.Context, userID uuid.UUID, file multipart.File, header *multipart.FileHeader, req UploadImageRequest) (*ProductImage, error) {
 	// Validate image file
-	if err := gcloud.ValidateImageFile(header); err != nil {
+	if err := gcloud.ValidateImageFileWithLimit(header, s.maxImageSize); err != nil {
 		return nil, fmt.Errorf("image validation failed: %w", err)
 	}
 
@@ -46,6 +151,10 @@ func (s *ImageService) UploadProductImage(ctx context.Context, userID uuid.UUID,
 		return nil, err
 	}
 
+	if err := s.checkImageLimit(ctx, s.db, req.ProductID, 1); err != nil {
+		return nil, err
+	}
+
 	// If this is set as primary, remove primary flag from other images
 	if req.IsPrimary {
 		if err := s.removePrimaryFlag(ctx, req.ProductID); err != nil {
@@ -94,14 +203,135 @@ func (s *ImageService) UploadProductImage(ctx context.Context, userID uuid.UUID,
 	if err := s.createProductImage(ctx, productImage); err != nil {
 		// If database save fails, clean up uploaded file
 		if deleteErr := s.storageClient.DeleteFile(ctx, uploadResult.StoragePath); deleteErr != nil {
-			fmt.Printf("Failed to clean up uploaded file after database error: %v\n", deleteErr)
+			logger.FromContext(ctx).Warn("failed to clean up uploaded file after database error", "path", uploadResult.StoragePath, "error", deleteErr)
+		}
+		// A concurrent upload may have filled the gallery meanwhile
+		if errors.Is(err, ErrTooManyImages) {
+			return nil, err
 		}
 		return nil, fmt.Errorf("failed to save image to database: %w", err)
 	}
 
+	s.recordEvent(ctx, req.ProductID, userID, ProductEventImageAdded, map[string]interface{}{
+		"image_id":  productImage.ID,
+		"image_url": productImage.ImageURL,
+	})
+
 	return productImage, nil
 }
 
+// UploadProductImages adds several images to a product as a unit: either all
+// of them are stored or none are. Files are validated before anything is
+// uploaded; if an upload or the database insert fails, the files already in
+// storage are deleted again. The first image becomes primary when the product
+// has none.
+func (s *ImageService) UploadProductImages(ctx context.Context, userID, productID uuid.UUID, headers []*multipart.FileHeader) ([]ProductImage, error) {
+	if err := s.validateProductOwnership(ctx, userID, productID); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkImageLimit(ctx, s.db, productID, len(headers)); err != nil {
+		return nil, err
+	}
+
+	results := make([]ImageUploadResult, len(headers))
+	invalid := false
+	for i, header := range headers {
+		results[i] = ImageUploadResult{Index: i, Filename: header.Filename}
+		if err := gcloud.ValidateImageFileWithLimit(header, s.maxImageSize); err != nil {
+			results[i].Error = err.Error()
+			invalid = true
+		}
+	}
+	if invalid {
+		return nil, &ImageBatchError{Err: ErrInvalidImages, Results: rollBackResults(results)}
+	}
+
+	displayOrder := s.getNextDisplayOrder(ctx, productID)
+	images := make([]ProductImage, 0, len(headers))
+	storagePaths := make([]string, 0, len(headers))
+
+	for i, header := range headers {
+		uploadResult, err := s.uploadImageFile(ctx, userID, productID, header)
+		if err != nil {
+			logger.FromContext(ctx).Warn("failed to upload product image", "product_id", productID, "filename", header.Filename, "error", err)
+			results[i].Error = err.Error()
+			s.cleanupUploadedFiles(ctx, storagePaths...)
+			return nil, &ImageBatchError{Err: ErrImageUploadFailed, Results: rollBackResults(results)}
+		}
+		storagePaths = append(storagePaths, uploadResult.StoragePath)
+
+		images = append(images, ProductImage{
+			ID:               uuid.New(),
+			ProductID:        productID,
+			ImageURL:         uploadResult.URL,
+			CloudStoragePath: uploadResult.StoragePath,
+			DisplayOrder:     displayOrder + i,
+			FileSize:         func() *int { size := int(uploadResult.FileSize); return &size }(),
+			MimeType:         &uploadResult.MimeType,
+			UploadedAt:       uploadResult.UploadedAt,
+		})
+	}
+
+	if err := s.createProductImages(ctx, productID, images); err != nil {
+		s.cleanupUploadedFiles(ctx, storagePaths...)
+		// A concurrent upload may have filled the gallery meanwhile
+		if errors.Is(err, ErrTooManyImages) {
+			return nil, err
+		}
+		logger.FromContext(ctx).Warn("failed to save product images", "product_id", productID, "error", err)
+		for i := range results {
+			results[i].Error = "failed to save image"
+		}
+		return nil, &ImageBatchError{Err: ErrImageUploadFailed, Results: results}
+	}
+
+	for i := range images {
+		s.recordEvent(ctx, productID, userID, ProductEventImageAdded, map[string]interface{}{
+			"image_id":  images[i].ID,
+			"image_url": images[i].ImageURL,
+		})
+	}
+
+	return images, nil
+}
+
+// rollBackResults marks every file of a failed batch without its own error
+func rollBackResults(results []ImageUploadResult) []ImageUploadResult {
+	for i := range results {
+		if results[i].Error == "" {
+			results[i].Error = imageRolledBack
+		}
+	}
+	return results
+}
+
+// uploadImageFile stores one image of a multi-image upload
+func (s *ImageService) uploadImageFile(ctx context.Context, userID, productID uuid.UUID, header *multipart.FileHeader) (*gcloud.UploadResult, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	defer file.Close()
+
+	uploadOptions := gcloud.UploadOptions{
+		Directory:    "products",
+		SubDirectory: productID.String(),
+		PublicRead:   true,
+		CacheControl: "public, max-age=31536000", // 1 year for product images
+		Metadata: map[string]string{
+			"product_id": productID.String(),
+			"user_id":    userID.String(),
+		},
+	}
+
+	uploadResult, err := s.storageClient.UploadFile(ctx, file, header, uploadOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload image to storage: %w", err)
+	}
+	return uploadResult, nil
+}
+
 // UpdateProductImage updates an existing product image
 func (s *ImageService) UpdateProductImage(ctx context.Context, userID, imageID uuid.UUID, updates map[string]interface{}) error {
 	// Get existing image
@@ -147,7 +377,7 @@ func (s *ImageService) DeleteProductImage(ctx context.Context, userID, imageID u
 
 	// Delete from Cloud Storage
 	if err := s.storageClient.DeleteFile(ctx, image.CloudStoragePath); err != nil {
-		fmt.Printf("Failed to delete file from storage: %v\n", err)
+		logger.FromContext(ctx).Warn("failed to delete file from storage", "path", image.CloudStoragePath, "error", err)
 		// Continue with database deletion even if storage deletion fails
 	}
 
@@ -156,16 +386,99 @@ func (s *ImageService) DeleteProductImage(ctx context.Context, userID, imageID u
 		return fmt.Errorf("failed to delete image from database: %w", err)
 	}
 
+	s.recordEvent(ctx, image.ProductID, userID, ProductEventImageRemoved, map[string]interface{}{
+		"image_id":  image.ID,
+		"image_url": image.ImageURL,
+	})
+
 	// If this was the primary image, set another image as primary
 	if image.IsPrimary {
 		if err := s.setPrimaryImageIfNeeded(ctx, image.ProductID); err != nil {
-			fmt.Printf("Failed to set new primary image: %v\n", err)
+			logger.FromContext(ctx).Warn("failed to set new primary image", "product_id", image.ProductID, "error", err)
 		}
 	}
 
 	return nil
 }
 
+// ReorderProductImages sets the display order and cover image of a product's
+// gallery. The order must cover every image of the product with exactly one
+// primary; all rows are updated in a single transaction so the old primary
+// flag is cleared atomically.
+func (s *ImageService) ReorderProductImages(ctx context.Context, userID, productID uuid.UUID, order []ImageOrder) ([]ProductImage, error) {
+	primaryCount := 0
+	ids := make([]uuid.UUID, len(order))
+	displayOrders := make([]int64, len(order))
+	primaryFlags := make([]bool, len(order))
+	seen := make(map[uuid.UUID]bool, len(order))
+	for i, item := range order {
+		if seen[item.ImageID] {
+			return nil, ErrInvalidImageOrder
+		}
+		seen[item.ImageID] = true
+		if item.IsPrimary {
+			primaryCount++
+		}
+		ids[i] = item.ImageID
+		displayOrders[i] = int64(item.DisplayOrder)
+		primaryFlags[i] = item.IsPrimary
+	}
+	if primaryCount != 1 {
+		return nil, ErrInvalidPrimaryImage
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the product so concurrent reorders of the same gallery serialize
+	var ownerID uuid.UUID
+	err = tx.QueryRowContext(ctx,
+		`SELECT user_id FROM products WHERE id = $1 AND is_active = true FOR UPDATE`, productID).Scan(&ownerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to check product ownership: %w", err)
+	}
+	if ownerID != userID {
+		return nil, ErrProductNotOwnedByUser
+	}
+
+	var imageCount, matched int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE id = ANY($2))
+		FROM product_images WHERE product_id = $1`, productID, pq.Array(ids)).Scan(&imageCount, &matched)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check product images: %w", err)
+	}
+	if imageCount != len(order) || matched != len(order) {
+		return nil, ErrInvalidImageOrder
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE product_images pi
+		SET display_order = o.display_order, is_primary = o.is_primary
+		FROM unnest($2::uuid[], $3::int[], $4::bool[]) AS o(id, display_order, is_primary)
+		WHERE pi.id = o.id AND pi.product_id = $1`,
+		productID, pq.Array(ids), pq.Array(displayOrders), pq.Array(primaryFlags))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reorder images: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit image order: %w", err)
+	}
+
+	s.recordEvent(ctx, productID, userID, ProductEventImagesReordered, map[string]interface{}{
+		"image_ids": ids,
+	})
+
+	return s.GetProductImages(ctx, productID)
+}
+
 // GetProductImages retrieves all images for a product
 func (s *ImageService) GetProductImages(ctx context.Context, productID uuid.UUID) ([]ProductImage, error) {
 	query := `
@@ -205,6 +518,174 @@ func (s *ImageService) GetResizedImageURL(storagePath string, width, height, qua
 	return s.storageClient.GenerateResizedImageURL(storagePath, width, height, quality)
 }
 
+// UploadProductVideo uploads the single video allowed for a product, with an
+// optional poster frame. The duration is read from the video container; files
+// that don't state one, such as live browser recordings, are stored without
+// it and bounded by the size limit alone.
+func (s *ImageService) UploadProductVideo(ctx context.Context, userID, productID uuid.UUID, file multipart.File, header *multipart.FileHeader, poster multipart.File, posterHeader *multipart.FileHeader) (*ProductVideo, error) {
+	// Validate video file
+	if err := gcloud.ValidateVideoFileWithLimit(header, s.maxVideoSize); err != nil {
+		return nil, fmt.Errorf("video validation failed: %w", err)
+	}
+	var durationSeconds *int
+	duration, err := media.VideoDuration(file, header.Size, header.Header.Get("Content-Type"))
+	switch {
+	case err == nil:
+		if duration > MaxVideoDuration {
+			return nil, fmt.Errorf("video validation failed: duration must not exceed %d seconds", int(MaxVideoDuration.Seconds()))
+		}
+		seconds := int(math.Ceil(duration.Seconds()))
+		durationSeconds = &seconds
+	case !errors.Is(err, media.ErrUnknownDuration):
+		return nil, fmt.Errorf("video validation failed: %w", err)
+	}
+	if poster != nil {
+		if err := gcloud.ValidateImageFileWithLimit(posterHeader, s.maxImageSize); err != nil {
+			return nil, fmt.Errorf("poster validation failed: %w", err)
+		}
+	}
+
+	// Check if product exists and user owns it
+	if err := s.validateProductOwnership(ctx, userID, productID); err != nil {
+		return nil, err
+	}
+
+	// Only one video per product
+	existing, err := s.GetProductVideo(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("product already has a video")
+	}
+
+	// Upload to Cloud Storage
+	uploadOptions := gcloud.UploadOptions{
+		Directory:    "products",
+		SubDirectory: productID.String() + "/video",
+		PublicRead:   true,
+		CacheControl: "public, max-age=31536000", // 1 year for product videos
+		Metadata: map[string]string{
+			"product_id": productID.String(),
+			"user_id":    userID.String(),
+		},
+	}
+
+	uploadResult, err := s.storageClient.UploadFile(ctx, file, header, uploadOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload video to storage: %w", err)
+	}
+
+	video := &ProductVideo{
+		ID:               uuid.New(),
+		ProductID:        productID,
+		VideoURL:         uploadResult.URL,
+		CloudStoragePath: uploadResult.StoragePath,
+		FileSize:         &uploadResult.FileSize,
+		MimeType:         &uploadResult.MimeType,
+		UploadedAt:       uploadResult.UploadedAt,
+		DurationSeconds:  durationSeconds,
+	}
+
+	// Poster frames are extracted client-side and uploaded alongside the video
+	if poster != nil {
+		posterResult, err := s.storageClient.UploadFile(ctx, poster, posterHeader, uploadOptions)
+		if err != nil {
+			s.cleanupUploadedFiles(ctx, uploadResult.StoragePath)
+			return nil, fmt.Errorf("failed to upload poster to storage: %w", err)
+		}
+		video.PosterURL = &posterResult.URL
+		video.PosterStoragePath = &posterResult.StoragePath
+	}
+
+	// Save to database
+	if err := s.createProductVideo(ctx, video); err != nil {
+		// If database save fails, clean up uploaded files
+		paths := []string{video.CloudStoragePath}
+		if video.PosterStoragePath != nil {
+			paths = append(paths, *video.PosterStoragePath)
+		}
+		s.cleanupUploadedFiles(ctx, paths...)
+		return nil, fmt.Errorf("failed to save video to database: %w", err)
+	}
+
+	s.recordEvent(ctx, productID, userID, ProductEventVideoAdded, map[string]interface{}{
+		"video_id":  video.ID,
+		"video_url": video.VideoURL,
+	})
+
+	return video, nil
+}
+
+// DeleteProductVideo removes the video of a product
+func (s *ImageService) DeleteProductVideo(ctx context.Context, userID, productID uuid.UUID) error {
+	// Check product ownership
+	if err := s.validateProductOwnership(ctx, userID, productID); err != nil {
+		return err
+	}
+
+	video, err := s.GetProductVideo(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if video == nil {
+		return fmt.Errorf("video not found")
+	}
+
+	// Delete from database
+	query := `DELETE FROM product_videos WHERE id = $1`
+	if _, err := s.db.ExecContext(ctx, query, video.ID); err != nil {
+		return fmt.Errorf("failed to delete video from database: %w", err)
+	}
+
+	// Delete from Cloud Storage, ignoring failures as with images
+	paths := []string{video.CloudStoragePath}
+	if video.PosterStoragePath != nil {
+		paths = append(paths, *video.PosterStoragePath)
+	}
+	s.cleanupUploadedFiles(ctx, paths...)
+
+	s.recordEvent(ctx, productID, userID, ProductEventVideoRemoved, map[string]interface{}{
+		"video_id":  video.ID,
+		"video_url": video.VideoURL,
+	})
+
+	return nil
+}
+
+// GetProductVideo retrieves the video for a product, if any
+func (s *ImageService) GetProductVideo(ctx context.Context, productID uuid.UUID) (*ProductVideo, error) {
+	query := `
+		SELECT id, product_id, video_url, cloud_storage_path, poster_url,
+			   poster_storage_path, duration_seconds, file_size, mime_type, uploaded_at
+		FROM product_videos 
+		WHERE product_id = $1`
+
+	video := &ProductVideo{}
+	err := s.db.QueryRowContext(ctx, query, productID).Scan(
+		&video.ID, &video.ProductID, &video.VideoURL, &video.CloudStoragePath,
+		&video.PosterURL, &video.PosterStoragePath, &video.DurationSeconds,
+		&video.FileSize, &video.MimeType, &video.UploadedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get product video: %w", err)
+	}
+
+	return video, nil
+}
+
+// DeleteStoredFiles removes files from Cloud Storage, logging failures.
+// It is a no-op when storage is disabled.
+func (s *ImageService) DeleteStoredFiles(ctx context.Context, storagePaths []string) {
+	if s.storageClient == nil {
+		return
+	}
+	s.cleanupUploadedFiles(ctx, storagePaths...)
+}
+
 // Helper methods
 func (s *ImageService) validateProductOwnership(ctx context.Context, userID, productID uuid.UUID) error {
 	query := `SELECT user_id FROM products WHERE id = $1 AND is_active = true`
@@ -224,6 +705,27 @@ func (s *ImageService) validateProductOwnership(ctx context.Context, userID, pro
 	return nil
 }
 
+// imageCounter runs the image count on the database or inside a transaction
+type imageCounter interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// checkImageLimit fails with a TooManyImagesError when adding images would
+// take the product over the allowed number. Uploads check early against
+// s.db to avoid storing files in vain; the insert checks again under
+// lockImageLimit.
+func (s *ImageService) checkImageLimit(ctx context.Context, db imageCounter, productID uuid.UUID, adding int) error {
+	var count int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM product_images WHERE product_id = $1`, productID).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to count product images: %w", err)
+	}
+	if count+adding > s.maxImagesPerProduct {
+		return &TooManyImagesError{Count: count, Limit: s.maxImagesPerProduct}
+	}
+	return nil
+}
+
 func (s *ImageService) removePrimaryFlag(ctx context.Context, productID uuid.UUID) error {
 	query := `UPDATE product_images SET is_primary = false WHERE product_id = $1 AND is_primary = true`
 	_, err := s.db.ExecContext(ctx, query, productID)
@@ -240,14 +742,75 @@ func (s *ImageService) getNextDisplayOrder(ctx context.Context, productID uuid.U
 	return nextOrder
 }
 
+// lockImageLimit locks the product row for the rest of tx and checks the
+// image limit, so concurrent uploads to one product cannot both pass it
+func (s *ImageService) lockImageLimit(ctx context.Context, tx *sql.Tx, productID uuid.UUID, adding int) error {
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM products WHERE id = $1 FOR UPDATE`, productID); err != nil {
+		return fmt.Errorf("failed to lock product: %w", err)
+	}
+	return s.checkImageLimit(ctx, tx, productID, adding)
+}
+
+// createProductImage inserts an image unless the product's gallery is full
 func (s *ImageService) createProductImage(ctx context.Context, image *ProductImage) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.lockImageLimit(ctx, tx, image.ProductID, 1); err != nil {
+		return err
+	}
+	if err := insertProductImage(ctx, tx, image); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// createProductImages inserts a batch of images in one transaction unless it
+// would overfill the product's gallery, making the first one primary when the
+// product has no primary image yet
+func (s *ImageService) createProductImages(ctx context.Context, productID uuid.UUID, images []ProductImage) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.lockImageLimit(ctx, tx, productID, len(images)); err != nil {
+		return err
+	}
+
+	var hasPrimary bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM product_images WHERE product_id = $1 AND is_primary = true)`,
+		productID).Scan(&hasPrimary)
+	if err != nil {
+		return fmt.Errorf("failed to check primary image: %w", err)
+	}
+	if !hasPrimary && len(images) > 0 {
+		images[0].IsPrimary = true
+	}
+
+	for i := range images {
+		if err := insertProductImage(ctx, tx, &images[i]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func insertProductImage(ctx context.Context, db execer, image *ProductImage) error {
 	query := `
 		INSERT INTO product_images (
 			id, product_id, image_url, cloud_storage_path, alt_text,
 			is_primary, display_order, file_size, mime_type, uploaded_at
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
-	_, err := s.db.ExecContext(ctx, query,
+	_, err := db.ExecContext(ctx, query,
 		image.ID, image.ProductID, image.ImageURL, image.CloudStoragePath,
 		image.AltText, image.IsPrimary, image.DisplayOrder, image.FileSize,
 		image.MimeType, image.UploadedAt)
@@ -255,6 +818,50 @@ func (s *ImageService) createProductImage(ctx context.Context, image *ProductIma
 	return err
 }
 
+func (s *ImageService) createProductVideo(ctx context.Context, video *ProductVideo) error {
+	query := `
+		INSERT INTO product_videos (
+			id, product_id, video_url, cloud_storage_path, poster_url,
+			poster_storage_path, duration_seconds, file_size, mime_type, uploaded_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := s.db.ExecContext(ctx, query,
+		video.ID, video.ProductID, video.VideoURL, video.CloudStoragePath,
+		video.PosterURL, video.PosterStoragePath, video.DurationSeconds,
+		video.FileSize, video.MimeType, video.UploadedAt)
+
+	return err
+}
+
+func (s *ImageService) recordEvent(ctx context.Context, productID, actorID uuid.UUID, eventType string, details map[string]interface{}) {
+	event := &ProductEvent{
+		ProductID: productID,
+		ActorID:   &actorID,
+		EventType: eventType,
+		Details:   details,
+	}
+	if err := insertProductEvent(ctx, s.db, event); err != nil {
+		logger.FromContext(ctx).Warn("failed to record product event", "event_type", eventType, "product_id", productID, "error", err)
+	}
+}
+
+// cleanupTimeout bounds deleting orphaned uploads once the request is done
+const cleanupTimeout = 30 * time.Second
+
+// cleanupUploadedFiles removes files whose database rows were never written.
+// It runs detached from ctx's cancellation, since an upload that failed
+// because the request timed out still has to delete what it stored.
+func (s *ImageService) cleanupUploadedFiles(ctx context.Context, storagePaths ...string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancel()
+
+	for _, path := range storagePaths {
+		if err := s.storageClient.DeleteFile(ctx, path); err != nil {
+			logger.FromContext(ctx).Warn("failed to clean up uploaded file", "path", path, "error", err)
+		}
+	}
+}
+
 func (s *ImageService) getProductImageByID(ctx context.Context, imageID uuid.UUID) (*ProductImage, error) {
 	query := `
 		SELECT id, product_id, image_url, cloud_storage_path, alt_text,
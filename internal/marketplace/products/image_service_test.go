@@ -0,0 +1,91 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateProductImageEnforcesLimitUnderConcurrency(t *testing.T) {
+	db := testDB(t)
+	repo := NewRepository(db)
+	service := NewImageService(db, nil)
+	service.SetImageLimits(0, 2)
+	product := createTestProduct(t, repo, createTestSeller(t, db), nil)
+
+	const uploads = 5
+	errs := make([]error, uploads)
+	var wg sync.WaitGroup
+	for i := 0; i < uploads; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = service.createProductImage(context.Background(), &ProductImage{
+				ID:               uuid.New(),
+				ProductID:        product.ID,
+				ImageURL:         "https://storage.test/image.jpg",
+				CloudStoragePath: "products/" + product.ID.String() + "/image.jpg",
+				DisplayOrder:     i + 1,
+				UploadedAt:       time.Now(),
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	stored := 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			stored++
+		case !errors.Is(err, ErrTooManyImages):
+			t.Errorf("upload %d: unexpected error %v", i, err)
+		}
+	}
+	if stored != 2 {
+		t.Errorf("expected 2 images to be stored, got %d", stored)
+	}
+}
+
+func TestCreateProductImagesRejectsBatchOverLimit(t *testing.T) {
+	db := testDB(t)
+	repo := NewRepository(db)
+	service := NewImageService(db, nil)
+	service.SetImageLimits(0, 3)
+	product := createTestProduct(t, repo, createTestSeller(t, db), nil)
+
+	batch := func(size int) []ProductImage {
+		images := make([]ProductImage, size)
+		for i := range images {
+			images[i] = ProductImage{
+				ID:               uuid.New(),
+				ProductID:        product.ID,
+				ImageURL:         "https://storage.test/image.jpg",
+				CloudStoragePath: "products/" + product.ID.String() + "/image.jpg",
+				DisplayOrder:     i + 1,
+				UploadedAt:       time.Now(),
+			}
+		}
+		return images
+	}
+
+	if err := service.createProductImages(context.Background(), product.ID, batch(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := service.createProductImages(context.Background(), product.ID, batch(2))
+	var limitErr *TooManyImagesError
+	if !errors.As(err, &limitErr) || limitErr.Count != 2 || limitErr.Limit != 3 {
+		t.Fatalf("expected a TooManyImagesError for 2 of 3 images, got %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM product_images WHERE product_id = $1`, product.ID).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("expected the rejected batch to store nothing, got %d images", count)
+	}
+}
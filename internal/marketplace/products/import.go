@@ -0,0 +1,371 @@
+package products
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"agro-mas-backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// MaxImportRows bounds the number of products in a single catalog upload
+const MaxImportRows = 1000
+
+// Columns every import file must declare in its header
+var requiredImportColumns = []string{"title", "category", "price_type"}
+
+// ImportRow is a parsed CSV line together with the problems found in it
+type ImportRow struct {
+	Line    int
+	Request *CreateProductRequest
+	Errors  []string
+}
+
+// ImportRowResult reports whether a single line of an import file is valid,
+// and once imported, the product created from it
+type ImportRowResult struct {
+	Line      int        `json:"line"`
+	Title     string     `json:"title,omitempty"`
+	Valid     bool       `json:"valid"`
+	Errors    []string   `json:"errors,omitempty"`
+	ProductID *uuid.UUID `json:"product_id,omitempty"`
+}
+
+// ImportPreviewResponse summarizes the validation of an import file
+type ImportPreviewResponse struct {
+	TotalRows   int               `json:"total_rows"`
+	ValidRows   int               `json:"valid_rows"`
+	InvalidRows int               `json:"invalid_rows"`
+	Rows        []ImportRowResult `json:"rows"`
+}
+
+// ImportResponse reports the outcome of a catalog import. Valid rows are
+// created even when others are rejected.
+type ImportResponse struct {
+	TotalRows    int               `json:"total_rows"`
+	CreatedRows  int               `json:"created_rows"`
+	RejectedRows int               `json:"rejected_rows"`
+	Rows         []ImportRowResult `json:"rows"`
+}
+
+// PreviewImport parses and validates a product CSV without creating anything
+func (s *Service) PreviewImport(ctx context.Context, r io.Reader) (*ImportPreviewResponse, error) {
+	rows, err := s.parseImport(r, ParseProductCSV)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ImportPreviewResponse{
+		TotalRows: len(rows),
+		Rows:      make([]ImportRowResult, 0, len(rows)),
+	}
+
+	for _, row := range rows {
+		result := ImportRowResult{
+			Line:   row.Line,
+			Title:  row.Request.Title,
+			Valid:  len(row.Errors) == 0,
+			Errors: row.Errors,
+		}
+		if result.Valid {
+			response.ValidRows++
+		} else {
+			response.InvalidRows++
+		}
+		response.Rows = append(response.Rows, result)
+	}
+
+	return response, nil
+}
+
+// ImportProducts creates every valid row of a CSV or JSON catalog for the
+// seller in a single transaction and reports the rejected rows with their
+// problems. Rows are validated exactly as PreviewImport does.
+func (s *Service) ImportProducts(ctx context.Context, userID uuid.UUID, r io.Reader, parse func(io.Reader) ([]*ImportRow, error)) (*ImportResponse, error) {
+	rows, err := s.parseImport(r, parse)
+	if err != nil {
+		return nil, err
+	}
+
+	sellerInfo, err := s.sellerInfo(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ImportResponse{
+		TotalRows: len(rows),
+		Rows:      make([]ImportRowResult, 0, len(rows)),
+	}
+
+	products := make([]*Product, 0, len(rows))
+	for _, row := range rows {
+		result := ImportRowResult{
+			Line:   row.Line,
+			Title:  row.Request.Title,
+			Valid:  len(row.Errors) == 0,
+			Errors: row.Errors,
+		}
+		if result.Valid {
+			product := s.newProduct(uuid.New(), userID, row.Request, *sellerInfo)
+			products = append(products, product)
+			result.ProductID = &product.ID
+			response.CreatedRows++
+		} else {
+			response.RejectedRows++
+		}
+		response.Rows = append(response.Rows, result)
+	}
+
+	if len(products) > 0 {
+		if err := s.checkProductLimit(ctx, userID, sellerInfo.VerificationLevel, len(products)); err != nil {
+			return nil, err
+		}
+		if err := s.repo.CreateProducts(ctx, products); err != nil {
+			return nil, fmt.Errorf("failed to import products: %w", err)
+		}
+	}
+
+	for _, product := range products {
+		s.recordEvent(ctx, product.ID, userID, ProductEventCreated, map[string]interface{}{
+			"title":    product.Title,
+			"category": product.Category,
+			"import":   true,
+		})
+	}
+
+	logger.FromContext(ctx).Info("products imported",
+		"user_id", userID, "created", response.CreatedRows, "rejected", response.RejectedRows)
+
+	return response, nil
+}
+
+// parseImport reads and validates every row of an import file. Preview and
+// import both go through it so they accept exactly the same rows.
+func (s *Service) parseImport(r io.Reader, parse func(io.Reader) ([]*ImportRow, error)) ([]*ImportRow, error) {
+	rows, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		row.Errors = append(row.Errors, s.validateImportRequest(row.Request)...)
+	}
+
+	return rows, nil
+}
+
+// validateImportRequest applies the product creation rules plus the checks the
+// request binding would otherwise enforce, collecting every problem
+func (s *Service) validateImportRequest(req *CreateProductRequest) []string {
+	var problems []string
+
+	if strings.TrimSpace(req.Title) == "" {
+		problems = append(problems, "title is required")
+	}
+	for _, err := range productRequestProblems(req) {
+		problems = append(problems, err.Error())
+	}
+	if req.Price != nil && *req.Price < 0 {
+		problems = append(problems, "price must not be negative")
+	}
+	if req.Price == nil && req.PriceType != "" && req.PriceType != "quote" {
+		problems = append(problems, "price is required unless price_type is quote")
+	}
+	if req.Quantity != nil && *req.Quantity < 0 {
+		problems = append(problems, "quantity must not be negative")
+	}
+	if err := s.validateCategoryDetails(req); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	return problems
+}
+
+// ParseProductCSV turns a CSV catalog into create requests. The first line is a
+// header naming the columns; unknown columns are ignored. Values that cannot be
+// parsed are reported on their row rather than failing the whole file.
+func ParseProductCSV(r io.Reader) ([]*ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidImportFile)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range requiredImportColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidImportFile, name)
+		}
+	}
+
+	rows := make([]*ImportRow, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+		}
+
+		if len(rows) == MaxImportRows {
+			return nil, ErrImportTooLarge
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, parseImportRecord(line, record, columns))
+	}
+
+	return rows, nil
+}
+
+// ParseProductJSON reads a JSON array of create requests, numbering rows by
+// their position in the array. Elements are decoded one at a time so an
+// oversized array is rejected without loading it whole.
+func ParseProductJSON(r io.Reader) ([]*ImportRow, error) {
+	decoder := json.NewDecoder(r)
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, fmt.Errorf("%w: expected a JSON array of products", ErrInvalidImportFile)
+	}
+
+	rows := make([]*ImportRow, 0)
+	for decoder.More() {
+		if len(rows) == MaxImportRows {
+			return nil, ErrImportTooLarge
+		}
+
+		row := &ImportRow{Line: len(rows) + 1, Request: &CreateProductRequest{}}
+		if err := decoder.Decode(row.Request); err != nil {
+			return nil, fmt.Errorf("%w: product %d: %v", ErrInvalidImportFile, row.Line, err)
+		}
+		row.Request.Category = strings.ToLower(row.Request.Category)
+		row.Request.PriceType = strings.ToLower(row.Request.PriceType)
+		rows = append(rows, row)
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+
+	return rows, nil
+}
+
+func parseImportRecord(line int, record []string, columns map[string]int) *ImportRow {
+	row := &ImportRow{Line: line, Request: &CreateProductRequest{}}
+	req := row.Request
+
+	value := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	optional := func(name string) *string {
+		if v := value(name); v != "" {
+			return &v
+		}
+		return nil
+	}
+	list := func(name string) []string {
+		var items []string
+		for _, item := range strings.Split(value(name), ";") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	number := func(name string) *float64 {
+		v := value(name)
+		if v == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("%s must be a number", name))
+			return nil
+		}
+		return &f
+	}
+	integer := func(name string) *int {
+		v := value(name)
+		if v == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("%s must be a whole number", name))
+			return nil
+		}
+		return &n
+	}
+	boolean := func(name string) bool {
+		v := value(name)
+		if v == "" {
+			return false
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("%s must be true or false", name))
+		}
+		return b
+	}
+
+	req.Title = value("title")
+	req.Description = optional("description")
+	req.Category = strings.ToLower(value("category"))
+	req.Subcategory = optional("subcategory")
+	req.Price = number("price")
+	req.PriceType = strings.ToLower(value("price_type"))
+	req.Unit = optional("unit")
+	req.Quantity = integer("quantity")
+	req.MinOrderQuantity = integer("min_order_quantity")
+	req.Province = optional("province")
+	req.City = optional("city")
+	req.PickupAvailable = boolean("pickup_available")
+	req.DeliveryAvailable = boolean("delivery_available")
+	req.DeliveryRadius = integer("delivery_radius")
+	req.Tags = list("tags")
+
+	// Each category carries a minimal set of detail columns
+	switch req.Category {
+	case "transport":
+		req.TransportDetails = &TransportDetails{
+			VehicleType:         optional("vehicle_type"),
+			CapacityTons:        number("capacity_tons"),
+			CapacityCubicMeters: number("capacity_cubic_meters"),
+			PricePerKm:          number("price_per_km"),
+			ServiceProvinces:    list("service_provinces"),
+		}
+	case "livestock":
+		organic := boolean("is_organic")
+		req.LivestockDetails = &LivestockDetails{
+			AnimalType: optional("animal_type"),
+			Breed:      optional("breed"),
+			AgeMonths:  integer("age_months"),
+			WeightKg:   number("weight_kg"),
+			Gender:     optional("gender"),
+			IsOrganic:  &organic,
+		}
+	case "supplies":
+		req.SuppliesDetails = &SuppliesDetails{
+			SupplyType: optional("supply_type"),
+			Brand:      optional("brand"),
+			Model:      optional("model"),
+		}
+	}
+
+	return row
+}
@@ -0,0 +1,90 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var ErrProductLimitReached = errors.New("active product limit reached for verification level")
+
+// DefaultProductLimits caps active listings per verification level (index),
+// 0 meaning unlimited: unverified sellers get 5 listings, fully verified ones
+// have no cap
+var DefaultProductLimits = []int{5, 20, 100, 500, 0}
+
+// ProductLimitError reports how many active listings the seller has and the
+// cap for their verification level, so clients can prompt them to get verified
+type ProductLimitError struct {
+	VerificationLevel int `json:"verification_level"`
+	ActiveProducts    int `json:"active_products"`
+	Limit             int `json:"limit"`
+}
+
+func (e *ProductLimitError) Error() string {
+	return fmt.Sprintf("%s: %d of %d active products at verification level %d",
+		ErrProductLimitReached, e.ActiveProducts, e.Limit, e.VerificationLevel)
+}
+
+func (e *ProductLimitError) Is(target error) bool {
+	return target == ErrProductLimitReached
+}
+
+// SetProductLimits configures the active listing cap for each verification
+// level, indexed by level. Levels past the end use the last entry and 0 means
+// unlimited; an empty list disables the limits.
+func (s *Service) SetProductLimits(limits []int) {
+	s.productLimits = limits
+}
+
+// productLimit returns the active listing cap for a verification level, or 0 if unlimited
+func (s *Service) productLimit(verificationLevel int) int {
+	if len(s.productLimits) == 0 {
+		return 0
+	}
+	if verificationLevel < 0 {
+		verificationLevel = 0
+	}
+	if verificationLevel >= len(s.productLimits) {
+		verificationLevel = len(s.productLimits) - 1
+	}
+	return s.productLimits[verificationLevel]
+}
+
+// checkProductLimit fails with a ProductLimitError when adding more active
+// products would take the seller past the cap of their verification level.
+// Soft-deleted and inactive products don't count.
+func (s *Service) checkProductLimit(ctx context.Context, userID uuid.UUID, verificationLevel, adding int) error {
+	limit := s.productLimit(verificationLevel)
+	if limit <= 0 {
+		return nil
+	}
+
+	active, err := s.repo.CountActiveProducts(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to count active products: %w", err)
+	}
+	if active+adding > limit {
+		return &ProductLimitError{
+			VerificationLevel: verificationLevel,
+			ActiveProducts:    active,
+			Limit:             limit,
+		}
+	}
+
+	return nil
+}
+
+// sellerVerificationLevel returns the owner's current verification level for
+// a stored product, falling back to the level copied onto the listing
+func sellerVerificationLevel(product *Product) int {
+	if product.liveSellerVerificationLevel != nil {
+		return *product.liveSellerVerificationLevel
+	}
+	if product.SellerVerificationLevel != nil {
+		return *product.SellerVerificationLevel
+	}
+	return 0
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,6 +22,7 @@ type Product struct {
 	Currency                string              `json:"currency" db:"currency"`
 	Unit                    *string             `json:"unit,omitempty" db:"unit"`
 	Quantity                *int                `json:"quantity,omitempty" db:"quantity"`
+	MinOrderQuantity        *int                `json:"min_order_quantity,omitempty" db:"min_order_quantity"`
 	AvailableFrom           *time.Time          `json:"available_from,omitempty" db:"available_from"`
 	AvailableUntil          *time.Time          `json:"available_until,omitempty" db:"available_until"`
 	IsActive                bool                `json:"is_active" db:"is_active"`
@@ -31,10 +33,14 @@ type Product struct {
 	PickupAvailable         bool                `json:"pickup_available" db:"pickup_available"`
 	DeliveryAvailable       bool                `json:"delivery_available" db:"delivery_available"`
 	DeliveryRadius          *int                `json:"delivery_radius,omitempty" db:"delivery_radius"`
+	DistanceKm              *float64            `json:"distance_km,omitempty"` // from the buyer location of a search
 	SellerName              *string             `json:"seller_name,omitempty" db:"seller_name"`
 	SellerPhone             *string             `json:"seller_phone,omitempty" db:"seller_phone"`
 	SellerRating            *float64            `json:"seller_rating,omitempty" db:"seller_rating"`
 	SellerVerificationLevel *int                `json:"seller_verification_level,omitempty" db:"seller_verification_level"`
+	SellerInfoStale         *bool               `json:"seller_info_stale,omitempty"`
+	SellerTier              string              `json:"seller_tier,omitempty"`
+	ComplianceWarnings      []string            `json:"compliance_warnings,omitempty"`
 	ViewsCount              int                 `json:"views_count" db:"views_count"`
 	FavoritesCount          int                 `json:"favorites_count" db:"favorites_count"`
 	InquiriesCount          int                 `json:"inquiries_count" db:"inquiries_count"`
@@ -42,13 +48,52 @@ type Product struct {
 	CreatedAt               time.Time           `json:"created_at" db:"created_at"`
 	UpdatedAt               time.Time           `json:"updated_at" db:"updated_at"`
 	PublishedAt             *time.Time          `json:"published_at,omitempty" db:"published_at"`
+	Status                  string              `json:"status,omitempty" db:"status"`
+	DeletedAt               *time.Time          `json:"deleted_at,omitempty" db:"deleted_at"`
 	ExpiresAt               *time.Time          `json:"expires_at,omitempty" db:"expires_at"`
 	Metadata                *ProductMetadata    `json:"metadata,omitempty" db:"metadata"`
 	Tags                    []string            `json:"tags,omitempty" db:"tags"`
 	Images                  []ProductImage      `json:"images,omitempty"`
+	Video                   *ProductVideo       `json:"video,omitempty"`
 	TransportDetails        *TransportDetails   `json:"transport_details,omitempty"`
 	LivestockDetails        *LivestockDetails   `json:"livestock_details,omitempty"`
 	SuppliesDetails         *SuppliesDetails    `json:"supplies_details,omitempty"`
+
+	// Live seller values read alongside the denormalized copy, used for the freshness check
+	liveSellerVerificationLevel *int
+	liveSellerRating            *float64
+	liveSellerTotalSales        *int
+	liveSellerSince             *time.Time
+}
+
+// checkSellerFreshness flags the product when the denormalized seller data
+// differs from the live user record. Ratings within tolerance are not stale.
+func (p *Product) checkSellerFreshness(ratingTolerance float64) {
+	if p.liveSellerVerificationLevel == nil && p.liveSellerRating == nil {
+		return
+	}
+
+	stale := false
+	if p.liveSellerVerificationLevel != nil &&
+		(p.SellerVerificationLevel == nil || *p.SellerVerificationLevel != *p.liveSellerVerificationLevel) {
+		stale = true
+	}
+	if p.liveSellerRating != nil {
+		if p.SellerRating == nil || math.Abs(*p.SellerRating-*p.liveSellerRating) > ratingTolerance {
+			stale = true
+		}
+	}
+
+	p.SellerInfoStale = &stale
+}
+
+// isListed reports whether buyers can see the product: active, published and
+// not expired, matching the public search filter
+func (p *Product) isListed(now time.Time) bool {
+	if !p.IsActive || p.DeletedAt != nil || p.Status != ProductStatusPublished || p.PublishedAt == nil {
+		return false
+	}
+	return p.ExpiresAt == nil || p.ExpiresAt.After(now)
 }
 
 type Point struct {
@@ -75,14 +120,27 @@ type ProductImage struct {
 	UploadedAt       time.Time `json:"uploaded_at" db:"uploaded_at"`
 }
 
+type ProductVideo struct {
+	ID                uuid.UUID `json:"id" db:"id"`
+	ProductID         uuid.UUID `json:"product_id" db:"product_id"`
+	VideoURL          string    `json:"video_url" db:"video_url"`
+	CloudStoragePath  string    `json:"cloud_storage_path" db:"cloud_storage_path"`
+	PosterURL         *string   `json:"poster_url,omitempty" db:"poster_url"`
+	PosterStoragePath *string   `json:"-" db:"poster_storage_path"`
+	DurationSeconds   *int      `json:"duration_seconds,omitempty" db:"duration_seconds"`
+	FileSize          *int64    `json:"file_size,omitempty" db:"file_size"`
+	MimeType          *string   `json:"mime_type,omitempty" db:"mime_type"`
+	UploadedAt        time.Time `json:"uploaded_at" db:"uploaded_at"`
+}
+
 type TransportDetails struct {
 	ProductID             uuid.UUID   `json:"product_id" db:"product_id"`
 	VehicleType           *string     `json:"vehicle_type,omitempty" db:"vehicle_type"`
 	CapacityTons          *float64    `json:"capacity_tons,omitempty" db:"capacity_tons"`
 	CapacityCubicMeters   *float64    `json:"capacity_cubic_meters,omitempty" db:"capacity_cubic_meters"`
 	PricePerKm            *float64    `json:"price_per_km,omitempty" db:"price_per_km"`
-	HasRefrigeration      bool        `json:"has_refrigeration" db:"has_refrigeration"`
-	HasLivestockEquipment bool        `json:"has_livestock_equipment" db:"has_livestock_equipment"`
+	HasRefrigeration      *bool       `json:"has_refrigeration" db:"has_refrigeration"`
+	HasLivestockEquipment *bool       `json:"has_livestock_equipment" db:"has_livestock_equipment"`
 	ServiceProvinces      []string    `json:"service_provinces,omitempty" db:"service_provinces"`
 	MinDistanceKm         *int        `json:"min_distance_km,omitempty" db:"min_distance_km"`
 	MaxDistanceKm         *int        `json:"max_distance_km,omitempty" db:"max_distance_km"`
@@ -104,7 +162,7 @@ type LivestockDetails struct {
 	HealthCertificates     []string                 `json:"health_certificates,omitempty" db:"health_certificates"`
 	Vaccinations           *VaccinationRecords      `json:"vaccinations,omitempty" db:"vaccinations"`
 	LastVeterinaryCheck    *time.Time               `json:"last_veterinary_check,omitempty" db:"last_veterinary_check"`
-	IsOrganic              bool                     `json:"is_organic" db:"is_organic"`
+	IsOrganic              *bool                    `json:"is_organic" db:"is_organic"`
 	IsPregnant             *bool                    `json:"is_pregnant,omitempty" db:"is_pregnant"`
 	BreedingHistory        *BreedingHistory         `json:"breeding_history,omitempty" db:"breeding_history"`
 	GeneticInformation     *string                  `json:"genetic_information,omitempty" db:"genetic_information"`
@@ -166,8 +224,10 @@ type CreateProductRequest struct {
 	PriceType           string              `json:"price_type" binding:"required,oneof=fixed negotiable per_unit quote"`
 	Unit                *string             `json:"unit,omitempty"`
 	Quantity            *int                `json:"quantity,omitempty"`
+	MinOrderQuantity    *int                `json:"min_order_quantity,omitempty"`
 	AvailableFrom       *time.Time          `json:"available_from,omitempty"`
 	AvailableUntil      *time.Time          `json:"available_until,omitempty"`
+	ExpiresAt           *time.Time          `json:"expires_at,omitempty"`
 	Province            *string             `json:"province,omitempty"`
 	City                *string             `json:"city,omitempty"`
 	LocationCoordinates *Point              `json:"location_coordinates,omitempty"`
@@ -178,6 +238,10 @@ type CreateProductRequest struct {
 	TransportDetails    *TransportDetails   `json:"transport_details,omitempty"`
 	LivestockDetails    *LivestockDetails   `json:"livestock_details,omitempty"`
 	SuppliesDetails     *SuppliesDetails    `json:"supplies_details,omitempty"`
+
+	// SaveAsDraft stores the listing as a draft without the category details
+	// check; PublishProduct validates it in full later
+	SaveAsDraft bool `json:"save_as_draft,omitempty"`
 }
 
 type UpdateProductRequest struct {
@@ -188,8 +252,10 @@ type UpdateProductRequest struct {
 	PriceType           *string             `json:"price_type,omitempty"`
 	Unit                *string             `json:"unit,omitempty"`
 	Quantity            *int                `json:"quantity,omitempty"`
+	MinOrderQuantity    *int                `json:"min_order_quantity,omitempty"`
 	AvailableFrom       *time.Time          `json:"available_from,omitempty"`
 	AvailableUntil      *time.Time          `json:"available_until,omitempty"`
+	ExpiresAt           *time.Time          `json:"expires_at,omitempty"`
 	Province            *string             `json:"province,omitempty"`
 	City                *string             `json:"city,omitempty"`
 	LocationCoordinates *Point              `json:"location_coordinates,omitempty"`
@@ -202,9 +268,29 @@ type UpdateProductRequest struct {
 	SuppliesDetails     *SuppliesDetails    `json:"supplies_details,omitempty"`
 }
 
+// UpdateProductDetailsRequest replaces the category details of a product. Only
+// the details matching the product's category may be set.
+type UpdateProductDetailsRequest struct {
+	TransportDetails *TransportDetails `json:"transport_details,omitempty"`
+	LivestockDetails *LivestockDetails `json:"livestock_details,omitempty"`
+	SuppliesDetails  *SuppliesDetails  `json:"supplies_details,omitempty"`
+}
+
+// ProductDetailsUpdate carries the category details upserted alongside a
+// product update
+type ProductDetailsUpdate struct {
+	Transport *TransportDetails
+	Livestock *LivestockDetails
+	Supplies  *SuppliesDetails
+}
+
 type ProductSearchRequest struct {
 	Query            string    `json:"query,omitempty"`
+	// Language selects the text search config for Query (default "spanish")
+	Language         string    `json:"language,omitempty"`
 	Category         string    `json:"category,omitempty"`
+	// Categories matches any of the listed categories; merged with Category
+	Categories       []string  `json:"categories,omitempty"`
 	Subcategory      string    `json:"subcategory,omitempty"`
 	Province         string    `json:"province,omitempty"`
 	City             string    `json:"city,omitempty"`
@@ -215,9 +301,68 @@ type ProductSearchRequest struct {
 	DeliveryAvailable *bool    `json:"delivery_available,omitempty"`
 	IsVerifiedSeller *bool     `json:"is_verified_seller,omitempty"`
 	Tags             []string  `json:"tags,omitempty"`
-	SortBy           string    `json:"sort_by,omitempty"` // price_asc, price_desc, date_asc, date_desc, relevance, rating
+	// TagMatch selects how Tags combine: "any" (default) matches products with
+	// at least one of the tags, "all" only products carrying every tag
+	TagMatch         string    `json:"tag_match,omitempty"`
+
+	// Category-specific detail filters. Livestock filters only apply when
+	// the search is scoped to the single category "livestock" and transport
+	// filters to "transport"; otherwise they are ignored.
+	IsOrganic             *bool `json:"is_organic,omitempty"`
+	IsPregnant            *bool `json:"is_pregnant,omitempty"`
+	HasRefrigeration      *bool `json:"has_refrigeration,omitempty"`
+	HasLivestockEquipment *bool `json:"has_livestock_equipment,omitempty"`
+
+	// Livestock range filters (livestock only)
+	MinAgeMonths *int     `json:"min_age_months,omitempty"`
+	MaxAgeMonths *int     `json:"max_age_months,omitempty"`
+	MinWeightKg  *float64 `json:"min_weight_kg,omitempty"`
+	MaxWeightKg  *float64 `json:"max_weight_kg,omitempty"`
+
+	// Transport capacity and route filters (transport only)
+	MinCapacityTons        *float64 `json:"min_capacity_tons,omitempty"`
+	MinCapacityCubicMeters *float64 `json:"min_capacity_cubic_meters,omitempty"`
+	ServiceProvince        string   `json:"service_province,omitempty"`
+
+	// BuyerLat and BuyerLng locate the buyer. Results then carry their
+	// distance, and delivery_available=true keeps only sellers whose
+	// delivery radius reaches the buyer.
+	BuyerLat *float64 `json:"buyer_lat,omitempty"`
+	BuyerLng *float64 `json:"buyer_lng,omitempty"`
+
+	// AvailableOn keeps products whose availability window covers the date,
+	// today when unset, and excludes carriers with a blocked or booked slot
+	// covering it. Owners listing their own products see every window unless
+	// they set it.
+	AvailableOn *time.Time `json:"available_on,omitempty"`
+
+	// Supplies shelf-life filters on expiry_date, both inclusive. They apply
+	// when the search is scoped to supplies or to no category at all.
+	ExpiringAfter  *time.Time `json:"expiring_after,omitempty"`
+	ExpiringBefore *time.Time `json:"expiring_before,omitempty"`
+
+	SortBy           string    `json:"sort_by,omitempty"` // price_asc, price_desc, date_asc, date_desc, relevance, rating, expiry_asc
 	Page             int       `json:"page,omitempty"`
 	PageSize         int       `json:"page_size,omitempty"`
+
+	// Cursor is the opaque NextCursor of a previous page. It enables keyset
+	// pagination for date sorts; other sorts ignore it and use Page.
+	Cursor string `json:"cursor,omitempty"`
+	cursor *searchCursor
+
+	// ownerID lists one seller's own products, including unpublished and expired ones
+	ownerID *uuid.UUID
+}
+
+// searchCursor is the decoded position of the last product of a page
+type searchCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// usesKeyset reports whether the sort order supports cursor pagination
+func (r *ProductSearchRequest) usesKeyset() bool {
+	return r.SortBy == "" || r.SortBy == "date_desc" || r.SortBy == "date_asc"
 }
 
 type ProductListResponse struct {
@@ -226,6 +371,259 @@ type ProductListResponse struct {
 	Page        int       `json:"page"`
 	PageSize    int       `json:"page_size"`
 	TotalPages  int       `json:"total_pages"`
+	NextCursor  string    `json:"next_cursor,omitempty"`
+}
+
+// Product listing statuses
+const (
+	ProductStatusDraft       = "draft"
+	ProductStatusPublished   = "published"
+	ProductStatusUnpublished = "unpublished"
+	ProductStatusArchived    = "archived"
+)
+
+// Availability slot statuses
+const (
+	SlotStatusAvailable = "available"
+	SlotStatusBlocked   = "blocked"
+	SlotStatusBooked    = "booked"
+)
+
+// AvailabilitySlot marks a date range of a transport product as free or taken
+type AvailabilitySlot struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	ProductID uuid.UUID `json:"product_id" db:"product_id"`
+	StartDate time.Time `json:"start_date" db:"start_date"`
+	EndDate   time.Time `json:"end_date" db:"end_date"`
+	Status    string    `json:"status" db:"status"`
+	Note      *string   `json:"note,omitempty" db:"note"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type CreateAvailabilitySlotRequest struct {
+	StartDate time.Time `json:"start_date" binding:"required"`
+	EndDate   time.Time `json:"end_date" binding:"required"`
+	Status    string    `json:"status" binding:"required,oneof=available blocked booked"`
+	Note      *string   `json:"note,omitempty"`
+}
+
+// BulkPublishRequest lists the products to publish or unpublish at once
+type BulkPublishRequest struct {
+	ProductIDs []uuid.UUID `json:"product_ids" binding:"required,min=1,max=200"`
+}
+
+// BulkPublishResult reports the outcome for a single product of a bulk operation
+type BulkPublishResult struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"` // not_found, not_owned, draft
+}
+
+type BulkPublishResponse struct {
+	Results   []BulkPublishResult `json:"results"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+}
+
+const (
+	BulkErrorNotFound = "not_found"
+	BulkErrorNotOwned = "not_owned"
+	BulkErrorDraft    = "draft"
+)
+
+// PriceHistoryEntry records the price a product was listed at from ChangedAt on
+type PriceHistoryEntry struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	ProductID uuid.UUID  `json:"product_id" db:"product_id"`
+	Price     *float64   `json:"price,omitempty" db:"price"`
+	PriceType string     `json:"price_type" db:"price_type"`
+	ChangedAt time.Time  `json:"changed_at" db:"changed_at"`
+	ChangedBy *uuid.UUID `json:"changed_by,omitempty" db:"changed_by"`
+}
+
+// DeletedProduct is a soft-deleted listing the seller can still restore
+type DeletedProduct struct {
+	ID              uuid.UUID `json:"id"`
+	Title           string    `json:"title"`
+	Category        string    `json:"category"`
+	Price           *float64  `json:"price,omitempty"`
+	Currency        string    `json:"currency"`
+	Unit            string    `json:"unit"`
+	DeletedAt       time.Time `json:"deleted_at"`
+	RestorableUntil time.Time `json:"restorable_until"`
+}
+
+// PurgeResult summarizes a seller's permanent cleanup of soft-deleted listings
+type PurgeResult struct {
+	ProductsPurged          int      `json:"products_purged"`
+	ImagesDeleted           int      `json:"images_deleted"`
+	VideosDeleted           int      `json:"videos_deleted"`
+	SkippedWithTransactions int      `json:"skipped_with_transactions"`
+	StoragePaths            []string `json:"-"`
+}
+
+// PriceSuggestionRequest describes a listing to price against comparables.
+// Detail attributes are optional and only narrow the comparables of their category.
+type PriceSuggestionRequest struct {
+	Category    string `json:"category" binding:"required,oneof=transport livestock supplies"`
+	Subcategory string `json:"subcategory,omitempty"`
+	PriceType   string `json:"price_type,omitempty"`
+	Unit        string `json:"unit,omitempty"`
+	Province    string `json:"province,omitempty"`
+
+	// Livestock attributes
+	AnimalType string   `json:"animal_type,omitempty"`
+	Breed      string   `json:"breed,omitempty"`
+	WeightKg   *float64 `json:"weight_kg,omitempty"`
+
+	// Supplies attributes
+	SupplyType string `json:"supply_type,omitempty"`
+	Brand      string `json:"brand,omitempty"`
+
+	// Transport attributes
+	VehicleType string `json:"vehicle_type,omitempty"`
+
+	// Only listings priced in this currency are compared; defaults to DefaultCurrency
+	Currency string `json:"currency,omitempty" binding:"omitempty,len=3"`
+}
+
+type PriceSuggestionResponse struct {
+	Median        *float64 `json:"median,omitempty"`
+	LowerQuartile *float64 `json:"lower_quartile,omitempty"`
+	UpperQuartile *float64 `json:"upper_quartile,omitempty"`
+	Currency      string   `json:"currency"`
+	SampleSize    int      `json:"sample_size"`
+	LowConfidence bool     `json:"low_confidence"`
+}
+
+// ComparablePriceStats summarizes the prices of a product's comparables
+type ComparablePriceStats struct {
+	Count       int      `json:"count"`
+	MinPrice    *float64 `json:"min_price,omitempty"`
+	MedianPrice *float64 `json:"median_price,omitempty"`
+	MaxPrice    *float64 `json:"max_price,omitempty"`
+	Currency    string   `json:"currency"`
+}
+
+// ComparableProductsResponse lists the cheapest comparables of a product.
+// Stats cover every comparable, not only the listed ones. RadiusKm is set when
+// comparables were matched by distance, Province when matched by province.
+type ComparableProductsResponse struct {
+	Products []*Product           `json:"products"`
+	Stats    ComparablePriceStats `json:"stats"`
+	RadiusKm *float64             `json:"radius_km,omitempty"`
+	Province *string              `json:"province,omitempty"`
+}
+
+// ProductStats counts products overall and by category for the admin dashboard.
+// Active products are live in search: active, published and not expired.
+type ProductStats struct {
+	TotalProducts    int            `json:"total_products"`
+	ActiveProducts   int            `json:"active_products"`
+	TotalByCategory  map[string]int `json:"total_by_category"`
+	ActiveByCategory map[string]int `json:"active_by_category"`
+}
+
+// TitleSuggestion is a lightweight autocomplete match
+type TitleSuggestion struct {
+	ID       uuid.UUID `json:"id"`
+	Title    string    `json:"title"`
+	Category string    `json:"category"`
+}
+
+// SimilarProduct is a lightweight listing recommended alongside another.
+// DistanceKm is set only when both listings have coordinates.
+type SimilarProduct struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Category    string    `json:"category"`
+	Subcategory *string   `json:"subcategory,omitempty"`
+	Price       *float64  `json:"price,omitempty"`
+	PriceType   string    `json:"price_type"`
+	Currency    string    `json:"currency"`
+	Unit        *string   `json:"unit,omitempty"`
+	Province    *string   `json:"province,omitempty"`
+	City        *string   `json:"city,omitempty"`
+	ImageURL    *string   `json:"image_url,omitempty"`
+	SharedTags  int       `json:"shared_tags"`
+	DistanceKm  *float64  `json:"distance_km,omitempty"`
+}
+
+// EstimateRequest asks for an all-in cost of buying a quantity delivered to the buyer
+type EstimateRequest struct {
+	Quantity         int     `json:"quantity" binding:"required,min=1"`
+	BuyerCoordinates *Point  `json:"buyer_coordinates,omitempty"`
+	BuyerProvince    *string `json:"buyer_province,omitempty"`
+}
+
+// EstimateResponse breaks down the estimated total. Amounts are omitted when
+// they cannot be computed; quote products only set ContactForPrice.
+type EstimateResponse struct {
+	ProductID       uuid.UUID `json:"product_id"`
+	Quantity        int       `json:"quantity"`
+	Currency        string    `json:"currency"`
+	PriceType       string    `json:"price_type"`
+	ContactForPrice bool      `json:"contact_for_price"`
+	Negotiable      bool      `json:"negotiable"`
+	UnitPrice       *float64  `json:"unit_price,omitempty"`
+	Subtotal        *float64  `json:"subtotal,omitempty"`
+	DistanceKm      *float64  `json:"distance_km,omitempty"`
+	DeliveryCost    *float64  `json:"delivery_cost,omitempty"`
+	DeliveryNote    string    `json:"delivery_note,omitempty"`
+	Total           *float64  `json:"total,omitempty"`
+}
+
+// Tag match modes for ProductSearchRequest.TagMatch
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// Text search languages for ProductSearchRequest.Language
+const (
+	SearchLanguageSpanish    = "spanish"
+	SearchLanguageEnglish    = "english"
+	SearchLanguagePortuguese = "portuguese"
+	SearchLanguageSimple     = "simple"
+)
+
+// searchLanguageConfigs maps each accepted language to its Postgres text search config
+var searchLanguageConfigs = map[string]string{
+	SearchLanguageSpanish:    "spanish",
+	SearchLanguageEnglish:    "english",
+	SearchLanguagePortuguese: "portuguese",
+	SearchLanguageSimple:     "simple",
+}
+
+// Product audit event types
+const (
+	ProductEventCreated         = "created"
+	ProductEventUpdated         = "updated"
+	ProductEventPublished       = "published"
+	ProductEventUnpublished     = "unpublished"
+	ProductEventDeleted         = "deleted"
+	ProductEventRestored        = "restored"
+	ProductEventImageAdded      = "image_added"
+	ProductEventImageRemoved    = "image_removed"
+	ProductEventVideoAdded      = "video_added"
+	ProductEventVideoRemoved    = "video_removed"
+	ProductEventImagesReordered = "images_reordered"
+	ProductEventPriceChanged    = "price_changed"
+)
+
+// ProductEvent is a single entry in a product's audit trail
+type ProductEvent struct {
+	ID        uuid.UUID              `json:"id" db:"id"`
+	ProductID uuid.UUID              `json:"product_id" db:"product_id"`
+	ActorID   *uuid.UUID             `json:"actor_id,omitempty" db:"actor_id"`
+	EventType string                 `json:"event_type" db:"event_type"`
+	Details   map[string]interface{} `json:"details,omitempty" db:"details"`
+	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+}
+
+type ProductAuditResponse struct {
+	ProductID uuid.UUID      `json:"product_id"`
+	Events    []ProductEvent `json:"events"`
 }
 
 // Database driver interfaces
@@ -6,7 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"agro-mas-backend/internal/storage"
+	"agro-mas-backend/pkg/sqlutil"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
@@ -27,6 +30,35 @@ func (r *Repository) CreateProduct(ctx context.Context, product *Product) error
 	}
 	defer tx.Rollback()
 
+	if err := r.insertProduct(ctx, tx, product); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateProducts inserts a batch of products and their details in a single
+// transaction, so either the whole batch is stored or none of it is
+func (r *Repository) CreateProducts(ctx context.Context, products []*Product) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, product := range products {
+		if err := r.insertProduct(ctx, tx, product); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertProduct writes a product row and its category details within tx
+func (r *Repository) insertProduct(ctx context.Context, tx *sql.Tx, product *Product) error {
+	var err error
+
 	// Insert main product
 	query := `
 		INSERT INTO products (
@@ -34,11 +66,12 @@ func (r *Repository) CreateProduct(ctx context.Context, product *Product) error
 			currency, unit, quantity, available_from, available_until, is_active,
 			is_featured, province, city, location_coordinates, pickup_available,
 			delivery_available, delivery_radius, seller_name, seller_phone,
-			seller_rating, seller_verification_level, search_keywords, metadata, tags
+			seller_rating, seller_verification_level, search_keywords, metadata, tags,
+			min_order_quantity, expires_at, status
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
 			ST_GeomFromText('POINT(' || $18 || ' ' || $19 || ')', 4326),
-			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29
+			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32
 		)`
 
 	var lng, lat sql.NullFloat64
@@ -65,7 +98,8 @@ func (r *Repository) CreateProduct(ctx context.Context, product *Product) error
 		product.Province, product.City, lng, lat, product.PickupAvailable,
 		product.DeliveryAvailable, product.DeliveryRadius, product.SellerName,
 		product.SellerPhone, product.SellerRating, product.SellerVerificationLevel,
-		product.SearchKeywords, metadataJSON, pq.Array(product.Tags))
+		product.SearchKeywords, metadataJSON, pq.Array(product.Tags),
+		product.MinOrderQuantity, product.ExpiresAt, product.Status)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert product: %w", err)
@@ -75,25 +109,25 @@ func (r *Repository) CreateProduct(ctx context.Context, product *Product) error
 	switch product.Category {
 	case "transport":
 		if product.TransportDetails != nil {
-			if err := r.insertTransportDetails(ctx, tx, product.TransportDetails); err != nil {
+			if err := r.upsertTransportDetails(ctx, tx, product.TransportDetails); err != nil {
 				return fmt.Errorf("failed to insert transport details: %w", err)
 			}
 		}
 	case "livestock":
 		if product.LivestockDetails != nil {
-			if err := r.insertLivestockDetails(ctx, tx, product.LivestockDetails); err != nil {
+			if err := r.upsertLivestockDetails(ctx, tx, product.LivestockDetails); err != nil {
 				return fmt.Errorf("failed to insert livestock details: %w", err)
 			}
 		}
 	case "supplies":
 		if product.SuppliesDetails != nil {
-			if err := r.insertSuppliesDetails(ctx, tx, product.SuppliesDetails); err != nil {
+			if err := r.upsertSuppliesDetails(ctx, tx, product.SuppliesDetails); err != nil {
 				return fmt.Errorf("failed to insert supplies details: %w", err)
 			}
 		}
 	}
 
-	return tx.Commit()
+	return nil
 }
 
 // GetProductByID retrieves a product by its ID
@@ -109,9 +143,14 @@ func (r *Repository) GetProductByID(ctx context.Context, id uuid.UUID) (*Product
 			delivery_radius, seller_name, seller_phone, seller_rating,
 			seller_verification_level, views_count, favorites_count, inquiries_count,
 			search_keywords, created_at, updated_at, published_at, expires_at,
-			metadata, tags
+			metadata, tags, min_order_quantity, status, deleted_at,
+			seller.verification_level, seller.rating, seller.total_sales, seller.since
 		FROM products 
-		WHERE id = $1`
+		LEFT JOIN LATERAL (
+			SELECT verification_level, rating, total_sales, created_at AS since
+			FROM users WHERE users.id = products.user_id
+		) seller ON true
+		WHERE products.id = $1`
 
 	product := &Product{}
 	var lng, lat sql.NullFloat64
@@ -127,7 +166,10 @@ func (r *Repository) GetProductByID(ctx context.Context, id uuid.UUID) (*Product
 		&product.SellerPhone, &product.SellerRating, &product.SellerVerificationLevel,
 		&product.ViewsCount, &product.FavoritesCount, &product.InquiriesCount,
 		&product.SearchKeywords, &product.CreatedAt, &product.UpdatedAt,
-		&product.PublishedAt, &product.ExpiresAt, &metadataJSON, pq.Array(&product.Tags))
+		&product.PublishedAt, &product.ExpiresAt, &metadataJSON, pq.Array(&product.Tags),
+		&product.MinOrderQuantity, &product.Status, &product.DeletedAt,
+		&product.liveSellerVerificationLevel, &product.liveSellerRating,
+		&product.liveSellerTotalSales, &product.liveSellerSince)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -161,19 +203,249 @@ func (r *Repository) GetProductByID(ctx context.Context, id uuid.UUID) (*Product
 
 // SearchProducts searches for products with filters
 func (r *Repository) SearchProducts(ctx context.Context, req *ProductSearchRequest) ([]*Product, int, error) {
-	whereConditions := []string{"p.is_active = true", "p.published_at IS NOT NULL"}
+	filter := buildSearchFilter(req)
+	whereClause := strings.Join(filter.conditions, " AND ")
+	args := filter.args
+	argIndex := filter.argIndex
+
+	// Count total results
+	countQuery := fmt.Sprintf(`
+		SELECT COUNT(*) 
+		FROM products p
+		LEFT JOIN users u ON p.user_id = u.id
+		%s
+		WHERE %s`, filter.joins, whereClause)
+
+	var totalCount int
+	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count products: %w", err)
+	}
+
+	// Determine sorting; date sorts break ties on id so keyset pagination is stable
+	orderBy := "p.created_at DESC, p.id DESC"
+	switch req.SortBy {
+	case "price_asc":
+		orderBy = "p.price ASC NULLS LAST"
+	case "price_desc":
+		orderBy = "p.price DESC NULLS LAST"
+	case "date_asc":
+		orderBy = "p.created_at ASC, p.id ASC"
+	case "date_desc":
+		orderBy = "p.created_at DESC, p.id DESC"
+	case "rating":
+		orderBy = "p.seller_rating DESC NULLS LAST"
+	case "relevance":
+		if req.Query != "" {
+			orderBy = filter.textSearchRank
+		}
+	case "expiry_asc":
+		if filter.joinSupplies {
+			orderBy = "sd.expiry_date ASC, p.id ASC"
+		}
+	}
+
+	// Set pagination defaults
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+
+	// Keyset pagination: continue after the cursor instead of skipping rows.
+	// Applied after counting so total_count still reflects the whole result set.
+	if req.cursor != nil && req.usesKeyset() {
+		operator := "<"
+		if req.SortBy == "date_asc" {
+			operator = ">"
+		}
+		whereClause += fmt.Sprintf(" AND (p.created_at, p.id) %s ($%d, $%d)", operator, argIndex, argIndex+1)
+		args = append(args, req.cursor.CreatedAt, req.cursor.ID)
+		argIndex += 2
+		offset = 0
+	}
+
+	distanceColumn := "NULL::float8"
+	if req.BuyerLat != nil && req.BuyerLng != nil {
+		distanceColumn = fmt.Sprintf(`CASE WHEN p.location_coordinates IS NOT NULL
+				THEN ST_Distance(
+					ST_GeogFromText('POINT(' || $%d || ' ' || $%d || ')'),
+					ST_GeogFromText(ST_AsText(p.location_coordinates))
+				) / 1000
+			END`, argIndex, argIndex+1)
+		args = append(args, *req.BuyerLng, *req.BuyerLat)
+		argIndex += 2
+	}
+
+	// Get products
+	query := fmt.Sprintf(`
+		SELECT %s,
+			%s AS distance_km
+		FROM products p
+		LEFT JOIN users u ON p.user_id = u.id
+		%s
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d`, productListColumns, distanceColumn, filter.joins, whereClause, orderBy, argIndex, argIndex+1)
+
+	args = append(args, req.PageSize, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search products: %w", err)
+	}
+	defer rows.Close()
+
+	products, err := r.scanProductList(ctx, rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return products, totalCount, nil
+}
+
+// productListColumns are the product columns, joined with the live seller as
+// u, that scanProductList reads before a trailing distance_km column
+const productListColumns = `
+			p.id, p.user_id, p.title, p.description, p.category, p.subcategory,
+			p.price, p.price_type, p.currency, p.unit, p.quantity, p.available_from,
+			p.available_until, p.is_active, p.is_featured, p.province, p.city,
+			CASE WHEN p.location_coordinates IS NOT NULL THEN p.location_coordinates[0] ELSE NULL END as lng,
+			CASE WHEN p.location_coordinates IS NOT NULL THEN p.location_coordinates[1] ELSE NULL END as lat,
+			p.pickup_available, p.delivery_available, p.delivery_radius,
+			p.seller_name, p.seller_phone, p.seller_rating, p.seller_verification_level,
+			p.views_count, p.favorites_count, p.inquiries_count, p.search_keywords,
+			p.created_at, p.updated_at, p.published_at, p.expires_at, p.metadata, p.tags,
+			p.min_order_quantity, p.status,
+			u.verification_level, u.rating, u.total_sales, u.created_at`
+
+// scanProductList reads rows selected with productListColumns and loads the
+// details of the whole list at once
+func (r *Repository) scanProductList(ctx context.Context, rows *sql.Rows) ([]*Product, error) {
+	products := make([]*Product, 0)
+	for rows.Next() {
+		product := &Product{}
+		var lng, lat sql.NullFloat64
+		var metadataJSON sql.NullString
+
+		err := rows.Scan(
+			&product.ID, &product.UserID, &product.Title, &product.Description,
+			&product.Category, &product.Subcategory, &product.Price, &product.PriceType,
+			&product.Currency, &product.Unit, &product.Quantity, &product.AvailableFrom,
+			&product.AvailableUntil, &product.IsActive, &product.IsFeatured,
+			&product.Province, &product.City, &lng, &lat, &product.PickupAvailable,
+			&product.DeliveryAvailable, &product.DeliveryRadius, &product.SellerName,
+			&product.SellerPhone, &product.SellerRating, &product.SellerVerificationLevel,
+			&product.ViewsCount, &product.FavoritesCount, &product.InquiriesCount,
+			&product.SearchKeywords, &product.CreatedAt, &product.UpdatedAt,
+			&product.PublishedAt, &product.ExpiresAt, &metadataJSON, pq.Array(&product.Tags),
+			&product.MinOrderQuantity, &product.Status,
+			&product.liveSellerVerificationLevel, &product.liveSellerRating,
+			&product.liveSellerTotalSales, &product.liveSellerSince,
+			&product.DistanceKm)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+
+		// Parse coordinates
+		if lng.Valid && lat.Valid {
+			product.LocationCoordinates = &Point{
+				Lng: lng.Float64,
+				Lat: lat.Float64,
+			}
+		}
+
+		// Parse metadata
+		if metadataJSON.Valid && metadataJSON.String != "" {
+			if err := json.Unmarshal([]byte(metadataJSON.String), &product.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Load details for the whole list at once
+	if err := r.loadProductDetailsBatch(ctx, products); err != nil {
+		return nil, fmt.Errorf("failed to load product details: %w", err)
+	}
+
+	return products, nil
+}
+
+// searchFilter is the WHERE clause, arguments and detail joins built from a
+// search request, shared by product search and saved search matching
+type searchFilter struct {
+	conditions     []string
+	args           []interface{}
+	argIndex       int
+	joins          string
+	textSearchRank string
+	joinSupplies   bool
+}
+
+func buildSearchFilter(req *ProductSearchRequest) *searchFilter {
+	whereConditions := []string{"p.is_active = true", "p.status = 'published'", "p.published_at IS NOT NULL", "(p.expires_at IS NULL OR p.expires_at > NOW())"}
 	args := []interface{}{}
 	argIndex := 1
 
-	// Add filters
+	// Owners see their unpublished listings and the expired ones they may renew
+	if req.ownerID != nil {
+		whereConditions = []string{
+			fmt.Sprintf("p.user_id = $%d", argIndex),
+			"(p.is_active = true OR p.expires_at <= NOW())",
+		}
+		args = append(args, *req.ownerID)
+		argIndex++
+	}
+
+	// Both window ends are inclusive; a missing end leaves that side open
+	if req.AvailableOn != nil || req.ownerID == nil {
+		date := "CURRENT_DATE"
+		if req.AvailableOn != nil {
+			date = fmt.Sprintf("$%d::date", argIndex)
+			args = append(args, *req.AvailableOn)
+			argIndex++
+		}
+		whereConditions = append(whereConditions, fmt.Sprintf(
+			"(p.available_from IS NULL OR p.available_from <= %s) AND (p.available_until IS NULL OR p.available_until >= %s)",
+			date, date))
+	}
+
+	// Add filters. The text search config comes from the allowlist only, never
+	// from the request, since it is interpolated into the query.
+	var textSearchRank string
 	if req.Query != "" {
-		whereConditions = append(whereConditions,
-			fmt.Sprintf("to_tsvector('spanish', p.title || ' ' || COALESCE(p.description, '') || ' ' || COALESCE(p.search_keywords, '')) @@ plainto_tsquery('spanish', $%d)", argIndex))
+		config, ok := searchLanguageConfigs[req.Language]
+		if !ok {
+			config = searchLanguageConfigs[SearchLanguageSpanish]
+		}
+		// Spanish matches the indexed search_vector column; other languages
+		// build the document at query time
+		document := "p.search_vector"
+		if config != searchLanguageConfigs[SearchLanguageSpanish] {
+			document = fmt.Sprintf("to_tsvector('%s', p.title || ' ' || COALESCE(p.description, '') || ' ' || COALESCE(p.search_keywords, ''))", config)
+		}
+		query := fmt.Sprintf("websearch_to_tsquery('%s', $%d)", config, argIndex)
+		textSearchRank = fmt.Sprintf("ts_rank(%s, %s) DESC", document, query)
+
+		whereConditions = append(whereConditions, document+" @@ "+query)
 		args = append(args, req.Query)
 		argIndex++
 	}
 
-	if req.Category != "" {
+	if len(req.Categories) > 0 {
+		whereConditions = append(whereConditions, fmt.Sprintf("p.category = ANY($%d)", argIndex))
+		args = append(args, pq.Array(req.Categories))
+		argIndex++
+	} else if req.Category != "" {
 		whereConditions = append(whereConditions, fmt.Sprintf("p.category = $%d", argIndex))
 		args = append(args, req.Category)
 		argIndex++
@@ -227,6 +499,18 @@ func (r *Repository) SearchProducts(ctx context.Context, req *ProductSearchReque
 		argIndex++
 	}
 
+	// Delivery must reach the buyer. Listings without coordinates or a radius
+	// cannot be ruled out, so they are kept.
+	if req.DeliveryAvailable != nil && *req.DeliveryAvailable && req.BuyerLat != nil && req.BuyerLng != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf(`(p.location_coordinates IS NULL OR p.delivery_radius IS NULL OR ST_DWithin(
+			ST_GeogFromText('POINT(' || $%d || ' ' || $%d || ')'),
+			ST_GeogFromText(ST_AsText(p.location_coordinates)),
+			p.delivery_radius * 1000
+		))`, argIndex, argIndex+1))
+		args = append(args, *req.BuyerLng, *req.BuyerLat)
+		argIndex += 2
+	}
+
 	if req.IsVerifiedSeller != nil {
 		whereConditions = append(whereConditions, fmt.Sprintf("u.is_verified = $%d", argIndex))
 		args = append(args, *req.IsVerifiedSeller)
@@ -234,140 +518,176 @@ func (r *Repository) SearchProducts(ctx context.Context, req *ProductSearchReque
 	}
 
 	if len(req.Tags) > 0 {
-		whereConditions = append(whereConditions, fmt.Sprintf("p.tags && $%d", argIndex))
+		// && is array overlap (any tag), @> is containment (all tags)
+		tagOperator := "&&"
+		if req.TagMatch == TagMatchAll {
+			tagOperator = "@>"
+		}
+		whereConditions = append(whereConditions, fmt.Sprintf("p.tags %s $%d", tagOperator, argIndex))
 		args = append(args, pq.Array(req.Tags))
 		argIndex++
 	}
 
-	whereClause := strings.Join(whereConditions, " AND ")
-
-	// Count total results
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*) 
-		FROM products p
-		LEFT JOIN users u ON p.user_id = u.id
-		WHERE %s`, whereClause)
-
-	var totalCount int
-	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count products: %w", err)
-	}
-
-	// Determine sorting
-	orderBy := "p.created_at DESC"
-	switch req.SortBy {
-	case "price_asc":
-		orderBy = "p.price ASC NULLS LAST"
-	case "price_desc":
-		orderBy = "p.price DESC NULLS LAST"
-	case "date_asc":
-		orderBy = "p.created_at ASC"
-	case "date_desc":
-		orderBy = "p.created_at DESC"
-	case "rating":
-		orderBy = "p.seller_rating DESC NULLS LAST"
-	case "relevance":
-		if req.Query != "" {
-			orderBy = "ts_rank(to_tsvector('spanish', p.title || ' ' || COALESCE(p.description, '') || ' ' || COALESCE(p.search_keywords, '')), plainto_tsquery('spanish', $1)) DESC"
+	// Category-specific detail filters join their detail table and are
+	// ignored unless the search is scoped to the matching category
+	joinLivestock := false
+	joinTransport := false
+
+	if req.Category == "livestock" {
+		if req.IsOrganic != nil {
+			whereConditions = append(whereConditions, fmt.Sprintf("ld.is_organic = $%d", argIndex))
+			args = append(args, *req.IsOrganic)
+			argIndex++
+			joinLivestock = true
 		}
-	}
 
-	// Set pagination defaults
-	if req.Page < 1 {
-		req.Page = 1
-	}
-	if req.PageSize < 1 || req.PageSize > 100 {
-		req.PageSize = 20
-	}
+		if req.IsPregnant != nil {
+			whereConditions = append(whereConditions, fmt.Sprintf("ld.is_pregnant = $%d", argIndex))
+			args = append(args, *req.IsPregnant)
+			argIndex++
+			joinLivestock = true
+		}
 
-	offset := (req.Page - 1) * req.PageSize
+		if req.MinAgeMonths != nil {
+			whereConditions = append(whereConditions, fmt.Sprintf("ld.age_months >= $%d", argIndex))
+			args = append(args, *req.MinAgeMonths)
+			argIndex++
+			joinLivestock = true
+		}
 
-	// Get products
-	query := fmt.Sprintf(`
-		SELECT 
-			p.id, p.user_id, p.title, p.description, p.category, p.subcategory,
-			p.price, p.price_type, p.currency, p.unit, p.quantity, p.available_from,
-			p.available_until, p.is_active, p.is_featured, p.province, p.city,
-			CASE WHEN p.location_coordinates IS NOT NULL THEN p.location_coordinates[0] ELSE NULL END as lng,
-			CASE WHEN p.location_coordinates IS NOT NULL THEN p.location_coordinates[1] ELSE NULL END as lat,
-			p.pickup_available, p.delivery_available, p.delivery_radius,
-			p.seller_name, p.seller_phone, p.seller_rating, p.seller_verification_level,
-			p.views_count, p.favorites_count, p.inquiries_count, p.search_keywords,
-			p.created_at, p.updated_at, p.published_at, p.expires_at, p.metadata, p.tags
-		FROM products p
-		LEFT JOIN users u ON p.user_id = u.id
-		WHERE %s
-		ORDER BY %s
-		LIMIT $%d OFFSET $%d`, whereClause, orderBy, argIndex, argIndex+1)
+		if req.MaxAgeMonths != nil {
+			whereConditions = append(whereConditions, fmt.Sprintf("ld.age_months <= $%d", argIndex))
+			args = append(args, *req.MaxAgeMonths)
+			argIndex++
+			joinLivestock = true
+		}
 
-	args = append(args, req.PageSize, offset)
+		if req.MinWeightKg != nil {
+			whereConditions = append(whereConditions, fmt.Sprintf("ld.weight_kg >= $%d", argIndex))
+			args = append(args, *req.MinWeightKg)
+			argIndex++
+			joinLivestock = true
+		}
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to search products: %w", err)
+		if req.MaxWeightKg != nil {
+			whereConditions = append(whereConditions, fmt.Sprintf("ld.weight_kg <= $%d", argIndex))
+			args = append(args, *req.MaxWeightKg)
+			argIndex++
+			joinLivestock = true
+		}
 	}
-	defer rows.Close()
 
-	products := make([]*Product, 0)
-	for rows.Next() {
-		product := &Product{}
-		var lng, lat sql.NullFloat64
-		var metadataJSON sql.NullString
+	if req.Category == "transport" {
+		if req.HasRefrigeration != nil {
+			whereConditions = append(whereConditions, fmt.Sprintf("td.has_refrigeration = $%d", argIndex))
+			args = append(args, *req.HasRefrigeration)
+			argIndex++
+			joinTransport = true
+		}
 
-		err := rows.Scan(
-			&product.ID, &product.UserID, &product.Title, &product.Description,
-			&product.Category, &product.Subcategory, &product.Price, &product.PriceType,
-			&product.Currency, &product.Unit, &product.Quantity, &product.AvailableFrom,
-			&product.AvailableUntil, &product.IsActive, &product.IsFeatured,
-			&product.Province, &product.City, &lng, &lat, &product.PickupAvailable,
-			&product.DeliveryAvailable, &product.DeliveryRadius, &product.SellerName,
-			&product.SellerPhone, &product.SellerRating, &product.SellerVerificationLevel,
-			&product.ViewsCount, &product.FavoritesCount, &product.InquiriesCount,
-			&product.SearchKeywords, &product.CreatedAt, &product.UpdatedAt,
-			&product.PublishedAt, &product.ExpiresAt, &metadataJSON, pq.Array(&product.Tags))
+		if req.HasLivestockEquipment != nil {
+			whereConditions = append(whereConditions, fmt.Sprintf("td.has_livestock_equipment = $%d", argIndex))
+			args = append(args, *req.HasLivestockEquipment)
+			argIndex++
+			joinTransport = true
+		}
 
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan product: %w", err)
+		if req.MinCapacityTons != nil {
+			whereConditions = append(whereConditions, fmt.Sprintf("td.capacity_tons >= $%d", argIndex))
+			args = append(args, *req.MinCapacityTons)
+			argIndex++
+			joinTransport = true
 		}
 
-		// Parse coordinates
-		if lng.Valid && lat.Valid {
-			product.LocationCoordinates = &Point{
-				Lng: lng.Float64,
-				Lat: lat.Float64,
-			}
+		if req.MinCapacityCubicMeters != nil {
+			whereConditions = append(whereConditions, fmt.Sprintf("td.capacity_cubic_meters >= $%d", argIndex))
+			args = append(args, *req.MinCapacityCubicMeters)
+			argIndex++
+			joinTransport = true
 		}
 
-		// Parse metadata
-		if metadataJSON.Valid && metadataJSON.String != "" {
-			if err := json.Unmarshal([]byte(metadataJSON.String), &product.Metadata); err != nil {
-				return nil, 0, fmt.Errorf("failed to unmarshal metadata: %w", err)
-			}
+		if req.ServiceProvince != "" {
+			whereConditions = append(whereConditions, fmt.Sprintf("$%d = ANY(td.service_provinces)", argIndex))
+			args = append(args, req.ServiceProvince)
+			argIndex++
+			joinTransport = true
 		}
 
-		products = append(products, product)
+		if req.AvailableOn != nil {
+			whereConditions = append(whereConditions, fmt.Sprintf(`NOT EXISTS (
+				SELECT 1 FROM product_availability_slots s
+				WHERE s.product_id = p.id AND s.status IN ('blocked', 'booked')
+				  AND $%d::date BETWEEN s.start_date AND s.end_date)`, argIndex))
+			args = append(args, *req.AvailableOn)
+			argIndex++
+		}
 	}
 
-	// Load details for all products (in batches for performance)
-	for _, product := range products {
-		if err := r.loadProductDetails(ctx, product); err != nil {
-			return nil, 0, fmt.Errorf("failed to load product details: %w", err)
+	joinSupplies := false
+	if req.Category == "supplies" || len(req.Categories) == 0 {
+		if req.ExpiringAfter != nil {
+			whereConditions = append(whereConditions, fmt.Sprintf("sd.expiry_date >= $%d::date", argIndex))
+			args = append(args, *req.ExpiringAfter)
+			argIndex++
+			joinSupplies = true
+		}
+
+		if req.ExpiringBefore != nil {
+			whereConditions = append(whereConditions, fmt.Sprintf("sd.expiry_date <= $%d::date", argIndex))
+			args = append(args, *req.ExpiringBefore)
+			argIndex++
+			joinSupplies = true
 		}
 	}
 
-	return products, totalCount, nil
+	detailJoins := []string{}
+	if joinLivestock {
+		detailJoins = append(detailJoins, "JOIN livestock_details ld ON ld.product_id = p.id")
+	}
+	if joinTransport {
+		detailJoins = append(detailJoins, "JOIN transport_details td ON td.product_id = p.id")
+	}
+	if joinSupplies {
+		detailJoins = append(detailJoins, "JOIN supplies_details sd ON sd.product_id = p.id")
+	}
+
+	return &searchFilter{
+		conditions:     whereConditions,
+		args:           args,
+		argIndex:       argIndex,
+		joins:          strings.Join(detailJoins, "\n\t\t"),
+		textSearchRank: textSearchRank,
+		joinSupplies:   joinSupplies,
+	}
 }
 
-// Helper methods for category-specific details
-func (r *Repository) insertTransportDetails(ctx context.Context, tx *sql.Tx, details *TransportDetails) error {
+// Helper methods for category-specific details. Each writes the full row,
+// replacing any details already stored for the product; partial updates are
+// merged with the stored details first (see mergeCategoryDetails). Unset
+// flags are stored as false.
+func (r *Repository) upsertTransportDetails(ctx context.Context, tx *sql.Tx, details *TransportDetails) error {
 	query := `
 		INSERT INTO transport_details (
 			product_id, vehicle_type, capacity_tons, capacity_cubic_meters,
 			price_per_km, has_refrigeration, has_livestock_equipment,
 			service_provinces, min_distance_km, max_distance_km,
 			license_plate, license_expiry, insurance_expiry, vehicle_year
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+		) VALUES ($1, $2, $3, $4, $5, COALESCE($6, false), COALESCE($7, false), $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (product_id) DO UPDATE SET
+			vehicle_type = EXCLUDED.vehicle_type,
+			capacity_tons = EXCLUDED.capacity_tons,
+			capacity_cubic_meters = EXCLUDED.capacity_cubic_meters,
+			price_per_km = EXCLUDED.price_per_km,
+			has_refrigeration = EXCLUDED.has_refrigeration,
+			has_livestock_equipment = EXCLUDED.has_livestock_equipment,
+			service_provinces = EXCLUDED.service_provinces,
+			min_distance_km = EXCLUDED.min_distance_km,
+			max_distance_km = EXCLUDED.max_distance_km,
+			license_plate = EXCLUDED.license_plate,
+			license_expiry = EXCLUDED.license_expiry,
+			insurance_expiry = EXCLUDED.insurance_expiry,
+			vehicle_year = EXCLUDED.vehicle_year,
+			updated_at = NOW()`
 
 	_, err := tx.ExecContext(ctx, query,
 		details.ProductID, details.VehicleType, details.CapacityTons,
@@ -379,7 +699,7 @@ func (r *Repository) insertTransportDetails(ctx context.Context, tx *sql.Tx, det
 	return err
 }
 
-func (r *Repository) insertLivestockDetails(ctx context.Context, tx *sql.Tx, details *LivestockDetails) error {
+func (r *Repository) upsertLivestockDetails(ctx context.Context, tx *sql.Tx, details *LivestockDetails) error {
 	var vaccinationsJSON, breedingHistoryJSON []byte
 	var err error
 
@@ -402,7 +722,21 @@ func (r *Repository) insertLivestockDetails(ctx context.Context, tx *sql.Tx, det
 			product_id, animal_type, breed, age_months, weight_kg, gender,
 			health_certificates, vaccinations, last_veterinary_check,
 			is_organic, is_pregnant, breeding_history, genetic_information
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE($10, false), $11, $12, $13)
+		ON CONFLICT (product_id) DO UPDATE SET
+			animal_type = EXCLUDED.animal_type,
+			breed = EXCLUDED.breed,
+			age_months = EXCLUDED.age_months,
+			weight_kg = EXCLUDED.weight_kg,
+			gender = EXCLUDED.gender,
+			health_certificates = EXCLUDED.health_certificates,
+			vaccinations = EXCLUDED.vaccinations,
+			last_veterinary_check = EXCLUDED.last_veterinary_check,
+			is_organic = EXCLUDED.is_organic,
+			is_pregnant = EXCLUDED.is_pregnant,
+			breeding_history = EXCLUDED.breeding_history,
+			genetic_information = EXCLUDED.genetic_information,
+			updated_at = NOW()`
 
 	_, err = tx.ExecContext(ctx, query,
 		details.ProductID, details.AnimalType, details.Breed, details.AgeMonths,
@@ -413,14 +747,29 @@ func (r *Repository) insertLivestockDetails(ctx context.Context, tx *sql.Tx, det
 	return err
 }
 
-func (r *Repository) insertSuppliesDetails(ctx context.Context, tx *sql.Tx, details *SuppliesDetails) error {
+func (r *Repository) upsertSuppliesDetails(ctx context.Context, tx *sql.Tx, details *SuppliesDetails) error {
 	query := `
 		INSERT INTO supplies_details (
 			product_id, supply_type, brand, model, active_ingredients,
 			concentration, expiry_date, batch_number, registration_number,
 			required_licenses, safety_data_sheet_url, storage_requirements,
 			handling_instructions, disposal_instructions
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (product_id) DO UPDATE SET
+			supply_type = EXCLUDED.supply_type,
+			brand = EXCLUDED.brand,
+			model = EXCLUDED.model,
+			active_ingredients = EXCLUDED.active_ingredients,
+			concentration = EXCLUDED.concentration,
+			expiry_date = EXCLUDED.expiry_date,
+			batch_number = EXCLUDED.batch_number,
+			registration_number = EXCLUDED.registration_number,
+			required_licenses = EXCLUDED.required_licenses,
+			safety_data_sheet_url = EXCLUDED.safety_data_sheet_url,
+			storage_requirements = EXCLUDED.storage_requirements,
+			handling_instructions = EXCLUDED.handling_instructions,
+			disposal_instructions = EXCLUDED.disposal_instructions,
+			updated_at = NOW()`
 
 	_, err := tx.ExecContext(ctx, query,
 		details.ProductID, details.SupplyType, details.Brand, details.Model,
@@ -441,6 +790,13 @@ func (r *Repository) loadProductDetails(ctx context.Context, product *Product) e
 	}
 	product.Images = images
 
+	// Load video
+	video, err := r.getProductVideo(ctx, product.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load product video: %w", err)
+	}
+	product.Video = video
+
 	// Load category-specific details
 	switch product.Category {
 	case "transport":
@@ -466,85 +822,77 @@ func (r *Repository) loadProductDetails(ctx context.Context, product *Product) e
 	return nil
 }
 
-func (r *Repository) getProductImages(ctx context.Context, productID uuid.UUID) ([]ProductImage, error) {
-	query := `
-		SELECT id, product_id, image_url, cloud_storage_path, alt_text,
-			   is_primary, display_order, file_size, mime_type, uploaded_at
-		FROM product_images 
-		WHERE product_id = $1 
-		ORDER BY is_primary DESC, display_order ASC`
-
-	rows, err := r.db.QueryContext(ctx, query, productID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	images := make([]ProductImage, 0)
-	for rows.Next() {
-		img := ProductImage{}
-		err := rows.Scan(&img.ID, &img.ProductID, &img.ImageURL, &img.CloudStoragePath,
-			&img.AltText, &img.IsPrimary, &img.DisplayOrder, &img.FileSize,
-			&img.MimeType, &img.UploadedAt)
-		if err != nil {
-			return nil, err
-		}
-		images = append(images, img)
-	}
+// Column lists and row scanners shared by the single-product getters and the
+// batch loaders
+const (
+	productImageColumns = `id, product_id, image_url, cloud_storage_path, alt_text,
+			   is_primary, display_order, file_size, mime_type, uploaded_at`
 
-	return images, nil
-}
+	productVideoColumns = `id, product_id, video_url, cloud_storage_path, poster_url,
+			   poster_storage_path, duration_seconds, file_size, mime_type, uploaded_at`
 
-func (r *Repository) getTransportDetails(ctx context.Context, productID uuid.UUID) (*TransportDetails, error) {
-	query := `
-		SELECT product_id, vehicle_type, capacity_tons, capacity_cubic_meters,
+	transportDetailsColumns = `product_id, vehicle_type, capacity_tons, capacity_cubic_meters,
 			   price_per_km, has_refrigeration, has_livestock_equipment,
 			   service_provinces, min_distance_km, max_distance_km,
 			   license_plate, license_expiry, insurance_expiry, vehicle_year,
-			   created_at, updated_at
-		FROM transport_details WHERE product_id = $1`
+			   created_at, updated_at`
+
+	livestockDetailsColumns = `product_id, animal_type, breed, age_months, weight_kg, gender,
+			   health_certificates, vaccinations, last_veterinary_check,
+			   is_organic, is_pregnant, breeding_history, genetic_information,
+			   created_at, updated_at`
+
+	suppliesDetailsColumns = `product_id, supply_type, brand, model, active_ingredients,
+			   concentration, expiry_date, batch_number, registration_number,
+			   required_licenses, safety_data_sheet_url, storage_requirements,
+			   handling_instructions, disposal_instructions, created_at, updated_at`
+)
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
+func scanProductImage(row rowScanner) (ProductImage, error) {
+	img := ProductImage{}
+	err := row.Scan(&img.ID, &img.ProductID, &img.ImageURL, &img.CloudStoragePath,
+		&img.AltText, &img.IsPrimary, &img.DisplayOrder, &img.FileSize,
+		&img.MimeType, &img.UploadedAt)
+	return img, err
+}
+
+func scanProductVideo(row rowScanner) (*ProductVideo, error) {
+	video := &ProductVideo{}
+	err := row.Scan(
+		&video.ID, &video.ProductID, &video.VideoURL, &video.CloudStoragePath,
+		&video.PosterURL, &video.PosterStoragePath, &video.DurationSeconds,
+		&video.FileSize, &video.MimeType, &video.UploadedAt)
+	return video, err
+}
+
+func scanTransportDetails(row rowScanner) (*TransportDetails, error) {
 	details := &TransportDetails{}
-	err := r.db.QueryRowContext(ctx, query, productID).Scan(
+	err := row.Scan(
 		&details.ProductID, &details.VehicleType, &details.CapacityTons,
 		&details.CapacityCubicMeters, &details.PricePerKm, &details.HasRefrigeration,
 		&details.HasLivestockEquipment, pq.Array(&details.ServiceProvinces),
 		&details.MinDistanceKm, &details.MaxDistanceKm, &details.LicensePlate,
 		&details.LicenseExpiry, &details.InsuranceExpiry, &details.VehicleYear,
 		&details.CreatedAt, &details.UpdatedAt)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
-	}
-
-	return details, nil
+	return details, err
 }
 
-func (r *Repository) getLivestockDetails(ctx context.Context, productID uuid.UUID) (*LivestockDetails, error) {
-	query := `
-		SELECT product_id, animal_type, breed, age_months, weight_kg, gender,
-			   health_certificates, vaccinations, last_veterinary_check,
-			   is_organic, is_pregnant, breeding_history, genetic_information,
-			   created_at, updated_at
-		FROM livestock_details WHERE product_id = $1`
-
+func scanLivestockDetails(row rowScanner) (*LivestockDetails, error) {
 	details := &LivestockDetails{}
 	var vaccinationsJSON, breedingHistoryJSON sql.NullString
 
-	err := r.db.QueryRowContext(ctx, query, productID).Scan(
+	err := row.Scan(
 		&details.ProductID, &details.AnimalType, &details.Breed, &details.AgeMonths,
 		&details.WeightKg, &details.Gender, pq.Array(&details.HealthCertificates),
 		&vaccinationsJSON, &details.LastVeterinaryCheck, &details.IsOrganic,
 		&details.IsPregnant, &breedingHistoryJSON, &details.GeneticInformation,
 		&details.CreatedAt, &details.UpdatedAt)
-
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
 		return nil, err
 	}
 
@@ -564,23 +912,89 @@ func (r *Repository) getLivestockDetails(ctx context.Context, productID uuid.UUI
 	return details, nil
 }
 
-func (r *Repository) getSuppliesDetails(ctx context.Context, productID uuid.UUID) (*SuppliesDetails, error) {
-	query := `
-		SELECT product_id, supply_type, brand, model, active_ingredients,
-			   concentration, expiry_date, batch_number, registration_number,
-			   required_licenses, safety_data_sheet_url, storage_requirements,
-			   handling_instructions, disposal_instructions, created_at, updated_at
-		FROM supplies_details WHERE product_id = $1`
-
+func scanSuppliesDetails(row rowScanner) (*SuppliesDetails, error) {
 	details := &SuppliesDetails{}
-	err := r.db.QueryRowContext(ctx, query, productID).Scan(
+	err := row.Scan(
 		&details.ProductID, &details.SupplyType, &details.Brand, &details.Model,
 		pq.Array(&details.ActiveIngredients), &details.Concentration,
 		&details.ExpiryDate, &details.BatchNumber, &details.RegistrationNumber,
 		pq.Array(&details.RequiredLicenses), &details.SafetyDataSheetURL,
 		&details.StorageRequirements, &details.HandlingInstructions,
 		&details.DisposalInstructions, &details.CreatedAt, &details.UpdatedAt)
+	return details, err
+}
+
+func (r *Repository) getProductImages(ctx context.Context, productID uuid.UUID) ([]ProductImage, error) {
+	query := `
+		SELECT ` + productImageColumns + `
+		FROM product_images 
+		WHERE product_id = $1 
+		ORDER BY is_primary DESC, display_order ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := make([]ProductImage, 0)
+	for rows.Next() {
+		img, err := scanProductImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+
+	return images, nil
+}
+
+func (r *Repository) getProductVideo(ctx context.Context, productID uuid.UUID) (*ProductVideo, error) {
+	query := `SELECT ` + productVideoColumns + ` FROM product_videos WHERE product_id = $1`
+
+	video, err := scanProductVideo(r.db.QueryRowContext(ctx, query, productID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return video, nil
+}
+
+func (r *Repository) getTransportDetails(ctx context.Context, productID uuid.UUID) (*TransportDetails, error) {
+	query := `SELECT ` + transportDetailsColumns + ` FROM transport_details WHERE product_id = $1`
+
+	details, err := scanTransportDetails(r.db.QueryRowContext(ctx, query, productID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return details, nil
+}
+
+func (r *Repository) getLivestockDetails(ctx context.Context, productID uuid.UUID) (*LivestockDetails, error) {
+	query := `SELECT ` + livestockDetailsColumns + ` FROM livestock_details WHERE product_id = $1`
+
+	details, err := scanLivestockDetails(r.db.QueryRowContext(ctx, query, productID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return details, nil
+}
+
+func (r *Repository) getSuppliesDetails(ctx context.Context, productID uuid.UUID) (*SuppliesDetails, error) {
+	query := `SELECT ` + suppliesDetailsColumns + ` FROM supplies_details WHERE product_id = $1`
 
+	details, err := scanSuppliesDetails(r.db.QueryRowContext(ctx, query, productID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -591,8 +1005,237 @@ func (r *Repository) getSuppliesDetails(ctx context.Context, productID uuid.UUID
 	return details, nil
 }
 
+// loadProductDetailsBatch loads the images, videos and category details of a
+// page of products with one query per table instead of several per product
+func (r *Repository) loadProductDetailsBatch(ctx context.Context, products []*Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*Product, len(products))
+	ids := make([]uuid.UUID, 0, len(products))
+	idsByCategory := make(map[string][]uuid.UUID)
+	for _, product := range products {
+		product.Images = make([]ProductImage, 0)
+		byID[product.ID] = product
+		ids = append(ids, product.ID)
+		idsByCategory[product.Category] = append(idsByCategory[product.Category], product.ID)
+	}
+
+	// Images, in the same order as the single-product path
+	err := r.queryEach(ctx, `
+		SELECT `+productImageColumns+`
+		FROM product_images
+		WHERE product_id = ANY($1)
+		ORDER BY product_id, is_primary DESC, display_order ASC`, ids, func(rows *sql.Rows) error {
+		img, err := scanProductImage(rows)
+		if err != nil {
+			return err
+		}
+		byID[img.ProductID].Images = append(byID[img.ProductID].Images, img)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load product images: %w", err)
+	}
+
+	err = r.queryEach(ctx, `SELECT `+productVideoColumns+` FROM product_videos WHERE product_id = ANY($1)`, ids, func(rows *sql.Rows) error {
+		video, err := scanProductVideo(rows)
+		if err != nil {
+			return err
+		}
+		byID[video.ProductID].Video = video
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load product videos: %w", err)
+	}
+
+	// Category details, only for the categories present on the page
+	if transportIDs := idsByCategory["transport"]; len(transportIDs) > 0 {
+		err = r.queryEach(ctx, `SELECT `+transportDetailsColumns+` FROM transport_details WHERE product_id = ANY($1)`, transportIDs, func(rows *sql.Rows) error {
+			details, err := scanTransportDetails(rows)
+			if err != nil {
+				return err
+			}
+			byID[details.ProductID].TransportDetails = details
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load transport details: %w", err)
+		}
+	}
+
+	if livestockIDs := idsByCategory["livestock"]; len(livestockIDs) > 0 {
+		err = r.queryEach(ctx, `SELECT `+livestockDetailsColumns+` FROM livestock_details WHERE product_id = ANY($1)`, livestockIDs, func(rows *sql.Rows) error {
+			details, err := scanLivestockDetails(rows)
+			if err != nil {
+				return err
+			}
+			byID[details.ProductID].LivestockDetails = details
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load livestock details: %w", err)
+		}
+	}
+
+	if suppliesIDs := idsByCategory["supplies"]; len(suppliesIDs) > 0 {
+		err = r.queryEach(ctx, `SELECT `+suppliesDetailsColumns+` FROM supplies_details WHERE product_id = ANY($1)`, suppliesIDs, func(rows *sql.Rows) error {
+			details, err := scanSuppliesDetails(rows)
+			if err != nil {
+				return err
+			}
+			byID[details.ProductID].SuppliesDetails = details
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load supplies details: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// queryEach runs a query over a set of product IDs and calls fn for each row
+func (r *Repository) queryEach(ctx context.Context, query string, ids []uuid.UUID, fn func(rows *sql.Rows) error) error {
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
 // UpdateProduct updates an existing product
 func (r *Repository) UpdateProduct(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	return updateProduct(ctx, r.db, id, updates)
+}
+
+// UpdateProductWithPriceChange applies the updates and appends the new price to
+// the product's price history in one transaction. The first recorded change
+// also stores the previous price, so products listed before history tracking
+// keep their original price.
+func (r *Repository) UpdateProductWithPriceChange(ctx context.Context, id uuid.UUID, updates map[string]interface{}, previous, current *PriceHistoryEntry) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateProduct(ctx, tx, id, updates); err != nil {
+		return err
+	}
+	if err := recordPriceChange(ctx, tx, id, previous, current); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateProductWithDetails applies the updates and upserts the category details
+// in one transaction. A price change is recorded too when previous and current
+// are given.
+func (r *Repository) UpdateProductWithDetails(ctx context.Context, id uuid.UUID, updates map[string]interface{}, details *ProductDetailsUpdate, previous, current *PriceHistoryEntry) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateProduct(ctx, tx, id, updates); err != nil {
+		return err
+	}
+
+	if details.Transport != nil {
+		details.Transport.ProductID = id
+		if err := r.upsertTransportDetails(ctx, tx, details.Transport); err != nil {
+			return fmt.Errorf("failed to upsert transport details: %w", err)
+		}
+	}
+	if details.Livestock != nil {
+		details.Livestock.ProductID = id
+		if err := r.upsertLivestockDetails(ctx, tx, details.Livestock); err != nil {
+			return fmt.Errorf("failed to upsert livestock details: %w", err)
+		}
+	}
+	if details.Supplies != nil {
+		details.Supplies.ProductID = id
+		if err := r.upsertSuppliesDetails(ctx, tx, details.Supplies); err != nil {
+			return fmt.Errorf("failed to upsert supplies details: %w", err)
+		}
+	}
+
+	if previous != nil && current != nil {
+		if err := recordPriceChange(ctx, tx, id, previous, current); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// recordPriceChange appends the current price to the product's history, seeding
+// it with the previous price on the first change
+func recordPriceChange(ctx context.Context, tx *sql.Tx, id uuid.UUID, previous, current *PriceHistoryEntry) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO product_price_history (product_id, price, price_type, changed_at, changed_by)
+		SELECT $1, $2, $3, $4, $5
+		WHERE NOT EXISTS (SELECT 1 FROM product_price_history WHERE product_id = $1)`,
+		id, previous.Price, previous.PriceType, previous.ChangedAt, previous.ChangedBy)
+	if err != nil {
+		return fmt.Errorf("failed to record previous price: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO product_price_history (product_id, price, price_type, changed_by)
+		VALUES ($1, $2, $3, $4)`,
+		id, current.Price, current.PriceType, current.ChangedBy)
+	if err != nil {
+		return fmt.Errorf("failed to record price change: %w", err)
+	}
+
+	return nil
+}
+
+// GetPriceHistory returns a product's recorded prices, oldest first
+func (r *Repository) GetPriceHistory(ctx context.Context, productID uuid.UUID) ([]PriceHistoryEntry, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, product_id, price, price_type, changed_at, changed_by
+		FROM product_price_history
+		WHERE product_id = $1
+		ORDER BY changed_at, id`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+	defer rows.Close()
+
+	history := make([]PriceHistoryEntry, 0)
+	for rows.Next() {
+		var entry PriceHistoryEntry
+		if err := rows.Scan(&entry.ID, &entry.ProductID, &entry.Price, &entry.PriceType,
+			&entry.ChangedAt, &entry.ChangedBy); err != nil {
+			return nil, fmt.Errorf("failed to scan price history: %w", err)
+		}
+		history = append(history, entry)
+	}
+
+	return history, rows.Err()
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func updateProduct(ctx context.Context, db execer, id uuid.UUID, updates map[string]interface{}) error {
 	if len(updates) == 0 {
 		return nil
 	}
@@ -618,7 +1261,7 @@ func (r *Repository) UpdateProduct(ctx context.Context, id uuid.UUID, updates ma
 	query := fmt.Sprintf("UPDATE products SET %s WHERE id = $%d", strings.Join(setParts, ", "), argIndex)
 	args = append(args, id)
 
-	_, err := r.db.ExecContext(ctx, query, args...)
+	_, err := db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update product: %w", err)
 	}
@@ -628,7 +1271,10 @@ func (r *Repository) UpdateProduct(ctx context.Context, id uuid.UUID, updates ma
 
 // DeleteProduct soft deletes a product
 func (r *Repository) DeleteProduct(ctx context.Context, id uuid.UUID) error {
-	query := `UPDATE products SET is_active = false, updated_at = NOW() WHERE id = $1`
+	query := `
+		UPDATE products SET is_active = false, deleted_at = NOW(), updated_at = NOW(),
+			status = CASE WHEN status = 'draft' THEN status ELSE 'archived' END
+		WHERE id = $1`
 	_, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete product: %w", err)
@@ -636,9 +1282,1176 @@ func (r *Repository) DeleteProduct(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// RestoreProduct reactivates a soft-deleted product, reporting false when the
+// product was not deleted or was deleted before deletedAfter
+func (r *Repository) RestoreProduct(ctx context.Context, id uuid.UUID, deletedAfter time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE products SET is_active = true, deleted_at = NULL, updated_at = NOW(),
+			status = CASE WHEN status <> 'archived' THEN status
+				WHEN published_at IS NOT NULL THEN 'published' ELSE 'unpublished' END
+		WHERE id = $1 AND deleted_at IS NOT NULL AND deleted_at >= $2`, id, deletedAfter)
+	if err != nil {
+		return false, fmt.Errorf("failed to restore product: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// CountActiveProducts counts the seller's active listings, excluding
+// soft-deleted products
+func (r *Repository) CountActiveProducts(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM products
+		WHERE user_id = $1 AND is_active = true AND deleted_at IS NULL`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count active products: %w", err)
+	}
+	return count, nil
+}
+
+// GetSellerInfo reads the seller details copied onto new listings from the
+// active user record. Returns nil when the user does not exist or was deleted.
+func (r *Repository) GetSellerInfo(ctx context.Context, userID uuid.UUID) (*SellerInfo, error) {
+	info := &SellerInfo{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(NULLIF(business_name, ''), first_name || ' ' || last_name),
+			   COALESCE(phone, ''), COALESCE(rating, 0), COALESCE(verification_level, 0)
+		FROM users
+		WHERE id = $1 AND is_active = true AND deleted_at IS NULL`, userID).Scan(
+		&info.Name, &info.Phone, &info.Rating, &info.VerificationLevel)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seller info: %w", err)
+	}
+	return info, nil
+}
+
+// GetDeletedProducts lists the user's products soft-deleted after the given
+// time, most recently deleted first
+func (r *Repository) GetDeletedProducts(ctx context.Context, userID uuid.UUID, deletedAfter time.Time) ([]*DeletedProduct, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, title, category, price, currency, unit, deleted_at
+		FROM products
+		WHERE user_id = $1 AND deleted_at IS NOT NULL AND deleted_at > $2
+		ORDER BY deleted_at DESC`, userID, deletedAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted products: %w", err)
+	}
+	defer rows.Close()
+
+	products := make([]*DeletedProduct, 0)
+	for rows.Next() {
+		product := &DeletedProduct{}
+		if err := rows.Scan(&product.ID, &product.Title, &product.Category, &product.Price,
+			&product.Currency, &product.Unit, &product.DeletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted product: %w", err)
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
 // IncrementViewsCount increments the views count for a product
 func (r *Repository) IncrementViewsCount(ctx context.Context, productID uuid.UUID) error {
-	query := `UPDATE products SET views_count = views_count + 1, updated_at = NOW() WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, productID)
-	return err
+	return r.AddViewsCounts(ctx, map[uuid.UUID]int{productID: 1})
+}
+
+// AddViewsCounts adds each product's delta to its views count in one
+// statement. Views are not edits, so updated_at is left alone.
+func (r *Repository) AddViewsCounts(ctx context.Context, deltas map[uuid.UUID]int) error {
+	ids := make([]uuid.UUID, 0, len(deltas))
+	counts := make([]int64, 0, len(deltas))
+	for productID, delta := range deltas {
+		ids = append(ids, productID)
+		counts = append(counts, int64(delta))
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE products p
+		SET views_count = p.views_count + d.delta
+		FROM unnest($1::uuid[], $2::int[]) AS d(id, delta)
+		WHERE p.id = d.id`, pq.Array(ids), pq.Array(counts))
+	if err != nil {
+		return fmt.Errorf("failed to add product views: %w", err)
+	}
+	return nil
+}
+
+// RecordProductEvent appends an entry to the product audit trail
+func (r *Repository) RecordProductEvent(ctx context.Context, event *ProductEvent) error {
+	return insertProductEvent(ctx, r.db, event)
+}
+
+// GetProductEvents retrieves the audit trail of a product in chronological order
+func (r *Repository) GetProductEvents(ctx context.Context, productID uuid.UUID) ([]ProductEvent, error) {
+	query := `
+		SELECT id, product_id, actor_id, event_type, details, created_at
+		FROM product_events
+		WHERE product_id = $1
+		ORDER BY created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query product events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]ProductEvent, 0)
+	for rows.Next() {
+		event := ProductEvent{}
+		var detailsJSON []byte
+
+		if err := rows.Scan(&event.ID, &event.ProductID, &event.ActorID, &event.EventType,
+			&detailsJSON, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan product event: %w", err)
+		}
+
+		if len(detailsJSON) > 0 {
+			if err := json.Unmarshal(detailsJSON, &event.Details); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal event details: %w", err)
+			}
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func insertProductEvent(ctx context.Context, db *sql.DB, event *ProductEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	detailsJSON, err := json.Marshal(event.Details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event details: %w", err)
+	}
+
+	query := `
+		INSERT INTO product_events (id, product_id, actor_id, event_type, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err = db.ExecContext(ctx, query, event.ID, event.ProductID, event.ActorID,
+		event.EventType, detailsJSON, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record product event: %w", err)
+	}
+
+	return nil
+}
+
+// GetPriceSuggestion computes price quartiles over comparable active listings and
+// transactions completed within the last 180 days, all in the request currency.
+// Transaction prices are per unit.
+func (r *Repository) GetPriceSuggestion(ctx context.Context, req *PriceSuggestionRequest) (*PriceSuggestionResponse, error) {
+	conditions := []string{"p.category = $1", "p.currency = $2"}
+	args := []interface{}{req.Category, req.Currency}
+	argIndex := 3
+	detailJoin := ""
+
+	if req.Subcategory != "" {
+		conditions = append(conditions, fmt.Sprintf("p.subcategory = $%d", argIndex))
+		args = append(args, req.Subcategory)
+		argIndex++
+	}
+
+	if req.PriceType != "" {
+		conditions = append(conditions, fmt.Sprintf("p.price_type = $%d", argIndex))
+		args = append(args, req.PriceType)
+		argIndex++
+	}
+
+	if req.Unit != "" {
+		conditions = append(conditions, fmt.Sprintf("p.unit = $%d", argIndex))
+		args = append(args, req.Unit)
+		argIndex++
+	}
+
+	if req.Province != "" {
+		conditions = append(conditions, fmt.Sprintf("p.province = $%d", argIndex))
+		args = append(args, req.Province)
+		argIndex++
+	}
+
+	switch req.Category {
+	case "livestock":
+		detailJoin = "JOIN livestock_details ld ON ld.product_id = p.id"
+		if req.AnimalType != "" {
+			conditions = append(conditions, fmt.Sprintf("ld.animal_type = $%d", argIndex))
+			args = append(args, req.AnimalType)
+			argIndex++
+		}
+		if req.Breed != "" {
+			conditions = append(conditions, fmt.Sprintf("ld.breed = $%d", argIndex))
+			args = append(args, req.Breed)
+			argIndex++
+		}
+		if req.WeightKg != nil {
+			// Comparable animals weigh within 20% of the requested weight
+			conditions = append(conditions, fmt.Sprintf("ld.weight_kg BETWEEN $%d * 0.8 AND $%d * 1.2", argIndex, argIndex))
+			args = append(args, *req.WeightKg)
+			argIndex++
+		}
+	case "supplies":
+		detailJoin = "JOIN supplies_details sd ON sd.product_id = p.id"
+		if req.SupplyType != "" {
+			conditions = append(conditions, fmt.Sprintf("sd.supply_type = $%d", argIndex))
+			args = append(args, req.SupplyType)
+			argIndex++
+		}
+		if req.Brand != "" {
+			// Case-insensitive exact match; wildcards in the brand match literally
+			conditions = append(conditions, fmt.Sprintf(`sd.brand ILIKE $%d ESCAPE '\'`, argIndex))
+			args = append(args, sqlutil.EscapeLike(req.Brand))
+			argIndex++
+		}
+	case "transport":
+		detailJoin = "JOIN transport_details td ON td.product_id = p.id"
+		if req.VehicleType != "" {
+			conditions = append(conditions, fmt.Sprintf("td.vehicle_type = $%d", argIndex))
+			args = append(args, req.VehicleType)
+			argIndex++
+		}
+	}
+
+	whereClause := strings.Join(conditions, " AND ")
+
+	query := fmt.Sprintf(`
+		WITH comparables AS (
+			SELECT p.price AS price
+			FROM products p
+			%s
+			WHERE p.is_active = true AND p.published_at IS NOT NULL
+			  AND p.price IS NOT NULL AND %s
+			UNION ALL
+			SELECT t.final_price / NULLIF(t.quantity, 0) AS price
+			FROM transactions t
+			JOIN products p ON p.id = t.product_id
+			%s
+			WHERE t.status = 'completed'
+			  AND t.completed_at >= NOW() - INTERVAL '180 days' AND %s
+		)
+		SELECT COUNT(price),
+			   percentile_cont(0.25) WITHIN GROUP (ORDER BY price),
+			   percentile_cont(0.5) WITHIN GROUP (ORDER BY price),
+			   percentile_cont(0.75) WITHIN GROUP (ORDER BY price)
+		FROM comparables
+		WHERE price IS NOT NULL`, detailJoin, whereClause, detailJoin, whereClause)
+
+	suggestion := &PriceSuggestionResponse{Currency: req.Currency}
+	var lower, median, upper sql.NullFloat64
+
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+		&suggestion.SampleSize, &lower, &median, &upper)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute price suggestion: %w", err)
+	}
+
+	if median.Valid {
+		suggestion.LowerQuartile = &lower.Float64
+		suggestion.Median = &median.Float64
+		suggestion.UpperQuartile = &upper.Float64
+	}
+
+	return suggestion, nil
+}
+
+// GetComparableProducts returns active listings comparable to the product,
+// cheapest first, with price stats over all of them. Comparables share the
+// category, subcategory, currency, price type and unit, and for livestock the
+// animal type and breed. They lie within radiusKm of the product when it has coordinates, or
+// in its province otherwise. Listings by excludeUserID are left out.
+func (r *Repository) GetComparableProducts(ctx context.Context, product *Product, excludeUserID *uuid.UUID, radiusKm float64, limit int) ([]*Product, *ComparablePriceStats, error) {
+	conditions := []string{
+		"p.is_active = true",
+		"p.status = 'published'",
+		"p.published_at IS NOT NULL",
+		"(p.expires_at IS NULL OR p.expires_at > NOW())",
+		"p.price IS NOT NULL",
+		"p.id <> $1",
+		"p.category = $2",
+		"p.currency = $3",
+		"p.price_type = $4",
+	}
+	args := []interface{}{product.ID, product.Category, product.Currency, product.PriceType}
+	argIndex := 5
+	detailJoin := ""
+
+	// Prices per head and per kilo are not comparable
+	if product.Unit != nil {
+		conditions = append(conditions, fmt.Sprintf("p.unit = $%d", argIndex))
+		args = append(args, *product.Unit)
+		argIndex++
+	} else {
+		conditions = append(conditions, "p.unit IS NULL")
+	}
+
+	if product.Subcategory != nil {
+		conditions = append(conditions, fmt.Sprintf("p.subcategory = $%d", argIndex))
+		args = append(args, *product.Subcategory)
+		argIndex++
+	}
+
+	if excludeUserID != nil {
+		conditions = append(conditions, fmt.Sprintf("p.user_id <> $%d", argIndex))
+		args = append(args, *excludeUserID)
+		argIndex++
+	}
+
+	if product.Category == "livestock" && product.LivestockDetails != nil {
+		detailJoin = "JOIN livestock_details ld ON ld.product_id = p.id"
+		if product.LivestockDetails.AnimalType != nil {
+			conditions = append(conditions, fmt.Sprintf("ld.animal_type = $%d", argIndex))
+			args = append(args, *product.LivestockDetails.AnimalType)
+			argIndex++
+		}
+		if product.LivestockDetails.Breed != nil {
+			conditions = append(conditions, fmt.Sprintf("ld.breed = $%d", argIndex))
+			args = append(args, *product.LivestockDetails.Breed)
+			argIndex++
+		}
+	}
+
+	if product.LocationCoordinates != nil {
+		conditions = append(conditions, "p.location_coordinates IS NOT NULL",
+			fmt.Sprintf(`ST_DWithin(
+			ST_GeogFromText('POINT(' || $%d || ' ' || $%d || ')'),
+			ST_GeogFromText(ST_AsText(p.location_coordinates)),
+			$%d
+		)`, argIndex, argIndex+1, argIndex+2))
+		args = append(args, product.LocationCoordinates.Lng, product.LocationCoordinates.Lat, radiusKm*1000)
+		argIndex += 3
+	} else if product.Province != nil {
+		conditions = append(conditions, fmt.Sprintf("p.province = $%d", argIndex))
+		args = append(args, *product.Province)
+		argIndex++
+	}
+
+	whereClause := strings.Join(conditions, " AND ")
+
+	stats := &ComparablePriceStats{Currency: product.Currency}
+	var minPrice, medianPrice, maxPrice sql.NullFloat64
+	err := r.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*), MIN(p.price),
+			   percentile_cont(0.5) WITHIN GROUP (ORDER BY p.price),
+			   MAX(p.price)
+		FROM products p
+		%s
+		WHERE %s`, detailJoin, whereClause), args...).Scan(
+		&stats.Count, &minPrice, &medianPrice, &maxPrice)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compute comparable prices: %w", err)
+	}
+
+	if medianPrice.Valid {
+		stats.MinPrice = &minPrice.Float64
+		stats.MedianPrice = &medianPrice.Float64
+		stats.MaxPrice = &maxPrice.Float64
+	}
+
+	if stats.Count == 0 {
+		return []*Product{}, stats, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s,
+			NULL::float8 AS distance_km
+		FROM products p
+		LEFT JOIN users u ON p.user_id = u.id
+		%s
+		WHERE %s
+		ORDER BY p.price ASC, p.id ASC
+		LIMIT $%d`, productListColumns, detailJoin, whereClause, argIndex), append(args, limit)...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find comparable products: %w", err)
+	}
+	defer rows.Close()
+
+	comparables, err := r.scanProductList(ctx, rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return comparables, stats, nil
+}
+
+// CreateAvailabilitySlot stores a new availability slot for a product
+func (r *Repository) CreateAvailabilitySlot(ctx context.Context, slot *AvailabilitySlot) error {
+	query := `
+		INSERT INTO product_availability_slots (id, product_id, start_date, end_date, status, note)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at`
+
+	err := r.db.QueryRowContext(ctx, query, slot.ID, slot.ProductID, slot.StartDate,
+		slot.EndDate, slot.Status, slot.Note).Scan(&slot.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create availability slot: %w", err)
+	}
+
+	return nil
+}
+
+// GetAvailabilitySlots lists a product's slots ordered by start date
+func (r *Repository) GetAvailabilitySlots(ctx context.Context, productID uuid.UUID) ([]AvailabilitySlot, error) {
+	query := `
+		SELECT id, product_id, start_date, end_date, status, note, created_at
+		FROM product_availability_slots
+		WHERE product_id = $1
+		ORDER BY start_date ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query availability slots: %w", err)
+	}
+	defer rows.Close()
+
+	slots := make([]AvailabilitySlot, 0)
+	for rows.Next() {
+		slot := AvailabilitySlot{}
+		if err := rows.Scan(&slot.ID, &slot.ProductID, &slot.StartDate, &slot.EndDate,
+			&slot.Status, &slot.Note, &slot.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan availability slot: %w", err)
+		}
+		slots = append(slots, slot)
+	}
+
+	return slots, rows.Err()
+}
+
+// DeleteAvailabilitySlot removes a slot belonging to the given product
+func (r *Repository) DeleteAvailabilitySlot(ctx context.Context, productID, slotID uuid.UUID) (bool, error) {
+	query := `DELETE FROM product_availability_slots WHERE id = $1 AND product_id = $2`
+	result, err := r.db.ExecContext(ctx, query, slotID, productID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete availability slot: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// IsBookedOn reports whether a blocked or booked slot covers the given date
+func (r *Repository) IsBookedOn(ctx context.Context, productID uuid.UUID, date time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM product_availability_slots
+			WHERE product_id = $1 AND status IN ('blocked', 'booked')
+			  AND $2::date BETWEEN start_date AND end_date
+		)`
+
+	var booked bool
+	if err := r.db.QueryRowContext(ctx, query, productID, date).Scan(&booked); err != nil {
+		return false, fmt.Errorf("failed to check availability: %w", err)
+	}
+	return booked, nil
+}
+
+// AddFavorite saves the product to the user's favorites and bumps its
+// favorites_count. Returns false when it was already a favorite.
+func (r *Repository) AddFavorite(ctx context.Context, userID, productID uuid.UUID) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO user_favorites (user_id, product_id) VALUES ($1, $2)
+		ON CONFLICT (user_id, product_id) DO NOTHING`, userID, productID)
+	if err != nil {
+		return false, fmt.Errorf("failed to add favorite: %w", err)
+	}
+
+	// Only count newly added favorites so repeated requests are idempotent
+	if added, _ := result.RowsAffected(); added == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE products SET favorites_count = favorites_count + 1 WHERE id = $1`, productID)
+	if err != nil {
+		return false, fmt.Errorf("failed to increment favorites count: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit favorite: %w", err)
+	}
+
+	return true, nil
+}
+
+// RemoveFavorite removes the product from the user's favorites and decrements
+// its favorites_count. Returns false when it was not a favorite.
+func (r *Repository) RemoveFavorite(ctx context.Context, userID, productID uuid.UUID) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`DELETE FROM user_favorites WHERE user_id = $1 AND product_id = $2`, userID, productID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove favorite: %w", err)
+	}
+
+	if removed, _ := result.RowsAffected(); removed == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE products SET favorites_count = GREATEST(favorites_count - 1, 0) WHERE id = $1`, productID)
+	if err != nil {
+		return false, fmt.Errorf("failed to decrement favorites count: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit favorite removal: %w", err)
+	}
+
+	return true, nil
+}
+
+// ListFavorites returns the user's active favorite products, most recently saved first
+func (r *Repository) ListFavorites(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*Product, int, error) {
+	var totalCount int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM user_favorites f
+		JOIN products p ON p.id = f.product_id
+		WHERE f.user_id = $1 AND p.is_active = true`, userID).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count favorites: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT f.product_id FROM user_favorites f
+		JOIN products p ON p.id = f.product_id
+		WHERE f.user_id = $1 AND p.is_active = true
+		ORDER BY f.created_at DESC
+		LIMIT $2 OFFSET $3`, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list favorites: %w", err)
+	}
+
+	productIDs := make([]uuid.UUID, 0, limit)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, 0, fmt.Errorf("failed to scan favorite: %w", err)
+		}
+		productIDs = append(productIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	products := make([]*Product, len(productIDs))
+	err = storage.RunLimited(ctx, len(productIDs), func(ctx context.Context, i int) error {
+		product, err := r.GetProductByID(ctx, productIDs[i])
+		products[i] = product
+		return err
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load favorite products: %w", err)
+	}
+
+	// Drop products removed between the two queries
+	favorites := make([]*Product, 0, len(products))
+	for _, product := range products {
+		if product != nil {
+			favorites = append(favorites, product)
+		}
+	}
+
+	return favorites, totalCount, nil
+}
+
+// AutocompleteTitles returns live published products whose title contains the text,
+// ranking prefix matches first and then by trigram similarity
+func (r *Repository) AutocompleteTitles(ctx context.Context, text string, limit int) ([]*TitleSuggestion, error) {
+	escaped := sqlutil.EscapeLike(text)
+
+	query := `
+		SELECT id, title, category
+		FROM products
+		WHERE is_active = true AND status = 'published' AND published_at IS NOT NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		  AND title ILIKE $1
+		ORDER BY title ILIKE $2 DESC, similarity(title, $3) DESC, views_count DESC
+		LIMIT $4`
+
+	rows, err := r.db.QueryContext(ctx, query, "%"+escaped+"%", escaped+"%", text, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to autocomplete titles: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := make([]*TitleSuggestion, 0, limit)
+	for rows.Next() {
+		suggestion := &TitleSuggestion{}
+		if err := rows.Scan(&suggestion.ID, &suggestion.Title, &suggestion.Category); err != nil {
+			return nil, fmt.Errorf("failed to scan title suggestion: %w", err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, rows.Err()
+}
+
+// GetSimilarProducts recommends live listings in the product's category from
+// other sellers. Candidates share a tag or the subcategory, or any listing of
+// the category when the product has neither. They rank by shared tags, then
+// same subcategory, then distance when both have coordinates. Returns nil
+// when the product does not exist.
+func (r *Repository) GetSimilarProducts(ctx context.Context, productID uuid.UUID, limit int) ([]*SimilarProduct, error) {
+	query := `
+		WITH src AS (
+			SELECT id, user_id, category, subcategory,
+				   COALESCE(tags, '{}') AS tags, location_coordinates
+			FROM products WHERE id = $1
+		)
+		SELECT p.id, p.title, p.category, p.subcategory, p.price, p.price_type,
+			   p.currency, p.unit, p.province, p.city,
+			   (SELECT i.image_url FROM product_images i
+				WHERE i.product_id = p.id
+				ORDER BY i.is_primary DESC, i.display_order ASC
+				LIMIT 1) AS image_url,
+			   cardinality(ARRAY(
+				   SELECT unnest(COALESCE(p.tags, '{}')) INTERSECT SELECT unnest(src.tags)
+			   )) AS shared_tags,
+			   CASE WHEN p.location_coordinates IS NOT NULL AND src.location_coordinates IS NOT NULL
+				   THEN ST_Distance(
+					   ST_GeogFromText(ST_AsText(src.location_coordinates)),
+					   ST_GeogFromText(ST_AsText(p.location_coordinates))
+				   ) / 1000
+			   END AS distance_km
+		FROM src
+		LEFT JOIN products p ON p.id <> src.id
+			AND p.user_id <> src.user_id
+			AND p.category = src.category
+			AND p.is_active = true
+			AND p.published_at IS NOT NULL
+			AND (p.expires_at IS NULL OR p.expires_at > NOW())
+			AND (p.tags && src.tags
+				OR p.subcategory = src.subcategory
+				OR (cardinality(src.tags) = 0 AND src.subcategory IS NULL))
+		ORDER BY shared_tags DESC,
+				 (p.subcategory IS NOT DISTINCT FROM src.subcategory) DESC,
+				 distance_km ASC NULLS LAST,
+				 p.id ASC
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, productID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get similar products: %w", err)
+	}
+	defer rows.Close()
+
+	var similar []*SimilarProduct
+	for rows.Next() {
+		var (
+			candidateID         uuid.NullUUID
+			title, category     sql.NullString
+			priceType, currency sql.NullString
+			sharedTags          sql.NullInt64
+			product             SimilarProduct
+		)
+		if err := rows.Scan(&candidateID, &title, &category, &product.Subcategory,
+			&product.Price, &priceType, &currency, &product.Unit, &product.Province,
+			&product.City, &product.ImageURL, &sharedTags, &product.DistanceKm); err != nil {
+			return nil, fmt.Errorf("failed to scan similar product: %w", err)
+		}
+		if similar == nil {
+			similar = []*SimilarProduct{}
+		}
+
+		// The product exists but has no candidates
+		if !candidateID.Valid {
+			continue
+		}
+
+		product.ID = candidateID.UUID
+		product.Title = title.String
+		product.Category = category.String
+		product.PriceType = priceType.String
+		product.Currency = currency.String
+		product.SharedTags = int(sharedTags.Int64)
+		similar = append(similar, &product)
+	}
+
+	return similar, rows.Err()
+}
+
+// GetCheapestTransportRate returns the lowest per-km rate among active transport
+// listings serving the province (or any province when they list none).
+// Returns nil when no carrier publishes a per-km rate.
+func (r *Repository) GetCheapestTransportRate(ctx context.Context, province string) (*float64, error) {
+	query := `
+		SELECT MIN(td.price_per_km)
+		FROM transport_details td
+		JOIN products p ON p.id = td.product_id
+		WHERE p.is_active = true AND p.published_at IS NOT NULL
+		  AND td.price_per_km IS NOT NULL
+		  AND ($1 = '' OR td.service_provinces IS NULL
+		       OR cardinality(td.service_provinces) = 0 OR $1 = ANY(td.service_provinces))`
+
+	var rate sql.NullFloat64
+	if err := r.db.QueryRowContext(ctx, query, province).Scan(&rate); err != nil {
+		return nil, fmt.Errorf("failed to get transport rate: %w", err)
+	}
+	if !rate.Valid {
+		return nil, nil
+	}
+	return &rate.Float64, nil
+}
+
+// GetProductStats counts total and live products grouped by category
+func (r *Repository) GetProductStats(ctx context.Context) (*ProductStats, error) {
+	query := `
+		SELECT category, COUNT(*),
+			COUNT(CASE WHEN is_active = true AND published_at IS NOT NULL
+				AND (expires_at IS NULL OR expires_at > NOW()) THEN 1 END)
+		FROM products
+		GROUP BY category`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := &ProductStats{
+		TotalByCategory:  make(map[string]int),
+		ActiveByCategory: make(map[string]int),
+	}
+	for rows.Next() {
+		var category string
+		var total, active int
+		if err := rows.Scan(&category, &total, &active); err != nil {
+			return nil, fmt.Errorf("failed to scan product stats: %w", err)
+		}
+		stats.TotalByCategory[category] = total
+		stats.ActiveByCategory[category] = active
+		stats.TotalProducts += total
+		stats.ActiveProducts += active
+	}
+
+	return stats, rows.Err()
+}
+
+// ExpireProducts deactivates active products whose expiry has passed and
+// returns how many were expired
+func (r *Repository) ExpireProducts(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE products SET is_active = false, updated_at = NOW()
+		WHERE is_active = true AND expires_at IS NOT NULL AND expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire products: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// CreateSavedSearch stores a saved search
+func (r *Repository) CreateSavedSearch(ctx context.Context, search *SavedSearch) error {
+	criteriaJSON, err := json.Marshal(search.Criteria)
+	if err != nil {
+		return fmt.Errorf("failed to marshal search criteria: %w", err)
+	}
+
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO saved_searches (id, user_id, name, criteria, notify)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING last_matched_at, created_at`,
+		search.ID, search.UserID, search.Name, criteriaJSON, search.Notify).Scan(
+		&search.LastMatchedAt, &search.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create saved search: %w", err)
+	}
+
+	return nil
+}
+
+// CountSavedSearches returns how many saved searches the user has
+func (r *Repository) CountSavedSearches(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM saved_searches WHERE user_id = $1`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count saved searches: %w", err)
+	}
+	return count, nil
+}
+
+// GetSavedSearches lists the user's saved searches, newest first
+func (r *Repository) GetSavedSearches(ctx context.Context, userID uuid.UUID) ([]*SavedSearch, error) {
+	return r.querySavedSearches(ctx, `
+		SELECT id, user_id, name, criteria, notify, last_matched_at, created_at
+		FROM saved_searches
+		WHERE user_id = $1
+		ORDER BY created_at DESC`, userID)
+}
+
+// GetNotifySavedSearches lists every saved search with notifications enabled
+func (r *Repository) GetNotifySavedSearches(ctx context.Context) ([]*SavedSearch, error) {
+	return r.querySavedSearches(ctx, `
+		SELECT id, user_id, name, criteria, notify, last_matched_at, created_at
+		FROM saved_searches
+		WHERE notify = true
+		ORDER BY last_matched_at`)
+}
+
+func (r *Repository) querySavedSearches(ctx context.Context, query string, args ...interface{}) ([]*SavedSearch, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := make([]*SavedSearch, 0)
+	for rows.Next() {
+		search := &SavedSearch{}
+		var criteriaJSON []byte
+		if err := rows.Scan(&search.ID, &search.UserID, &search.Name, &criteriaJSON,
+			&search.Notify, &search.LastMatchedAt, &search.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan saved search: %w", err)
+		}
+		if err := json.Unmarshal(criteriaJSON, &search.Criteria); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal search criteria: %w", err)
+		}
+		searches = append(searches, search)
+	}
+
+	return searches, rows.Err()
+}
+
+// DeleteSavedSearch removes a saved search belonging to the user
+func (r *Repository) DeleteSavedSearch(ctx context.Context, userID, searchID uuid.UUID) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM saved_searches WHERE id = $1 AND user_id = $2`, searchID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete saved search: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// MatchSavedSearch returns the products published in (since, until] that match
+// the criteria, using the same filters as SearchProducts. The user's own
+// listings never match.
+func (r *Repository) MatchSavedSearch(ctx context.Context, criteria *ProductSearchRequest, userID uuid.UUID, since, until time.Time) ([]uuid.UUID, error) {
+	filter := buildSearchFilter(criteria)
+	conditions := append(filter.conditions,
+		fmt.Sprintf("p.user_id <> $%d", filter.argIndex),
+		fmt.Sprintf("p.published_at > $%d", filter.argIndex+1),
+		fmt.Sprintf("p.published_at <= $%d", filter.argIndex+2),
+	)
+	args := append(filter.args, userID, since, until)
+
+	query := fmt.Sprintf(`
+		SELECT p.id
+		FROM products p
+		LEFT JOIN users u ON p.user_id = u.id
+		%s
+		WHERE %s
+		ORDER BY p.published_at, p.id`, filter.joins, strings.Join(conditions, " AND "))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match saved search: %w", err)
+	}
+	defer rows.Close()
+
+	productIDs := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan product id: %w", err)
+		}
+		productIDs = append(productIDs, id)
+	}
+
+	return productIDs, rows.Err()
+}
+
+// RecordSavedSearchMatches records a notification per matched product, skipping
+// products already notified for this search, and advances the search's
+// matching watermark. Returns the number of notifications recorded.
+func (r *Repository) RecordSavedSearchMatches(ctx context.Context, search *SavedSearch, productIDs []uuid.UUID, channels []string, matchedUntil time.Time) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var recorded int64
+	if len(productIDs) > 0 {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO saved_search_notifications (saved_search_id, user_id, product_id, channels)
+			SELECT $1, $2, product_id, $4
+			FROM unnest($3::uuid[]) AS product_id
+			ON CONFLICT (saved_search_id, product_id) DO NOTHING`,
+			search.ID, search.UserID, pq.Array(productIDs), pq.Array(channels))
+		if err != nil {
+			return 0, fmt.Errorf("failed to record saved search matches: %w", err)
+		}
+		recorded, _ = result.RowsAffected()
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE saved_searches SET last_matched_at = $2 WHERE id = $1`, search.ID, matchedUntil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update saved search: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit saved search matches: %w", err)
+	}
+
+	return int(recorded), nil
+}
+
+// GetTransportDocuments lists the user's transport products with a license or
+// insurance expiring before the given date, soonest first
+func (r *Repository) GetTransportDocuments(ctx context.Context, userID uuid.UUID, before time.Time) ([]TransportCompliance, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT p.id, p.title, p.is_active, p.published_at,
+			   td.license_plate, td.license_expiry, td.insurance_expiry
+		FROM products p
+		JOIN transport_details td ON td.product_id = p.id
+		WHERE p.user_id = $1 AND p.category = 'transport' AND p.deleted_at IS NULL
+		  AND (td.license_expiry < $2::date OR td.insurance_expiry < $2::date)
+		ORDER BY LEAST(td.license_expiry, td.insurance_expiry) ASC, p.id`, userID, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transport documents: %w", err)
+	}
+	defer rows.Close()
+
+	listings := make([]TransportCompliance, 0)
+	for rows.Next() {
+		var listing TransportCompliance
+		if err := rows.Scan(&listing.ProductID, &listing.Title, &listing.IsActive, &listing.PublishedAt,
+			&listing.LicensePlate, &listing.LicenseExpiry, &listing.InsuranceExpiry); err != nil {
+			return nil, fmt.Errorf("failed to scan transport documents: %w", err)
+		}
+		listings = append(listings, listing)
+	}
+
+	return listings, rows.Err()
+}
+
+// UnpublishExpiredInsurance unpublishes transport products whose insurance
+// expired before today and returns their IDs
+func (r *Repository) UnpublishExpiredInsurance(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE products p SET published_at = NULL, sold_out_at = NULL, updated_at = NOW(),
+			status = CASE WHEN status = 'published' THEN 'unpublished' ELSE status END
+		FROM transport_details td
+		WHERE td.product_id = p.id AND p.category = 'transport'
+		  AND p.published_at IS NOT NULL AND td.insurance_expiry < CURRENT_DATE
+		RETURNING p.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpublish expired insurance: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan product id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// productOwner is the owner and status of a product, checked before bulk changes
+type productOwner struct {
+	UserID uuid.UUID
+	Status string
+}
+
+// GetProductOwners returns the owner of each existing product among the given IDs
+func (r *Repository) GetProductOwners(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID]productOwner, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, user_id, status FROM products WHERE id = ANY($1)`, pq.Array(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product owners: %w", err)
+	}
+	defer rows.Close()
+
+	owners := make(map[uuid.UUID]productOwner)
+	for rows.Next() {
+		var id uuid.UUID
+		var owner productOwner
+		if err := rows.Scan(&id, &owner.UserID, &owner.Status); err != nil {
+			return nil, fmt.Errorf("failed to scan product owner: %w", err)
+		}
+		owners[id] = owner
+	}
+
+	return owners, rows.Err()
+}
+
+// SetProductsPublished publishes or unpublishes the user's products among the
+// given IDs in a single statement and returns the IDs that were updated.
+// Drafts are left alone since they must pass validation one at a time.
+func (r *Repository) SetProductsPublished(ctx context.Context, userID uuid.UUID, productIDs []uuid.UUID, publish bool) ([]uuid.UUID, error) {
+	publishedAt, status := "NULL", ProductStatusUnpublished
+	if publish {
+		publishedAt, status = "NOW()", ProductStatusPublished
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE products SET published_at = %s, status = '%s', sold_out_at = NULL, updated_at = NOW()
+		WHERE id = ANY($1) AND user_id = $2 AND status NOT IN ('draft', 'archived')
+		RETURNING id`, publishedAt, status)
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(productIDs), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update products: %w", err)
+	}
+	defer rows.Close()
+
+	updated := make([]uuid.UUID, 0, len(productIDs))
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan product id: %w", err)
+		}
+		updated = append(updated, id)
+	}
+
+	return updated, rows.Err()
+}
+
+// purgeableCondition selects a user's products soft-deleted before a cutoff.
+// It must not rely on is_active, which expiry clears too: expired listings
+// stay renewable and are never purged.
+const purgeableCondition = "p.user_id = $1 AND p.deleted_at IS NOT NULL AND p.deleted_at < $2"
+
+// PurgeDeletedProducts permanently deletes a user's products soft-deleted before
+// deletedBefore in one transaction. Products referenced by any transaction are
+// kept, since transactions hold a foreign key to the product as part of the
+// trade record. Detail, image, video and event rows cascade; the storage paths
+// of removed media are returned.
+func (r *Repository) PurgeDeletedProducts(ctx context.Context, userID uuid.UUID, deletedBefore time.Time) (*PurgeResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &PurgeResult{StoragePaths: make([]string, 0)}
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM products p
+		WHERE `+purgeableCondition+`
+		  AND EXISTS (SELECT 1 FROM transactions t WHERE t.product_id = p.id)`,
+		userID, deletedBefore).Scan(&result.SkippedWithTransactions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count products with transactions: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id FROM products p
+		WHERE `+purgeableCondition+`
+		  AND NOT EXISTS (SELECT 1 FROM transactions t WHERE t.product_id = p.id)
+		FOR UPDATE`, userID, deletedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select products to purge: %w", err)
+	}
+
+	productIDs := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan product id: %w", err)
+		}
+		productIDs = append(productIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+
+	// Collect media storage paths before the rows cascade away
+	rows, err = tx.QueryContext(ctx, `
+		SELECT cloud_storage_path FROM product_images WHERE product_id = ANY($1)`,
+		pq.Array(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect image paths: %w", err)
+	}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan image path: %w", err)
+		}
+		result.StoragePaths = append(result.StoragePaths, path)
+		result.ImagesDeleted++
+	}
+	rows.Close()
+
+	rows, err = tx.QueryContext(ctx, `
+		SELECT cloud_storage_path, poster_storage_path FROM product_videos WHERE product_id = ANY($1)`,
+		pq.Array(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect video paths: %w", err)
+	}
+	for rows.Next() {
+		var path string
+		var posterPath sql.NullString
+		if err := rows.Scan(&path, &posterPath); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan video path: %w", err)
+		}
+		result.StoragePaths = append(result.StoragePaths, path)
+		if posterPath.Valid {
+			result.StoragePaths = append(result.StoragePaths, posterPath.String)
+		}
+		result.VideosDeleted++
+	}
+	rows.Close()
+
+	deleted, err := tx.ExecContext(ctx, `DELETE FROM products WHERE id = ANY($1)`, pq.Array(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge products: %w", err)
+	}
+	purged, err := deleted.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	result.ProductsPurged = int(purged)
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit purge: %w", err)
+	}
+
+	return result, nil
 }
@@ -0,0 +1,198 @@
+package products
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const availabilityWindow = "(p.available_from IS NULL OR p.available_from <= %s) AND (p.available_until IS NULL OR p.available_until >= %s)"
+
+func windowCondition(filter *searchFilter) (string, bool) {
+	for _, condition := range filter.conditions {
+		if strings.Contains(condition, "p.available_from") {
+			return condition, true
+		}
+	}
+	return "", false
+}
+
+func TestSearchProductsAvailabilityWindowBoundaries(t *testing.T) {
+	db := testDB(t)
+	repo := NewRepository(db)
+	sellerID := createTestSeller(t, db)
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	product := createTestProduct(t, repo, sellerID, func(p *Product) {
+		p.AvailableFrom = &from
+		p.AvailableUntil = &until
+	})
+
+	// Listings are available on the first and last day of their window and
+	// not on the days around it
+	tests := []struct {
+		date time.Time
+		want bool
+	}{
+		{from.AddDate(0, 0, -1), false},
+		{from, true},
+		{until, true},
+		{until.AddDate(0, 0, 1), false},
+	}
+
+	for _, tt := range tests {
+		date := tt.date
+		results, _, err := repo.SearchProducts(context.Background(), &ProductSearchRequest{ownerID: &sellerID, AvailableOn: &date})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		found := len(results) == 1 && results[0].ID == product.ID
+		if found != tt.want || len(results) > 1 {
+			t.Errorf("available on %s: expected listed=%v, got %d results", date.Format("2006-01-02"), tt.want, len(results))
+		}
+	}
+}
+
+func TestSearchFilterAvailabilityWindowDefaultsToToday(t *testing.T) {
+	filter := buildSearchFilter(&ProductSearchRequest{})
+
+	condition, ok := windowCondition(filter)
+	if !ok {
+		t.Fatal("expected public searches to hide listings outside their window")
+	}
+	if want := strings.ReplaceAll(availabilityWindow, "%s", "CURRENT_DATE"); condition != want {
+		t.Errorf("expected window as of today %q, got %q", want, condition)
+	}
+	if len(filter.args) != 0 {
+		t.Errorf("expected no arguments, got %v", filter.args)
+	}
+}
+
+func TestSearchFilterAvailabilityWindowForOwner(t *testing.T) {
+	ownerID := uuid.New()
+
+	filter := buildSearchFilter(&ProductSearchRequest{ownerID: &ownerID})
+	if condition, ok := windowCondition(filter); ok {
+		t.Errorf("expected owners to see listings outside their window, got %q", condition)
+	}
+
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	filter = buildSearchFilter(&ProductSearchRequest{ownerID: &ownerID, AvailableOn: &date})
+	if _, ok := windowCondition(filter); !ok {
+		t.Error("expected an explicit date to filter the owner's listings too")
+	}
+}
+
+func TestPurgeDeletedProductsKeepsExpiredListings(t *testing.T) {
+	db := testDB(t)
+	repo := NewRepository(db)
+	sellerID := createTestSeller(t, db)
+	cutoff := time.Now().Add(-24 * time.Hour)
+
+	// ExpireProducts only clears is_active, so an expired listing has
+	// deleted_at NULL and stays renewable
+	expired := createTestProduct(t, repo, sellerID, func(p *Product) { p.IsActive = false })
+	deletedBefore := createTestProduct(t, repo, sellerID, nil)
+	deletedAfter := createTestProduct(t, repo, sellerID, nil)
+	for id, deletedAt := range map[uuid.UUID]time.Time{
+		deletedBefore.ID: cutoff.Add(-time.Hour),
+		deletedAfter.ID:  cutoff.Add(time.Hour),
+	} {
+		if _, err := db.Exec(`UPDATE products SET is_active = false, deleted_at = $2 WHERE id = $1`, id, deletedAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := repo.PurgeDeletedProducts(context.Background(), sellerID, cutoff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ProductsPurged != 1 {
+		t.Errorf("expected 1 product to be purged, got %d", result.ProductsPurged)
+	}
+
+	for id, want := range map[uuid.UUID]bool{
+		expired.ID:       true,
+		deletedBefore.ID: false,
+		deletedAfter.ID:  true,
+	} {
+		var exists bool
+		if err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)`, id).Scan(&exists); err != nil {
+			t.Fatal(err)
+		}
+		if exists != want {
+			t.Errorf("product %s: expected kept=%v, got %v", id, want, exists)
+		}
+	}
+}
+
+func TestGetProductByIDRunsAgainstDatabase(t *testing.T) {
+	db := testDB(t)
+	repo := NewRepository(db)
+	sellerID := createTestSeller(t, db)
+	created := createTestProduct(t, repo, sellerID, nil)
+
+	product, err := repo.GetProductByID(context.Background(), created.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if product == nil || product.ID != created.ID {
+		t.Fatalf("expected product %s, got %+v", created.ID, product)
+	}
+	if product.CreatedAt.IsZero() {
+		t.Error("expected the product creation time to be read")
+	}
+	if product.liveSellerSince == nil {
+		t.Error("expected the seller's account creation time to be read")
+	}
+}
+
+func TestGetSellerInfoReadsUserRecord(t *testing.T) {
+	db := testDB(t)
+	repo := NewRepository(db)
+	sellerID := createTestSeller(t, db)
+
+	info, err := repo.GetSellerInfo(context.Background(), sellerID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info == nil || info.Name != "Test Seller" || info.VerificationLevel != 1 {
+		t.Errorf("expected the stored seller profile, got %+v", info)
+	}
+
+	missing, err := repo.GetSellerInfo(context.Background(), uuid.New())
+	if err != nil || missing != nil {
+		t.Errorf("expected nil for an unknown user, got %+v, %v", missing, err)
+	}
+}
+
+func TestGetComparableProductsMatchesPriceTypeAndUnit(t *testing.T) {
+	db := testDB(t)
+	repo := NewRepository(db)
+	sellerID := createTestSeller(t, db)
+
+	subcategory := "test-" + uuid.NewString()
+	listing := func(priceType, unit string) func(*Product) {
+		return func(p *Product) {
+			p.Subcategory = &subcategory
+			p.PriceType = priceType
+			p.Unit = &unit
+		}
+	}
+	source := createTestProduct(t, repo, sellerID, listing("per_unit", "head"))
+	match := createTestProduct(t, repo, sellerID, listing("per_unit", "head"))
+	createTestProduct(t, repo, sellerID, listing("per_unit", "kg"))
+	createTestProduct(t, repo, sellerID, listing("fixed", "head"))
+
+	comparables, stats, err := repo.GetComparableProducts(context.Background(), source, nil, 100, 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(comparables) != 1 || comparables[0].ID != match.ID || stats.Count != 1 {
+		t.Errorf("expected only the per-head listing %s, got %d listings", match.ID, len(comparables))
+	}
+}
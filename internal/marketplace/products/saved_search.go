@@ -0,0 +1,179 @@
+package products
+
+import (
+	"context"
+	"time"
+
+	"agro-mas-backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// Saved searches a single user may keep
+const maxSavedSearchesPerUser = 20
+
+// SavedSearch is a product search a user stored to re-run or be alerted about
+type SavedSearch struct {
+	ID            uuid.UUID            `json:"id" db:"id"`
+	UserID        uuid.UUID            `json:"user_id" db:"user_id"`
+	Name          string               `json:"name" db:"name"`
+	Criteria      ProductSearchRequest `json:"criteria" db:"criteria"`
+	Notify        bool                 `json:"notify" db:"notify"`
+	LastMatchedAt time.Time            `json:"last_matched_at" db:"last_matched_at"`
+	CreatedAt     time.Time            `json:"created_at" db:"created_at"`
+}
+
+// CreateSavedSearchRequest stores the filters of a search. Notify defaults to true.
+type CreateSavedSearchRequest struct {
+	Name     string               `json:"name" binding:"required,max=100"`
+	Criteria ProductSearchRequest `json:"criteria"`
+	Notify   *bool                `json:"notify,omitempty"`
+}
+
+// AcceptedChannelsFunc returns the channels among channels on which a user
+// accepts new-match notifications
+type AcceptedChannelsFunc func(ctx context.Context, userID uuid.UUID, channels []string) ([]string, error)
+
+// SetNotifier sets the channels saved search alerts may be delivered on and
+// the filter of them by each user's notification preferences
+func (s *Service) SetNotifier(channels []string, acceptedChannels AcceptedChannelsFunc) {
+	s.notificationChannels = channels
+	s.acceptedChannels = acceptedChannels
+}
+
+// channelCache remembers the channels each user accepts during one matching
+// run, so a user with several saved searches has their preferences loaded once
+type channelCache struct {
+	channels []string
+	accepted AcceptedChannelsFunc
+	byUser   map[uuid.UUID][]string
+}
+
+func (s *Service) newChannelCache() *channelCache {
+	return &channelCache{
+		channels: s.notificationChannels,
+		accepted: s.acceptedChannels,
+		byUser:   make(map[uuid.UUID][]string),
+	}
+}
+
+// get returns the channels on which the user accepts the alert. Failed
+// lookups are not remembered and are tried again for the next search.
+func (c *channelCache) get(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	if c.accepted == nil {
+		return nil, nil
+	}
+	if channels, ok := c.byUser[userID]; ok {
+		return channels, nil
+	}
+
+	channels, err := c.accepted(ctx, userID, c.channels)
+	if err != nil {
+		return nil, err
+	}
+	c.byUser[userID] = channels
+	return channels, nil
+}
+
+// CreateSavedSearch validates and stores the user's search criteria. Paging
+// and sorting are not part of a saved search.
+func (s *Service) CreateSavedSearch(ctx context.Context, userID uuid.UUID, req *CreateSavedSearchRequest) (*SavedSearch, error) {
+	criteria := req.Criteria
+	if err := normalizeSearchRequest(&criteria); err != nil {
+		return nil, err
+	}
+	criteria.Page = 0
+	criteria.PageSize = 0
+	criteria.SortBy = ""
+	criteria.Cursor = ""
+
+	count, err := s.repo.CountSavedSearches(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxSavedSearchesPerUser {
+		return nil, ErrTooManySavedSearches
+	}
+
+	search := &SavedSearch{
+		ID:       uuid.New(),
+		UserID:   userID,
+		Name:     req.Name,
+		Criteria: criteria,
+		Notify:   req.Notify == nil || *req.Notify,
+	}
+
+	if err := s.repo.CreateSavedSearch(ctx, search); err != nil {
+		return nil, err
+	}
+	return search, nil
+}
+
+// GetSavedSearches lists the user's saved searches, newest first
+func (s *Service) GetSavedSearches(ctx context.Context, userID uuid.UUID) ([]*SavedSearch, error) {
+	return s.repo.GetSavedSearches(ctx, userID)
+}
+
+// DeleteSavedSearch removes one of the user's saved searches
+func (s *Service) DeleteSavedSearch(ctx context.Context, userID, searchID uuid.UUID) error {
+	deleted, err := s.repo.DeleteSavedSearch(ctx, userID, searchID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+// MatchSavedSearches records a notification for every product published since
+// the last run that matches a saved search with notify enabled, on the channels
+// the user enabled for new matches. It returns the number of notifications
+// recorded; a failing search is logged and skipped.
+func (s *Service) MatchSavedSearches(ctx context.Context) (int, error) {
+	searches, err := s.repo.GetNotifySavedSearches(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	until := time.Now()
+	recorded := 0
+	channels := s.newChannelCache()
+	for _, search := range searches {
+		n, err := s.matchSavedSearch(ctx, search, until, channels)
+		if err != nil {
+			logger.FromContext(ctx).Warn("failed to match saved search", "saved_search_id", search.ID, "error", err)
+			continue
+		}
+		recorded += n
+	}
+
+	return recorded, nil
+}
+
+func (s *Service) matchSavedSearch(ctx context.Context, search *SavedSearch, until time.Time, cache *channelCache) (int, error) {
+	criteria := search.Criteria
+	if err := normalizeSearchRequest(&criteria); err != nil {
+		return 0, err
+	}
+
+	productIDs, err := s.repo.MatchSavedSearch(ctx, &criteria, search.UserID, search.LastMatchedAt, until)
+	if err != nil {
+		return 0, err
+	}
+
+	var channels []string
+	if len(productIDs) > 0 {
+		channels, err = cache.get(ctx, search.UserID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Users who opted out of every channel get nothing recorded, but the
+	// search still moves past these products
+	if len(channels) == 0 {
+		productIDs = nil
+	}
+
+	return s.repo.RecordSavedSearchMatches(ctx, search, productIDs, channels, until)
+}
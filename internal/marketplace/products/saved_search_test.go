@@ -0,0 +1,80 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestChannelCacheLoadsPreferencesOncePerUser(t *testing.T) {
+	userID, otherID := uuid.New(), uuid.New()
+	lookups := map[uuid.UUID]int{}
+	accepted := func(ctx context.Context, id uuid.UUID, channels []string) ([]string, error) {
+		if !reflect.DeepEqual(channels, []string{"email", "whatsapp"}) {
+			t.Errorf("expected every configured channel to be offered, got %v", channels)
+		}
+		lookups[id]++
+		if id == userID {
+			return []string{"email"}, nil
+		}
+		return []string{}, nil
+	}
+	service := &Service{}
+	service.SetNotifier([]string{"email", "whatsapp"}, accepted)
+	cache := service.newChannelCache()
+
+	// A user with several saved searches is looked up once per run
+	for i := 0; i < 3; i++ {
+		channels, err := cache.get(context.Background(), userID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(channels, []string{"email"}) {
+			t.Errorf("expected only the enabled channel, got %v", channels)
+		}
+	}
+	channels, err := cache.get(context.Background(), otherID)
+	if err != nil || len(channels) != 0 {
+		t.Errorf("expected no channels for a user who opted out, got %v, %v", channels, err)
+	}
+
+	if lookups[userID] != 1 || lookups[otherID] != 1 {
+		t.Errorf("expected one lookup per user, got %v", lookups)
+	}
+}
+
+func TestChannelCacheWithoutNotifier(t *testing.T) {
+	cache := (&Service{notificationChannels: []string{"email"}}).newChannelCache()
+
+	channels, err := cache.get(context.Background(), uuid.New())
+	if err != nil || len(channels) != 0 {
+		t.Errorf("expected no channels without a notifier, got %v, %v", channels, err)
+	}
+}
+
+func TestChannelCacheRetriesFailedLookups(t *testing.T) {
+	lookupErr := errors.New("lookup failed")
+	calls := 0
+	accepted := func(context.Context, uuid.UUID, []string) ([]string, error) {
+		calls++
+		if calls == 1 {
+			return nil, lookupErr
+		}
+		return []string{"email"}, nil
+	}
+	service := &Service{}
+	service.SetNotifier([]string{"email"}, accepted)
+	cache := service.newChannelCache()
+	userID := uuid.New()
+
+	if _, err := cache.get(context.Background(), userID); !errors.Is(err, lookupErr) {
+		t.Errorf("expected the lookup error, got %v", err)
+	}
+	channels, err := cache.get(context.Background(), userID)
+	if err != nil || !reflect.DeepEqual(channels, []string{"email"}) {
+		t.Errorf("expected the failed lookup to be retried, got %v, %v", channels, err)
+	}
+}
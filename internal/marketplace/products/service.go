@@ -2,55 +2,226 @@ package products
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"agro-mas-backend/pkg/logger"
 	"github.com/google/uuid"
 )
 
 var (
-	ErrProductNotFound     = errors.New("product not found")
-	ErrProductNotOwnedByUser = errors.New("product not owned by user")
-	ErrInvalidCategory     = errors.New("invalid product category")
-	ErrInvalidPriceType    = errors.New("invalid price type")
-	ErrProductNotActive    = errors.New("product is not active")
+	ErrProductNotFound         = errors.New("product not found")
+	ErrProductNotOwnedByUser   = errors.New("product not owned by user")
+	ErrInvalidCategory         = errors.New("invalid product category")
+	ErrInvalidPriceType        = errors.New("invalid price type")
+	ErrProductNotActive        = errors.New("product is not active")
+	ErrDraftNotFound           = errors.New("draft not found")
+	ErrDraftIncomplete         = errors.New("draft is incomplete")
+	ErrDraftAlreadyPublished   = errors.New("draft has already been published")
+	ErrInvalidSearchRange      = errors.New("search range minimum exceeds maximum")
+	ErrInvalidTagMatch         = errors.New("tag_match must be 'any' or 'all'")
+	ErrNotBookable             = errors.New("availability slots are only supported for transport products")
+	ErrInvalidSlotRange        = errors.New("slot start date must not be after end date")
+	ErrSlotNotFound            = errors.New("availability slot not found")
+	ErrCarrierBooked           = errors.New("carrier is already booked on the requested date")
+	ErrInvalidCursor           = errors.New("invalid pagination cursor")
+	ErrInvalidImportFile       = errors.New("invalid import file")
+	ErrImportTooLarge          = errors.New("import file exceeds the maximum number of rows")
+	ErrInvalidMinOrderQuantity = errors.New("min_order_quantity must be positive and not exceed quantity")
+	ErrQuantityUnavailable     = errors.New("requested quantity is below the minimum order or exceeds stock")
+	ErrInvalidExpiry           = errors.New("expires_at must be in the future")
+	ErrInvalidGridSize         = errors.New("grid size must be between 2 and 50")
+	ErrInvalidBounds           = errors.New("north_east must be north-east of south_west")
+	ErrBoundsTooLarge          = errors.New("bounding box must not span more than 10 degrees per side")
+	ErrInvalidImageOrder       = errors.New("image order must list every product image exactly once")
+	ErrInvalidPrimaryImage     = errors.New("exactly one image must be primary")
+	ErrCoordinatesOutOfRange   = errors.New("coordinates are outside Argentina")
+	ErrInvalidSearchLanguage   = errors.New("language must be one of spanish, english, portuguese or simple")
+	ErrIncompleteBuyerLocation = errors.New("buyer_lat and buyer_lng must be given together")
+	ErrInvalidQuery            = errors.New("search query is invalid")
+	ErrProductNotDeleted       = errors.New("product is not deleted")
+	ErrRestoreWindowExpired    = errors.New("product was deleted too long ago to be restored")
+	ErrInvalidExpiryWindow     = errors.New("days must be between 1 and 365")
+	ErrSavedSearchNotFound     = errors.New("saved search not found")
+	ErrTooManySavedSearches    = errors.New("saved search limit reached")
+	ErrDetailsCategoryMismatch = errors.New("details do not match the product category")
+	ErrDetailsRequired         = errors.New("category details are required")
+	ErrSellerNotFound          = errors.New("seller not found")
 )
 
 type Service struct {
 	repo *Repository
+
+	// Seller freshness check on product responses
+	sellerStaleCheck      bool
+	sellerRatingTolerance float64
+
+	// Seller tier enrichment, computed from the live seller record
+	sellerTier SellerTierFunc
+
+	// How long soft-deleted products remain restorable
+	deletedRetention time.Duration
+
+	// Unpublish transport listings once their insurance expires
+	autoUnpublishExpiredInsurance bool
+
+	// Channels saved search alerts may use and the per-user filter of them
+	notificationChannels []string
+	acceptedChannels     AcceptedChannelsFunc
+
+	// Active listing caps per seller verification level
+	productLimits []int
+
+	// Batches view increments off the request path; nil writes them directly
+	viewCounter *ViewCounter
+}
+
+// SetViewCounter batches product view increments through counter, which
+// the caller runs and flushes
+func (s *Service) SetViewCounter(counter *ViewCounter) {
+	s.viewCounter = counter
+}
+
+// Retention window applied when none is configured
+const defaultDeletedRetention = 30 * 24 * time.Hour
+
+// DefaultCurrency prices new listings and price suggestions (Argentine Peso)
+const DefaultCurrency = "ARS"
+
+// SellerTierFunc computes a seller's trust tier from completed sales, rating,
+// verification level and account creation date
+type SellerTierFunc func(totalSales int, rating float64, verificationLevel int, since time.Time) string
+
+// SetSellerTierFunc enables seller tier enrichment on product responses
+func (s *Service) SetSellerTierFunc(fn SellerTierFunc) {
+	s.sellerTier = fn
+}
+
+// SetSellerStaleCheck enables the seller_info_stale flag on product responses.
+// Rating differences up to ratingTolerance are not considered stale.
+func (s *Service) SetSellerStaleCheck(enabled bool, ratingTolerance float64) {
+	s.sellerStaleCheck = enabled
+	s.sellerRatingTolerance = ratingTolerance
 }
 
 func NewService(repo *Repository) *Service {
 	return &Service{
-		repo: repo,
+		repo:             repo,
+		deletedRetention: defaultDeletedRetention,
+		productLimits:    DefaultProductLimits,
+	}
+}
+
+// SetDeletedRetention sets how long soft-deleted products stay restorable
+func (s *Service) SetDeletedRetention(retention time.Duration) {
+	if retention > 0 {
+		s.deletedRetention = retention
 	}
 }
 
 // CreateProduct creates a new product with validation
-func (s *Service) CreateProduct(ctx context.Context, userID uuid.UUID, req *CreateProductRequest, sellerInfo SellerInfo) (*Product, error) {
+func (s *Service) CreateProduct(ctx context.Context, userID uuid.UUID, req *CreateProductRequest) (*Product, error) {
+	return s.createProduct(ctx, uuid.New(), userID, req)
+}
+
+func (s *Service) createProduct(ctx context.Context, productID, userID uuid.UUID, req *CreateProductRequest) (*Product, error) {
+	if err := validateProductRequest(req); err != nil {
+		return nil, err
+	}
+
+	// Validate category-specific details; drafts may fill them in before publishing
+	if !req.SaveAsDraft {
+		if err := s.validateCategoryDetails(req); err != nil {
+			return nil, fmt.Errorf("category validation failed: %w", err)
+		}
+	}
+
+	sellerInfo, err := s.sellerInfo(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkProductLimit(ctx, userID, sellerInfo.VerificationLevel, 1); err != nil {
+		return nil, err
+	}
+
+	product := s.newProduct(productID, userID, req, *sellerInfo)
+
+	// Create product in database
+	if err := s.repo.CreateProduct(ctx, product); err != nil {
+		return nil, fmt.Errorf("failed to create product in database: %w", err)
+	}
+
+	s.recordEvent(ctx, product.ID, userID, ProductEventCreated, map[string]interface{}{
+		"title":    product.Title,
+		"category": product.Category,
+		"status":   product.Status,
+	})
+
+	product.ComplianceWarnings = transportComplianceWarnings(product.TransportDetails, time.Now())
+	return product, nil
+}
+
+// validateProductRequest runs the checks every listing must pass, drafts included
+func validateProductRequest(req *CreateProductRequest) error {
+	if problems := productRequestProblems(req); len(problems) > 0 {
+		return problems[0]
+	}
+	return nil
+}
+
+// productRequestProblems collects every rule a listing breaks instead of
+// stopping at the first one, so import can report them all on a row
+func productRequestProblems(req *CreateProductRequest) []error {
+	var problems []error
+
 	// Validate category
 	if !isValidCategory(req.Category) {
-		return nil, ErrInvalidCategory
+		problems = append(problems, ErrInvalidCategory)
 	}
 
 	// Validate price type
 	if !isValidPriceType(req.PriceType) {
-		return nil, ErrInvalidPriceType
+		problems = append(problems, ErrInvalidPriceType)
 	}
 
-	// Validate category-specific details
-	if err := s.validateCategoryDetails(req); err != nil {
-		return nil, fmt.Errorf("category validation failed: %w", err)
+	if err := validateMinOrderQuantity(req.MinOrderQuantity, req.Quantity); err != nil {
+		problems = append(problems, err)
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		problems = append(problems, ErrInvalidExpiry)
+	}
+
+	if req.LocationCoordinates != nil {
+		if err := ValidateCoordinates(req.LocationCoordinates.Lat, req.LocationCoordinates.Lng); err != nil {
+			problems = append(problems, err)
+		}
 	}
 
+	return problems
+}
+
+// newProduct builds a product from a validated create request
+func (s *Service) newProduct(productID, userID uuid.UUID, req *CreateProductRequest, sellerInfo SellerInfo) *Product {
 	// Generate search keywords
 	searchKeywords := s.generateSearchKeywords(req)
 
+	status := ProductStatusUnpublished
+	if req.SaveAsDraft {
+		status = ProductStatusDraft
+	}
+
 	// Create product object
 	product := &Product{
-		ID:                      uuid.New(),
+		ID:                      productID,
 		UserID:                  userID,
 		Title:                   req.Title,
 		Description:             req.Description,
@@ -58,11 +229,13 @@ func (s *Service) CreateProduct(ctx context.Context, userID uuid.UUID, req *Crea
 		Subcategory:             req.Subcategory,
 		Price:                   req.Price,
 		PriceType:               req.PriceType,
-		Currency:                "ARS", // Default to Argentine Peso
+		Currency:                DefaultCurrency,
 		Unit:                    req.Unit,
 		Quantity:                req.Quantity,
+		MinOrderQuantity:        req.MinOrderQuantity,
 		AvailableFrom:           req.AvailableFrom,
 		AvailableUntil:          req.AvailableUntil,
+		ExpiresAt:               req.ExpiresAt,
 		IsActive:                true,
 		IsFeatured:              false,
 		Province:                req.Province,
@@ -81,6 +254,7 @@ func (s *Service) CreateProduct(ctx context.Context, userID uuid.UUID, req *Crea
 		SearchKeywords:          &searchKeywords,
 		CreatedAt:               time.Now(),
 		UpdatedAt:               time.Now(),
+		Status:                  status,
 		Tags:                    req.Tags,
 	}
 
@@ -109,12 +283,7 @@ func (s *Service) CreateProduct(ctx context.Context, userID uuid.UUID, req *Crea
 		}
 	}
 
-	// Create product in database
-	if err := s.repo.CreateProduct(ctx, product); err != nil {
-		return nil, fmt.Errorf("failed to create product in database: %w", err)
-	}
-
-	return product, nil
+	return product
 }
 
 // GetProductByID retrieves a product by its ID and increments view count
@@ -129,12 +298,16 @@ func (s *Service) GetProductByID(ctx context.Context, id uuid.UUID, incrementVie
 
 	// Increment view count if requested and product is active
 	if incrementView && product.IsActive {
-		if err := s.repo.IncrementViewsCount(ctx, id); err != nil {
+		if s.viewCounter != nil {
+			s.viewCounter.Add(id)
+		} else if err := s.repo.IncrementViewsCount(ctx, id); err != nil {
 			// Log error but don't fail the request
-			fmt.Printf("Failed to increment view count for product %s: %v\n", id, err)
+			logger.FromContext(ctx).Warn("failed to increment view count", "product_id", id, "error", err)
 		}
 	}
 
+	s.enrichSeller(product)
+
 	return product, nil
 }
 
@@ -148,6 +321,19 @@ func (s *Service) SearchProducts(ctx context.Context, req *ProductSearchRequest)
 		req.PageSize = 20
 	}
 
+	if err := normalizeSearchRequest(req); err != nil {
+		return nil, err
+	}
+
+	// Relevance, price and rating sorts fall back to offset pagination
+	if req.Cursor != "" && req.usesKeyset() {
+		cursor, err := decodeSearchCursor(req.Cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		req.cursor = cursor
+	}
+
 	// Perform search
 	products, totalCount, err := s.repo.SearchProducts(ctx, req)
 	if err != nil {
@@ -160,26 +346,35 @@ func (s *Service) SearchProducts(ctx context.Context, req *ProductSearchRequest)
 	// Convert to slice of Product structs instead of pointers for response
 	productList := make([]Product, len(products))
 	for i, p := range products {
+		s.enrichSeller(p)
 		productList[i] = *p
 	}
 
-	return &ProductListResponse{
+	response := &ProductListResponse{
 		Products:   productList,
 		TotalCount: totalCount,
 		Page:       req.Page,
 		PageSize:   req.PageSize,
 		TotalPages: totalPages,
-	}, nil
+	}
+
+	// A full page may have more results after it
+	if req.usesKeyset() && len(products) == req.PageSize {
+		last := products[len(products)-1]
+		response.NextCursor = encodeSearchCursor(last.CreatedAt, last.ID)
+	}
+
+	return response, nil
 }
 
 // UpdateProduct updates an existing product
 func (s *Service) UpdateProduct(ctx context.Context, userID, productID uuid.UUID, req *UpdateProductRequest) (*Product, error) {
-	// Get existing product
+	// Get existing product. Deleted products must be restored before editing.
 	existingProduct, err := s.repo.GetProductByID(ctx, productID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
-	if existingProduct == nil {
+	if existingProduct == nil || existingProduct.DeletedAt != nil || existingProduct.Status == ProductStatusArchived {
 		return nil, ErrProductNotFound
 	}
 
@@ -188,6 +383,12 @@ func (s *Service) UpdateProduct(ctx context.Context, userID, productID uuid.UUID
 		return nil, ErrProductNotOwnedByUser
 	}
 
+	// Category is immutable, so only its own details may be replaced
+	details, err := categoryDetailsUpdate(existingProduct, req)
+	if err != nil {
+		return nil, err
+	}
+
 	// Prepare updates map
 	updates := make(map[string]interface{})
 
@@ -215,12 +416,40 @@ func (s *Service) UpdateProduct(ctx context.Context, userID, productID uuid.UUID
 	if req.Quantity != nil {
 		updates["quantity"] = *req.Quantity
 	}
+	if req.MinOrderQuantity != nil || req.Quantity != nil {
+		// Check the resulting pair, falling back to the stored values
+		minOrder, quantity := existingProduct.MinOrderQuantity, existingProduct.Quantity
+		if req.MinOrderQuantity != nil {
+			minOrder = req.MinOrderQuantity
+			updates["min_order_quantity"] = *req.MinOrderQuantity
+		}
+		if req.Quantity != nil {
+			quantity = req.Quantity
+		}
+		if err := validateMinOrderQuantity(minOrder, quantity); err != nil {
+			return nil, err
+		}
+	}
 	if req.AvailableFrom != nil {
 		updates["available_from"] = *req.AvailableFrom
 	}
 	if req.AvailableUntil != nil {
 		updates["available_until"] = *req.AvailableUntil
 	}
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			return nil, ErrInvalidExpiry
+		}
+		updates["expires_at"] = *req.ExpiresAt
+
+		// Extending an expired listing renews it, which counts against the cap
+		if !existingProduct.IsActive && existingProduct.ExpiresAt != nil && !existingProduct.ExpiresAt.After(time.Now()) {
+			if err := s.checkProductLimit(ctx, userID, sellerVerificationLevel(existingProduct), 1); err != nil {
+				return nil, err
+			}
+			updates["is_active"] = true
+		}
+	}
 	if req.Province != nil {
 		updates["province"] = *req.Province
 	}
@@ -228,6 +457,9 @@ func (s *Service) UpdateProduct(ctx context.Context, userID, productID uuid.UUID
 		updates["city"] = *req.City
 	}
 	if req.LocationCoordinates != nil {
+		if err := ValidateCoordinates(req.LocationCoordinates.Lat, req.LocationCoordinates.Lng); err != nil {
+			return nil, err
+		}
 		updates["location_coordinates"] = req.LocationCoordinates
 	}
 	if req.PickupAvailable != nil {
@@ -243,37 +475,222 @@ func (s *Service) UpdateProduct(ctx context.Context, userID, productID uuid.UUID
 		updates["tags"] = req.Tags
 	}
 
-	// Update search keywords if title or description changed
-	if req.Title != nil || req.Description != nil {
-		// Create a temporary request to generate new keywords
-		tempReq := &CreateProductRequest{
-			Title:       getStringValue(req.Title, existingProduct.Title),
-			Description: getStringPtr(req.Description, existingProduct.Description),
-			Category:    existingProduct.Category,
-			Subcategory: getStringPtr(req.Subcategory, existingProduct.Subcategory),
-			Tags:        getSliceValue(req.Tags, existingProduct.Tags),
+	// Update search keywords if title, description or details changed
+	if req.Title != nil || req.Description != nil || details != nil {
+		searchKeywords := s.generateSearchKeywords(keywordsRequest(existingProduct, req))
+		if existingProduct.SearchKeywords == nil || *existingProduct.SearchKeywords != searchKeywords {
+			updates["search_keywords"] = searchKeywords
 		}
-		searchKeywords := s.generateSearchKeywords(tempReq)
-		updates["search_keywords"] = searchKeywords
 	}
 
-	// Update product in database
-	if err := s.repo.UpdateProduct(ctx, productID, updates); err != nil {
+	// Update product in database, recording the price only when it actually changes
+	newPrice, newPriceType := existingProduct.Price, existingProduct.PriceType
+	if req.Price != nil {
+		newPrice = req.Price
+	}
+	if req.PriceType != nil {
+		newPriceType = *req.PriceType
+	}
+
+	var previous, current *PriceHistoryEntry
+	if !samePrice(existingProduct.Price, newPrice) || newPriceType != existingProduct.PriceType {
+		previous = &PriceHistoryEntry{
+			Price:     existingProduct.Price,
+			PriceType: existingProduct.PriceType,
+			ChangedAt: existingProduct.CreatedAt,
+			ChangedBy: &existingProduct.UserID,
+		}
+		current = &PriceHistoryEntry{
+			Price:     newPrice,
+			PriceType: newPriceType,
+			ChangedBy: &userID,
+		}
+	}
+
+	switch {
+	case details != nil:
+		err = s.repo.UpdateProductWithDetails(ctx, productID, updates, details, previous, current)
+	case previous != nil:
+		err = s.repo.UpdateProductWithPriceChange(ctx, productID, updates, previous, current)
+	default:
+		err = s.repo.UpdateProduct(ctx, productID, updates)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
 
+	if len(updates) > 0 || details != nil {
+		fields := make([]string, 0, len(updates)+1)
+		for field := range updates {
+			if field != "search_keywords" {
+				fields = append(fields, field)
+			}
+		}
+		if details != nil {
+			fields = append(fields, existingProduct.Category+"_details")
+		}
+		sort.Strings(fields)
+		s.recordEvent(ctx, productID, userID, ProductEventUpdated, map[string]interface{}{
+			"fields": fields,
+		})
+	}
+
 	// Return updated product
-	return s.repo.GetProductByID(ctx, productID)
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product != nil {
+		product.ComplianceWarnings = transportComplianceWarnings(product.TransportDetails, time.Now())
+	}
+	return product, nil
+}
+
+// UpdateProductDetails updates the category-specific details of a product
+// without touching its other fields. Details left out of the request keep
+// their stored values.
+func (s *Service) UpdateProductDetails(ctx context.Context, userID, productID uuid.UUID, req *UpdateProductDetailsRequest) (*Product, error) {
+	if req.TransportDetails == nil && req.LivestockDetails == nil && req.SuppliesDetails == nil {
+		return nil, ErrDetailsRequired
+	}
+
+	return s.UpdateProduct(ctx, userID, productID, &UpdateProductRequest{
+		TransportDetails: req.TransportDetails,
+		LivestockDetails: req.LivestockDetails,
+		SuppliesDetails:  req.SuppliesDetails,
+	})
+}
+
+// categoryDetailsUpdate collects the details carried by an update, merged
+// over the product's stored details, rejecting any that belong to a category
+// other than the product's. It returns nil when the update has no details.
+func categoryDetailsUpdate(product *Product, req *UpdateProductRequest) (*ProductDetailsUpdate, error) {
+	if req.TransportDetails == nil && req.LivestockDetails == nil && req.SuppliesDetails == nil {
+		return nil, nil
+	}
+
+	category := product.Category
+	if (req.TransportDetails != nil && category != "transport") ||
+		(req.LivestockDetails != nil && category != "livestock") ||
+		(req.SuppliesDetails != nil && category != "supplies") {
+		return nil, ErrDetailsCategoryMismatch
+	}
+
+	details := &ProductDetailsUpdate{}
+	if req.TransportDetails != nil {
+		details.Transport = mergeTransportDetails(product.TransportDetails, req.TransportDetails)
+	}
+	if req.LivestockDetails != nil {
+		details.Livestock = mergeLivestockDetails(product.LivestockDetails, req.LivestockDetails)
+	}
+	if req.SuppliesDetails != nil {
+		details.Supplies = mergeSuppliesDetails(product.SuppliesDetails, req.SuppliesDetails)
+	}
+	return details, nil
+}
+
+// mergeTransportDetails applies the fields set in patch over stored, which
+// may be nil when the product has no details yet
+func mergeTransportDetails(stored, patch *TransportDetails) *TransportDetails {
+	merged := &TransportDetails{}
+	if stored != nil {
+		*merged = *stored
+	}
+	merged.VehicleType = getPtr(patch.VehicleType, merged.VehicleType)
+	merged.CapacityTons = getPtr(patch.CapacityTons, merged.CapacityTons)
+	merged.CapacityCubicMeters = getPtr(patch.CapacityCubicMeters, merged.CapacityCubicMeters)
+	merged.PricePerKm = getPtr(patch.PricePerKm, merged.PricePerKm)
+	merged.HasRefrigeration = getPtr(patch.HasRefrigeration, merged.HasRefrigeration)
+	merged.HasLivestockEquipment = getPtr(patch.HasLivestockEquipment, merged.HasLivestockEquipment)
+	merged.ServiceProvinces = getSliceValue(patch.ServiceProvinces, merged.ServiceProvinces)
+	merged.MinDistanceKm = getPtr(patch.MinDistanceKm, merged.MinDistanceKm)
+	merged.MaxDistanceKm = getPtr(patch.MaxDistanceKm, merged.MaxDistanceKm)
+	merged.LicensePlate = getPtr(patch.LicensePlate, merged.LicensePlate)
+	merged.LicenseExpiry = getPtr(patch.LicenseExpiry, merged.LicenseExpiry)
+	merged.InsuranceExpiry = getPtr(patch.InsuranceExpiry, merged.InsuranceExpiry)
+	merged.VehicleYear = getPtr(patch.VehicleYear, merged.VehicleYear)
+	return merged
+}
+
+// mergeLivestockDetails applies the fields set in patch over stored, which
+// may be nil when the product has no details yet
+func mergeLivestockDetails(stored, patch *LivestockDetails) *LivestockDetails {
+	merged := &LivestockDetails{}
+	if stored != nil {
+		*merged = *stored
+	}
+	merged.AnimalType = getPtr(patch.AnimalType, merged.AnimalType)
+	merged.Breed = getPtr(patch.Breed, merged.Breed)
+	merged.AgeMonths = getPtr(patch.AgeMonths, merged.AgeMonths)
+	merged.WeightKg = getPtr(patch.WeightKg, merged.WeightKg)
+	merged.Gender = getPtr(patch.Gender, merged.Gender)
+	merged.HealthCertificates = getSliceValue(patch.HealthCertificates, merged.HealthCertificates)
+	merged.Vaccinations = getPtr(patch.Vaccinations, merged.Vaccinations)
+	merged.LastVeterinaryCheck = getPtr(patch.LastVeterinaryCheck, merged.LastVeterinaryCheck)
+	merged.IsOrganic = getPtr(patch.IsOrganic, merged.IsOrganic)
+	merged.IsPregnant = getPtr(patch.IsPregnant, merged.IsPregnant)
+	merged.BreedingHistory = getPtr(patch.BreedingHistory, merged.BreedingHistory)
+	merged.GeneticInformation = getPtr(patch.GeneticInformation, merged.GeneticInformation)
+	return merged
+}
+
+// mergeSuppliesDetails applies the fields set in patch over stored, which
+// may be nil when the product has no details yet
+func mergeSuppliesDetails(stored, patch *SuppliesDetails) *SuppliesDetails {
+	merged := &SuppliesDetails{}
+	if stored != nil {
+		*merged = *stored
+	}
+	merged.SupplyType = getPtr(patch.SupplyType, merged.SupplyType)
+	merged.Brand = getPtr(patch.Brand, merged.Brand)
+	merged.Model = getPtr(patch.Model, merged.Model)
+	merged.ActiveIngredients = getSliceValue(patch.ActiveIngredients, merged.ActiveIngredients)
+	merged.Concentration = getPtr(patch.Concentration, merged.Concentration)
+	merged.ExpiryDate = getPtr(patch.ExpiryDate, merged.ExpiryDate)
+	merged.BatchNumber = getPtr(patch.BatchNumber, merged.BatchNumber)
+	merged.RegistrationNumber = getPtr(patch.RegistrationNumber, merged.RegistrationNumber)
+	merged.RequiredLicenses = getSliceValue(patch.RequiredLicenses, merged.RequiredLicenses)
+	merged.SafetyDataSheetURL = getPtr(patch.SafetyDataSheetURL, merged.SafetyDataSheetURL)
+	merged.StorageRequirements = getPtr(patch.StorageRequirements, merged.StorageRequirements)
+	merged.HandlingInstructions = getPtr(patch.HandlingInstructions, merged.HandlingInstructions)
+	merged.DisposalInstructions = getPtr(patch.DisposalInstructions, merged.DisposalInstructions)
+	return merged
+}
+
+// keywordsRequest merges an update into the stored product so its search
+// keywords can be regenerated, details included
+func keywordsRequest(product *Product, req *UpdateProductRequest) *CreateProductRequest {
+	merged := &CreateProductRequest{
+		Title:            getStringValue(req.Title, product.Title),
+		Description:      getStringPtr(req.Description, product.Description),
+		Category:         product.Category,
+		Subcategory:      getStringPtr(req.Subcategory, product.Subcategory),
+		Tags:             getSliceValue(req.Tags, product.Tags),
+		TransportDetails: product.TransportDetails,
+		LivestockDetails: product.LivestockDetails,
+		SuppliesDetails:  product.SuppliesDetails,
+	}
+	if req.TransportDetails != nil {
+		merged.TransportDetails = mergeTransportDetails(product.TransportDetails, req.TransportDetails)
+	}
+	if req.LivestockDetails != nil {
+		merged.LivestockDetails = mergeLivestockDetails(product.LivestockDetails, req.LivestockDetails)
+	}
+	if req.SuppliesDetails != nil {
+		merged.SuppliesDetails = mergeSuppliesDetails(product.SuppliesDetails, req.SuppliesDetails)
+	}
+	return merged
 }
 
-// PublishProduct publishes a product to make it visible in searches
+// PublishProduct publishes a product to make it visible in searches. Drafts
+// must first pass the full validation skipped when they were saved.
 func (s *Service) PublishProduct(ctx context.Context, userID, productID uuid.UUID) error {
 	// Get existing product
 	existingProduct, err := s.repo.GetProductByID(ctx, productID)
 	if err != nil {
 		return fmt.Errorf("failed to get product: %w", err)
 	}
-	if existingProduct == nil {
+	if existingProduct == nil || existingProduct.Status == ProductStatusArchived {
 		return ErrProductNotFound
 	}
 
@@ -282,12 +699,25 @@ func (s *Service) PublishProduct(ctx context.Context, userID, productID uuid.UUI
 		return ErrProductNotOwnedByUser
 	}
 
+	if existingProduct.Status == ProductStatusDraft {
+		if err := s.validateDraftProduct(existingProduct); err != nil {
+			return err
+		}
+	}
+
 	// Update published_at timestamp
 	updates := map[string]interface{}{
 		"published_at": time.Now(),
+		"status":       ProductStatusPublished,
+		"sold_out_at":  nil,
+	}
+
+	if err := s.repo.UpdateProduct(ctx, productID, updates); err != nil {
+		return err
 	}
 
-	return s.repo.UpdateProduct(ctx, productID, updates)
+	s.recordEvent(ctx, productID, userID, ProductEventPublished, nil)
+	return nil
 }
 
 // UnpublishProduct unpublishes a product to hide it from searches
@@ -297,7 +727,7 @@ func (s *Service) UnpublishProduct(ctx context.Context, userID, productID uuid.U
 	if err != nil {
 		return fmt.Errorf("failed to get product: %w", err)
 	}
-	if existingProduct == nil {
+	if existingProduct == nil || existingProduct.Status == ProductStatusArchived {
 		return ErrProductNotFound
 	}
 
@@ -306,12 +736,99 @@ func (s *Service) UnpublishProduct(ctx context.Context, userID, productID uuid.U
 		return ErrProductNotOwnedByUser
 	}
 
+	// Drafts were never visible; keep them drafts so publishing still validates them
+	if existingProduct.Status == ProductStatusDraft {
+		return nil
+	}
+
 	// Set published_at to null
 	updates := map[string]interface{}{
 		"published_at": nil,
+		"status":       ProductStatusUnpublished,
+		"sold_out_at":  nil,
+	}
+
+	if err := s.repo.UpdateProduct(ctx, productID, updates); err != nil {
+		return err
+	}
+
+	s.recordEvent(ctx, productID, userID, ProductEventUnpublished, nil)
+	return nil
+}
+
+// PublishProducts publishes several of the user's products at once
+func (s *Service) PublishProducts(ctx context.Context, userID uuid.UUID, productIDs []uuid.UUID) (*BulkPublishResponse, error) {
+	return s.setProductsPublished(ctx, userID, productIDs, true)
+}
+
+// UnpublishProducts hides several of the user's products from searches at once
+func (s *Service) UnpublishProducts(ctx context.Context, userID uuid.UUID, productIDs []uuid.UUID) (*BulkPublishResponse, error) {
+	return s.setProductsPublished(ctx, userID, productIDs, false)
+}
+
+func (s *Service) setProductsPublished(ctx context.Context, userID uuid.UUID, productIDs []uuid.UUID, publish bool) (*BulkPublishResponse, error) {
+	owners, err := s.repo.GetProductOwners(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make([]uuid.UUID, 0, len(productIDs))
+	for _, id := range productIDs {
+		if owner, ok := owners[id]; ok && owner.UserID == userID && owner.Status != ProductStatusDraft {
+			owned = append(owned, id)
+		}
+	}
+
+	updated := map[uuid.UUID]bool{}
+	if len(owned) > 0 {
+		ids, err := s.repo.SetProductsPublished(ctx, userID, owned, publish)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			updated[id] = true
+		}
+	}
+
+	eventType := ProductEventUnpublished
+	if publish {
+		eventType = ProductEventPublished
+	}
+
+	response := &BulkPublishResponse{Results: make([]BulkPublishResult, 0, len(productIDs))}
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range productIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		result := BulkPublishResult{ProductID: id}
+		owner, exists := owners[id]
+		switch {
+		case updated[id]:
+			result.Success = true
+			s.recordEvent(ctx, id, userID, eventType, nil)
+		case !exists:
+			result.Error = BulkErrorNotFound
+		case owner.UserID != userID:
+			result.Error = BulkErrorNotOwned
+		case owner.Status == ProductStatusDraft:
+			result.Error = BulkErrorDraft
+		default:
+			// Removed between the ownership check and the update
+			result.Error = BulkErrorNotFound
+		}
+
+		if result.Success {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+		response.Results = append(response.Results, result)
 	}
 
-	return s.repo.UpdateProduct(ctx, productID, updates)
+	return response, nil
 }
 
 // DeleteProduct soft deletes a product
@@ -330,99 +847,424 @@ func (s *Service) DeleteProduct(ctx context.Context, userID, productID uuid.UUID
 		return ErrProductNotOwnedByUser
 	}
 
-	return s.repo.DeleteProduct(ctx, productID)
-}
-
-// GetUserProducts retrieves products belonging to a specific user
-func (s *Service) GetUserProducts(ctx context.Context, userID uuid.UUID, page, pageSize int) (*ProductListResponse, error) {
-	req := &ProductSearchRequest{
-		Page:     page,
-		PageSize: pageSize,
+	if err := s.repo.DeleteProduct(ctx, productID); err != nil {
+		return err
 	}
 
-	// Add a filter for user ownership (this would need to be implemented in repository)
-	// For now, we'll get all products and filter in memory (not efficient for production)
-	products, _, err := s.repo.SearchProducts(ctx, req)
+	s.recordEvent(ctx, productID, userID, ProductEventDeleted, nil)
+	return nil
+}
+
+// RestoreProduct undoes a soft delete. Products deleted longer than the
+// retention window ago fail with ErrRestoreWindowExpired, even before they
+// are purged; products that were already purged are reported as not found.
+func (s *Service) RestoreProduct(ctx context.Context, userID, productID uuid.UUID) error {
+	existingProduct, err := s.repo.GetProductByID(ctx, productID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user products: %w", err)
+		return fmt.Errorf("failed to get product: %w", err)
 	}
-
-	// Filter by user ID
-	userProducts := make([]*Product, 0)
-	for _, product := range products {
-		if product.UserID == userID {
-			userProducts = append(userProducts, product)
-		}
+	if existingProduct == nil {
+		return ErrProductNotFound
 	}
 
-	// Convert to response format
-	productList := make([]Product, len(userProducts))
-	for i, p := range userProducts {
-		productList[i] = *p
+	if existingProduct.UserID != userID {
+		return ErrProductNotOwnedByUser
 	}
 
-	return &ProductListResponse{
-		Products:   productList,
-		TotalCount: len(userProducts),
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: (len(userProducts) + pageSize - 1) / pageSize,
-	}, nil
-}
-
-// Helper functions
-func isValidCategory(category string) bool {
-	validCategories := []string{"transport", "livestock", "supplies"}
-	for _, valid := range validCategories {
-		if category == valid {
-			return true
-		}
+	if existingProduct.DeletedAt == nil {
+		return ErrProductNotDeleted
+	}
+	deletedAfter := time.Now().Add(-s.deletedRetention)
+	if existingProduct.DeletedAt.Before(deletedAfter) {
+		return ErrRestoreWindowExpired
 	}
-	return false
-}
 
-func isValidPriceType(priceType string) bool {
-	validTypes := []string{"fixed", "negotiable", "per_unit", "quote"}
-	for _, valid := range validTypes {
-		if priceType == valid {
-			return true
-		}
+	// A restored listing is active again, so it counts against the cap
+	if err := s.checkProductLimit(ctx, userID, sellerVerificationLevel(existingProduct), 1); err != nil {
+		return err
 	}
-	return false
-}
 
-func (s *Service) validateCategoryDetails(req *CreateProductRequest) error {
-	switch req.Category {
-	case "transport":
-		if req.TransportDetails == nil {
-			return errors.New("transport details are required for transport category")
-		}
-		// Add more specific transport validation here
-	case "livestock":
-		if req.LivestockDetails == nil {
-			return errors.New("livestock details are required for livestock category")
-		}
-		// Add more specific livestock validation here
-	case "supplies":
-		if req.SuppliesDetails == nil {
-			return errors.New("supplies details are required for supplies category")
-		}
-		// Add more specific supplies validation here
+	restored, err := s.repo.RestoreProduct(ctx, productID, deletedAfter)
+	if err != nil {
+		return err
 	}
+	if !restored {
+		return ErrProductNotDeleted
+	}
+
+	s.recordEvent(ctx, productID, userID, ProductEventRestored, nil)
 	return nil
 }
 
-func (s *Service) generateSearchKeywords(req *CreateProductRequest) string {
-	keywords := []string{
-		req.Title,
+// GetDeletedProducts lists the user's soft-deleted products that are still
+// within the retention window
+func (s *Service) GetDeletedProducts(ctx context.Context, userID uuid.UUID) ([]*DeletedProduct, error) {
+	products, err := s.repo.GetDeletedProducts(ctx, userID, time.Now().Add(-s.deletedRetention))
+	if err != nil {
+		return nil, err
 	}
 
-	if req.Description != nil {
-		keywords = append(keywords, *req.Description)
+	for _, product := range products {
+		product.RestorableUntil = product.DeletedAt.Add(s.deletedRetention)
 	}
+	return products, nil
+}
 
-	if req.Subcategory != nil {
-		keywords = append(keywords, *req.Subcategory)
+// PurgeDeletedProducts permanently removes the user's soft-deleted products
+// whose retention window has passed. Expired listings are not deleted and are
+// kept for renewal. Media files are not touched here; the caller deletes
+// result.StoragePaths from storage.
+func (s *Service) PurgeDeletedProducts(ctx context.Context, userID uuid.UUID) (*PurgeResult, error) {
+	return s.repo.PurgeDeletedProducts(ctx, userID, time.Now().Add(-s.deletedRetention))
+}
+
+// GetPriceHistory returns the chronological prices of a product. Products whose
+// price never changed report their current price since creation. Only the
+// owner sees the history of a listing that is not live.
+func (s *Service) GetPriceHistory(ctx context.Context, productID uuid.UUID, viewerID *uuid.UUID) ([]PriceHistoryEntry, error) {
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	if !product.isListed(time.Now()) && (viewerID == nil || *viewerID != product.UserID) {
+		return nil, ErrProductNotFound
+	}
+
+	history, err := s.repo.GetPriceHistory(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(history) == 0 {
+		history = append(history, PriceHistoryEntry{
+			ProductID: product.ID,
+			Price:     product.Price,
+			PriceType: product.PriceType,
+			ChangedAt: product.CreatedAt,
+			ChangedBy: &product.UserID,
+		})
+	}
+	return history, nil
+}
+
+// samePrice compares optional prices, treating two missing prices as equal
+func samePrice(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// GetProductAudit returns the chronological audit trail of a product for
+// support staff, with its recorded price changes merged in
+func (s *Service) GetProductAudit(ctx context.Context, productID uuid.UUID) (*ProductAuditResponse, error) {
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+
+	events, err := s.repo.GetProductEvents(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Listings created before event tracking have no recorded creation
+	hasCreated := false
+	for _, event := range events {
+		if event.EventType == ProductEventCreated {
+			hasCreated = true
+			break
+		}
+	}
+	if !hasCreated {
+		events = append(events, ProductEvent{
+			ProductID: product.ID,
+			ActorID:   &product.UserID,
+			EventType: ProductEventCreated,
+			CreatedAt: product.CreatedAt,
+		})
+	}
+
+	history, err := s.repo.GetPriceHistory(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProductAuditResponse{
+		ProductID: product.ID,
+		Events:    mergePriceHistory(events, history),
+	}, nil
+}
+
+// mergePriceHistory adds a price_changed event per history entry and orders
+// the trail by time
+func mergePriceHistory(events []ProductEvent, history []PriceHistoryEntry) []ProductEvent {
+	for _, entry := range history {
+		details := map[string]interface{}{"price_type": entry.PriceType}
+		if entry.Price != nil {
+			details["price"] = *entry.Price
+		}
+		events = append(events, ProductEvent{
+			ID:        entry.ID,
+			ProductID: entry.ProductID,
+			ActorID:   entry.ChangedBy,
+			EventType: ProductEventPriceChanged,
+			Details:   details,
+			CreatedAt: entry.ChangedAt,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.Before(events[j].CreatedAt)
+	})
+	return events
+}
+
+// AddFavorite saves a listed product to the user's favorites. Favoriting an
+// already saved product is a no-op.
+func (s *Service) AddFavorite(ctx context.Context, userID, productID uuid.UUID) error {
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil || !product.isListed(time.Now()) {
+		return ErrProductNotFound
+	}
+
+	_, err = s.repo.AddFavorite(ctx, userID, productID)
+	return err
+}
+
+// RemoveFavorite removes a product from the user's favorites. Removing a
+// product that is not a favorite is a no-op.
+func (s *Service) RemoveFavorite(ctx context.Context, userID, productID uuid.UUID) error {
+	_, err := s.repo.RemoveFavorite(ctx, userID, productID)
+	return err
+}
+
+// ListFavorites returns the user's saved products
+func (s *Service) ListFavorites(ctx context.Context, userID uuid.UUID, page, pageSize int) (*ProductListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	products, totalCount, err := s.repo.ListFavorites(ctx, userID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list favorites: %w", err)
+	}
+
+	productList := make([]Product, len(products))
+	for i, p := range products {
+		s.enrichSeller(p)
+		productList[i] = *p
+	}
+
+	return &ProductListResponse{
+		Products:   productList,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (totalCount + pageSize - 1) / pageSize,
+	}, nil
+}
+
+// GetProductStats returns product counts by category for the admin dashboard
+func (s *Service) GetProductStats(ctx context.Context) (*ProductStats, error) {
+	return s.repo.GetProductStats(ctx)
+}
+
+// ExpireProducts deactivates products whose expiry has passed so they drop out
+// of search. Owners still see them in their own listings and can renew them.
+func (s *Service) ExpireProducts(ctx context.Context) (int64, error) {
+	return s.repo.ExpireProducts(ctx)
+}
+
+// GetUserProducts retrieves products belonging to a specific user
+func (s *Service) GetUserProducts(ctx context.Context, userID uuid.UUID, page, pageSize int) (*ProductListResponse, error) {
+	req := &ProductSearchRequest{
+		Page:     page,
+		PageSize: pageSize,
+		ownerID:  &userID,
+	}
+
+	return s.SearchProducts(ctx, req)
+}
+
+// GetExpiringSupplies lists the seller's supplies whose expiry date falls
+// within the next days days, soonest first, so they can be discounted in time
+func (s *Service) GetExpiringSupplies(ctx context.Context, userID uuid.UUID, days, page, pageSize int) (*ProductListResponse, error) {
+	if days < 1 || days > 365 {
+		return nil, ErrInvalidExpiryWindow
+	}
+
+	today := startOfDay(time.Now())
+	until := today.AddDate(0, 0, days)
+
+	req := &ProductSearchRequest{
+		Category:       "supplies",
+		ExpiringAfter:  &today,
+		ExpiringBefore: &until,
+		SortBy:         "expiry_asc",
+		Page:           page,
+		PageSize:       pageSize,
+		ownerID:        &userID,
+	}
+
+	return s.SearchProducts(ctx, req)
+}
+
+// CreateDraft starts a listing for the creation wizard as a draft product. It
+// needs a category and price type; the rest is validated on publish.
+func (s *Service) CreateDraft(ctx context.Context, userID uuid.UUID, req *CreateProductRequest) (*Product, error) {
+	req.SaveAsDraft = true
+	return s.CreateProduct(ctx, userID, req)
+}
+
+// SaveDraft auto-saves wizard progress onto the user's draft product. Only the
+// fields set in the request change.
+func (s *Service) SaveDraft(ctx context.Context, userID, productID uuid.UUID, req *UpdateProductRequest) (*Product, error) {
+	if _, err := s.GetDraft(ctx, userID, productID); err != nil {
+		return nil, err
+	}
+
+	return s.UpdateProduct(ctx, userID, productID, req)
+}
+
+// GetDraft returns the user's draft product so the wizard can resume. Products
+// that were published in the meantime are reported as such.
+func (s *Service) GetDraft(ctx context.Context, userID, productID uuid.UUID) (*Product, error) {
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil || product.DeletedAt != nil || product.Status == ProductStatusArchived {
+		return nil, ErrDraftNotFound
+	}
+	if product.UserID != userID {
+		return nil, ErrProductNotOwnedByUser
+	}
+	if product.Status != ProductStatusDraft {
+		return nil, ErrDraftAlreadyPublished
+	}
+
+	return product, nil
+}
+
+// PublishDraft validates the user's draft product and publishes it
+func (s *Service) PublishDraft(ctx context.Context, userID, productID uuid.UUID) (*Product, error) {
+	if _, err := s.GetDraft(ctx, userID, productID); err != nil {
+		return nil, err
+	}
+
+	if err := s.PublishProduct(ctx, userID, productID); err != nil {
+		return nil, err
+	}
+
+	return s.GetProductByID(ctx, productID, false)
+}
+
+// Helper functions
+func isValidCategory(category string) bool {
+	validCategories := []string{"transport", "livestock", "supplies"}
+	for _, valid := range validCategories {
+		if category == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMinOrderQuantity checks that an optional minimum order is positive
+// and, when stock is tracked, does not exceed the available quantity
+func validateMinOrderQuantity(minOrder, quantity *int) error {
+	if minOrder == nil {
+		return nil
+	}
+	if *minOrder < 1 || (quantity != nil && *minOrder > *quantity) {
+		return ErrInvalidMinOrderQuantity
+	}
+	return nil
+}
+
+func isValidPriceType(priceType string) bool {
+	validTypes := []string{"fixed", "negotiable", "per_unit", "quote"}
+	for _, valid := range validTypes {
+		if priceType == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDraftProduct runs the create validation on a stored draft before it
+// is published
+func (s *Service) validateDraftProduct(product *Product) error {
+	if strings.TrimSpace(product.Title) == "" {
+		return fmt.Errorf("%w: title is required", ErrDraftIncomplete)
+	}
+
+	req := &CreateProductRequest{
+		Title:               product.Title,
+		Category:            product.Category,
+		PriceType:           product.PriceType,
+		Quantity:            product.Quantity,
+		MinOrderQuantity:    product.MinOrderQuantity,
+		ExpiresAt:           product.ExpiresAt,
+		LocationCoordinates: product.LocationCoordinates,
+		TransportDetails:    product.TransportDetails,
+		LivestockDetails:    product.LivestockDetails,
+		SuppliesDetails:     product.SuppliesDetails,
+	}
+	if err := validateProductRequest(req); err != nil {
+		return err
+	}
+	if err := s.validateCategoryDetails(req); err != nil {
+		return fmt.Errorf("%w: %v", ErrDraftIncomplete, err)
+	}
+	return nil
+}
+
+func (s *Service) validateCategoryDetails(req *CreateProductRequest) error {
+	switch req.Category {
+	case "transport":
+		if req.TransportDetails == nil {
+			return errors.New("transport details are required for transport category")
+		}
+		// Add more specific transport validation here
+	case "livestock":
+		if req.LivestockDetails == nil {
+			return errors.New("livestock details are required for livestock category")
+		}
+		// Add more specific livestock validation here
+	case "supplies":
+		if req.SuppliesDetails == nil {
+			return errors.New("supplies details are required for supplies category")
+		}
+		// Add more specific supplies validation here
+	}
+	return nil
+}
+
+func (s *Service) generateSearchKeywords(req *CreateProductRequest) string {
+	keywords := []string{
+		req.Title,
+	}
+
+	if req.Description != nil {
+		keywords = append(keywords, *req.Description)
+	}
+
+	if req.Subcategory != nil {
+		keywords = append(keywords, *req.Subcategory)
 	}
 
 	keywords = append(keywords, req.Category)
@@ -457,6 +1299,130 @@ func (s *Service) generateSearchKeywords(req *CreateProductRequest) string {
 	return strings.Join(keywords, " ")
 }
 
+// normalizeSearchRequest applies defaults to the filters of a search request
+// and rejects invalid ones
+func normalizeSearchRequest(req *ProductSearchRequest) error {
+	query, err := sanitizeSearchQuery(req.Query)
+	if err != nil {
+		return err
+	}
+	req.Query = query
+
+	switch req.TagMatch {
+	case "":
+		req.TagMatch = TagMatchAny
+	case TagMatchAny, TagMatchAll:
+	default:
+		return ErrInvalidTagMatch
+	}
+
+	if err := validateSearchRanges(req); err != nil {
+		return err
+	}
+
+	req.Language = strings.ToLower(strings.TrimSpace(req.Language))
+	if req.Language == "" {
+		req.Language = SearchLanguageSpanish
+	}
+	if _, ok := searchLanguageConfigs[req.Language]; !ok {
+		return ErrInvalidSearchLanguage
+	}
+
+	if (req.BuyerLat == nil) != (req.BuyerLng == nil) {
+		return ErrIncompleteBuyerLocation
+	}
+	if req.BuyerLat != nil {
+		if err := ValidateCoordinates(*req.BuyerLat, *req.BuyerLng); err != nil {
+			return err
+		}
+	}
+
+	normalizeSearchCategories(req)
+	return nil
+}
+
+// Search query bounds. Queries are cut to MaxSearchQueryLength characters;
+// raw input beyond maxSearchQueryInput bytes is rejected outright.
+const (
+	MaxSearchQueryLength = 200
+	maxSearchQueryInput  = 4096
+)
+
+// sanitizeSearchQuery normalizes free text before it reaches the text search:
+// control characters and invalid UTF-8 become spaces, whitespace runs collapse
+// to one space and the result is capped at MaxSearchQueryLength characters
+func sanitizeSearchQuery(query string) (string, error) {
+	if len(query) > maxSearchQueryInput {
+		return "", ErrInvalidQuery
+	}
+
+	query = strings.ToValidUTF8(query, " ")
+	query = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return ' '
+		}
+		return r
+	}, query)
+	query = strings.Join(strings.Fields(query), " ")
+
+	if utf8.RuneCountInString(query) > MaxSearchQueryLength {
+		query = strings.TrimSpace(string([]rune(query)[:MaxSearchQueryLength]))
+	}
+
+	return query, nil
+}
+
+// normalizeSearchCategories merges Category into Categories without duplicates.
+// Category is kept only when the search targets exactly one category, since
+// the category-specific detail filters depend on it.
+func normalizeSearchCategories(req *ProductSearchRequest) {
+	seen := make(map[string]bool)
+	categories := make([]string, 0, len(req.Categories)+1)
+	for _, category := range append([]string{req.Category}, req.Categories...) {
+		category = strings.TrimSpace(category)
+		if category == "" || seen[category] {
+			continue
+		}
+		seen[category] = true
+		categories = append(categories, category)
+	}
+
+	req.Categories = categories
+	req.Category = ""
+	if len(categories) == 1 {
+		req.Category = categories[0]
+	}
+}
+
+// encodeSearchCursor builds the opaque cursor for the given last product
+func encodeSearchCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSearchCursor(cursor string) (*searchCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	createdAtStr, idStr, found := strings.Cut(string(raw), "|")
+	if !found {
+		return nil, errors.New("malformed cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtStr)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &searchCursor{CreatedAt: createdAt, ID: id}, nil
+}
+
 // Helper types and functions
 type SellerInfo struct {
 	Name              string
@@ -465,6 +1431,19 @@ type SellerInfo struct {
 	VerificationLevel int
 }
 
+// sellerInfo loads the seller's current profile, whose verification level
+// also sets their active listing cap
+func (s *Service) sellerInfo(ctx context.Context, userID uuid.UUID) (*SellerInfo, error) {
+	info, err := s.repo.GetSellerInfo(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, ErrSellerNotFound
+	}
+	return info, nil
+}
+
 func getStringValue(ptr *string, defaultValue string) string {
 	if ptr != nil {
 		return *ptr
@@ -479,9 +1458,386 @@ func getStringPtr(ptr *string, defaultPtr *string) *string {
 	return defaultPtr
 }
 
+func getPtr[T any](ptr *T, defaultPtr *T) *T {
+	if ptr != nil {
+		return ptr
+	}
+	return defaultPtr
+}
+
 func getSliceValue(slice []string, defaultSlice []string) []string {
 	if slice != nil {
 		return slice
 	}
 	return defaultSlice
-}
\ No newline at end of file
+}
+
+// AddAvailabilitySlot adds a blocked, booked or available date range to a transport product
+func (s *Service) AddAvailabilitySlot(ctx context.Context, userID, productID uuid.UUID, req *CreateAvailabilitySlotRequest) (*AvailabilitySlot, error) {
+	product, err := s.getBookableProduct(ctx, userID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.StartDate.After(req.EndDate) {
+		return nil, ErrInvalidSlotRange
+	}
+
+	slot := &AvailabilitySlot{
+		ID:        uuid.New(),
+		ProductID: product.ID,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Status:    req.Status,
+		Note:      req.Note,
+	}
+
+	if err := s.repo.CreateAvailabilitySlot(ctx, slot); err != nil {
+		return nil, err
+	}
+
+	return slot, nil
+}
+
+// GetAvailabilitySlots returns the availability calendar of a product
+func (s *Service) GetAvailabilitySlots(ctx context.Context, productID uuid.UUID) ([]AvailabilitySlot, error) {
+	return s.repo.GetAvailabilitySlots(ctx, productID)
+}
+
+// DeleteAvailabilitySlot removes a slot from the owner's transport product
+func (s *Service) DeleteAvailabilitySlot(ctx context.Context, userID, productID, slotID uuid.UUID) error {
+	if _, err := s.getBookableProduct(ctx, userID, productID); err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.DeleteAvailabilitySlot(ctx, productID, slotID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrSlotNotFound
+	}
+
+	return nil
+}
+
+// CheckBookable fails with ErrCarrierBooked when a transport product has a
+// blocked or booked slot on date. Other categories have no calendar and are
+// always bookable. Transactions and quotes check it before taking a carrier.
+func (s *Service) CheckBookable(ctx context.Context, product *Product, date time.Time) error {
+	if product.Category != "transport" {
+		return nil
+	}
+
+	booked, err := s.repo.IsBookedOn(ctx, product.ID, date)
+	if err != nil {
+		return err
+	}
+	if booked {
+		return ErrCarrierBooked
+	}
+	return nil
+}
+
+func (s *Service) getBookableProduct(ctx context.Context, userID, productID uuid.UUID) (*Product, error) {
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	if product.UserID != userID {
+		return nil, ErrProductNotOwnedByUser
+	}
+	if product.Category != "transport" {
+		return nil, ErrNotBookable
+	}
+	return product, nil
+}
+
+// Autocomplete bounds; text shorter than MinAutocompleteLength gets no suggestions
+const (
+	MinAutocompleteLength  = 2
+	MaxAutocompleteLength  = 100
+	MaxAutocompleteResults = 10
+)
+
+// AutocompleteTitles suggests product titles for partially typed search text
+func (s *Service) AutocompleteTitles(ctx context.Context, text string, limit int) ([]*TitleSuggestion, error) {
+	text = strings.TrimSpace(text)
+	if len([]rune(text)) < MinAutocompleteLength {
+		return []*TitleSuggestion{}, nil
+	}
+	if len([]rune(text)) > MaxAutocompleteLength {
+		text = string([]rune(text)[:MaxAutocompleteLength])
+	}
+
+	if limit < 1 || limit > MaxAutocompleteResults {
+		limit = MaxAutocompleteResults
+	}
+
+	return s.repo.AutocompleteTitles(ctx, text, limit)
+}
+
+// Result limits for similar product recommendations
+const (
+	DefaultSimilarResults = 8
+	MaxSimilarResults     = 24
+)
+
+// GetSimilarProducts recommends listings like the product for buyers viewing it
+func (s *Service) GetSimilarProducts(ctx context.Context, productID uuid.UUID, limit int) ([]*SimilarProduct, error) {
+	if limit < 1 {
+		limit = DefaultSimilarResults
+	}
+	if limit > MaxSimilarResults {
+		limit = MaxSimilarResults
+	}
+
+	similar, err := s.repo.GetSimilarProducts(ctx, productID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if similar == nil {
+		return nil, ErrProductNotFound
+	}
+
+	return similar, nil
+}
+
+// EstimateTotal computes an all-in estimate for buying a quantity of a product,
+// including delivery to the buyer when the seller delivers. Taxes are not
+// modeled, so the total is the subtotal plus delivery. Negotiable and quote
+// listings get no totals, only the flag to contact the seller.
+func (s *Service) EstimateTotal(ctx context.Context, productID uuid.UUID, req *EstimateRequest) (*EstimateResponse, error) {
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil || !product.IsActive || product.PublishedAt == nil {
+		return nil, ErrProductNotFound
+	}
+
+	if (product.MinOrderQuantity != nil && req.Quantity < *product.MinOrderQuantity) ||
+		(product.Quantity != nil && req.Quantity > *product.Quantity) {
+		return nil, ErrQuantityUnavailable
+	}
+
+	estimate := &EstimateResponse{
+		ProductID:  product.ID,
+		Quantity:   req.Quantity,
+		Currency:   product.Currency,
+		PriceType:  product.PriceType,
+		Negotiable: product.PriceType == "negotiable",
+	}
+	if product.PriceType == "per_unit" {
+		estimate.UnitPrice = product.Price
+	}
+
+	subtotal := estimateSubtotal(product, req.Quantity)
+	if subtotal == nil {
+		estimate.ContactForPrice = true
+		return estimate, nil
+	}
+	estimate.Subtotal = subtotal
+	total := *subtotal
+
+	if err := s.estimateDelivery(ctx, product, req, estimate); err != nil {
+		return nil, err
+	}
+	if estimate.DeliveryCost != nil {
+		total = roundCurrency(total + *estimate.DeliveryCost)
+	}
+	estimate.Total = &total
+
+	return estimate, nil
+}
+
+// estimateSubtotal prices a quantity of the product by its price type: a
+// fixed price is for the whole listing and a per-unit price is multiplied by
+// the quantity. Negotiable and quote listings, and listings without a price,
+// have no subtotal.
+func estimateSubtotal(product *Product, quantity int) *float64 {
+	if product.Price == nil {
+		return nil
+	}
+
+	var subtotal float64
+	switch product.PriceType {
+	case "fixed":
+		subtotal = roundCurrency(*product.Price)
+	case "per_unit":
+		subtotal = roundCurrency(*product.Price * float64(quantity))
+	default:
+		return nil
+	}
+	return &subtotal
+}
+
+// estimateDelivery fills the delivery part of an estimate. Transport listings
+// use their own per-km rate; other products use the cheapest matching carrier.
+func (s *Service) estimateDelivery(ctx context.Context, product *Product, req *EstimateRequest, estimate *EstimateResponse) error {
+	switch {
+	case !product.DeliveryAvailable:
+		estimate.DeliveryNote = "delivery not offered, pickup only"
+		return nil
+	case req.BuyerCoordinates == nil || product.LocationCoordinates == nil:
+		estimate.DeliveryNote = "delivery cost requires buyer and product locations"
+		return nil
+	}
+
+	distance := roundCurrency(haversineKm(*product.LocationCoordinates, *req.BuyerCoordinates))
+	estimate.DistanceKm = &distance
+
+	if product.DeliveryRadius != nil && distance > float64(*product.DeliveryRadius) {
+		estimate.DeliveryNote = "buyer is outside the seller's delivery radius"
+		return nil
+	}
+
+	var rate *float64
+	if product.TransportDetails != nil && product.TransportDetails.PricePerKm != nil {
+		rate = product.TransportDetails.PricePerKm
+	} else {
+		province := ""
+		if req.BuyerProvince != nil {
+			province = *req.BuyerProvince
+		}
+		matched, err := s.repo.GetCheapestTransportRate(ctx, province)
+		if err != nil {
+			return err
+		}
+		rate = matched
+	}
+
+	if rate == nil {
+		estimate.DeliveryNote = "no per-km delivery rate available, contact the seller"
+		return nil
+	}
+
+	cost := roundCurrency(distance * *rate)
+	estimate.DeliveryCost = &cost
+	return nil
+}
+
+func roundCurrency(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// MinPriceComparables is the sample size below which a price suggestion is low confidence
+const MinPriceComparables = 5
+
+// SuggestPrice returns the median and interquartile price range of comparable listings
+func (s *Service) SuggestPrice(ctx context.Context, req *PriceSuggestionRequest) (*PriceSuggestionResponse, error) {
+	if !isValidCategory(req.Category) {
+		return nil, ErrInvalidCategory
+	}
+	if req.PriceType != "" && !isValidPriceType(req.PriceType) {
+		return nil, ErrInvalidPriceType
+	}
+	if req.Currency == "" {
+		req.Currency = DefaultCurrency
+	}
+	req.Currency = strings.ToUpper(req.Currency)
+
+	suggestion, err := s.repo.GetPriceSuggestion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestion.LowConfidence = suggestion.SampleSize < MinPriceComparables
+	return suggestion, nil
+}
+
+// Comparable search bounds for price discovery
+const (
+	ComparableRadiusKm   = 100
+	MaxComparableResults = 20
+)
+
+// GetComparableProducts returns active listings similar to the product, so
+// buyers and sellers can judge its price. The product must be listed unless
+// the viewer owns it. The viewer's own listings are excluded when viewerID
+// is set.
+func (s *Service) GetComparableProducts(ctx context.Context, productID uuid.UUID, viewerID *uuid.UUID) (*ComparableProductsResponse, error) {
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	if !product.isListed(time.Now()) && (viewerID == nil || *viewerID != product.UserID) {
+		return nil, ErrProductNotFound
+	}
+
+	comparables, stats, err := s.repo.GetComparableProducts(ctx, product, viewerID, ComparableRadiusKm, MaxComparableResults)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, comparable := range comparables {
+		s.enrichSeller(comparable)
+	}
+
+	response := &ComparableProductsResponse{
+		Products: comparables,
+		Stats:    *stats,
+	}
+	if product.LocationCoordinates != nil {
+		radiusKm := float64(ComparableRadiusKm)
+		response.RadiusKm = &radiusKm
+	} else {
+		response.Province = product.Province
+	}
+
+	return response, nil
+}
+
+// enrichSeller adds the seller freshness flag and tier from the live seller record
+func (s *Service) enrichSeller(product *Product) {
+	if s.sellerStaleCheck {
+		product.checkSellerFreshness(s.sellerRatingTolerance)
+	}
+
+	if s.sellerTier != nil && product.liveSellerSince != nil {
+		totalSales, rating, level := 0, 0.0, 0
+		if product.liveSellerTotalSales != nil {
+			totalSales = *product.liveSellerTotalSales
+		}
+		if product.liveSellerRating != nil {
+			rating = *product.liveSellerRating
+		}
+		if product.liveSellerVerificationLevel != nil {
+			level = *product.liveSellerVerificationLevel
+		}
+		product.SellerTier = s.sellerTier(totalSales, rating, level, *product.liveSellerSince)
+	}
+}
+
+// recordEvent stores an audit event; failures are logged and never fail the caller
+func (s *Service) recordEvent(ctx context.Context, productID, actorID uuid.UUID, eventType string, details map[string]interface{}) {
+	event := &ProductEvent{
+		ProductID: productID,
+		ActorID:   &actorID,
+		EventType: eventType,
+		Details:   details,
+	}
+	if err := s.repo.RecordProductEvent(ctx, event); err != nil {
+		logger.FromContext(ctx).Warn("failed to record product event", "event_type", eventType, "product_id", productID, "error", err)
+	}
+}
+
+// validateSearchRanges rejects min/max filter pairs where min exceeds max
+func validateSearchRanges(req *ProductSearchRequest) error {
+	if req.MinAgeMonths != nil && req.MaxAgeMonths != nil && *req.MinAgeMonths > *req.MaxAgeMonths {
+		return ErrInvalidSearchRange
+	}
+	if req.MinWeightKg != nil && req.MaxWeightKg != nil && *req.MinWeightKg > *req.MaxWeightKg {
+		return ErrInvalidSearchRange
+	}
+	if req.ExpiringAfter != nil && req.ExpiringBefore != nil && req.ExpiringAfter.After(*req.ExpiringBefore) {
+		return ErrInvalidSearchRange
+	}
+	return nil
+}
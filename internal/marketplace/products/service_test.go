@@ -0,0 +1,361 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestCategoryDetailsUpdate(t *testing.T) {
+	tests := []struct {
+		name     string
+		category string
+		req      *UpdateProductRequest
+		wantErr  error
+	}{
+		{"transport", "transport", &UpdateProductRequest{TransportDetails: &TransportDetails{VehicleType: strPtr("camion")}}, nil},
+		{"livestock", "livestock", &UpdateProductRequest{LivestockDetails: &LivestockDetails{Breed: strPtr("angus")}}, nil},
+		{"supplies", "supplies", &UpdateProductRequest{SuppliesDetails: &SuppliesDetails{Brand: strPtr("bayer")}}, nil},
+		{"transport on livestock", "livestock", &UpdateProductRequest{TransportDetails: &TransportDetails{}}, ErrDetailsCategoryMismatch},
+		{"livestock on supplies", "supplies", &UpdateProductRequest{LivestockDetails: &LivestockDetails{}}, ErrDetailsCategoryMismatch},
+		{"supplies on transport", "transport", &UpdateProductRequest{SuppliesDetails: &SuppliesDetails{}}, ErrDetailsCategoryMismatch},
+		{"mixed", "transport", &UpdateProductRequest{TransportDetails: &TransportDetails{}, SuppliesDetails: &SuppliesDetails{}}, ErrDetailsCategoryMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details, err := categoryDetailsUpdate(&Product{Category: tt.category}, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && details == nil {
+				t.Fatal("expected details to upsert")
+			}
+		})
+	}
+
+	details, err := categoryDetailsUpdate(&Product{Category: "transport"}, &UpdateProductRequest{Title: strPtr("Camion")})
+	if err != nil || details != nil {
+		t.Errorf("expected no details for a plain update, got %v, %v", details, err)
+	}
+}
+
+func TestKeywordsRequestUsesUpdatedDetails(t *testing.T) {
+	s := &Service{}
+	tests := []struct {
+		name    string
+		product *Product
+		req     *UpdateProductRequest
+		want    string
+		stale   string
+	}{
+		{
+			name: "transport",
+			product: &Product{Title: "Flete", Category: "transport",
+				TransportDetails: &TransportDetails{VehicleType: strPtr("camioneta")}},
+			req:   &UpdateProductRequest{TransportDetails: &TransportDetails{VehicleType: strPtr("jaula")}},
+			want:  "jaula",
+			stale: "camioneta",
+		},
+		{
+			name: "livestock",
+			product: &Product{Title: "Novillos", Category: "livestock",
+				LivestockDetails: &LivestockDetails{AnimalType: strPtr("bovino"), Breed: strPtr("hereford")}},
+			req:   &UpdateProductRequest{LivestockDetails: &LivestockDetails{AnimalType: strPtr("bovino"), Breed: strPtr("angus")}},
+			want:  "angus",
+			stale: "hereford",
+		},
+		{
+			name: "supplies",
+			product: &Product{Title: "Glifosato", Category: "supplies",
+				SuppliesDetails: &SuppliesDetails{SupplyType: strPtr("herbicida"), Brand: strPtr("atanor")}},
+			req:   &UpdateProductRequest{SuppliesDetails: &SuppliesDetails{SupplyType: strPtr("herbicida"), Brand: strPtr("bayer")}},
+			want:  "bayer",
+			stale: "atanor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keywords := s.generateSearchKeywords(keywordsRequest(tt.product, tt.req))
+			if !strings.Contains(keywords, tt.want) {
+				t.Errorf("expected keywords %q to contain %q", keywords, tt.want)
+			}
+			if strings.Contains(keywords, tt.stale) {
+				t.Errorf("expected keywords %q to drop %q", keywords, tt.stale)
+			}
+		})
+	}
+}
+
+func TestKeywordsRequestKeepsStoredDetails(t *testing.T) {
+	product := &Product{Title: "Flete", Category: "transport",
+		TransportDetails: &TransportDetails{VehicleType: strPtr("camioneta")}}
+
+	keywords := (&Service{}).generateSearchKeywords(keywordsRequest(product, &UpdateProductRequest{Title: strPtr("Flete refrigerado")}))
+	if !strings.Contains(keywords, "Flete refrigerado") || !strings.Contains(keywords, "camioneta") {
+		t.Errorf("expected new title and stored vehicle type in %q", keywords)
+	}
+}
+
+func TestSanitizeSearchQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"plain", "tractor usado", "tractor usado"},
+		{"whitespace", "  tractor \t\n usado  ", "tractor usado"},
+		{"punctuation", `"camion" -jaula OR (soja) & 'trigo' \ | ! : *`, `"camion" -jaula OR (soja) & 'trigo' \ | ! : *`},
+		{"control characters", "soja\x00\x07trigo\x1b", "soja trigo"},
+		{"invalid utf8", "ma\xffiz", "ma iz"},
+		{"emoji", "🚜 tractor 🐄", "🚜 tractor 🐄"},
+		{"only spaces", " \t ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sanitizeSearchQuery(tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSanitizeSearchQueryLength(t *testing.T) {
+	got, err := sanitizeSearchQuery(strings.Repeat("ñandú ", 100))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len([]rune(got)); n > MaxSearchQueryLength {
+		t.Errorf("expected at most %d characters, got %d", MaxSearchQueryLength, n)
+	}
+	if strings.HasSuffix(got, " ") {
+		t.Errorf("expected no trailing space after truncation, got %q", got)
+	}
+
+	if _, err := sanitizeSearchQuery(strings.Repeat("a", maxSearchQueryInput+1)); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery for oversized input, got %v", err)
+	}
+}
+
+func TestNormalizeSearchRequestSanitizesQuery(t *testing.T) {
+	req := &ProductSearchRequest{Query: "  soja\x00  trigo "}
+	if err := normalizeSearchRequest(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Query != "soja trigo" {
+		t.Errorf("expected sanitized query, got %q", req.Query)
+	}
+}
+
+func TestProductRequestProblemsCollectsAll(t *testing.T) {
+	minOrder := 0
+	req := &CreateProductRequest{Category: "unknown", PriceType: "barter", MinOrderQuantity: &minOrder}
+
+	problems := productRequestProblems(req)
+	want := []error{ErrInvalidCategory, ErrInvalidPriceType, ErrInvalidMinOrderQuantity}
+	if len(problems) != len(want) {
+		t.Fatalf("expected %d problems, got %v", len(want), problems)
+	}
+	for i, err := range want {
+		if !errors.Is(problems[i], err) {
+			t.Errorf("problem %d: expected %v, got %v", i, err, problems[i])
+		}
+	}
+
+	if err := validateProductRequest(req); !errors.Is(err, ErrInvalidCategory) {
+		t.Errorf("expected the first problem from validateProductRequest, got %v", err)
+	}
+}
+
+func TestProductIsListed(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		product Product
+		want    bool
+	}{
+		{"published", Product{IsActive: true, Status: ProductStatusPublished, PublishedAt: &past}, true},
+		{"published with future expiry", Product{IsActive: true, Status: ProductStatusPublished, PublishedAt: &past, ExpiresAt: &future}, true},
+		{"expired", Product{IsActive: true, Status: ProductStatusPublished, PublishedAt: &past, ExpiresAt: &past}, false},
+		{"draft", Product{IsActive: true, Status: ProductStatusDraft}, false},
+		{"unpublished", Product{IsActive: true, Status: ProductStatusUnpublished, PublishedAt: &past}, false},
+		{"inactive", Product{Status: ProductStatusPublished, PublishedAt: &past}, false},
+		{"deleted", Product{IsActive: true, Status: ProductStatusPublished, PublishedAt: &past, DeletedAt: &past}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.product.isListed(now); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEstimateSubtotalByPriceType(t *testing.T) {
+	price := 1500.0
+
+	tests := []struct {
+		name      string
+		priceType string
+		price     *float64
+		want      *float64
+	}{
+		{"fixed price is the whole listing", "fixed", &price, floatPtr(1500)},
+		{"per unit price times quantity", "per_unit", &price, floatPtr(6000)},
+		{"negotiable has no estimate", "negotiable", &price, nil},
+		{"quote has no estimate", "quote", nil, nil},
+		{"missing price", "per_unit", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := estimateSubtotal(&Product{PriceType: tt.priceType, Price: tt.price}, 4)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestCategoryDetailsUpdateKeepsStoredFields(t *testing.T) {
+	refrigerated := true
+	product := &Product{Category: "transport", TransportDetails: &TransportDetails{
+		VehicleType:      strPtr("camion"),
+		LicensePlate:     strPtr("AB123CD"),
+		HasRefrigeration: &refrigerated,
+	}}
+
+	details, err := categoryDetailsUpdate(product, &UpdateProductRequest{
+		TransportDetails: &TransportDetails{VehicleType: strPtr("jaula")},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := details.Transport
+	if got.VehicleType == nil || *got.VehicleType != "jaula" {
+		t.Errorf("expected the patched vehicle type, got %v", got.VehicleType)
+	}
+	if got.LicensePlate == nil || *got.LicensePlate != "AB123CD" {
+		t.Errorf("expected the stored license plate to be kept, got %v", got.LicensePlate)
+	}
+	if got.HasRefrigeration == nil || !*got.HasRefrigeration {
+		t.Errorf("expected the stored refrigeration flag to be kept, got %v", got.HasRefrigeration)
+	}
+	if *product.TransportDetails.VehicleType != "camion" {
+		t.Error("expected the stored details to be left untouched")
+	}
+}
+
+func TestMergePriceHistoryOrdersTimeline(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	events := []ProductEvent{
+		{EventType: ProductEventCreated, CreatedAt: start},
+		{EventType: ProductEventPublished, CreatedAt: start.Add(2 * time.Hour)},
+	}
+	history := []PriceHistoryEntry{
+		{Price: floatPtr(1000), PriceType: "fixed", ChangedAt: start},
+		{Price: floatPtr(900), PriceType: "fixed", ChangedAt: start.Add(time.Hour)},
+		{PriceType: "quote", ChangedAt: start.Add(3 * time.Hour)},
+	}
+
+	merged := mergePriceHistory(events, history)
+
+	want := []string{ProductEventCreated, ProductEventPriceChanged, ProductEventPriceChanged, ProductEventPublished, ProductEventPriceChanged}
+	if len(merged) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(merged))
+	}
+	for i, eventType := range want {
+		if merged[i].EventType != eventType {
+			t.Errorf("event %d: expected %s, got %s", i, eventType, merged[i].EventType)
+		}
+	}
+	if merged[2].Details["price"] != 900.0 {
+		t.Errorf("expected the price change to carry the new price, got %v", merged[2].Details)
+	}
+	if _, ok := merged[4].Details["price"]; ok {
+		t.Errorf("expected no price on a switch to quote, got %v", merged[4].Details)
+	}
+}
+
+func TestDraftEndpointsCheckOwnership(t *testing.T) {
+	db := testDB(t)
+	service := NewService(NewRepository(db))
+	ctx := context.Background()
+	ownerID := createTestSeller(t, db)
+	otherID := createTestSeller(t, db)
+
+	draft, err := service.CreateDraft(ctx, ownerID, &CreateProductRequest{
+		Title:     "Novillos Angus",
+		Category:  "livestock",
+		PriceType: "fixed",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if draft.Status != ProductStatusDraft {
+		t.Fatalf("expected a draft product, got status %s", draft.Status)
+	}
+
+	title := "Vaquillonas"
+	if _, err := service.GetDraft(ctx, otherID, draft.ID); err != ErrProductNotOwnedByUser {
+		t.Errorf("expected another seller to be refused the draft, got %v", err)
+	}
+	if _, err := service.SaveDraft(ctx, otherID, draft.ID, &UpdateProductRequest{Title: &title}); err != ErrProductNotOwnedByUser {
+		t.Errorf("expected another seller to be refused saving the draft, got %v", err)
+	}
+	if _, err := service.PublishDraft(ctx, otherID, draft.ID); err != ErrProductNotOwnedByUser {
+		t.Errorf("expected another seller to be refused publishing the draft, got %v", err)
+	}
+
+	saved, err := service.SaveDraft(ctx, ownerID, draft.ID, &UpdateProductRequest{Title: &title})
+	if err != nil || saved.Title != title || saved.Status != ProductStatusDraft {
+		t.Errorf("expected the owner to save the draft, got %+v, %v", saved, err)
+	}
+}
+
+func TestGetPriceHistoryHidesUnlistedProducts(t *testing.T) {
+	db := testDB(t)
+	repo := NewRepository(db)
+	service := NewService(repo)
+	ctx := context.Background()
+	ownerID := createTestSeller(t, db)
+	otherID := createTestSeller(t, db)
+
+	listed := createTestProduct(t, repo, ownerID, nil)
+	draft := createTestProduct(t, repo, ownerID, func(p *Product) {
+		p.Status = ProductStatusDraft
+		p.PublishedAt = nil
+	})
+
+	if history, err := service.GetPriceHistory(ctx, listed.ID, nil); err != nil || len(history) == 0 {
+		t.Errorf("expected a listed product's history to be public, got %v, %v", history, err)
+	}
+	if _, err := service.GetPriceHistory(ctx, draft.ID, nil); err != ErrProductNotFound {
+		t.Errorf("expected an anonymous viewer to be refused a draft's history, got %v", err)
+	}
+	if _, err := service.GetPriceHistory(ctx, draft.ID, &otherID); err != ErrProductNotFound {
+		t.Errorf("expected another seller to be refused a draft's history, got %v", err)
+	}
+	if history, err := service.GetPriceHistory(ctx, draft.ID, &ownerID); err != nil || len(history) == 0 {
+		t.Errorf("expected the owner to see the draft's history, got %v, %v", history, err)
+	}
+}
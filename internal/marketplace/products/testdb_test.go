@@ -0,0 +1,95 @@
+package products
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"agro-mas-backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// testDB connects to the PostgreSQL database named by TEST_DATABASE_URL and
+// applies the migrations, for tests that must run the real queries. The test
+// is skipped when the variable is unset.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := storage.NewDatabase(url, storage.PoolConfig{MaxOpenConns: 4, MaxIdleConns: 2})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrations, err := filepath.Abs("../../../migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RunMigrations(migrations); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	return db.GetDB()
+}
+
+// createTestSeller inserts a seller removed with its products when the test ends
+func createTestSeller(t *testing.T, db *sql.DB) uuid.UUID {
+	t.Helper()
+
+	id := uuid.New()
+	_, err := db.Exec(`
+		INSERT INTO users (id, email, password_hash, first_name, last_name, phone, role, verification_level)
+		VALUES ($1, $2, '', 'Test', 'Seller', '1155550000', 'seller', 1)`, id, id.String()+"@test.local")
+	if err != nil {
+		t.Fatalf("failed to create test seller: %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM users WHERE id = $1`, id) })
+
+	return id
+}
+
+// createTestProduct stores a published livestock listing of the seller after
+// applying mutate
+func createTestProduct(t *testing.T, repo *Repository, sellerID uuid.UUID, mutate func(*Product)) *Product {
+	t.Helper()
+
+	price := 1000.0
+	publishedAt := time.Now().Add(-time.Hour)
+	product := &Product{
+		ID:          uuid.New(),
+		UserID:      sellerID,
+		Title:       "Novillos Angus",
+		Category:    "livestock",
+		Price:       &price,
+		PriceType:   "fixed",
+		Currency:    DefaultCurrency,
+		IsActive:    true,
+		SellerName:  strPtr("Test Seller"),
+		Status:      ProductStatusPublished,
+		PublishedAt: &publishedAt,
+	}
+	if mutate != nil {
+		mutate(product)
+	}
+
+	ctx := context.Background()
+	if err := repo.CreateProduct(ctx, product); err != nil {
+		t.Fatalf("failed to create test product: %v", err)
+	}
+	// The insert leaves published_at to the publish flow
+	if product.PublishedAt != nil {
+		if err := repo.UpdateProduct(ctx, product.ID, map[string]interface{}{"published_at": *product.PublishedAt}); err != nil {
+			t.Fatalf("failed to publish test product: %v", err)
+		}
+	}
+
+	return product
+}
@@ -0,0 +1,106 @@
+package products
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"agro-mas-backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// DefaultViewFlushThreshold is how many views may accumulate before an early flush
+const DefaultViewFlushThreshold = 1000
+
+// ViewCounter batches product view increments in memory so the read path
+// never writes. Run flushes them periodically, or early once threshold views
+// are pending; call Flush once more on shutdown so none are lost.
+type ViewCounter struct {
+	repo      *Repository
+	threshold int
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]int
+	total   int
+
+	// Signals Run that the threshold was reached
+	full chan struct{}
+}
+
+func NewViewCounter(repo *Repository, threshold int) *ViewCounter {
+	if threshold <= 0 {
+		threshold = DefaultViewFlushThreshold
+	}
+	return &ViewCounter{
+		repo:      repo,
+		threshold: threshold,
+		pending:   make(map[uuid.UUID]int),
+		full:      make(chan struct{}, 1),
+	}
+}
+
+// Add counts one view of a product without blocking on the database
+func (v *ViewCounter) Add(productID uuid.UUID) {
+	v.mu.Lock()
+	v.pending[productID]++
+	v.total++
+	full := v.total >= v.threshold
+	v.mu.Unlock()
+
+	if full {
+		select {
+		case v.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush writes the pending views, one increment per product. Views that
+// fail to write are put back for the next flush.
+func (v *ViewCounter) Flush(ctx context.Context) error {
+	v.mu.Lock()
+	if len(v.pending) == 0 {
+		v.mu.Unlock()
+		return nil
+	}
+	batch := v.pending
+	v.pending = make(map[uuid.UUID]int)
+	v.total = 0
+	v.mu.Unlock()
+
+	if err := v.repo.AddViewsCounts(ctx, batch); err != nil {
+		v.mu.Lock()
+		for productID, delta := range batch {
+			v.pending[productID] += delta
+			v.total += delta
+		}
+		v.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Run flushes every interval, or sooner when the threshold is reached, until
+// ctx is cancelled. It does not flush on exit: requests may still be adding
+// views then, so the caller flushes after the server has stopped.
+func (v *ViewCounter) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-v.full:
+		}
+
+		if err := v.Flush(ctx); err != nil {
+			logger.FromContext(ctx).Error("failed to flush product views", "error", err)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transaction event types delivered to webhooks
+const (
+	EventStatusChanged = "transaction.status_changed"
+	EventReviewAdded   = "transaction.review_added"
+)
+
+// TransactionEvent is an outbox entry describing a change to a transaction.
+// It is written alongside the change and fanned out to the webhooks of both
+// parties.
+type TransactionEvent struct {
+	Type          string
+	TransactionID uuid.UUID
+	BuyerID       uuid.UUID
+	SellerID      uuid.UUID
+	Payload       map[string]interface{}
+}
+
+// newStatusEvent reports a transaction moving from its current status to status
+func newStatusEvent(transaction *Transaction, status string) *TransactionEvent {
+	return &TransactionEvent{
+		Type:          EventStatusChanged,
+		TransactionID: transaction.ID,
+		BuyerID:       transaction.BuyerID,
+		SellerID:      transaction.SellerID,
+		Payload: map[string]interface{}{
+			"transaction_id":  transaction.ID,
+			"reference":       transaction.Reference,
+			"product_id":      transaction.ProductID,
+			"buyer_id":        transaction.BuyerID,
+			"seller_id":       transaction.SellerID,
+			"previous_status": transaction.Status,
+			"status":          status,
+			"occurred_at":     time.Now(),
+		},
+	}
+}
+
+// newReviewEvent reports a review left by one party of the transaction
+func newReviewEvent(transaction *Transaction, reviewerID, reviewedUserID uuid.UUID, rating int) *TransactionEvent {
+	return &TransactionEvent{
+		Type:          EventReviewAdded,
+		TransactionID: transaction.ID,
+		BuyerID:       transaction.BuyerID,
+		SellerID:      transaction.SellerID,
+		Payload: map[string]interface{}{
+			"transaction_id":   transaction.ID,
+			"reference":        transaction.Reference,
+			"reviewer_id":      reviewerID,
+			"reviewed_user_id": reviewedUserID,
+			"rating":           rating,
+			"occurred_at":      time.Now(),
+		},
+	}
+}
+
+// insertEvent writes one outbox row per webhook registered by either party.
+// Run it in the database transaction of the change so neither is lost.
+func insertEvent(ctx context.Context, db execer, event *TransactionEvent) error {
+	if event == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event payload: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO transaction_events (transaction_id, webhook_id, event_type, payload)
+		SELECT $1, w.id, $2, $3 FROM user_webhooks w
+		WHERE w.user_id IN ($4, $5)`,
+		event.TransactionID, event.Type, payload, event.BuyerID, event.SellerID)
+	if err != nil {
+		return fmt.Errorf("failed to record transaction event: %w", err)
+	}
+	return nil
+}
+
+// WebhookDelivery is an outbox row claimed for delivery to its webhook
+type WebhookDelivery struct {
+	ID            uuid.UUID
+	TransactionID uuid.UUID
+	EventType     string
+	Payload       json.RawMessage
+	Attempts      int
+	CreatedAt     time.Time
+	URL           string
+	Secret        string
+}
+
+// ClaimWebhookDeliveries returns up to limit undelivered events that are due
+// and have attempts left, oldest first. Claimed rows are leased for lease so
+// other instances skip them while they are being sent.
+func (r *Repository) ClaimWebhookDeliveries(ctx context.Context, limit, maxAttempts int, lease time.Duration) ([]*WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE transaction_events e
+		SET next_attempt_at = NOW() + $3 * INTERVAL '1 second'
+		FROM user_webhooks w
+		WHERE w.id = e.webhook_id AND e.id IN (
+			SELECT id FROM transaction_events
+			WHERE delivered = false AND attempts < $2 AND next_attempt_at <= NOW()
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING e.id, e.transaction_id, e.event_type, e.payload, e.attempts,
+				  e.created_at, w.url, w.secret`, limit, maxAttempts, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*WebhookDelivery
+	for rows.Next() {
+		delivery := &WebhookDelivery{}
+		if err := rows.Scan(&delivery.ID, &delivery.TransactionID, &delivery.EventType,
+			&delivery.Payload, &delivery.Attempts, &delivery.CreatedAt,
+			&delivery.URL, &delivery.Secret); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// MarkWebhookDelivered records a successful delivery
+func (r *Repository) MarkWebhookDelivered(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE transaction_events
+		SET delivered = true, delivered_at = NOW(), attempts = attempts + 1, last_error = NULL
+		WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivered: %w", err)
+	}
+	return nil
+}
+
+// MarkWebhookFailed records a failed attempt and schedules the next one after retryIn
+func (r *Repository) MarkWebhookFailed(ctx context.Context, id uuid.UUID, reason string, retryIn time.Duration) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE transaction_events
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = NOW() + $3 * INTERVAL '1 second'
+		WHERE id = $1`, id, reason, retryIn.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to record webhook failure: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package transactions
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+)
+
+// fakeDB is a minimal database/sql driver that records the statements it is
+// sent and answers queries from a callback, for checking what a repository
+// method runs inside its transaction
+type fakeDB struct {
+	mu         sync.Mutex
+	statements []string
+	committed  bool
+
+	// rows answers a query with its columns and values; nil means no rows
+	rows func(query string, args []driver.Value) ([]string, [][]driver.Value)
+}
+
+func newFakeDB(rows func(query string, args []driver.Value) ([]string, [][]driver.Value)) (*fakeDB, *sql.DB) {
+	fake := &fakeDB{rows: rows}
+	return fake, sql.OpenDB(fake)
+}
+
+// executed reports whether a statement containing fragment was run
+func (f *fakeDB) executed(fragment string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, statement := range f.statements {
+		if strings.Contains(statement, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeDB) record(query string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statements = append(f.statements, query)
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return &fakeTx{db: c.db}, nil }
+
+type fakeTx struct{ db *fakeDB }
+
+func (t *fakeTx) Commit() error {
+	t.db.mu.Lock()
+	defer t.db.mu.Unlock()
+	t.db.committed = true
+	return nil
+}
+func (t *fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.record(s.query)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.record(s.query)
+	var columns []string
+	var values [][]driver.Value
+	if s.db.rows != nil {
+		columns, values = s.db.rows(s.query, args)
+	}
+	return &fakeRows{columns: columns, values: values}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,185 @@
+package transactions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultIdempotencyKeyTTL is how long a key replays the transaction it created
+const DefaultIdempotencyKeyTTL = 24 * time.Hour
+
+// MaxIdempotencyKeyLength bounds the Idempotency-Key header
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyKey is the transaction a buyer's key created and a hash of the
+// request it was created from
+type IdempotencyKey struct {
+	TransactionID uuid.UUID
+	RequestHash   string
+}
+
+// idempotencyStore persists which transaction each buyer's key created
+type idempotencyStore interface {
+	GetIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*IdempotencyKey, error)
+	SaveIdempotencyKey(ctx context.Context, userID uuid.UUID, key, requestHash string, transactionID uuid.UUID, expiresAt time.Time) error
+}
+
+// idempotencyCall is an in-flight creation other requests with the same key wait on
+type idempotencyCall struct {
+	done          chan struct{}
+	requestHash   string
+	transactionID uuid.UUID
+	err           error
+}
+
+// matches reports whether a stored key was created from the request with
+// requestHash. Keys stored without a hash match any request.
+func (k *IdempotencyKey) matches(requestHash string) bool {
+	return k.RequestHash == "" || k.RequestHash == requestHash
+}
+
+// idempotencyKeys runs a creation at most once per user and key. Concurrent
+// duplicates on this instance wait for the first request; later ones are
+// answered from the store until the key expires.
+type idempotencyKeys struct {
+	store    idempotencyStore
+	ttl      time.Duration
+	mu       sync.Mutex
+	inflight map[string]*idempotencyCall
+}
+
+func newIdempotencyKeys(store idempotencyStore, ttl time.Duration) *idempotencyKeys {
+	return &idempotencyKeys{
+		store:    store,
+		ttl:      ttl,
+		inflight: make(map[string]*idempotencyCall),
+	}
+}
+
+// do returns the transaction created for the key, calling create only when
+// there is none yet. replayed is true when the ID comes from an earlier or
+// concurrent request rather than this call's create. Reusing a key with a
+// different requestHash fails with ErrIdempotencyKeyReused.
+func (k *idempotencyKeys) do(ctx context.Context, userID uuid.UUID, key, requestHash string, create func() (uuid.UUID, error)) (transactionID uuid.UUID, replayed bool, err error) {
+	inflightKey := userID.String() + ":" + key
+
+	k.mu.Lock()
+	if call, ok := k.inflight[inflightKey]; ok {
+		k.mu.Unlock()
+		if call.requestHash != requestHash {
+			return uuid.Nil, false, ErrIdempotencyKeyReused
+		}
+		select {
+		case <-call.done:
+			return call.transactionID, call.err == nil, call.err
+		case <-ctx.Done():
+			return uuid.Nil, false, ctx.Err()
+		}
+	}
+	call := &idempotencyCall{done: make(chan struct{}), requestHash: requestHash}
+	k.inflight[inflightKey] = call
+	k.mu.Unlock()
+
+	defer func() {
+		call.transactionID, call.err = transactionID, err
+		k.mu.Lock()
+		delete(k.inflight, inflightKey)
+		k.mu.Unlock()
+		close(call.done)
+	}()
+
+	existing, err := k.store.GetIdempotencyKey(ctx, userID, key)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	if existing != nil {
+		if !existing.matches(requestHash) {
+			return uuid.Nil, false, ErrIdempotencyKeyReused
+		}
+		return existing.TransactionID, true, nil
+	}
+
+	transactionID, err = create()
+	if err != nil {
+		// Another instance may have won the race for the same key
+		if existing, lookupErr := k.store.GetIdempotencyKey(ctx, userID, key); lookupErr == nil && existing != nil {
+			if !existing.matches(requestHash) {
+				return uuid.Nil, false, ErrIdempotencyKeyReused
+			}
+			return existing.TransactionID, true, nil
+		}
+		return uuid.Nil, false, err
+	}
+
+	if err := k.store.SaveIdempotencyKey(ctx, userID, key, requestHash, transactionID, time.Now().Add(k.ttl)); err != nil {
+		return uuid.Nil, false, err
+	}
+
+	return transactionID, false, nil
+}
+
+// SetIdempotencyKeyTTL overrides how long idempotency keys are remembered
+func (s *Service) SetIdempotencyKeyTTL(ttl time.Duration) {
+	if ttl > 0 {
+		s.idempotency.ttl = ttl
+	}
+}
+
+// PurgeExpiredIdempotencyKeys deletes keys past their TTL and returns how
+// many were removed
+func (s *Service) PurgeExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	return s.repo.PurgeExpiredIdempotencyKeys(ctx)
+}
+
+// hashRequest returns the hex SHA-256 of the request's JSON encoding, so
+// retries that differ only in formatting or field order hash the same
+func hashRequest(req *CreateTransactionRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CreateTransactionIdempotent calls create at most once per buyer and
+// idempotency key. Repeats of a key within its TTL, including concurrent
+// ones, get the original transaction back with replayed set; repeats with a
+// different req fail with ErrIdempotencyKeyReused.
+func (s *Service) CreateTransactionIdempotent(ctx context.Context, buyerID uuid.UUID, key string, req *CreateTransactionRequest, create func(ctx context.Context) (*Transaction, error)) (transaction *Transaction, replayed bool, err error) {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return nil, false, ErrInvalidIdempotencyKey
+	}
+
+	requestHash, err := hashRequest(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	transactionID, replayed, err := s.idempotency.do(ctx, buyerID, key, requestHash, func() (uuid.UUID, error) {
+		created, err := create(ctx)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		transaction = created
+		return created.ID, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if transaction != nil {
+		return transaction, false, nil
+	}
+
+	transaction, err = s.GetTransactionByID(ctx, buyerID, transactionID)
+	if err != nil {
+		return nil, false, err
+	}
+	return transaction, replayed, nil
+}
@@ -0,0 +1,170 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryIdempotencyStore struct {
+	mu   sync.Mutex
+	keys map[string]IdempotencyKey
+}
+
+func (m *memoryIdempotencyStore) GetIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*IdempotencyKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stored, ok := m.keys[userID.String()+key]; ok {
+		return &stored, nil
+	}
+	return nil, nil
+}
+
+func (m *memoryIdempotencyStore) SaveIdempotencyKey(ctx context.Context, userID uuid.UUID, key, requestHash string, transactionID uuid.UUID, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[userID.String()+key]; !ok {
+		m.keys[userID.String()+key] = IdempotencyKey{TransactionID: transactionID, RequestHash: requestHash}
+	}
+	return nil
+}
+
+func TestIdempotencyKeysConcurrentDuplicates(t *testing.T) {
+	keys := newIdempotencyKeys(&memoryIdempotencyStore{keys: map[string]IdempotencyKey{}}, time.Hour)
+	buyerID := uuid.New()
+
+	var creates int32
+	release := make(chan struct{})
+	create := func() (uuid.UUID, error) {
+		atomic.AddInt32(&creates, 1)
+		<-release
+		return uuid.New(), nil
+	}
+
+	const requests = 10
+	ids := make([]uuid.UUID, requests)
+	replays := make([]bool, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id, replayed, err := keys.do(context.Background(), buyerID, "buy-1", "hash-1", create)
+			if err != nil {
+				t.Errorf("request %d: %v", i, err)
+			}
+			ids[i], replays[i] = id, replayed
+		}(i)
+	}
+
+	// Let the duplicates pile up behind the first request before it finishes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if creates != 1 {
+		t.Fatalf("expected 1 transaction to be created, got %d", creates)
+	}
+	created := 0
+	for i := range ids {
+		if ids[i] != ids[0] {
+			t.Errorf("request %d got transaction %s, want %s", i, ids[i], ids[0])
+		}
+		if !replays[i] {
+			created++
+		}
+	}
+	if created != 1 {
+		t.Errorf("expected exactly 1 request to report a new transaction, got %d", created)
+	}
+
+	// A later retry is answered from the store
+	id, replayed, err := keys.do(context.Background(), buyerID, "buy-1", "hash-1", create)
+	if err != nil || !replayed || id != ids[0] {
+		t.Errorf("expected replay of %s, got %s replayed=%v err=%v", ids[0], id, replayed, err)
+	}
+	if creates != 1 {
+		t.Errorf("expected retry not to create, got %d creates", creates)
+	}
+}
+
+func TestIdempotencyKeysFailedCreateCanBeRetried(t *testing.T) {
+	keys := newIdempotencyKeys(&memoryIdempotencyStore{keys: map[string]IdempotencyKey{}}, time.Hour)
+	buyerID := uuid.New()
+
+	_, _, err := keys.do(context.Background(), buyerID, "buy-1", "hash-1", func() (uuid.UUID, error) {
+		return uuid.Nil, ErrProductNotAvailable
+	})
+	if !errors.Is(err, ErrProductNotAvailable) {
+		t.Fatalf("expected create error, got %v", err)
+	}
+
+	want := uuid.New()
+	id, replayed, err := keys.do(context.Background(), buyerID, "buy-1", "hash-1", func() (uuid.UUID, error) {
+		return want, nil
+	})
+	if err != nil || replayed || id != want {
+		t.Errorf("expected a fresh create of %s, got %s replayed=%v err=%v", want, id, replayed, err)
+	}
+}
+
+func TestIdempotencyKeysRejectDifferentRequest(t *testing.T) {
+	keys := newIdempotencyKeys(&memoryIdempotencyStore{keys: map[string]IdempotencyKey{}}, time.Hour)
+	buyerID := uuid.New()
+
+	want := uuid.New()
+	if _, _, err := keys.do(context.Background(), buyerID, "buy-1", "hash-1", func() (uuid.UUID, error) {
+		return want, nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	created := false
+	_, _, err := keys.do(context.Background(), buyerID, "buy-1", "hash-2", func() (uuid.UUID, error) {
+		created = true
+		return uuid.New(), nil
+	})
+	if err != ErrIdempotencyKeyReused {
+		t.Errorf("expected ErrIdempotencyKeyReused, got %v", err)
+	}
+	if created {
+		t.Error("expected a reused key not to create a transaction")
+	}
+
+	// The same request still replays the original
+	id, replayed, err := keys.do(context.Background(), buyerID, "buy-1", "hash-1", nil)
+	if err != nil || !replayed || id != want {
+		t.Errorf("expected replay of %s, got %s replayed=%v err=%v", want, id, replayed, err)
+	}
+}
+
+func TestHashRequestIgnoresFormatting(t *testing.T) {
+	productID := uuid.New()
+	var a, b CreateTransactionRequest
+	if err := json.Unmarshal([]byte(`{"product_id":"`+productID.String()+`","quantity":3}`), &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`{ "quantity": 3, "product_id": "`+productID.String()+`" }`), &b); err != nil {
+		t.Fatal(err)
+	}
+
+	hashA, err := hashRequest(&a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashB, _ := hashRequest(&b)
+	if hashA != hashB {
+		t.Errorf("expected equal hashes for reformatted requests, got %s and %s", hashA, hashB)
+	}
+
+	b.Quantity = 4
+	if hashC, _ := hashRequest(&b); hashC == hashA {
+		t.Error("expected a different quantity to change the hash")
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 type Transaction struct {
 	ID                      uuid.UUID              `json:"id" db:"id"`
+	Reference               string                 `json:"reference" db:"reference"`
 	ProductID               uuid.UUID              `json:"product_id" db:"product_id"`
 	BuyerID                 uuid.UUID              `json:"buyer_id" db:"buyer_id"`
 	SellerID                uuid.UUID              `json:"seller_id" db:"seller_id"`
@@ -142,15 +143,15 @@ type AddReviewRequest struct {
 }
 
 type TransactionListRequest struct {
-	Status       string `json:"status,omitempty"`
-	ProductID    string `json:"product_id,omitempty"`
-	BuyerID      string `json:"buyer_id,omitempty"`
-	SellerID     string `json:"seller_id,omitempty"`
-	DateFrom     string `json:"date_from,omitempty"`
-	DateTo       string `json:"date_to,omitempty"`
-	SortBy       string `json:"sort_by,omitempty"` // date_asc, date_desc, amount_asc, amount_desc
-	Page         int    `json:"page,omitempty"`
-	PageSize     int    `json:"page_size,omitempty"`
+	Status    string `json:"status,omitempty" form:"status"`
+	ProductID string `json:"product_id,omitempty" form:"product_id"`
+	BuyerID   string `json:"buyer_id,omitempty" form:"buyer_id"`
+	SellerID  string `json:"seller_id,omitempty" form:"seller_id"`
+	DateFrom  string `json:"date_from,omitempty" form:"date_from"`
+	DateTo    string `json:"date_to,omitempty" form:"date_to"`
+	SortBy    string `json:"sort_by,omitempty" form:"sort_by"` // date_asc, date_desc, amount_asc, amount_desc
+	Page      int    `json:"page,omitempty" form:"page"`
+	PageSize  int    `json:"page_size,omitempty" form:"page_size"`
 }
 
 type TransactionListResponse struct {
@@ -161,6 +162,21 @@ type TransactionListResponse struct {
 	TotalPages   int           `json:"total_pages"`
 }
 
+// ActiveTransaction is a compact view of an open purchase for the buyer dashboard
+type ActiveTransaction struct {
+	ID           uuid.UUID `json:"id"`
+	ProductID    uuid.UUID `json:"product_id"`
+	SellerID     uuid.UUID `json:"seller_id"`
+	Status       string    `json:"status"`
+	FinalPrice   float64   `json:"final_price"`
+	Currency     string    `json:"currency"`
+	Quantity     int       `json:"quantity"`
+	ProductTitle string    `json:"product_title"`
+	SellerName   string    `json:"seller_name"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
 type TransactionStatsResponse struct {
 	TotalTransactions      int                `json:"total_transactions"`
 	CompletedTransactions  int                `json:"completed_transactions"`
@@ -188,6 +204,27 @@ type ProductInquiry struct {
 	WhatsAppMessageID *string   `json:"whatsapp_message_id,omitempty" db:"whatsapp_message_id"`
 	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Joined from products so inbox listings are readable
+	ProductTitle *string `json:"product_title,omitempty"`
+}
+
+// InquiryListRequest filters the inquiries a user sent or received
+type InquiryListRequest struct {
+	Role        string `json:"role,omitempty" form:"role"` // buyer, seller, or empty for both
+	ProductID   string `json:"product_id,omitempty" form:"product_id"`
+	InquiryType string `json:"inquiry_type,omitempty" form:"inquiry_type"`
+	IsResponded *bool  `json:"is_responded,omitempty" form:"is_responded"`
+	Page        int    `json:"page,omitempty" form:"page"`
+	PageSize    int    `json:"page_size,omitempty" form:"page_size"`
+}
+
+type InquiryListResponse struct {
+	Inquiries  []*ProductInquiry `json:"inquiries"`
+	TotalCount int               `json:"total_count"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
 }
 
 type CreateInquiryRequest struct {
@@ -201,6 +238,26 @@ type RespondToInquiryRequest struct {
 	Response string `json:"response" binding:"required"`
 }
 
+type OpenDisputeRequest struct {
+	Reason string `json:"reason" binding:"required,max=2000"`
+}
+
+type CancelTransactionRequest struct {
+	Reason string `json:"reason" binding:"required,max=2000"`
+}
+
+type ResolveDisputeRequest struct {
+	Resolution  string `json:"resolution" binding:"required,max=2000"`
+	FinalStatus string `json:"final_status" binding:"required,oneof=completed cancelled"`
+}
+
+type AddCommunicationMessageRequest struct {
+	Channel     string                 `json:"channel" binding:"required,oneof=whatsapp email internal"`
+	MessageType string                 `json:"message_type" binding:"required,oneof=text image location document"`
+	Content     string                 `json:"content" binding:"required,max=4000"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
 // Database driver interfaces
 func (p *Point) Scan(value interface{}) error {
 	if value == nil {
@@ -285,6 +342,21 @@ const (
 	InquiryTypeLogistics = "logistics"
 )
 
+// Constants for communication log channels
+const (
+	ChannelWhatsApp = "whatsapp"
+	ChannelEmail    = "email"
+	ChannelInternal = "internal"
+)
+
+// Constants for communication log message types
+const (
+	MessageTypeText     = "text"
+	MessageTypeImage    = "image"
+	MessageTypeLocation = "location"
+	MessageTypeDocument = "document"
+)
+
 // Validation functions
 func IsValidTransactionStatus(status string) bool {
 	validStatuses := []string{StatusPending, StatusConfirmed, StatusInProgress, StatusCompleted, StatusCancelled, StatusDisputed}
@@ -306,6 +378,26 @@ func IsValidPaymentStatus(status string) bool {
 	return false
 }
 
+func IsValidMessageChannel(channel string) bool {
+	validChannels := []string{ChannelWhatsApp, ChannelEmail, ChannelInternal}
+	for _, valid := range validChannels {
+		if channel == valid {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidMessageType(messageType string) bool {
+	validTypes := []string{MessageTypeText, MessageTypeImage, MessageTypeLocation, MessageTypeDocument}
+	for _, valid := range validTypes {
+		if messageType == valid {
+			return true
+		}
+	}
+	return false
+}
+
 func IsValidInquiryType(inquiryType string) bool {
 	validTypes := []string{InquiryTypeGeneral, InquiryTypePrice, InquiryTypeAvailability, InquiryTypeTechnical, InquiryTypeLogistics}
 	for _, valid := range validTypes {
@@ -0,0 +1,425 @@
+package transactions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"agro-mas-backend/internal/auth"
+	"agro-mas-backend/pkg/gcloud"
+	"agro-mas-backend/pkg/logger"
+	"agro-mas-backend/pkg/pdf"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrReceiptNotAvailable   = errors.New("receipts are only available for confirmed, in-progress or completed transactions")
+	ErrReceiptStorageMissing = errors.New("receipt storage is not configured")
+)
+
+// ReceiptURLTTL is how long a receipt download link stays valid
+const ReceiptURLTTL = 15 * time.Minute
+
+type ReceiptResponse struct {
+	ReceiptNumber string    `json:"receipt_number"`
+	URL           string    `json:"url"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	GeneratedAt   time.Time `json:"generated_at"`
+}
+
+// receiptRecord is the stored PDF of a transaction's receipt
+type receiptRecord struct {
+	TransactionID        uuid.UUID
+	ReceiptNumber        string
+	StoragePath          string
+	TransactionUpdatedAt time.Time
+	GeneratedAt          time.Time
+}
+
+// ReceiptParty identifies a buyer or seller on a receipt
+type ReceiptParty struct {
+	FirstName    string
+	LastName     string
+	BusinessName *string
+	CUIT         *string
+	Email        string
+}
+
+// ReceiptService renders transaction receipts (remitos) as PDFs and keeps
+// them in private storage, served through signed URLs
+type ReceiptService struct {
+	repo          *Repository
+	storageClient *gcloud.StorageClient
+	logo          []byte
+}
+
+func NewReceiptService(repo *Repository, storageClient *gcloud.StorageClient) *ReceiptService {
+	return &ReceiptService{
+		repo:          repo,
+		storageClient: storageClient,
+	}
+}
+
+// SetLogo sets the JPEG printed in the receipt header. Without one the
+// platform name is printed instead.
+func (s *ReceiptService) SetLogo(jpeg []byte) {
+	s.logo = jpeg
+}
+
+// GetReceipt returns a download link for a transaction's receipt, rendering
+// it first if it was never generated or the transaction changed since. Only
+// the buyer, the seller or an admin may request it.
+func (s *ReceiptService) GetReceipt(ctx context.Context, userID uuid.UUID, role string, transactionID uuid.UUID) (*ReceiptResponse, error) {
+	transaction, err := s.repo.GetTransactionByID(ctx, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if transaction == nil {
+		return nil, ErrTransactionNotFound
+	}
+	if transaction.BuyerID != userID && transaction.SellerID != userID && role != "admin" {
+		return nil, ErrTransactionNotAuthorized
+	}
+
+	switch transaction.Status {
+	case StatusConfirmed, StatusInProgress, StatusCompleted:
+	default:
+		return nil, ErrReceiptNotAvailable
+	}
+
+	if s.storageClient == nil {
+		return nil, ErrReceiptStorageMissing
+	}
+
+	record, err := s.repo.GetReceipt(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil || !record.TransactionUpdatedAt.Equal(transaction.UpdatedAt) {
+		record, err = s.generate(ctx, transaction, record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	expiresAt := time.Now().Add(ReceiptURLTTL)
+	url, err := s.storageClient.GetFileURL(ctx, record.StoragePath, ReceiptURLTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign receipt URL: %w", err)
+	}
+
+	return &ReceiptResponse{
+		ReceiptNumber: record.ReceiptNumber,
+		URL:           url,
+		ExpiresAt:     expiresAt,
+		GeneratedAt:   record.GeneratedAt,
+	}, nil
+}
+
+// generate renders and uploads the receipt, replacing previous if set
+func (s *ReceiptService) generate(ctx context.Context, transaction *Transaction, previous *receiptRecord) (*receiptRecord, error) {
+	number := ""
+	if previous != nil {
+		number = previous.ReceiptNumber
+	} else {
+		seq, err := s.repo.NextReceiptSequence(ctx)
+		if err != nil {
+			return nil, err
+		}
+		number = fmt.Sprintf("AGM-%d-%06d", time.Now().Year(), seq)
+	}
+
+	parties, err := s.repo.GetReceiptParties(ctx, transaction.BuyerID, transaction.SellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	document := renderReceipt(ctx, transaction, number, parties[transaction.BuyerID], parties[transaction.SellerID], s.logo)
+
+	upload, err := s.storageClient.UploadFileFromBytes(ctx, document, "receipt.pdf", "application/pdf", gcloud.UploadOptions{
+		Directory:    "receipts",
+		SubDirectory: transaction.ID.String(),
+		// Unique per render so concurrent regenerations never share a path
+		// and the loser cannot delete the stored PDF
+		FileName:     strings.ToLower(number) + "_" + uuid.NewString(),
+		PublicRead:   false,
+		CacheControl: "private, no-store",
+		Metadata: map[string]string{
+			"transaction_id": transaction.ID.String(),
+			"receipt_number": number,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload receipt: %w", err)
+	}
+
+	record := &receiptRecord{
+		TransactionID:        transaction.ID,
+		ReceiptNumber:        number,
+		StoragePath:          upload.StoragePath,
+		TransactionUpdatedAt: transaction.UpdatedAt,
+	}
+	saved, err := s.repo.SaveReceipt(ctx, record, previous)
+	if err != nil || !saved {
+		if deleteErr := s.storageClient.DeleteFile(ctx, upload.StoragePath); deleteErr != nil {
+			logger.FromContext(ctx).Warn("failed to delete orphaned receipt", "path", upload.StoragePath, "error", deleteErr)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// A concurrent request stored its receipt first; serve that one so the
+		// number on the PDF always matches the stored number
+		current, err := s.repo.GetReceipt(ctx, transaction.ID)
+		if err != nil {
+			return nil, err
+		}
+		if current == nil {
+			return nil, fmt.Errorf("receipt for transaction %s was removed while generating", transaction.ID)
+		}
+		return current, nil
+	}
+
+	if previous != nil && previous.StoragePath != record.StoragePath {
+		if err := s.storageClient.DeleteFile(ctx, previous.StoragePath); err != nil {
+			logger.FromContext(ctx).Warn("failed to delete outdated receipt", "path", previous.StoragePath, "error", err)
+		}
+	}
+
+	return record, nil
+}
+
+// renderReceipt lays out the receipt on a single A4 page
+func renderReceipt(ctx context.Context, transaction *Transaction, number string, buyer, seller *ReceiptParty, logo []byte) []byte {
+	const (
+		left  = 50.0
+		right = pdf.PageWidth - 50
+	)
+
+	doc := pdf.New()
+	y := 50.0
+
+	// Header: logo or wordmark on the left, receipt number on the right
+	headerHeight := 0.0
+	if len(logo) > 0 {
+		height, err := doc.JPEG(logo, left, y, 120)
+		if err != nil {
+			logger.FromContext(ctx).Warn("failed to draw receipt logo", "error", err)
+		}
+		headerHeight = height
+	}
+	if headerHeight == 0 {
+		doc.Text(left, y+20, 22, true, "Agro Mas")
+		headerHeight = 28
+	}
+	doc.TextRight(right, y+12, 14, true, "Comprobante de operación")
+	doc.TextRight(right, y+30, 10, false, "N° "+number)
+	doc.TextRight(right, y+44, 10, false, "Referencia "+transaction.Reference)
+
+	y += headerHeight + 30
+	if y < 130 {
+		y = 130
+	}
+	doc.Line(left, y, right, y)
+	y += 25
+
+	// Parties
+	doc.Text(left, y, 11, true, "Vendedor")
+	doc.Text(left+260, y, 11, true, "Comprador")
+	y += 16
+	sellerLines := partyLines(seller)
+	buyerLines := partyLines(buyer)
+	for i := 0; i < len(sellerLines) || i < len(buyerLines); i++ {
+		if i < len(sellerLines) {
+			doc.Text(left, y, 10, false, sellerLines[i])
+		}
+		if i < len(buyerLines) {
+			doc.Text(left+260, y, 10, false, buyerLines[i])
+		}
+		y += 14
+	}
+
+	y += 12
+	doc.Line(left, y, right, y)
+	y += 25
+
+	// Product and amounts
+	productTitle := transaction.ProductID.String()
+	if transaction.Metadata != nil && transaction.Metadata.ProductTitle != "" {
+		productTitle = transaction.Metadata.ProductTitle
+	}
+	quantity := fmt.Sprintf("%d", transaction.Quantity)
+	if transaction.Unit != nil && *transaction.Unit != "" {
+		quantity += " " + *transaction.Unit
+	}
+
+	doc.Text(left, y, 11, true, "Producto")
+	doc.Text(left+300, y, 11, true, "Cantidad")
+	doc.TextRight(right, y, 11, true, "Precio final")
+	y += 16
+	doc.Text(left, y, 10, false, truncate(productTitle, 55))
+	doc.Text(left+300, y, 10, false, quantity)
+	doc.TextRight(right, y, 10, false, formatAmount(transaction.FinalPrice, transaction.Currency))
+	y += 20
+
+	if transaction.OriginalPrice != nil {
+		doc.Text(left, y, 9, false, "Precio publicado: "+formatAmount(*transaction.OriginalPrice, transaction.Currency))
+		y += 13
+	}
+	if transaction.NegotiatedPrice != nil {
+		doc.Text(left, y, 9, false, "Precio negociado: "+formatAmount(*transaction.NegotiatedPrice, transaction.Currency))
+		y += 13
+	}
+	if transaction.PaymentMethod != nil {
+		doc.Text(left, y, 9, false, "Forma de pago: "+*transaction.PaymentMethod)
+		y += 13
+	}
+
+	y += 12
+	doc.Line(left, y, right, y)
+	y += 25
+
+	// Dates
+	doc.Text(left, y, 11, true, "Fechas")
+	y += 16
+	dates := []struct {
+		label string
+		at    *time.Time
+	}{
+		{"Operación creada", &transaction.CreatedAt},
+		{"Retiro", transaction.PickupDate},
+		{"Entrega", transaction.DeliveryDate},
+		{"Pago", transaction.PaymentDate},
+		{"Finalizada", transaction.CompletedAt},
+	}
+	for _, date := range dates {
+		if date.at == nil {
+			continue
+		}
+		doc.Text(left, y, 10, false, date.label+": "+date.at.Format("02/01/2006"))
+		y += 14
+	}
+
+	doc.Text(left, pdf.PageHeight-40, 8, false,
+		fmt.Sprintf("Estado: %s. Emitido el %s por Agro Mas.", transaction.Status, time.Now().Format("02/01/2006 15:04")))
+
+	return doc.Bytes()
+}
+
+// partyLines describes a party by name, business name, CUIT and email
+func partyLines(party *ReceiptParty) []string {
+	if party == nil {
+		return []string{"-"}
+	}
+
+	lines := []string{truncate(strings.TrimSpace(party.FirstName+" "+party.LastName), 40)}
+	if party.BusinessName != nil && *party.BusinessName != "" {
+		lines = append(lines, truncate(*party.BusinessName, 40))
+	}
+	cuit := "-"
+	if party.CUIT != nil && *party.CUIT != "" {
+		cuit = auth.NewCUITValidator().FormatCUIT(*party.CUIT)
+	}
+	lines = append(lines, "CUIT: "+cuit)
+	if party.Email != "" {
+		lines = append(lines, truncate(party.Email, 40))
+	}
+	return lines
+}
+
+func formatAmount(amount float64, currency string) string {
+	return fmt.Sprintf("%s %.2f", currency, amount)
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+// GetReceipt returns the stored receipt of a transaction, or nil if none was generated
+func (r *Repository) GetReceipt(ctx context.Context, transactionID uuid.UUID) (*receiptRecord, error) {
+	record := &receiptRecord{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT transaction_id, receipt_number, storage_path, transaction_updated_at, generated_at
+		FROM transaction_receipts
+		WHERE transaction_id = $1`, transactionID).Scan(
+		&record.TransactionID, &record.ReceiptNumber, &record.StoragePath,
+		&record.TransactionUpdatedAt, &record.GeneratedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt: %w", err)
+	}
+	return record, nil
+}
+
+// SaveReceipt stores a generated receipt and sets its generation time. A first
+// receipt is only inserted when the transaction has none yet, and a
+// regenerated one only replaces previous while it is still the stored one.
+// Returns false when a concurrent request saved its receipt first.
+func (r *Repository) SaveReceipt(ctx context.Context, record, previous *receiptRecord) (bool, error) {
+	var err error
+	if previous == nil {
+		err = r.db.QueryRowContext(ctx, `
+			INSERT INTO transaction_receipts (transaction_id, receipt_number, storage_path, transaction_updated_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (transaction_id) DO NOTHING
+			RETURNING generated_at`,
+			record.TransactionID, record.ReceiptNumber, record.StoragePath, record.TransactionUpdatedAt).Scan(&record.GeneratedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, `
+			UPDATE transaction_receipts
+			SET storage_path = $2, transaction_updated_at = $3, generated_at = NOW()
+			WHERE transaction_id = $1 AND storage_path = $4
+			RETURNING generated_at`,
+			record.TransactionID, record.StoragePath, record.TransactionUpdatedAt, previous.StoragePath).Scan(&record.GeneratedAt)
+	}
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to save receipt: %w", err)
+	}
+	return true, nil
+}
+
+// NextReceiptSequence reserves the next receipt number
+func (r *Repository) NextReceiptSequence(ctx context.Context) (int64, error) {
+	var seq int64
+	if err := r.db.QueryRowContext(ctx, `SELECT nextval('transaction_receipt_number_seq')`).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("failed to reserve receipt number: %w", err)
+	}
+	return seq, nil
+}
+
+// GetReceiptParties loads the identity printed on receipts for each user
+func (r *Repository) GetReceiptParties(ctx context.Context, userIDs ...uuid.UUID) (map[uuid.UUID]*ReceiptParty, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, first_name, last_name, business_name, cuit, email
+		FROM users
+		WHERE id = ANY($1)`, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt parties: %w", err)
+	}
+	defer rows.Close()
+
+	parties := make(map[uuid.UUID]*ReceiptParty)
+	for rows.Next() {
+		var id uuid.UUID
+		party := &ReceiptParty{}
+		if err := rows.Scan(&id, &party.FirstName, &party.LastName, &party.BusinessName, &party.CUIT, &party.Email); err != nil {
+			return nil, fmt.Errorf("failed to scan receipt party: %w", err)
+		}
+		parties[id] = party
+	}
+
+	return parties, rows.Err()
+}
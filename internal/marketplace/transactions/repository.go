@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type Repository struct {
@@ -19,8 +20,44 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// CreateTransaction creates a new transaction in the database
-func (r *Repository) CreateTransaction(ctx context.Context, transaction *Transaction) error {
+// CreateTransaction reserves the transaction's quantity from the product's
+// stock and inserts the transaction, in one database transaction. The stock is
+// decremented with a guard instead of read-then-write, so concurrent buyers
+// can't oversell; ErrInsufficientQuantity is returned when too little is left.
+// Products that don't track quantity are left untouched. With unpublishSoldOut,
+// a product whose stock reaches zero is unpublished and marked sold out, so a
+// later restock publishes it again. The reference number is padded to at least
+// six digits and grows past them rather than wrapping.
+func (r *Repository) CreateTransaction(ctx context.Context, transaction *Transaction, unpublishSoldOut bool) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var remaining sql.NullInt64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE products SET quantity = quantity - $2, updated_at = NOW()
+		WHERE id = $1 AND (quantity IS NULL OR quantity >= $2)
+		RETURNING quantity`, transaction.ProductID, transaction.Quantity).Scan(&remaining)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInsufficientQuantity
+		}
+		return fmt.Errorf("failed to reserve product quantity: %w", err)
+	}
+
+	if unpublishSoldOut && remaining.Valid && remaining.Int64 == 0 {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE products SET published_at = NULL, updated_at = NOW(),
+				sold_out_at = CASE WHEN status = 'published' THEN NOW() END,
+				status = CASE WHEN status = 'published' THEN 'unpublished' ELSE status END
+			WHERE id = $1`, transaction.ProductID)
+		if err != nil {
+			return fmt.Errorf("failed to unpublish sold out product: %w", err)
+		}
+	}
+
 	query := `
 		INSERT INTO transactions (
 			id, product_id, buyer_id, seller_id, status, transaction_type,
@@ -29,14 +66,18 @@ func (r *Repository) CreateTransaction(ctx context.Context, transaction *Transac
 			pickup_date, pickup_contact_name, pickup_contact_phone,
 			delivery_address, delivery_coordinates, delivery_date,
 			delivery_contact_name, delivery_contact_phone, whatsapp_thread_id,
-			communication_log, notes, metadata
+			communication_log, notes, metadata, reference
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
 			ST_GeomFromText('POINT(' || $16 || ' ' || $17 || ')', 4326),
 			$18, $19, $20, $21,
 			ST_GeomFromText('POINT(' || $22 || ' ' || $23 || ')', 4326),
-			$24, $25, $26, $27, $28, $29, $30
-		)`
+			$24, $25, $26, $27, $28, $29, $30,
+			(SELECT 'AGR-' || to_char(NOW(), 'YYYY') || '-' ||
+				CASE WHEN n < 1000000 THEN lpad(n::text, 6, '0') ELSE n::text END
+			 FROM nextval('transaction_reference_seq') AS n)
+		)
+		RETURNING reference`
 
 	var pickupLng, pickupLat, deliveryLng, deliveryLat sql.NullFloat64
 
@@ -55,7 +96,6 @@ func (r *Repository) CreateTransaction(ctx context.Context, transaction *Transac
 	}
 
 	var communicationLogJSON, metadataJSON []byte
-	var err error
 
 	if transaction.CommunicationLog != nil {
 		communicationLogJSON, err = json.Marshal(transaction.CommunicationLog)
@@ -71,7 +111,7 @@ func (r *Repository) CreateTransaction(ctx context.Context, transaction *Transac
 		}
 	}
 
-	_, err = r.db.ExecContext(ctx, query,
+	err = tx.QueryRowContext(ctx, query,
 		transaction.ID, transaction.ProductID, transaction.BuyerID, transaction.SellerID,
 		transaction.Status, transaction.TransactionType, transaction.OriginalPrice,
 		transaction.NegotiatedPrice, transaction.FinalPrice, transaction.Currency,
@@ -80,20 +120,101 @@ func (r *Repository) CreateTransaction(ctx context.Context, transaction *Transac
 		transaction.PickupDate, transaction.PickupContactName, transaction.PickupContactPhone,
 		transaction.DeliveryAddress, deliveryLng, deliveryLat, transaction.DeliveryDate,
 		transaction.DeliveryContactName, transaction.DeliveryContactPhone,
-		transaction.WhatsAppThreadID, communicationLogJSON, transaction.Notes, metadataJSON)
+		transaction.WhatsAppThreadID, communicationLogJSON, transaction.Notes, metadataJSON).Scan(&transaction.Reference)
 
 	if err != nil {
+		// The partial unique index guards against concurrent duplicate requests
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" && pqErr.Constraint == "idx_transactions_open_product_buyer" {
+			return ErrTransactionAlreadyExists
+		}
 		return fmt.Errorf("failed to create transaction: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
+// GetIdempotencyKey returns the transaction a buyer's unexpired idempotency
+// key created, or nil if there is none
+func (r *Repository) GetIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*IdempotencyKey, error) {
+	query := `
+		SELECT transaction_id, request_hash FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2 AND expires_at > NOW()`
+
+	var stored IdempotencyKey
+	err := r.db.QueryRowContext(ctx, query, userID, key).Scan(&stored.TransactionID, &stored.RequestHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return &stored, nil
+}
+
+// SaveIdempotencyKey records the transaction created for a key and the hash
+// of its request. An expired row for the same key is replaced; a live one is
+// kept.
+func (r *Repository) SaveIdempotencyKey(ctx context.Context, userID uuid.UUID, key, requestHash string, transactionID uuid.UUID, expiresAt time.Time) error {
+	query := `
+		INSERT INTO idempotency_keys (user_id, key, transaction_id, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, key) DO UPDATE SET
+			transaction_id = EXCLUDED.transaction_id,
+			request_hash = EXCLUDED.request_hash,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, key, transactionID, requestHash, expiresAt); err != nil {
+		return fmt.Errorf("failed to save idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpiredIdempotencyKeys deletes idempotency keys past their expiry
+func (r *Repository) PurgeExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// HasOpenTransaction checks whether the buyer already has a non-terminal transaction for the product
+func (r *Repository) HasOpenTransaction(ctx context.Context, productID, buyerID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM transactions
+			WHERE product_id = $1 AND buyer_id = $2
+			AND status NOT IN ('completed', 'cancelled')
+		)`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, productID, buyerID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check open transactions: %w", err)
+	}
+	return exists, nil
+}
+
 // GetTransactionByID retrieves a transaction by its ID
 func (r *Repository) GetTransactionByID(ctx context.Context, id uuid.UUID) (*Transaction, error) {
+	return r.getTransaction(ctx, "id = $1", id)
+}
+
+// GetTransactionByReference retrieves a transaction by its human-readable reference
+func (r *Repository) GetTransactionByReference(ctx context.Context, reference string) (*Transaction, error) {
+	return r.getTransaction(ctx, "reference = $1", reference)
+}
+
+func (r *Repository) getTransaction(ctx context.Context, condition string, arg interface{}) (*Transaction, error) {
 	query := `
 		SELECT 
-			id, product_id, buyer_id, seller_id, status, transaction_type,
+			id, reference, product_id, buyer_id, seller_id, status, transaction_type,
 			original_price, negotiated_price, final_price, currency, quantity, unit,
 			payment_method, payment_status, payment_date, pickup_address,
 			ST_X(pickup_coordinates) as pickup_lng, ST_Y(pickup_coordinates) as pickup_lat,
@@ -106,14 +227,14 @@ func (r *Repository) GetTransactionByID(ctx context.Context, id uuid.UUID) (*Tra
 			created_at, updated_at, completed_at, cancelled_at, cancellation_reason,
 			notes, metadata
 		FROM transactions 
-		WHERE id = $1`
+		WHERE ` + condition
 
 	transaction := &Transaction{}
 	var pickupLng, pickupLat, deliveryLng, deliveryLat sql.NullFloat64
 	var communicationLogJSON, metadataJSON sql.NullString
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&transaction.ID, &transaction.ProductID, &transaction.BuyerID, &transaction.SellerID,
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(
+		&transaction.ID, &transaction.Reference, &transaction.ProductID, &transaction.BuyerID, &transaction.SellerID,
 		&transaction.Status, &transaction.TransactionType, &transaction.OriginalPrice,
 		&transaction.NegotiatedPrice, &transaction.FinalPrice, &transaction.Currency,
 		&transaction.Quantity, &transaction.Unit, &transaction.PaymentMethod,
@@ -241,7 +362,7 @@ func (r *Repository) ListTransactions(ctx context.Context, filters TransactionFi
 	// Get paginated results
 	query := fmt.Sprintf(`
 		SELECT 
-			id, product_id, buyer_id, seller_id, status, transaction_type,
+			id, reference, product_id, buyer_id, seller_id, status, transaction_type,
 			original_price, negotiated_price, final_price, currency, quantity, unit,
 			payment_method, payment_status, payment_date, pickup_address,
 			ST_X(pickup_coordinates) as pickup_lng, ST_Y(pickup_coordinates) as pickup_lat,
@@ -273,7 +394,7 @@ func (r *Repository) ListTransactions(ctx context.Context, filters TransactionFi
 		var communicationLogJSON, metadataJSON sql.NullString
 
 		err := rows.Scan(
-			&transaction.ID, &transaction.ProductID, &transaction.BuyerID, &transaction.SellerID,
+			&transaction.ID, &transaction.Reference, &transaction.ProductID, &transaction.BuyerID, &transaction.SellerID,
 			&transaction.Status, &transaction.TransactionType, &transaction.OriginalPrice,
 			&transaction.NegotiatedPrice, &transaction.FinalPrice, &transaction.Currency,
 			&transaction.Quantity, &transaction.Unit, &transaction.PaymentMethod,
@@ -331,8 +452,378 @@ func (r *Repository) ListTransactions(ctx context.Context, filters TransactionFi
 	return transactions, totalCount, nil
 }
 
+// ListActiveBuyerTransactions retrieves the buyer's non-terminal transactions, most recently updated first
+func (r *Repository) ListActiveBuyerTransactions(ctx context.Context, buyerID uuid.UUID) ([]*ActiveTransaction, error) {
+	query := `
+		SELECT 
+			id, product_id, seller_id, status, final_price, currency, quantity,
+			COALESCE(metadata->>'product_title', ''),
+			COALESCE(metadata->'seller_info'->>'name', ''),
+			created_at, updated_at
+		FROM transactions
+		WHERE buyer_id = $1
+		AND status IN ('pending', 'confirmed', 'in_progress', 'disputed')
+		ORDER BY updated_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, buyerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := make([]*ActiveTransaction, 0)
+	for rows.Next() {
+		transaction := &ActiveTransaction{}
+		err := rows.Scan(
+			&transaction.ID, &transaction.ProductID, &transaction.SellerID,
+			&transaction.Status, &transaction.FinalPrice, &transaction.Currency,
+			&transaction.Quantity, &transaction.ProductTitle, &transaction.SellerName,
+			&transaction.CreatedAt, &transaction.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan active transaction: %w", err)
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate active transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// SaveReview stores the rating and review left by one party of a transaction
+// and recalculates the reviewed user's aggregates in the same database
+// transaction, along with the review event. Returns false when that party
+// has already reviewed.
+func (r *Repository) SaveReview(ctx context.Context, transactionID uuid.UUID, reviewerIsBuyer bool, rating int, review *string, reviewedUserID uuid.UUID, event *TransactionEvent) (bool, error) {
+	reviewer := "seller"
+	if reviewerIsBuyer {
+		reviewer = "buyer"
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`
+		UPDATE transactions
+		SET %[1]s_rating = $2, %[1]s_review = $3, %[1]s_review_date = NOW(), updated_at = NOW()
+		WHERE id = $1 AND %[1]s_rating IS NULL`, reviewer)
+
+	result, err := tx.ExecContext(ctx, query, transactionID, rating, review)
+	if err != nil {
+		return false, fmt.Errorf("failed to save review: %w", err)
+	}
+	if saved, _ := result.RowsAffected(); saved == 0 {
+		return false, nil
+	}
+
+	if err := recalculateUserRating(ctx, tx, reviewedUserID); err != nil {
+		return false, err
+	}
+
+	if err := insertEvent(ctx, tx, event); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit review: %w", err)
+	}
+
+	return true, nil
+}
+
+// RecalculateUserRating rebuilds a user's rating, review count and completed
+// sales and purchases from their transactions. Safe to run as a backfill.
+func (r *Repository) RecalculateUserRating(ctx context.Context, userID uuid.UUID) error {
+	return recalculateUserRating(ctx, r.db, userID)
+}
+
+// recalculateUserRating averages the ratings a user received on completed
+// transactions: buyer ratings when they sold and seller ratings when they bought
+func recalculateUserRating(ctx context.Context, db execer, userID uuid.UUID) error {
+	query := `
+		WITH received AS (
+			SELECT buyer_rating AS rating FROM transactions
+			WHERE seller_id = $1 AND status = 'completed' AND buyer_rating IS NOT NULL
+			UNION ALL
+			SELECT seller_rating FROM transactions
+			WHERE buyer_id = $1 AND status = 'completed' AND seller_rating IS NOT NULL
+		)
+		UPDATE users SET
+			rating = COALESCE((SELECT ROUND(AVG(rating), 2) FROM received), 0),
+			total_reviews = (SELECT COUNT(*) FROM received),
+			total_sales = (SELECT COUNT(*) FROM transactions WHERE seller_id = $1 AND status = 'completed'),
+			total_purchases = (SELECT COUNT(*) FROM transactions WHERE buyer_id = $1 AND status = 'completed'),
+			updated_at = NOW()
+		WHERE id = $1`
+
+	if _, err := db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to recalculate user rating: %w", err)
+	}
+	return nil
+}
+
+// CancelTransaction marks a pending or confirmed transaction cancelled with
+// the given reason and adds its quantity back to the product, when the product
+// tracks stock, in one database transaction with the status event. Returns
+// false if the transaction was no longer pending or confirmed.
+func (r *Repository) CancelTransaction(ctx context.Context, id uuid.UUID, reason string, event *TransactionEvent) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var productID uuid.UUID
+	var quantity int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE transactions
+		SET status = 'cancelled', cancelled_at = NOW(), cancellation_reason = $2, updated_at = NOW()
+		WHERE id = $1 AND status IN ('pending', 'confirmed')
+		RETURNING product_id, quantity`, id, reason).Scan(&productID, &quantity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to cancel transaction: %w", err)
+	}
+
+	if err := restockProduct(ctx, tx, productID, quantity); err != nil {
+		return false, err
+	}
+
+	if err := insertEvent(ctx, tx, event); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit cancellation: %w", err)
+	}
+
+	return true, nil
+}
+
+// OpenDispute marks an in-progress transaction disputed with the given reason,
+// in one database transaction with the status event. Returns false if the
+// transaction was no longer in progress.
+func (r *Repository) OpenDispute(ctx context.Context, id uuid.UUID, reason string, event *TransactionEvent) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var disputedID uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+		UPDATE transactions
+		SET status = 'disputed', dispute_reason = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'in_progress'
+		RETURNING id`, id, reason).Scan(&disputedID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to open dispute: %w", err)
+	}
+
+	if err := insertEvent(ctx, tx, event); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit dispute: %w", err)
+	}
+
+	return true, nil
+}
+
+// ResolveDispute applies the resolution updates to a disputed transaction and
+// records the event in one database transaction. A cancelled outcome adds the
+// quantity back to the product, as CancelTransaction does. Returns false if
+// the transaction was no longer disputed.
+func (r *Repository) ResolveDispute(ctx context.Context, id uuid.UUID, updates map[string]interface{}, event *TransactionEvent) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var productID uuid.UUID
+	var quantity int
+	err = tx.QueryRowContext(ctx, `
+		SELECT product_id, quantity FROM transactions
+		WHERE id = $1 AND status = 'disputed'
+		FOR UPDATE`, id).Scan(&productID, &quantity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to lock disputed transaction: %w", err)
+	}
+
+	if err := updateTransaction(ctx, tx, id, updates); err != nil {
+		return false, err
+	}
+
+	if updates["status"] == StatusCancelled {
+		if err := restockProduct(ctx, tx, productID, quantity); err != nil {
+			return false, err
+		}
+	}
+
+	if err := insertEvent(ctx, tx, event); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit dispute resolution: %w", err)
+	}
+
+	return true, nil
+}
+
+// restockProduct adds a cancelled transaction's quantity back to the product,
+// when the product tracks stock. A listing that was unpublished because it
+// sold out is published again.
+func restockProduct(ctx context.Context, db execer, productID uuid.UUID, quantity int) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE products SET quantity = quantity + $2, updated_at = NOW(),
+			published_at = CASE WHEN sold_out_at IS NOT NULL AND status = 'unpublished' THEN NOW() ELSE published_at END,
+			status = CASE WHEN sold_out_at IS NOT NULL AND status = 'unpublished' THEN 'published' ELSE status END,
+			sold_out_at = NULL
+		WHERE id = $1 AND quantity IS NOT NULL`, productID, quantity)
+	if err != nil {
+		return fmt.Errorf("failed to restock product: %w", err)
+	}
+	return nil
+}
+
+// CancelUserTransactions cancels every pending and confirmed transaction of
+// the user with the given reason, restocks their products and records the
+// status events, inside the caller's database transaction. It is used when
+// an account is deleted. It returns the cancelled transactions with the
+// status they had before.
+func CancelUserTransactions(ctx context.Context, tx *sql.Tx, userID uuid.UUID, reason string) ([]*Transaction, error) {
+	rows, err := tx.QueryContext(ctx, `
+		WITH open_transactions AS (
+			SELECT id, status FROM transactions
+			WHERE (buyer_id = $1 OR seller_id = $1) AND status IN ('pending', 'confirmed')
+			FOR UPDATE
+		)
+		UPDATE transactions t
+		SET status = 'cancelled', cancelled_at = NOW(), cancellation_reason = $2, updated_at = NOW()
+		FROM open_transactions
+		WHERE t.id = open_transactions.id
+		RETURNING t.id, t.reference, t.product_id, t.buyer_id, t.seller_id, t.quantity, open_transactions.status`, userID, reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel open transactions: %w", err)
+	}
+
+	var cancelled []*Transaction
+	for rows.Next() {
+		transaction := &Transaction{}
+		if err := rows.Scan(&transaction.ID, &transaction.Reference, &transaction.ProductID,
+			&transaction.BuyerID, &transaction.SellerID, &transaction.Quantity, &transaction.Status); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan cancelled transaction: %w", err)
+		}
+		cancelled = append(cancelled, transaction)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, transaction := range cancelled {
+		if err := restockProduct(ctx, tx, transaction.ProductID, transaction.Quantity); err != nil {
+			return nil, err
+		}
+		if err := insertEvent(ctx, tx, newStatusEvent(transaction, StatusCancelled)); err != nil {
+			return nil, err
+		}
+	}
+
+	return cancelled, nil
+}
+
+// AnonymizeParty scrubs the name, email and phone snapshotted into the
+// metadata of the user's transactions, inside the caller's database
+// transaction. It is used when an account is anonymized.
+func AnonymizeParty(ctx context.Context, db execer, userID uuid.UUID) error {
+	const scrubbed = `'{"name": "Usuario eliminado", "email": "", "phone": ""}'::jsonb`
+
+	for _, party := range []string{"seller", "buyer"} {
+		_, err := db.ExecContext(ctx, `
+			UPDATE transactions
+			SET metadata = jsonb_set(metadata, '{`+party+`_info}', (metadata->'`+party+`_info') || `+scrubbed+`)
+			WHERE `+party+`_id = $1 AND metadata ? '`+party+`_info'`, userID)
+		if err != nil {
+			return fmt.Errorf("failed to anonymize transaction %s details: %w", party, err)
+		}
+	}
+	return nil
+}
+
+// AppendCommunicationMessage adds msg to the end of the transaction's
+// communication log in a single statement, so concurrent messages from both
+// parties are all kept. Logs stored as the legacy empty array start over as
+// an object.
+func (r *Repository) AppendCommunicationMessage(ctx context.Context, id uuid.UUID, msg CommunicationMessage) error {
+	message, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal communication message: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE transactions SET
+			communication_log = COALESCE(CASE WHEN jsonb_typeof(communication_log) = 'object' THEN communication_log END, '{}'::jsonb)
+				|| jsonb_build_object('messages', COALESCE(communication_log->'messages', '[]'::jsonb) || jsonb_build_array($2::jsonb)),
+			updated_at = NOW()
+		WHERE id = $1`, id, message)
+	if err != nil {
+		return fmt.Errorf("failed to add communication message: %w", err)
+	}
+	return nil
+}
+
 // UpdateTransaction updates an existing transaction
 func (r *Repository) UpdateTransaction(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	return updateTransaction(ctx, r.db, id, updates)
+}
+
+// UpdateTransactionWithEvent applies the updates and records the event in one
+// database transaction, so webhooks learn about every committed change
+func (r *Repository) UpdateTransactionWithEvent(ctx context.Context, id uuid.UUID, updates map[string]interface{}, event *TransactionEvent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateTransaction(ctx, tx, id, updates); err != nil {
+		return err
+	}
+
+	if err := insertEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction update: %w", err)
+	}
+	return nil
+}
+
+func updateTransaction(ctx context.Context, db execer, id uuid.UUID, updates map[string]interface{}) error {
 	if len(updates) == 0 {
 		return nil
 	}
@@ -365,7 +856,7 @@ func (r *Repository) UpdateTransaction(ctx context.Context, id uuid.UUID, update
 	query := fmt.Sprintf("UPDATE transactions SET %s WHERE id = $%d", strings.Join(setParts, ", "), argIndex)
 	args = append(args, id)
 
-	_, err := r.db.ExecContext(ctx, query, args...)
+	_, err := db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update transaction: %w", err)
 	}
@@ -374,14 +865,21 @@ func (r *Repository) UpdateTransaction(ctx context.Context, id uuid.UUID, update
 }
 
 // Product Inquiries
+// CreateInquiry inserts the inquiry and bumps the product's inquiries_count atomically
 func (r *Repository) CreateInquiry(ctx context.Context, inquiry *ProductInquiry) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO product_inquiries (
 			id, product_id, buyer_id, seller_id, inquiry_type, subject, message,
 			is_responded, whatsapp_sent, whatsapp_message_id
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = tx.ExecContext(ctx, query,
 		inquiry.ID, inquiry.ProductID, inquiry.BuyerID, inquiry.SellerID,
 		inquiry.InquiryType, inquiry.Subject, inquiry.Message,
 		inquiry.IsResponded, inquiry.WhatsAppSent, inquiry.WhatsAppMessageID)
@@ -390,23 +888,106 @@ func (r *Repository) CreateInquiry(ctx context.Context, inquiry *ProductInquiry)
 		return fmt.Errorf("failed to create inquiry: %w", err)
 	}
 
+	_, err = tx.ExecContext(ctx,
+		`UPDATE products SET inquiries_count = inquiries_count + 1 WHERE id = $1`,
+		inquiry.ProductID)
+	if err != nil {
+		return fmt.Errorf("failed to increment inquiries count: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit inquiry: %w", err)
+	}
+
 	return nil
 }
 
-func (r *Repository) GetInquiryByID(ctx context.Context, id uuid.UUID) (*ProductInquiry, error) {
+// DeleteInquiry soft-deletes an unresponded inquiry and decrements the product's
+// inquiries_count in the same transaction. Returns false if nothing was deleted.
+func (r *Repository) DeleteInquiry(ctx context.Context, id uuid.UUID) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var productID uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+		UPDATE product_inquiries
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND is_responded = false
+		RETURNING product_id`, id).Scan(&productID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to delete inquiry: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE products SET inquiries_count = GREATEST(inquiries_count - 1, 0) WHERE id = $1`,
+		productID)
+	if err != nil {
+		return false, fmt.Errorf("failed to decrement inquiries count: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit inquiry deletion: %w", err)
+	}
+
+	return true, nil
+}
+
+// ReconcileInquiriesCount recomputes products.inquiries_count from the inquiries table.
+// When productID is nil every product is reconciled. Returns the number of rows corrected.
+func (r *Repository) ReconcileInquiriesCount(ctx context.Context, productID *uuid.UUID) (int64, error) {
 	query := `
-		SELECT id, product_id, buyer_id, seller_id, inquiry_type, subject, message,
-			   response, responded_at, is_responded, whatsapp_sent, whatsapp_message_id,
-			   created_at, updated_at
-		FROM product_inquiries 
-		WHERE id = $1`
+		UPDATE products p
+		SET inquiries_count = COALESCE(c.total, 0)
+		FROM products p2
+		LEFT JOIN (
+			SELECT product_id, COUNT(*) AS total
+			FROM product_inquiries
+			WHERE deleted_at IS NULL
+			GROUP BY product_id
+		) c ON c.product_id = p2.id
+		WHERE p.id = p2.id
+		  AND p.inquiries_count IS DISTINCT FROM COALESCE(c.total, 0)
+		  AND ($1::uuid IS NULL OR p.id = $1)`
+
+	result, err := r.db.ExecContext(ctx, query, productID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reconcile inquiries count: %w", err)
+	}
 
-	inquiry := &ProductInquiry{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	return result.RowsAffected()
+}
+
+// inquiryColumns selects an inquiry together with its product title; queries
+// alias product_inquiries as i and join products as p
+const inquiryColumns = `
+	i.id, i.product_id, i.buyer_id, i.seller_id, i.inquiry_type, i.subject, i.message,
+	i.response, i.responded_at, i.is_responded, i.whatsapp_sent, i.whatsapp_message_id,
+	i.created_at, i.updated_at, p.title`
+
+func scanInquiry(row interface{ Scan(...interface{}) error }, inquiry *ProductInquiry) error {
+	return row.Scan(
 		&inquiry.ID, &inquiry.ProductID, &inquiry.BuyerID, &inquiry.SellerID,
 		&inquiry.InquiryType, &inquiry.Subject, &inquiry.Message, &inquiry.Response,
 		&inquiry.RespondedAt, &inquiry.IsResponded, &inquiry.WhatsAppSent,
-		&inquiry.WhatsAppMessageID, &inquiry.CreatedAt, &inquiry.UpdatedAt)
+		&inquiry.WhatsAppMessageID, &inquiry.CreatedAt, &inquiry.UpdatedAt,
+		&inquiry.ProductTitle)
+}
+
+func (r *Repository) GetInquiryByID(ctx context.Context, id uuid.UUID) (*ProductInquiry, error) {
+	query := `
+		SELECT ` + inquiryColumns + `
+		FROM product_inquiries i
+		LEFT JOIN products p ON p.id = i.product_id
+		WHERE i.id = $1 AND i.deleted_at IS NULL`
+
+	inquiry := &ProductInquiry{}
+	err := scanInquiry(r.db.QueryRowContext(ctx, query, id), inquiry)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -418,6 +999,84 @@ func (r *Repository) GetInquiryByID(ctx context.Context, id uuid.UUID) (*Product
 	return inquiry, nil
 }
 
+// ListInquiries retrieves inquiries matching the filters, newest first
+func (r *Repository) ListInquiries(ctx context.Context, filters InquiryFilters, limit, offset int) ([]*ProductInquiry, int, error) {
+	whereConditions := []string{"i.deleted_at IS NULL"}
+	args := []interface{}{}
+	argIndex := 1
+
+	if filters.BuyerID != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("i.buyer_id = $%d", argIndex))
+		args = append(args, *filters.BuyerID)
+		argIndex++
+	}
+
+	if filters.SellerID != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("i.seller_id = $%d", argIndex))
+		args = append(args, *filters.SellerID)
+		argIndex++
+	}
+
+	if filters.UserID != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("(i.buyer_id = $%d OR i.seller_id = $%d)", argIndex, argIndex))
+		args = append(args, *filters.UserID)
+		argIndex++
+	}
+
+	if filters.ProductID != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("i.product_id = $%d", argIndex))
+		args = append(args, *filters.ProductID)
+		argIndex++
+	}
+
+	if filters.InquiryType != "" {
+		whereConditions = append(whereConditions, fmt.Sprintf("i.inquiry_type = $%d", argIndex))
+		args = append(args, filters.InquiryType)
+		argIndex++
+	}
+
+	if filters.IsResponded != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("i.is_responded = $%d", argIndex))
+		args = append(args, *filters.IsResponded)
+		argIndex++
+	}
+
+	whereClause := strings.Join(whereConditions, " AND ")
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM product_inquiries i WHERE %s", whereClause)
+	var totalCount int
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count inquiries: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM product_inquiries i
+		LEFT JOIN products p ON p.id = i.product_id
+		WHERE %s
+		ORDER BY i.created_at DESC
+		LIMIT $%d OFFSET $%d`, inquiryColumns, whereClause, argIndex, argIndex+1)
+
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list inquiries: %w", err)
+	}
+	defer rows.Close()
+
+	inquiries := make([]*ProductInquiry, 0)
+	for rows.Next() {
+		inquiry := &ProductInquiry{}
+		if err := scanInquiry(rows, inquiry); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan inquiry: %w", err)
+		}
+		inquiries = append(inquiries, inquiry)
+	}
+
+	return inquiries, totalCount, rows.Err()
+}
+
 func (r *Repository) UpdateInquiry(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
 	if len(updates) == 0 {
 		return nil
@@ -563,6 +1222,15 @@ type TransactionFilters struct {
 	SortBy    string     `json:"sort_by"`
 }
 
+type InquiryFilters struct {
+	BuyerID     *uuid.UUID `json:"buyer_id"`
+	SellerID    *uuid.UUID `json:"seller_id"`
+	UserID      *uuid.UUID `json:"user_id"` // For inquiries the user either sent or received
+	ProductID   *uuid.UUID `json:"product_id"`
+	InquiryType string     `json:"inquiry_type"`
+	IsResponded *bool      `json:"is_responded"`
+}
+
 type TransactionStatsFilters struct {
 	DateFrom *time.Time `json:"date_from"`
 	DateTo   *time.Time `json:"date_to"`
@@ -0,0 +1,171 @@
+package transactions
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// disputedRow answers the dispute lock with a transaction of 3 units
+func disputedRow(productID uuid.UUID) func(string, []driver.Value) ([]string, [][]driver.Value) {
+	return func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		if strings.Contains(query, "status = 'disputed'") {
+			return []string{"product_id", "quantity"}, [][]driver.Value{{productID.String(), int64(3)}}
+		}
+		return nil, nil
+	}
+}
+
+func TestResolveDisputeCancelledRestocksProduct(t *testing.T) {
+	fake, db := newFakeDB(disputedRow(uuid.New()))
+	repo := NewRepository(db)
+
+	resolved, err := repo.ResolveDispute(context.Background(), uuid.New(),
+		map[string]interface{}{"status": StatusCancelled}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resolved {
+		t.Fatal("expected the disputed transaction to be resolved")
+	}
+	if !fake.executed("quantity = quantity + $2") {
+		t.Error("expected a cancelled resolution to restock the product")
+	}
+	if !fake.executed("THEN 'published'") {
+		t.Error("expected the restock to publish a sold out product again")
+	}
+	if !fake.committed {
+		t.Error("expected the resolution to commit")
+	}
+}
+
+func TestResolveDisputeCompletedKeepsStock(t *testing.T) {
+	fake, db := newFakeDB(disputedRow(uuid.New()))
+	repo := NewRepository(db)
+
+	if _, err := repo.ResolveDispute(context.Background(), uuid.New(),
+		map[string]interface{}{"status": StatusCompleted}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.executed("quantity = quantity + $2") {
+		t.Error("expected a completed resolution to leave stock alone")
+	}
+}
+
+func TestResolveDisputeNoLongerDisputed(t *testing.T) {
+	fake, db := newFakeDB(nil)
+	repo := NewRepository(db)
+
+	resolved, err := repo.ResolveDispute(context.Background(), uuid.New(),
+		map[string]interface{}{"status": StatusCancelled}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved || fake.committed {
+		t.Error("expected nothing to change once the dispute is gone")
+	}
+}
+
+func TestSaveReceiptKeepsConcurrentReceipt(t *testing.T) {
+	fake, db := newFakeDB(nil)
+	repo := NewRepository(db)
+
+	saved, err := repo.SaveReceipt(context.Background(), &receiptRecord{TransactionID: uuid.New()}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if saved {
+		t.Error("expected a conflicting insert to report the receipt as not saved")
+	}
+	if !fake.executed("ON CONFLICT (transaction_id) DO NOTHING") {
+		t.Error("expected the first receipt to be inserted without overwriting")
+	}
+}
+
+func TestSaveReceiptReplacesOnlyPrevious(t *testing.T) {
+	fake, db := newFakeDB(func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"generated_at"}, [][]driver.Value{{time.Now()}}
+	})
+	repo := NewRepository(db)
+
+	previous := &receiptRecord{StoragePath: "receipts/old.pdf"}
+	saved, err := repo.SaveReceipt(context.Background(), &receiptRecord{TransactionID: uuid.New()}, previous)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !saved {
+		t.Error("expected the regenerated receipt to be saved")
+	}
+	if !fake.executed("storage_path = $4") {
+		t.Error("expected the update to be guarded by the previous storage path")
+	}
+}
+
+func TestOpenDisputeOnlyFromInProgress(t *testing.T) {
+	fake, db := newFakeDB(func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		if strings.Contains(query, "status = 'in_progress'") {
+			return []string{"id"}, [][]driver.Value{{args[0]}}
+		}
+		return nil, nil
+	})
+	repo := NewRepository(db)
+
+	opened, err := repo.OpenDispute(context.Background(), uuid.New(), "no llegó", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opened || !fake.committed {
+		t.Error("expected the in-progress transaction to be disputed")
+	}
+}
+
+func TestOpenDisputeNoLongerInProgress(t *testing.T) {
+	fake, db := newFakeDB(nil)
+	repo := NewRepository(db)
+
+	opened, err := repo.OpenDispute(context.Background(), uuid.New(), "no llegó", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opened || fake.committed {
+		t.Error("expected nothing to change once the transaction left in_progress")
+	}
+}
+
+func TestCancelUserTransactionsRestocksEachProduct(t *testing.T) {
+	productID := uuid.New()
+	fake, db := newFakeDB(func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		if strings.Contains(query, "open_transactions") {
+			columns := []string{"id", "reference", "product_id", "buyer_id", "seller_id", "quantity", "status"}
+			return columns, [][]driver.Value{
+				{uuid.NewString(), "AGR-2026-000001", productID.String(), uuid.NewString(), uuid.NewString(), int64(2), StatusPending},
+				{uuid.NewString(), "AGR-2026-000002", productID.String(), uuid.NewString(), uuid.NewString(), int64(1), StatusConfirmed},
+			}
+		}
+		return nil, nil
+	})
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	cancelled, err := CancelUserTransactions(context.Background(), tx, uuid.New(), "cuenta eliminada")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cancelled) != 2 || cancelled[0].Status != StatusPending || cancelled[1].Status != StatusConfirmed {
+		t.Fatalf("expected both transactions with their previous status, got %+v", cancelled)
+	}
+	if !fake.executed("quantity = quantity + $2") {
+		t.Error("expected the products to be restocked")
+	}
+	if !fake.executed("INSERT INTO transaction_events") {
+		t.Error("expected the cancellations to be reported to webhooks")
+	}
+}
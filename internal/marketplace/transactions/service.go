@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"agro-mas-backend/internal/audit"
 	"github.com/google/uuid"
 )
 
@@ -16,14 +18,35 @@ var (
 	ErrTransactionAlreadyExists = errors.New("transaction already exists for this product and buyer")
 	ErrProductNotAvailable      = errors.New("product is not available for transaction")
 	ErrInsufficientQuantity     = errors.New("insufficient product quantity")
+	ErrBelowMinimumOrder        = errors.New("quantity is below the product's minimum order")
 	ErrInvalidReviewRating      = errors.New("review rating must be between 1 and 5")
 	ErrReviewAlreadyExists      = errors.New("review already exists for this transaction")
 	ErrInquiryNotFound          = errors.New("inquiry not found")
 	ErrInquiryNotAuthorized     = errors.New("user not authorized for this inquiry")
+	ErrInquiryAlreadyResponded  = errors.New("inquiry has already been responded to")
+	ErrOwnProductInquiry        = errors.New("cannot send an inquiry about your own product")
+	ErrInvalidMessageChannel    = errors.New("invalid message channel")
+	ErrInvalidMessageType       = errors.New("invalid message type")
+	ErrDisputeNotAllowed        = errors.New("only in-progress transactions can be disputed")
+	ErrDisputeReasonRequired    = errors.New("dispute reason is required")
+	ErrTransactionNotDisputed   = errors.New("transaction is not disputed")
+	ErrInvalidDisputeResolution = errors.New("dispute must be resolved as completed or cancelled")
+	ErrInvalidIdempotencyKey    = errors.New("idempotency key must be between 1 and 255 characters")
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used with a different request")
+	ErrInvalidInquiryRole       = errors.New("inquiry role must be buyer or seller")
+	ErrInvalidInquiryType       = errors.New("invalid inquiry type")
+	ErrCancelReasonRequired     = errors.New("cancellation reason is required")
+	ErrCancelNotAllowed         = errors.New("only pending or confirmed transactions can be cancelled")
 )
 
 type Service struct {
-	repo *Repository
+	repo        *Repository
+	idempotency *idempotencyKeys
+
+	// Unpublish products once transactions use up their stock
+	unpublishSoldOut bool
+
+	audit *audit.Recorder
 }
 
 type ProductInfo struct {
@@ -35,6 +58,7 @@ type ProductInfo struct {
 	Currency          string    `json:"currency"`
 	Unit              *string   `json:"unit"`
 	Quantity          *int      `json:"quantity"`
+	MinOrderQuantity  *int      `json:"min_order_quantity"`
 	IsActive          bool      `json:"is_active"`
 	IsAvailable       bool      `json:"is_available"`
 	SellerID          uuid.UUID `json:"seller_id"`
@@ -42,10 +66,22 @@ type ProductInfo struct {
 
 func NewService(repo *Repository) *Service {
 	return &Service{
-		repo: repo,
+		repo:        repo,
+		idempotency: newIdempotencyKeys(repo, DefaultIdempotencyKeyTTL),
 	}
 }
 
+// SetAuditRecorder records admin actions such as dispute resolutions
+func (s *Service) SetAuditRecorder(recorder *audit.Recorder) {
+	s.audit = recorder
+}
+
+// SetUnpublishSoldOut enables unpublishing products whose stock a new
+// transaction brings to zero
+func (s *Service) SetUnpublishSoldOut(enabled bool) {
+	s.unpublishSoldOut = enabled
+}
+
 // CreateTransaction creates a new transaction
 func (s *Service) CreateTransaction(ctx context.Context, buyerID uuid.UUID, req *CreateTransactionRequest, productInfo ProductInfo, sellerInfo SellerInfo, buyerInfo BuyerInfo) (*Transaction, error) {
 	// Validate product availability
@@ -58,11 +94,25 @@ func (s *Service) CreateTransaction(ctx context.Context, buyerID uuid.UUID, req
 		return nil, ErrInsufficientQuantity
 	}
 
+	// Enforce the seller's minimum order
+	if productInfo.MinOrderQuantity != nil && req.Quantity < *productInfo.MinOrderQuantity {
+		return nil, ErrBelowMinimumOrder
+	}
+
 	// Prevent self-transactions
 	if buyerID == productInfo.SellerID {
 		return nil, errors.New("cannot create transaction for your own product")
 	}
 
+	// Only one open transaction per buyer and product
+	hasOpen, err := s.repo.HasOpenTransaction(ctx, req.ProductID, buyerID)
+	if err != nil {
+		return nil, err
+	}
+	if hasOpen {
+		return nil, ErrTransactionAlreadyExists
+	}
+
 	// Calculate final price
 	var finalPrice float64
 	if req.NegotiatedPrice != nil {
@@ -122,8 +172,11 @@ func (s *Service) CreateTransaction(ctx context.Context, buyerID uuid.UUID, req
 		},
 	}
 
-	// Create transaction in database
-	if err := s.repo.CreateTransaction(ctx, transaction); err != nil {
+	// Create transaction in database, reserving its quantity
+	if err := s.repo.CreateTransaction(ctx, transaction, s.unpublishSoldOut); err != nil {
+		if err == ErrTransactionAlreadyExists || err == ErrInsufficientQuantity {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
@@ -148,6 +201,24 @@ func (s *Service) GetTransactionByID(ctx context.Context, userID, transactionID
 	return transaction, nil
 }
 
+// GetTransactionByReference retrieves a transaction by its human-readable reference
+func (s *Service) GetTransactionByReference(ctx context.Context, userID uuid.UUID, reference string) (*Transaction, error) {
+	transaction, err := s.repo.GetTransactionByReference(ctx, strings.ToUpper(strings.TrimSpace(reference)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if transaction == nil {
+		return nil, ErrTransactionNotFound
+	}
+
+	// Only the parties to the transaction may look it up
+	if transaction.BuyerID != userID && transaction.SellerID != userID {
+		return nil, ErrTransactionNotAuthorized
+	}
+
+	return transaction, nil
+}
+
 // UpdateTransactionStatus updates the status of a transaction
 func (s *Service) UpdateTransactionStatus(ctx context.Context, userID, transactionID uuid.UUID, newStatus string) error {
 	// Validate status
@@ -188,7 +259,7 @@ func (s *Service) UpdateTransactionStatus(ctx context.Context, userID, transacti
 	}
 
 	// Update transaction
-	return s.repo.UpdateTransaction(ctx, transactionID, updates)
+	return s.repo.UpdateTransactionWithEvent(ctx, transactionID, updates, newStatusEvent(transaction, newStatus))
 }
 
 // UpdateTransaction updates transaction details
@@ -209,6 +280,7 @@ func (s *Service) UpdateTransaction(ctx context.Context, userID, transactionID u
 
 	// Prepare updates
 	updates := make(map[string]interface{})
+	var event *TransactionEvent
 
 	if req.Status != nil {
 		if !IsValidTransactionStatus(*req.Status) {
@@ -218,6 +290,7 @@ func (s *Service) UpdateTransaction(ctx context.Context, userID, transactionID u
 			return nil, err
 		}
 		updates["status"] = *req.Status
+		event = newStatusEvent(transaction, *req.Status)
 	}
 
 	if req.NegotiatedPrice != nil {
@@ -277,7 +350,7 @@ func (s *Service) UpdateTransaction(ctx context.Context, userID, transactionID u
 	}
 
 	// Update transaction
-	if err := s.repo.UpdateTransaction(ctx, transactionID, updates); err != nil {
+	if err := s.repo.UpdateTransactionWithEvent(ctx, transactionID, updates, event); err != nil {
 		return nil, fmt.Errorf("failed to update transaction: %w", err)
 	}
 
@@ -285,6 +358,161 @@ func (s *Service) UpdateTransaction(ctx context.Context, userID, transactionID u
 	return s.repo.GetTransactionByID(ctx, transactionID)
 }
 
+// OpenDispute moves an in-progress transaction to disputed, recording the
+// reason. Only the buyer or seller may open a dispute.
+func (s *Service) OpenDispute(ctx context.Context, userID, transactionID uuid.UUID, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrDisputeReasonRequired
+	}
+
+	transaction, err := s.GetTransactionByID(ctx, userID, transactionID)
+	if err != nil {
+		return err
+	}
+
+	if transaction.Status != StatusInProgress {
+		return ErrDisputeNotAllowed
+	}
+
+	opened, err := s.repo.OpenDispute(ctx, transactionID, reason, newStatusEvent(transaction, StatusDisputed))
+	if err != nil {
+		return err
+	}
+	if !opened {
+		// The status changed since it was read
+		return ErrDisputeNotAllowed
+	}
+
+	return nil
+}
+
+// CancelTransaction cancels a pending or confirmed transaction, recording the
+// reason, and returns its quantity to the product's stock. Only the buyer or
+// seller may cancel.
+func (s *Service) CancelTransaction(ctx context.Context, userID, transactionID uuid.UUID, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrCancelReasonRequired
+	}
+
+	transaction, err := s.GetTransactionByID(ctx, userID, transactionID)
+	if err != nil {
+		return err
+	}
+
+	if transaction.Status != StatusPending && transaction.Status != StatusConfirmed {
+		return ErrCancelNotAllowed
+	}
+
+	cancelled, err := s.repo.CancelTransaction(ctx, transactionID, reason, newStatusEvent(transaction, StatusCancelled))
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		// The status changed since it was read
+		return ErrCancelNotAllowed
+	}
+
+	return nil
+}
+
+// ResolveDispute closes a disputed transaction as completed or cancelled and
+// records who resolved it and how. Cancelling restocks the product the same
+// way CancelTransaction does. Callers must restrict this to admins.
+func (s *Service) ResolveDispute(ctx context.Context, adminID, transactionID uuid.UUID, resolution, finalStatus string) error {
+	resolution = strings.TrimSpace(resolution)
+	if resolution == "" || (finalStatus != StatusCompleted && finalStatus != StatusCancelled) {
+		return ErrInvalidDisputeResolution
+	}
+
+	transaction, err := s.repo.GetTransactionByID(ctx, transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if transaction == nil {
+		return ErrTransactionNotFound
+	}
+
+	if transaction.Status != StatusDisputed {
+		return ErrTransactionNotDisputed
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":              finalStatus,
+		"dispute_resolution":  resolution,
+		"dispute_resolved_at": now,
+		"dispute_resolved_by": adminID,
+	}
+
+	switch finalStatus {
+	case StatusCompleted:
+		updates["completed_at"] = now
+	case StatusCancelled:
+		updates["cancelled_at"] = now
+	}
+
+	resolved, err := s.repo.ResolveDispute(ctx, transactionID, updates, newStatusEvent(transaction, finalStatus))
+	if err != nil {
+		return err
+	}
+	if !resolved {
+		return ErrTransactionNotDisputed
+	}
+
+	s.audit.Record(ctx, adminID, audit.ActionDisputeResolved, audit.EntityTransaction, transactionID, map[string]interface{}{
+		"status":         transaction.Status,
+		"dispute_reason": transaction.DisputeReason,
+	}, updates)
+
+	return nil
+}
+
+// AddCommunicationMessage appends a message to the transaction's communication
+// log. The sender must be a party to the transaction; the receiver is the other
+// party, and the ID and timestamp are assigned here.
+func (s *Service) AddCommunicationMessage(ctx context.Context, userID, transactionID uuid.UUID, msg CommunicationMessage) (*CommunicationMessage, error) {
+	if !IsValidMessageChannel(msg.Channel) {
+		return nil, ErrInvalidMessageChannel
+	}
+	if !IsValidMessageType(msg.MessageType) {
+		return nil, ErrInvalidMessageType
+	}
+
+	transaction, err := s.GetTransactionByID(ctx, userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	msg.ID = uuid.New().String()
+	msg.Timestamp = time.Now()
+	msg.SenderID = userID
+	msg.ReceiverID = transaction.SellerID
+	if userID == transaction.SellerID {
+		msg.ReceiverID = transaction.BuyerID
+	}
+
+	if err := s.repo.AppendCommunicationMessage(ctx, transactionID, msg); err != nil {
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
+// GetCommunicationMessages returns the communication log of a transaction to one of its parties
+func (s *Service) GetCommunicationMessages(ctx context.Context, userID, transactionID uuid.UUID) ([]CommunicationMessage, error) {
+	transaction, err := s.GetTransactionByID(ctx, userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.CommunicationLog == nil {
+		return []CommunicationMessage{}, nil
+	}
+	return transaction.CommunicationLog.Messages, nil
+}
+
 // AddReview adds a review for a completed transaction
 func (s *Service) AddReview(ctx context.Context, userID, transactionID uuid.UUID, req *AddReviewRequest) error {
 	// Validate rating
@@ -311,28 +539,34 @@ func (s *Service) AddReview(ctx context.Context, userID, transactionID uuid.UUID
 		return errors.New("can only review completed transactions")
 	}
 
-	// Prepare updates based on who is reviewing
-	updates := make(map[string]interface{})
-	
-	if userID == transaction.BuyerID {
-		// Buyer reviewing seller
+	// The buyer reviews the seller and the seller reviews the buyer
+	reviewerIsBuyer := userID == transaction.BuyerID
+	reviewedUserID := transaction.BuyerID
+	if reviewerIsBuyer {
 		if transaction.BuyerRating != nil {
 			return ErrReviewAlreadyExists
 		}
-		updates["buyer_rating"] = req.Rating
-		updates["buyer_review"] = req.Review
-		updates["buyer_review_date"] = time.Now()
-	} else {
-		// Seller reviewing buyer
-		if transaction.SellerRating != nil {
-			return ErrReviewAlreadyExists
-		}
-		updates["seller_rating"] = req.Rating
-		updates["seller_review"] = req.Review
-		updates["seller_review_date"] = time.Now()
+		reviewedUserID = transaction.SellerID
+	} else if transaction.SellerRating != nil {
+		return ErrReviewAlreadyExists
+	}
+
+	// Saving the review also refreshes the reviewed user's rating aggregates
+	event := newReviewEvent(transaction, userID, reviewedUserID, req.Rating)
+	saved, err := s.repo.SaveReview(ctx, transactionID, reviewerIsBuyer, req.Rating, req.Review, reviewedUserID, event)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return ErrReviewAlreadyExists
 	}
 
-	return s.repo.UpdateTransaction(ctx, transactionID, updates)
+	return nil
+}
+
+// RecalculateUserRating rebuilds a user's rating aggregates from their transactions
+func (s *Service) RecalculateUserRating(ctx context.Context, userID uuid.UUID) error {
+	return s.repo.RecalculateUserRating(ctx, userID)
 }
 
 // ListTransactions retrieves transactions with filters and pagination
@@ -411,6 +645,15 @@ func (s *Service) ListTransactions(ctx context.Context, userID *uuid.UUID, req *
 	}, nil
 }
 
+// ListActiveTransactions retrieves the buyer's open purchases
+func (s *Service) ListActiveTransactions(ctx context.Context, buyerID uuid.UUID) ([]*ActiveTransaction, error) {
+	transactions, err := s.repo.ListActiveBuyerTransactions(ctx, buyerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active transactions: %w", err)
+	}
+	return transactions, nil
+}
+
 // GetTransactionStats retrieves transaction statistics
 func (s *Service) GetTransactionStats(ctx context.Context, userID *uuid.UUID, dateFrom, dateTo *time.Time) (*TransactionStatsResponse, error) {
 	filters := TransactionStatsFilters{
@@ -428,6 +671,10 @@ func (s *Service) CreateInquiry(ctx context.Context, buyerID uuid.UUID, req *Cre
 		return nil, errors.New("invalid inquiry type")
 	}
 
+	if buyerID == sellerID {
+		return nil, ErrOwnProductInquiry
+	}
+
 	inquiry := &ProductInquiry{
 		ID:          uuid.New(),
 		ProductID:   req.ProductID,
@@ -448,6 +695,113 @@ func (s *Service) CreateInquiry(ctx context.Context, buyerID uuid.UUID, req *Cre
 	return inquiry, nil
 }
 
+// DeleteInquiry lets the buyer retract an inquiry the seller has not answered yet
+// ListInquiries retrieves the inquiries the user sent as a buyer or received
+// as a seller, optionally narrowed to one role
+func (s *Service) ListInquiries(ctx context.Context, userID uuid.UUID, req *InquiryListRequest) (*InquiryListResponse, error) {
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	filters := InquiryFilters{
+		IsResponded: req.IsResponded,
+	}
+
+	switch req.Role {
+	case "":
+		filters.UserID = &userID
+	case "buyer":
+		filters.BuyerID = &userID
+	case "seller":
+		filters.SellerID = &userID
+	default:
+		return nil, ErrInvalidInquiryRole
+	}
+
+	if req.InquiryType != "" {
+		if !IsValidInquiryType(req.InquiryType) {
+			return nil, ErrInvalidInquiryType
+		}
+		filters.InquiryType = req.InquiryType
+	}
+
+	if req.ProductID != "" {
+		if productID, err := uuid.Parse(req.ProductID); err == nil {
+			filters.ProductID = &productID
+		}
+	}
+
+	inquiries, totalCount, err := s.repo.ListInquiries(ctx, filters, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inquiries: %w", err)
+	}
+
+	return &InquiryListResponse{
+		Inquiries:  inquiries,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (totalCount + pageSize - 1) / pageSize,
+	}, nil
+}
+
+// GetInquiry retrieves an inquiry the user is the buyer or seller of
+func (s *Service) GetInquiry(ctx context.Context, userID, inquiryID uuid.UUID) (*ProductInquiry, error) {
+	inquiry, err := s.repo.GetInquiryByID(ctx, inquiryID)
+	if err != nil {
+		return nil, err
+	}
+	if inquiry == nil {
+		return nil, ErrInquiryNotFound
+	}
+	if inquiry.BuyerID != userID && inquiry.SellerID != userID {
+		return nil, ErrInquiryNotAuthorized
+	}
+	return inquiry, nil
+}
+
+func (s *Service) DeleteInquiry(ctx context.Context, buyerID, inquiryID uuid.UUID) error {
+	inquiry, err := s.repo.GetInquiryByID(ctx, inquiryID)
+	if err != nil {
+		return fmt.Errorf("failed to get inquiry: %w", err)
+	}
+	if inquiry == nil {
+		return ErrInquiryNotFound
+	}
+
+	// Only the buyer who sent the inquiry can retract it
+	if inquiry.BuyerID != buyerID {
+		return ErrInquiryNotAuthorized
+	}
+
+	// Responded inquiries are kept as part of the seller's record
+	if inquiry.IsResponded {
+		return ErrInquiryAlreadyResponded
+	}
+
+	deleted, err := s.repo.DeleteInquiry(ctx, inquiryID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		// Responded or deleted concurrently
+		return ErrInquiryAlreadyResponded
+	}
+
+	return nil
+}
+
+// ReconcileInquiriesCount repairs drifted product inquiry counters.
+// Pass nil to reconcile every product.
+func (s *Service) ReconcileInquiriesCount(ctx context.Context, productID *uuid.UUID) (int64, error) {
+	return s.repo.ReconcileInquiriesCount(ctx, productID)
+}
+
 func (s *Service) RespondToInquiry(ctx context.Context, sellerID, inquiryID uuid.UUID, req *RespondToInquiryRequest) error {
 	// Get inquiry
 	inquiry, err := s.repo.GetInquiryByID(ctx, inquiryID)
@@ -475,23 +829,18 @@ func (s *Service) RespondToInquiry(ctx context.Context, sellerID, inquiryID uuid
 
 // Helper functions
 func (s *Service) validateStatusTransition(currentStatus, newStatus string, userID uuid.UUID, transaction *Transaction) error {
-	// Define allowed transitions
+	// Define allowed transitions. Disputes are opened and resolved through
+	// OpenDispute and ResolveDispute, and cancellations go through
+	// CancelTransaction so stock is restored, never through a plain status change.
 	allowedTransitions := map[string]map[string]bool{
 		StatusPending: {
-			StatusConfirmed:  true,
-			StatusCancelled:  true,
+			StatusConfirmed: true,
 		},
 		StatusConfirmed: {
 			StatusInProgress: true,
-			StatusCancelled:  true,
 		},
 		StatusInProgress: {
 			StatusCompleted: true,
-			StatusDisputed:  true,
-		},
-		StatusDisputed: {
-			StatusCompleted: true,
-			StatusCancelled: true,
 		},
 	}
 
@@ -0,0 +1,163 @@
+package transactions
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"agro-mas-backend/pkg/logger"
+	"agro-mas-backend/pkg/safehttp"
+	"github.com/google/uuid"
+)
+
+// Webhook delivery defaults
+const (
+	DefaultWebhookTimeout     = 10 * time.Second
+	DefaultWebhookMaxAttempts = 10
+
+	webhookBatchSize   = 50
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+)
+
+// Headers sent with every webhook delivery. The signature is
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<unix seconds>.<body>">" keyed
+// with the webhook secret.
+const (
+	WebhookSignatureHeader = "X-Agromas-Signature"
+	WebhookEventHeader     = "X-Agromas-Event"
+	WebhookDeliveryHeader  = "X-Agromas-Delivery"
+)
+
+// WebhookDispatcher posts outbox events to the webhooks they were fanned out
+// to, retrying failures with exponential backoff. Its client refuses to
+// connect to internal addresses, whatever the webhook host resolves to now.
+type WebhookDispatcher struct {
+	repo        *Repository
+	client      *http.Client
+	maxAttempts int
+}
+
+func NewWebhookDispatcher(repo *Repository, timeout time.Duration, maxAttempts int) *WebhookDispatcher {
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultWebhookMaxAttempts
+	}
+	return &WebhookDispatcher{
+		repo:        repo,
+		client:      safehttp.NewClient(timeout),
+		maxAttempts: maxAttempts,
+	}
+}
+
+// webhookBody is the JSON posted to webhooks
+type webhookBody struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// DispatchPending sends the events that are due and returns how many were
+// delivered. Failed deliveries are rescheduled, so only claiming errors are returned.
+func (d *WebhookDispatcher) DispatchPending(ctx context.Context) (int, error) {
+	// Leave room for every delivery of the batch to time out before another
+	// instance may claim the same rows
+	lease := d.client.Timeout*webhookBatchSize + time.Minute
+
+	deliveries, err := d.repo.ClaimWebhookDeliveries(ctx, webhookBatchSize, d.maxAttempts, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if err := d.deliver(ctx, delivery); err != nil {
+			retryIn := webhookBackoff(delivery.Attempts + 1)
+			logger.FromContext(ctx).Warn("webhook delivery failed",
+				"event_id", delivery.ID, "attempt", delivery.Attempts+1, "retry_in", retryIn.String(), "error", err)
+			if err := d.repo.MarkWebhookFailed(ctx, delivery.ID, err.Error(), retryIn); err != nil {
+				logger.FromContext(ctx).Warn("failed to reschedule webhook delivery", "event_id", delivery.ID, "error", err)
+			}
+			continue
+		}
+
+		if err := d.repo.MarkWebhookDelivered(ctx, delivery.ID); err != nil {
+			// The receiver may see it again; deliveries carry an ID to dedupe on
+			logger.FromContext(ctx).Warn("failed to mark webhook delivered", "event_id", delivery.ID, "error", err)
+			continue
+		}
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery *WebhookDelivery) error {
+	body, err := json.Marshal(webhookBody{
+		ID:        delivery.ID,
+		Type:      delivery.EventType,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(delivery.Secret, time.Now().Unix(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the signature header value for a webhook body
+// sent at timestamp
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	ts := strconv.FormatInt(timestamp, 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff doubles the wait after each failed attempt, up to webhookMaxBackoff
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,345 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"time"
+
+	"agro-mas-backend/internal/audit"
+	"agro-mas-backend/pkg/gcloud"
+	"agro-mas-backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidDocumentType    = errors.New("invalid document type")
+	ErrInvalidDocumentFile    = errors.New("invalid document file")
+	ErrDocumentNotFound       = errors.New("document not found")
+	ErrDocumentAccessDenied   = errors.New("only the document owner or an admin can access this document")
+	ErrDocumentStorageMissing = errors.New("document storage is not configured")
+	ErrInvalidReviewStatus    = errors.New("review status must be approved or rejected")
+)
+
+// DocumentURLTTL is how long a signed document URL stays valid
+const DocumentURLTTL = 15 * time.Minute
+
+// Verification document types, matching the keys of VerificationDocuments
+const (
+	DocumentTypeIdentity        = "identity_document"
+	DocumentTypeCUITCertificate = "cuit_certificate"
+	DocumentTypeBusinessLicense = "business_license"
+	DocumentTypePropertyDeeds   = "property_deeds"
+)
+
+// Review states of a verification document
+const (
+	DocumentStatusPending  = "pending"
+	DocumentStatusApproved = "approved"
+	DocumentStatusRejected = "rejected"
+)
+
+// documentLevels lists the documents that must be approved to reach each
+// verification level above email (2=cuit, 3=business, 4=field). Each level
+// also requires the documents of the levels below it.
+var documentLevels = []struct {
+	Level     int
+	Documents []string
+}{
+	{Level: 2, Documents: []string{DocumentTypeIdentity, DocumentTypeCUITCertificate}},
+	{Level: 3, Documents: []string{DocumentTypeBusinessLicense}},
+	{Level: 4, Documents: []string{DocumentTypePropertyDeeds}},
+}
+
+// DocumentURLResponse is a short-lived link to a private verification document
+type DocumentURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ReviewDocumentRequest is an admin's decision on one verification document
+type ReviewDocumentRequest struct {
+	Status string  `json:"status" binding:"required"`
+	Notes  *string `json:"notes"`
+}
+
+// DocumentReviewResult is the reviewed document and the user's resulting level
+type DocumentReviewResult struct {
+	UserID            uuid.UUID     `json:"user_id"`
+	DocumentType      string        `json:"document_type"`
+	Document          *DocumentInfo `json:"document"`
+	VerificationLevel int           `json:"verification_level"`
+}
+
+// VerificationQueueEntry is a user with documents awaiting review
+type VerificationQueueEntry struct {
+	UserID            uuid.UUID              `json:"user_id"`
+	Email             string                 `json:"email"`
+	FirstName         string                 `json:"first_name"`
+	LastName          string                 `json:"last_name"`
+	BusinessName      *string                `json:"business_name,omitempty"`
+	VerificationLevel int                    `json:"verification_level"`
+	PendingDocuments  []string               `json:"pending_documents"`
+	OldestPendingAt   time.Time              `json:"oldest_pending_at"`
+	Documents         *VerificationDocuments `json:"documents"`
+}
+
+type VerificationQueueResponse struct {
+	Users      []*VerificationQueueEntry `json:"users"`
+	TotalCount int                       `json:"total_count"`
+	Page       int                       `json:"page"`
+	PageSize   int                       `json:"page_size"`
+	TotalPages int                       `json:"total_pages"`
+}
+
+// DocumentService stores KYC documents privately. Unlike product images they
+// are never publicly readable and are only served through signed URLs.
+type DocumentService struct {
+	repo          *Repository
+	storageClient *gcloud.StorageClient
+	audit         *audit.Recorder
+}
+
+func NewDocumentService(repo *Repository, storageClient *gcloud.StorageClient) *DocumentService {
+	return &DocumentService{
+		repo:          repo,
+		storageClient: storageClient,
+	}
+}
+
+// slot returns the field of docs holding the given document type, or nil if
+// the type is unknown
+func (docs *VerificationDocuments) slot(docType string) **DocumentInfo {
+	switch docType {
+	case DocumentTypeIdentity:
+		return &docs.IdentityDocument
+	case DocumentTypeCUITCertificate:
+		return &docs.CUITCertificate
+	case DocumentTypeBusinessLicense:
+		return &docs.BusinessLicense
+	case DocumentTypePropertyDeeds:
+		return &docs.PropertyDeeds
+	}
+	return nil
+}
+
+// Document returns the document of the given type, or nil if none was uploaded
+func (docs *VerificationDocuments) Document(docType string) *DocumentInfo {
+	if docs == nil {
+		return nil
+	}
+	if slot := docs.slot(docType); slot != nil {
+		return *slot
+	}
+	return nil
+}
+
+// DocumentVerificationLevel returns the highest verification level earned by
+// the approved documents, or 0 when they don't complete any level
+func DocumentVerificationLevel(docs *VerificationDocuments) int {
+	earned := 0
+	for _, step := range documentLevels {
+		for _, docType := range step.Documents {
+			document := docs.Document(docType)
+			if document == nil || document.Status != DocumentStatusApproved {
+				return earned
+			}
+		}
+		earned = step.Level
+	}
+	return earned
+}
+
+// UploadDocument stores a verification document under documents/{userID} and
+// records it as pending review, replacing any previous document of that type
+func (s *DocumentService) UploadDocument(ctx context.Context, userID uuid.UUID, docType string, file multipart.File, header *multipart.FileHeader) (*DocumentInfo, error) {
+	if (&VerificationDocuments{}).slot(docType) == nil {
+		return nil, ErrInvalidDocumentType
+	}
+	if err := gcloud.ValidateDocumentFile(header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocumentFile, err)
+	}
+	if s.storageClient == nil {
+		return nil, ErrDocumentStorageMissing
+	}
+
+	uploadOptions := gcloud.UploadOptions{
+		Directory:    "documents",
+		SubDirectory: userID.String(),
+		// Unique per upload so a quick re-upload never lands on the path of
+		// the document it replaces, which is deleted afterwards
+		FileName:     docType + "_" + uuid.NewString(),
+		PublicRead:   false,
+		CacheControl: "private, no-store",
+		Metadata: map[string]string{
+			"user_id":       userID.String(),
+			"document_type": docType,
+		},
+	}
+
+	uploadResult, err := s.storageClient.UploadFile(ctx, file, header, uploadOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload document to storage: %w", err)
+	}
+
+	document := &DocumentInfo{
+		StoragePath: uploadResult.StoragePath,
+		FileName:    uploadResult.FileName,
+		FileSize:    uploadResult.FileSize,
+		MimeType:    uploadResult.MimeType,
+		UploadedAt:  uploadResult.UploadedAt,
+		Status:      DocumentStatusPending,
+	}
+
+	var previousPath string
+	docs, err := s.repo.UpdateVerificationDocuments(ctx, userID, func(docs *VerificationDocuments) error {
+		slot := docs.slot(docType)
+		if *slot != nil {
+			previousPath = (*slot).StoragePath
+		}
+		*slot = document
+		return nil
+	})
+	if err == nil && docs == nil {
+		err = ErrUserNotFound
+	}
+	if err != nil {
+		if deleteErr := s.storageClient.DeleteFile(ctx, uploadResult.StoragePath); deleteErr != nil {
+			logger.FromContext(ctx).Warn("failed to clean up uploaded document after database error", "path", uploadResult.StoragePath, "error", deleteErr)
+		}
+		return nil, err
+	}
+
+	if previousPath != "" {
+		if err := s.storageClient.DeleteFile(ctx, previousPath); err != nil {
+			logger.FromContext(ctx).Warn("failed to delete replaced document", "path", previousPath, "error", err)
+		}
+	}
+
+	return document, nil
+}
+
+// SetAuditRecorder records document reviews
+func (s *DocumentService) SetAuditRecorder(recorder *audit.Recorder) {
+	s.audit = recorder
+}
+
+// GetDocumentURL returns a signed URL for one of userID's verification
+// documents. Only the owner or an admin may request it.
+func (s *DocumentService) GetDocumentURL(ctx context.Context, requesterID uuid.UUID, requesterRole string, userID uuid.UUID, docType string) (*DocumentURLResponse, error) {
+	if requesterID != userID && requesterRole != "admin" {
+		return nil, ErrDocumentAccessDenied
+	}
+	if (&VerificationDocuments{}).slot(docType) == nil {
+		return nil, ErrInvalidDocumentType
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	document := user.VerificationDocuments.Document(docType)
+	if document == nil || document.StoragePath == "" {
+		return nil, ErrDocumentNotFound
+	}
+	if s.storageClient == nil {
+		return nil, ErrDocumentStorageMissing
+	}
+
+	expiresAt := time.Now().Add(DocumentURLTTL)
+	url, err := s.storageClient.GetFileURL(ctx, document.StoragePath, DocumentURLTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign document URL: %w", err)
+	}
+
+	return &DocumentURLResponse{URL: url, ExpiresAt: expiresAt}, nil
+}
+
+// GetVerificationQueue lists users with documents awaiting review, oldest first
+func (s *DocumentService) GetVerificationQueue(ctx context.Context, page, pageSize int) (*VerificationQueueResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	entries, totalCount, err := s.repo.ListPendingDocumentUsers(ctx, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		entry.PendingDocuments = []string{}
+		for _, step := range documentLevels {
+			for _, docType := range step.Documents {
+				if document := entry.Documents.Document(docType); document != nil && document.Status == DocumentStatusPending {
+					entry.PendingDocuments = append(entry.PendingDocuments, docType)
+				}
+			}
+		}
+	}
+
+	return &VerificationQueueResponse{
+		Users:      entries,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (totalCount + pageSize - 1) / pageSize,
+	}, nil
+}
+
+// ReviewDocument approves or rejects one of a user's verification documents,
+// recording the reviewer. Once the approved documents complete a level, the
+// user's verification level is raised to it.
+func (s *DocumentService) ReviewDocument(ctx context.Context, reviewerID, userID uuid.UUID, docType string, req *ReviewDocumentRequest) (*DocumentReviewResult, error) {
+	if req.Status != DocumentStatusApproved && req.Status != DocumentStatusRejected {
+		return nil, ErrInvalidReviewStatus
+	}
+	if (&VerificationDocuments{}).slot(docType) == nil {
+		return nil, ErrInvalidDocumentType
+	}
+
+	var before, reviewed *DocumentInfo
+	docs, level, err := s.repo.ReviewVerificationDocuments(ctx, userID, reviewerID, func(docs *VerificationDocuments) error {
+		document := *docs.slot(docType)
+		if document == nil {
+			return ErrDocumentNotFound
+		}
+		previous := *document
+		before = &previous
+
+		reviewedAt := time.Now()
+		document.Status = req.Status
+		document.ReviewNotes = req.Notes
+		document.ReviewedBy = &reviewerID
+		document.ReviewedAt = &reviewedAt
+		reviewed = document
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if docs == nil {
+		return nil, ErrUserNotFound
+	}
+
+	result := &DocumentReviewResult{
+		UserID:            userID,
+		DocumentType:      docType,
+		Document:          reviewed,
+		VerificationLevel: level,
+	}
+
+	s.audit.Record(ctx, reviewerID, audit.ActionDocumentReviewed, audit.EntityUser, userID, map[string]interface{}{
+		"document_type": docType,
+		"document":      before,
+	}, result)
+
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"agro-mas-backend/internal/auth"
+	"agro-mas-backend/pkg/logger"
+	"agro-mas-backend/pkg/mailer"
+)
+
+// DefaultEmailVerificationTTL is how long a verification link stays valid
+const DefaultEmailVerificationTTL = 24 * time.Hour
+
+// SetMailer configures how verification and password reset emails are sent. Links point at
+// appBaseURL; with a nil mailer they are only logged, which is enough for development.
+func (s *Service) SetMailer(m mailer.Mailer, appBaseURL string) {
+	s.mailer = m
+	s.appBaseURL = strings.TrimRight(appBaseURL, "/")
+}
+
+// SetEmailVerificationTTL overrides how long verification links stay valid
+func (s *Service) SetEmailVerificationTTL(ttl time.Duration) {
+	if ttl > 0 {
+		s.emailVerificationTTL = ttl
+	}
+}
+
+// VerifyEmail consumes a verification token and marks the owner's email as verified
+func (s *Service) VerifyEmail(ctx context.Context, token string) error {
+	tokenHash := auth.HashToken(token)
+
+	userID, err := s.repo.ConsumeEmailVerificationToken(ctx, tokenHash)
+	if err != nil {
+		return err
+	}
+	if userID != nil {
+		return nil
+	}
+
+	expired, err := s.repo.IsEmailVerificationTokenExpired(ctx, tokenHash)
+	if err != nil {
+		return err
+	}
+	if expired {
+		return ErrVerificationTokenExpired
+	}
+	return ErrInvalidVerificationToken
+}
+
+// ResendEmailVerification issues a new verification link, invalidating earlier
+// ones. Unknown and already verified emails are ignored so the endpoint cannot
+// be used to probe accounts.
+func (s *Service) ResendEmailVerification(ctx context.Context, email string) error {
+	user, err := s.repo.GetUserByEmail(ctx, strings.ToLower(email))
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil
+	}
+
+	verified, err := s.repo.IsEmailVerified(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if verified {
+		return nil
+	}
+
+	return s.sendEmailVerification(ctx, user)
+}
+
+// sendEmailVerification stores a new token for the user and mails the link
+func (s *Service) sendEmailVerification(ctx context.Context, user *User) error {
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	expiresAt := time.Now().Add(s.emailVerificationTTL)
+	if err := s.repo.CreateEmailVerificationToken(ctx, user.ID, auth.HashToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	link := s.appLink("/verify-email", token)
+	return s.sendLink(ctx, user, link, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirmá tu email en Agro Mas",
+		Body: fmt.Sprintf("Hola %s,\n\nConfirmá tu email ingresando a este link:\n%s\n\nEl link vence el %s.\n",
+			user.FirstName, link, expiresAt.Format("02/01/2006 15:04")),
+	})
+}
+
+// appLink builds a frontend link carrying a one-time token
+func (s *Service) appLink(path, token string) string {
+	return fmt.Sprintf("%s%s?token=%s", s.appBaseURL, path, url.QueryEscape(token))
+}
+
+// sendLink mails a message carrying a one-time link, or only logs the link
+// when no mailer is configured
+func (s *Service) sendLink(ctx context.Context, user *User, link string, msg mailer.Message) error {
+	if s.mailer == nil {
+		logger.FromContext(ctx).Info("email link not sent, no mailer configured", "user_id", user.ID, "subject", msg.Subject, "link", link)
+		return nil
+	}
+	return s.mailer.Send(ctx, msg)
+}
@@ -3,6 +3,7 @@ package users
 import (
 	"database/sql/driver"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -52,23 +53,102 @@ type VerificationDocuments struct {
 }
 
 type DocumentInfo struct {
-	URL         string    `json:"url"`
-	FileName    string    `json:"file_name"`
-	FileSize    int64     `json:"file_size"`
-	MimeType    string    `json:"mime_type"`
-	UploadedAt  time.Time `json:"uploaded_at"`
-	Status      string    `json:"status"` // pending, approved, rejected
-	ReviewNotes *string   `json:"review_notes,omitempty"`
+	URL         string     `json:"url,omitempty"`          // public documents only
+	StoragePath string     `json:"storage_path,omitempty"` // private documents, served through signed URLs
+	FileName    string     `json:"file_name"`
+	FileSize    int64      `json:"file_size"`
+	MimeType    string     `json:"mime_type"`
+	UploadedAt  time.Time  `json:"uploaded_at"`
+	Status      string     `json:"status"` // pending, approved, rejected
+	ReviewNotes *string    `json:"review_notes,omitempty"`
+	ReviewedBy  *uuid.UUID `json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
 }
 
 type UserPreferences struct {
-	NotificationEmail    bool     `json:"notification_email"`
-	NotificationWhatsApp bool     `json:"notification_whatsapp"`
-	SearchRadius         int      `json:"search_radius_km"`
-	PreferredCategories  []string `json:"preferred_categories"`
-	Language             string   `json:"language"`
-	Currency             string   `json:"currency"`
-	PrivacyLevel         string   `json:"privacy_level"` // public, limited, private
+	NotificationEmail    bool                 `json:"notification_email"`
+	NotificationWhatsApp bool                 `json:"notification_whatsapp"`
+	SearchRadius         int                  `json:"search_radius_km"`
+	PreferredCategories  []string             `json:"preferred_categories"`
+	Language             string               `json:"language"`
+	Currency             string               `json:"currency"`
+	PrivacyLevel         string               `json:"privacy_level"` // public, limited, private
+	Notifications        NotificationSettings `json:"notifications,omitempty"`
+}
+
+// Notification event types that can be configured per channel
+const (
+	NotificationEventPriceAlert        = "price_alert"
+	NotificationEventReviewReminder    = "review_reminder"
+	NotificationEventNewMatch          = "new_match"
+	NotificationEventInquiry           = "inquiry"
+	NotificationEventTransactionUpdate = "transaction_update"
+)
+
+// Notification delivery channels
+const (
+	NotificationChannelEmail    = "email"
+	NotificationChannelWhatsApp = "whatsapp"
+)
+
+// NotificationEvents lists every configurable notification event
+var NotificationEvents = []string{
+	NotificationEventPriceAlert,
+	NotificationEventReviewReminder,
+	NotificationEventNewMatch,
+	NotificationEventInquiry,
+	NotificationEventTransactionUpdate,
+}
+
+// NotificationChannels lists every supported delivery channel
+var NotificationChannels = []string{
+	NotificationChannelEmail,
+	NotificationChannelWhatsApp,
+}
+
+// NotificationSettings maps an event type to the enabled state of each channel
+type NotificationSettings map[string]map[string]bool
+
+// NotificationEnabled reports whether an event should be delivered on a channel.
+// An explicit per-event setting wins; otherwise the global channel toggle applies.
+func (p *UserPreferences) NotificationEnabled(event, channel string) bool {
+	if p == nil {
+		return true
+	}
+
+	if channels, ok := p.Notifications[event]; ok {
+		if enabled, ok := channels[channel]; ok {
+			return enabled
+		}
+	}
+
+	switch channel {
+	case NotificationChannelEmail:
+		return p.NotificationEmail
+	case NotificationChannelWhatsApp:
+		return p.NotificationWhatsApp
+	}
+	return false
+}
+
+// UpdateNotificationPreferencesRequest represents a partial update of notification settings
+type UpdateNotificationPreferencesRequest struct {
+	Settings NotificationSettings `json:"settings" binding:"required"`
+}
+
+// UpdatePreferencesRequest represents a partial update of the general user
+// preferences; omitted fields keep their current value
+type UpdatePreferencesRequest struct {
+	NotificationEmail    *bool    `json:"notification_email,omitempty"`
+	NotificationWhatsApp *bool    `json:"notification_whatsapp,omitempty"`
+	SearchRadius         *int     `json:"search_radius_km,omitempty" binding:"omitempty,min=1,max=1000"`
+	PreferredCategories  []string `json:"preferred_categories,omitempty" binding:"omitempty,dive,oneof=transport livestock supplies"`
+	Language             *string  `json:"language,omitempty" binding:"omitempty,oneof=es en"`
+}
+
+// NotificationPreferencesResponse represents the effective setting for every event and channel
+type NotificationPreferencesResponse struct {
+	Settings NotificationSettings `json:"settings"`
 }
 
 // CreateUserRequest represents the request to create a new user
@@ -147,6 +227,73 @@ type PublicUserResponse struct {
 	TotalSales       int       `json:"total_sales"`
 	TotalReviews     int       `json:"total_reviews"`
 	CreatedAt        time.Time `json:"created_at"`
+	SellerTier       string    `json:"seller_tier,omitempty"`
+}
+
+// Seller tiers shown to buyers as a trust badge
+const (
+	SellerTierNew         = "new"
+	SellerTierEstablished = "established"
+	SellerTierTop         = "top_seller"
+)
+
+// SellerTierThresholds holds the minimums a seller must meet for each tier
+type SellerTierThresholds struct {
+	EstablishedMinSales     int
+	EstablishedMinRating    float64
+	EstablishedMinTenure    time.Duration
+	TopMinSales             int
+	TopMinRating            float64
+	TopMinVerificationLevel int
+	TopMinTenure            time.Duration
+}
+
+// Tier returns the seller tier for the given completed sales, rating,
+// verification level and account creation date
+func (t SellerTierThresholds) Tier(totalSales int, rating float64, verificationLevel int, since time.Time) string {
+	tenure := time.Since(since)
+
+	if totalSales >= t.TopMinSales && rating >= t.TopMinRating &&
+		verificationLevel >= t.TopMinVerificationLevel && tenure >= t.TopMinTenure {
+		return SellerTierTop
+	}
+	if totalSales >= t.EstablishedMinSales && rating >= t.EstablishedMinRating &&
+		tenure >= t.EstablishedMinTenure {
+		return SellerTierEstablished
+	}
+	return SellerTierNew
+}
+
+// UpdateVerificationRequest is the admin payload for changing a user's KYC state
+type UpdateVerificationRequest struct {
+	VerificationLevel *int  `json:"verification_level" binding:"required"`
+	IsVerified        *bool `json:"is_verified" binding:"required"`
+}
+
+// VerificationUpdate is the result of a verification change, including its audit fields
+type VerificationUpdate struct {
+	UserID            uuid.UUID `json:"user_id"`
+	VerificationLevel int       `json:"verification_level"`
+	IsVerified        bool      `json:"is_verified"`
+	VerifiedBy        uuid.UUID `json:"verified_by"`
+	VerifiedAt        time.Time `json:"verified_at"`
+}
+
+// UserListResponse is the paginated user list returned to admin views
+type UserListResponse struct {
+	Users      []UserResponse `json:"users"`
+	TotalCount int            `json:"total_count"`
+	Page       int            `json:"page"`
+	PageSize   int            `json:"page_size"`
+	TotalPages int            `json:"total_pages"`
+}
+
+// UserStats counts users overall and by role for the admin dashboard
+type UserStats struct {
+	TotalUsers   int            `json:"total_users"`
+	ActiveUsers  int            `json:"active_users"`
+	TotalByRole  map[string]int `json:"total_by_role"`
+	ActiveByRole map[string]int `json:"active_by_role"`
 }
 
 // Implement database/sql driver interfaces for custom types
@@ -211,4 +358,50 @@ func (u *User) ToPublicResponse() *PublicUserResponse {
 		TotalReviews:      u.TotalReviews,
 		CreatedAt:         u.CreatedAt,
 	}
-}
\ No newline at end of file
+}
+
+// DisplayName is the name shown to counterparties: the business name when
+// set, the full name otherwise
+func (u *User) DisplayName() string {
+	if u.BusinessName != nil && *u.BusinessName != "" {
+		return *u.BusinessName
+	}
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
+
+// DeleteAccountRequest confirms a user's request to delete their own account.
+// With Anonymize, personal data is scrubbed instead of only deactivating the account.
+type DeleteAccountRequest struct {
+	Password  string `json:"password" binding:"required"`
+	Anonymize bool   `json:"anonymize"`
+}
+
+// AccountDeletionResult summarizes what deleting an account changed. When
+// BlockingTransactions is above zero nothing was changed.
+type AccountDeletionResult struct {
+	UnpublishedProducts   int64     `json:"unpublished_products"`
+	CancelledTransactions int64     `json:"cancelled_transactions"`
+	Anonymized            bool      `json:"anonymized"`
+	BlockingTransactions  int       `json:"-"`
+	DeletedAt             time.Time `json:"deleted_at"`
+}
+
+// MergeUsersRequest represents an admin request to merge a duplicate account into another
+type MergeUsersRequest struct {
+	SourceUserID uuid.UUID `json:"source_user_id" binding:"required"`
+	TargetUserID uuid.UUID `json:"target_user_id" binding:"required"`
+}
+
+// MergeUsersResult summarizes the rows reassigned from the source to the target user
+type MergeUsersResult struct {
+	MergeID       uuid.UUID `json:"merge_id"`
+	SourceUserID  uuid.UUID `json:"source_user_id"`
+	TargetUserID  uuid.UUID `json:"target_user_id"`
+	Products      int64     `json:"products"`
+	Transactions  int64     `json:"transactions"`
+	Inquiries     int64     `json:"inquiries"`
+	Favorites     int64     `json:"favorites"`
+	Follows       int64     `json:"follows"`
+	WhatsAppLinks int64     `json:"whatsapp_links"`
+	MergedAt      time.Time `json:"merged_at"`
+}
@@ -0,0 +1,103 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"agro-mas-backend/internal/auth"
+	"agro-mas-backend/pkg/logger"
+	"agro-mas-backend/pkg/mailer"
+)
+
+// DefaultPasswordResetTTL is how long a password reset link stays valid
+const DefaultPasswordResetTTL = time.Hour
+
+// SetPasswordResetTTL overrides how long password reset links stay valid
+func (s *Service) SetPasswordResetTTL(ttl time.Duration) {
+	if ttl > 0 {
+		s.passwordResetTTL = ttl
+	}
+}
+
+// passwordResetTimeout bounds a reset request once the response was sent
+const passwordResetTimeout = 30 * time.Second
+
+// RequestPasswordReset mails a single-use reset link to the account with the
+// given email. The lookup and delivery run in the background, detached from
+// ctx's cancellation, so the caller's response neither reports nor takes
+// longer for an existing account; failures are only logged.
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), passwordResetTimeout)
+	go func() {
+		defer cancel()
+		if err := s.requestPasswordReset(ctx, email); err != nil {
+			logger.FromContext(ctx).Warn("failed to request password reset", "error", err)
+		}
+	}()
+}
+
+// requestPasswordReset stores a reset token for the account with the given
+// email and mails its link. Unknown emails are ignored.
+func (s *Service) requestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.repo.GetUserByEmail(ctx, strings.ToLower(email))
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil
+	}
+
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	expiresAt := time.Now().Add(s.passwordResetTTL)
+	if err := s.repo.CreatePasswordResetToken(ctx, user.ID, auth.HashToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	link := s.appLink("/reset-password", token)
+	err = s.sendLink(ctx, user, link, mailer.Message{
+		To:      user.Email,
+		Subject: "Restablecé tu contraseña de Agro Mas",
+		Body: fmt.Sprintf("Hola %s,\n\nPara elegir una nueva contraseña ingresá a este link:\n%s\n\nEl link vence el %s. Si no lo pediste, ignorá este mensaje.\n",
+			user.FirstName, link, expiresAt.Format("02/01/2006 15:04")),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send password reset email to user %s: %w", user.ID, err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token and ends all of the
+// user's sessions by revoking their refresh tokens and the access tokens
+// already issued to them
+func (s *Service) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if err := auth.ValidatePasswordStrength(newPassword); err != nil {
+		return fmt.Errorf("%w: %v", ErrWeakPassword, err)
+	}
+
+	passwordHash, err := s.passwordManager.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash new password: %w", err)
+	}
+
+	userID, err := s.repo.ResetPassword(ctx, auth.HashToken(token), passwordHash)
+	if err != nil {
+		return err
+	}
+	if userID == nil {
+		return ErrInvalidResetToken
+	}
+
+	if err := s.jwtManager.RevokeUserTokens(ctx, *userID); err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	logger.FromContext(ctx).Info("password reset", "user_id", *userID)
+	return nil
+}
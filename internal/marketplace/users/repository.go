@@ -6,8 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"agro-mas-backend/internal/marketplace/transactions"
+	"agro-mas-backend/pkg/sqlutil"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type Repository struct {
@@ -129,6 +133,65 @@ func (r *Repository) GetUserByID(ctx context.Context, id uuid.UUID) (*User, erro
 	return user, nil
 }
 
+// GetUsersByIDs loads the active users among ids in a single query, keyed by
+// ID. Unknown or inactive IDs are left out of the map.
+func (r *Repository) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*User, error) {
+	users := make(map[uuid.UUID]*User, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	query := `
+		SELECT 
+			id, email, first_name, last_name, phone, cuit,
+			business_name, business_type, tax_category, province, city, address,
+			CASE WHEN coordinates IS NOT NULL THEN coordinates[0] ELSE NULL END as lng, 
+			CASE WHEN coordinates IS NOT NULL THEN coordinates[1] ELSE NULL END as lat,
+			role, verification_level, is_active, is_verified, rating,
+			total_sales, total_purchases, total_reviews, created_at, updated_at,
+			last_login
+		FROM users 
+		WHERE id = ANY($1) AND is_active = true`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users by IDs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user := &User{}
+		var lng, lat sql.NullFloat64
+
+		err := rows.Scan(
+			&user.ID, &user.Email, &user.FirstName, &user.LastName,
+			&user.Phone, &user.CUIT, &user.BusinessName, &user.BusinessType,
+			&user.TaxCategory, &user.Province, &user.City, &user.Address,
+			&lng, &lat, &user.Role, &user.VerificationLevel, &user.IsActive,
+			&user.IsVerified, &user.Rating, &user.TotalSales, &user.TotalPurchases,
+			&user.TotalReviews, &user.CreatedAt, &user.UpdatedAt, &user.LastLogin)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+
+		if lng.Valid && lat.Valid {
+			user.Coordinates = &Point{
+				Lng: lng.Float64,
+				Lat: lat.Float64,
+			}
+		}
+
+		users[user.ID] = user
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate users: %w", err)
+	}
+
+	return users, nil
+}
+
 // GetUserByEmail retrieves a user by their email
 func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
 	query := `
@@ -280,6 +343,342 @@ func (r *Repository) UpdateUser(ctx context.Context, id uuid.UUID, updates map[s
 	return nil
 }
 
+// UpdateVerification sets a user's verification state and records the admin
+// who changed it. Returns the audit timestamp, or nil when the user does not exist.
+func (r *Repository) UpdateVerification(ctx context.Context, id uuid.UUID, level int, isVerified bool, verifiedBy uuid.UUID) (*time.Time, error) {
+	query := `
+		UPDATE users
+		SET verification_level = $2, is_verified = $3, verified_by = $4,
+			verified_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING verified_at`
+
+	var verifiedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, id, level, isVerified, verifiedBy).Scan(&verifiedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to update verification: %w", err)
+	}
+
+	return &verifiedAt, nil
+}
+
+// CreateRefreshToken stores the hash of a newly issued refresh token
+func (r *Repository) CreateRefreshToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	query := `INSERT INTO refresh_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	if _, err := r.db.ExecContext(ctx, query, userID, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+// ConsumeRefreshToken revokes a live refresh token and returns its owner. The
+// revocation and the check happen in one statement so a token can only be
+// rotated once. Returns nil if the token is unknown, expired or already revoked.
+func (r *Repository) ConsumeRefreshToken(ctx context.Context, tokenHash string) (*uuid.UUID, error) {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`
+
+	var userID uuid.UUID
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to consume refresh token: %w", err)
+	}
+
+	return &userID, nil
+}
+
+// RevokeRefreshToken marks a refresh token as revoked
+func (r *Repository) RevokeRefreshToken(ctx context.Context, tokenHash string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE token_hash = $1 AND revoked_at IS NULL`
+	if _, err := r.db.ExecContext(ctx, query, tokenHash); err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	return nil
+}
+
+// CreateEmailVerificationToken stores the hash of a new verification token,
+// discarding the user's earlier unused ones so only the latest link works
+func (r *Repository) CreateEmailVerificationToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	query := `
+		WITH discarded AS (
+			DELETE FROM email_verification_tokens WHERE user_id = $1 AND used_at IS NULL
+		)
+		INSERT INTO email_verification_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	if _, err := r.db.ExecContext(ctx, query, userID, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("failed to create email verification token: %w", err)
+	}
+	return nil
+}
+
+// ConsumeEmailVerificationToken marks a live token as used and the owner's
+// email as verified, raising their verification level to at least the email
+// level. Returns nil if the token is unknown, expired or already used.
+func (r *Repository) ConsumeEmailVerificationToken(ctx context.Context, tokenHash string) (*uuid.UUID, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+		UPDATE email_verification_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`, tokenHash).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to consume email verification token: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users
+		SET email_verified_at = NOW(), verification_level = GREATEST(verification_level, 1),
+			updated_at = NOW()
+		WHERE id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark email as verified: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit email verification: %w", err)
+	}
+
+	return &userID, nil
+}
+
+// IsEmailVerificationTokenExpired reports whether an unused token exists but
+// has expired, so callers can tell it apart from an unknown one
+func (r *Repository) IsEmailVerificationTokenExpired(ctx context.Context, tokenHash string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM email_verification_tokens
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at <= NOW()
+		)`
+
+	var expired bool
+	if err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(&expired); err != nil {
+		return false, fmt.Errorf("failed to check email verification token: %w", err)
+	}
+	return expired, nil
+}
+
+// IsEmailVerified reports whether the user has confirmed their email
+func (r *Repository) IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	query := `SELECT email_verified_at IS NOT NULL FROM users WHERE id = $1`
+
+	var verified bool
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&verified); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check email verification: %w", err)
+	}
+	return verified, nil
+}
+
+// CreatePasswordResetToken stores the hash of a new reset token, discarding
+// the user's earlier unused ones so only the latest link works
+func (r *Repository) CreatePasswordResetToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	query := `
+		WITH discarded AS (
+			DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL
+		)
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	if _, err := r.db.ExecContext(ctx, query, userID, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword consumes a live reset token, sets the owner's new password
+// hash and revokes all their refresh tokens so existing sessions end. Returns
+// nil if the token is unknown, expired or already used.
+func (r *Repository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (*uuid.UUID, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+		UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`, tokenHash).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to consume password reset token: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE users SET password_hash = $2, updated_at = NOW() WHERE id = $1`, userID, passwordHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update password: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit password reset: %w", err)
+	}
+
+	return &userID, nil
+}
+
+// UpdateVerificationDocuments applies update to a user's verification documents
+// while holding the user's row lock, so concurrent changes to different
+// documents don't overwrite each other. Returns nil if the user doesn't exist.
+func (r *Repository) UpdateVerificationDocuments(ctx context.Context, id uuid.UUID, update func(*VerificationDocuments) error) (*VerificationDocuments, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	docs, _, err := updateVerificationDocumentsTx(ctx, tx, id, update)
+	if err != nil || docs == nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit verification documents: %w", err)
+	}
+
+	return docs, nil
+}
+
+// ReviewVerificationDocuments applies a review to a user's verification
+// documents under the row lock and, in the same transaction, raises the user's
+// verification level to what the approved documents earn. The level is never
+// lowered. Returns nil if the user doesn't exist.
+func (r *Repository) ReviewVerificationDocuments(ctx context.Context, id, reviewerID uuid.UUID, update func(*VerificationDocuments) error) (*VerificationDocuments, int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	docs, level, err := updateVerificationDocumentsTx(ctx, tx, id, update)
+	if err != nil || docs == nil {
+		return nil, 0, err
+	}
+
+	if earned := DocumentVerificationLevel(docs); earned > level {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users
+			SET verification_level = $2, is_verified = true, verified_by = $3,
+				verified_at = NOW(), updated_at = NOW()
+			WHERE id = $1`, id, earned, reviewerID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to update verification level: %w", err)
+		}
+		level = earned
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit document review: %w", err)
+	}
+
+	return docs, level, nil
+}
+
+// updateVerificationDocumentsTx locks the user's row, applies update to the
+// stored documents and writes them back. It also returns the user's current
+// verification level. Returns nil documents if the user doesn't exist.
+func updateVerificationDocumentsTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, update func(*VerificationDocuments) error) (*VerificationDocuments, int, error) {
+	var docsJSON sql.NullString
+	var level int
+	err := tx.QueryRowContext(ctx, `SELECT verification_documents, verification_level FROM users WHERE id = $1 FOR UPDATE`, id).Scan(&docsJSON, &level)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to lock verification documents: %w", err)
+	}
+
+	docs := &VerificationDocuments{}
+	if docsJSON.Valid {
+		if err := json.Unmarshal([]byte(docsJSON.String), docs); err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal verification documents: %w", err)
+		}
+	}
+
+	if err := update(docs); err != nil {
+		return nil, 0, err
+	}
+
+	jsonData, err := json.Marshal(docs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to marshal verification documents: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE users SET verification_documents = $2, updated_at = NOW() WHERE id = $1`, id, string(jsonData))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to update verification documents: %w", err)
+	}
+
+	return docs, level, nil
+}
+
+// ListPendingDocumentUsers returns users with at least one verification
+// document awaiting review, oldest pending upload first, and the total count
+func (r *Repository) ListPendingDocumentUsers(ctx context.Context, limit, offset int) ([]*VerificationQueueEntry, int, error) {
+	query := `
+		SELECT u.id, u.email, u.first_name, u.last_name, u.business_name,
+			u.verification_level, u.verification_documents, q.oldest_pending,
+			COUNT(*) OVER() AS total_count
+		FROM users u
+		JOIN LATERAL (
+			SELECT MIN((d.value->>'uploaded_at')::timestamptz) AS oldest_pending
+			FROM jsonb_each(u.verification_documents) d
+			WHERE jsonb_typeof(d.value) = 'object' AND d.value->>'status' = 'pending'
+		) q ON q.oldest_pending IS NOT NULL
+		WHERE u.is_active = true
+		ORDER BY q.oldest_pending ASC
+		LIMIT $1 OFFSET $2`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list verification queue: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*VerificationQueueEntry
+	totalCount := 0
+	for rows.Next() {
+		entry := &VerificationQueueEntry{}
+		var docsJSON string
+		err := rows.Scan(&entry.UserID, &entry.Email, &entry.FirstName, &entry.LastName,
+			&entry.BusinessName, &entry.VerificationLevel, &docsJSON, &entry.OldestPendingAt,
+			&totalCount)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan verification queue entry: %w", err)
+		}
+
+		entry.Documents = &VerificationDocuments{}
+		if err := json.Unmarshal([]byte(docsJSON), entry.Documents); err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal verification documents: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, totalCount, rows.Err()
+}
+
 // UpdateLastLogin updates the last login timestamp for a user
 func (r *Repository) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
 	query := `UPDATE users SET last_login = NOW(), updated_at = NOW() WHERE id = $1`
@@ -320,6 +719,13 @@ func (r *Repository) ListUsers(ctx context.Context, filters UserFilters, limit,
 		argIndex++
 	}
 
+	if filters.Query != "" {
+		whereConditions = append(whereConditions, fmt.Sprintf(
+			"(email ILIKE $%[1]d OR first_name ILIKE $%[1]d OR last_name ILIKE $%[1]d OR business_name ILIKE $%[1]d)", argIndex))
+		args = append(args, "%"+sqlutil.EscapeLike(filters.Query)+"%")
+		argIndex++
+	}
+
 	whereClause := strings.Join(whereConditions, " AND ")
 
 	// Count total records
@@ -387,6 +793,38 @@ func (r *Repository) ListUsers(ctx context.Context, filters UserFilters, limit,
 	return users, totalCount, nil
 }
 
+// GetUserStats counts total and active users grouped by role
+func (r *Repository) GetUserStats(ctx context.Context) (*UserStats, error) {
+	query := `
+		SELECT role, COUNT(*), COUNT(CASE WHEN is_active = true THEN 1 END)
+		FROM users
+		GROUP BY role`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := &UserStats{
+		TotalByRole:  make(map[string]int),
+		ActiveByRole: make(map[string]int),
+	}
+	for rows.Next() {
+		var role string
+		var total, active int
+		if err := rows.Scan(&role, &total, &active); err != nil {
+			return nil, fmt.Errorf("failed to scan user stats: %w", err)
+		}
+		stats.TotalByRole[role] = total
+		stats.ActiveByRole[role] = active
+		stats.TotalUsers += total
+		stats.ActiveUsers += active
+	}
+
+	return stats, rows.Err()
+}
+
 // DeleteUser soft deletes a user by setting is_active to false
 func (r *Repository) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	query := `UPDATE users SET is_active = false, updated_at = NOW() WHERE id = $1`
@@ -397,9 +835,381 @@ func (r *Repository) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// DeleteAccount soft-deletes a user in one database transaction. Their
+// pending and confirmed transactions are cancelled with the reason, restocked
+// and reported to both parties' webhooks. Their products are unpublished and
+// their refresh tokens revoked. With anonymize, personal data on the user and
+// the contact details copied to their products and transactions are
+// scrubbed. References to verification documents are always cleared, since
+// the caller deletes the files. When the user has in-progress or disputed
+// transactions nothing changes and the result reports how many.
+func (r *Repository) DeleteAccount(ctx context.Context, userID uuid.UUID, anonymize bool, reason string) (*AccountDeletionResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	result := &AccountDeletionResult{Anonymized: anonymize}
+
+	// In-progress and disputed deals need the counterparty, so they block deletion
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM transactions
+		WHERE (buyer_id = $1 OR seller_id = $1)
+		AND status IN ('in_progress', 'disputed')`, userID).Scan(&result.BlockingTransactions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active transactions: %w", err)
+	}
+	if result.BlockingTransactions > 0 {
+		return result, nil
+	}
+
+	cancelled, err := transactions.CancelUserTransactions(ctx, tx, userID, reason)
+	if err != nil {
+		return nil, err
+	}
+	result.CancelledTransactions = int64(len(cancelled))
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE products SET published_at = NULL, updated_at = NOW(),
+			status = CASE WHEN status = 'published' THEN 'unpublished' ELSE status END
+		WHERE user_id = $1 AND published_at IS NOT NULL`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpublish products: %w", err)
+	}
+	if result.UnpublishedProducts, err = res.RowsAffected(); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	if anonymize {
+		// The email stays unique and unroutable; the empty hash matches no password
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users SET
+				email = 'deleted-' || id || '@deleted.invalid',
+				password_hash = '',
+				first_name = 'Usuario',
+				last_name = 'eliminado',
+				phone = NULL,
+				cuit = NULL,
+				business_name = NULL,
+				address = NULL,
+				coordinates = NULL
+			WHERE id = $1`, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to anonymize user: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE products SET seller_name = NULL, seller_phone = NULL, updated_at = NOW()
+			WHERE user_id = $1`, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to anonymize product seller details: %w", err)
+		}
+
+		if err := transactions.AnonymizeParty(ctx, tx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE users SET is_active = false, deleted_at = NOW(), verification_documents = NULL, updated_at = NOW()
+		WHERE id = $1
+		RETURNING deleted_at`, userID).Scan(&result.DeletedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit account deletion: %w", err)
+	}
+
+	return result, nil
+}
+
+// CreateWebhook stores a new webhook and sets its creation time
+func (r *Repository) CreateWebhook(ctx context.Context, webhook *Webhook) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO user_webhooks (id, user_id, url, secret) VALUES ($1, $2, $3, $4)
+		RETURNING created_at`, webhook.ID, webhook.UserID, webhook.URL, webhook.Secret).Scan(&webhook.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return nil
+}
+
+// CountWebhooks counts the webhooks registered by a user
+func (r *Repository) CountWebhooks(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_webhooks WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+	return count, nil
+}
+
+// ListWebhooks returns a user's webhooks, oldest first, without secrets
+func (r *Repository) ListWebhooks(ctx context.Context, userID uuid.UUID) ([]*Webhook, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, url, created_at FROM user_webhooks
+		WHERE user_id = $1
+		ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []*Webhook{}
+	for rows.Next() {
+		webhook := &Webhook{}
+		if err := rows.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// DeleteWebhook removes a user's webhook, returning false if they have none with that ID
+func (r *Repository) DeleteWebhook(ctx context.Context, userID, webhookID uuid.UUID) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM user_webhooks WHERE id = $1 AND user_id = $2`, webhookID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return deleted > 0, nil
+}
+
+// MergeUsers reassigns everything owned by the source user to the target user,
+// deactivates the source, revokes its refresh tokens and records the merge,
+// all inside one transaction. Both accounts are locked before the merge is
+// checked: it fails with ErrUserNotFound when either is missing and with
+// ErrMergeConflict when the source is privileged, the two have open deals
+// with each other or are both buying the same product. Closed transactions,
+// inquiries and WhatsApp links between the two stay with the source, so none
+// becomes a self-deal.
+func (r *Repository) MergeUsers(ctx context.Context, sourceID, targetID, mergedBy uuid.UUID) (*MergeUsersResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock both accounts so no concurrent merge or update interleaves
+	rows, err := tx.QueryContext(ctx, `SELECT id, role FROM users WHERE id IN ($1, $2) FOR UPDATE`, sourceID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock users: %w", err)
+	}
+	roles := make(map[uuid.UUID]string, 2)
+	for rows.Next() {
+		var id uuid.UUID
+		var role string
+		if err := rows.Scan(&id, &role); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan locked user: %w", err)
+		}
+		roles[id] = role
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := roles[targetID]; !ok {
+		return nil, ErrUserNotFound
+	}
+	sourceRole, ok := roles[sourceID]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+
+	// Privileged accounts are never merged away
+	if sourceRole == "admin" || sourceRole == "moderator" {
+		return nil, ErrMergeConflict
+	}
+
+	// Open deals between the two would turn into self-transactions, and open
+	// purchases of the same product would break the one-per-buyer rule
+	var conflicts int
+	err = tx.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM transactions
+			 WHERE ((buyer_id = $1 AND seller_id = $2) OR (buyer_id = $2 AND seller_id = $1))
+			 AND status NOT IN ('completed', 'cancelled'))
+			+
+			(SELECT COUNT(DISTINCT a.product_id) FROM transactions a
+			 JOIN transactions b ON b.product_id = a.product_id
+			 WHERE a.buyer_id = $1 AND b.buyer_id = $2
+			 AND a.status NOT IN ('completed', 'cancelled')
+			 AND b.status NOT IN ('completed', 'cancelled'))`, sourceID, targetID).Scan(&conflicts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check merge conflicts: %w", err)
+	}
+	if conflicts > 0 {
+		return nil, ErrMergeConflict
+	}
+
+	result := &MergeUsersResult{
+		MergeID:      uuid.New(),
+		SourceUserID: sourceID,
+		TargetUserID: targetID,
+	}
+
+	exec := func(query string) (int64, error) {
+		res, err := tx.ExecContext(ctx, query, sourceID, targetID)
+		if err != nil {
+			return 0, err
+		}
+		return res.RowsAffected()
+	}
+
+	asBuyer, err := exec(`UPDATE transactions SET buyer_id = $2 WHERE buyer_id = $1 AND seller_id <> $2`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign buyer transactions: %w", err)
+	}
+	asSeller, err := exec(`UPDATE transactions SET seller_id = $2 WHERE seller_id = $1 AND buyer_id <> $2`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign seller transactions: %w", err)
+	}
+	if _, err = exec(`UPDATE transactions SET dispute_resolved_by = $2 WHERE dispute_resolved_by = $1`); err != nil {
+		return nil, fmt.Errorf("failed to reassign dispute resolutions: %w", err)
+	}
+	result.Transactions = asBuyer + asSeller
+
+	asBuyer, err = exec(`UPDATE product_inquiries SET buyer_id = $2 WHERE buyer_id = $1 AND seller_id <> $2`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign buyer inquiries: %w", err)
+	}
+	asSeller, err = exec(`UPDATE product_inquiries SET seller_id = $2 WHERE seller_id = $1 AND buyer_id <> $2`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign seller inquiries: %w", err)
+	}
+	result.Inquiries = asBuyer + asSeller
+
+	// Drop favorites the target already has before moving the rest
+	_, err = exec(`
+		DELETE FROM user_favorites f
+		WHERE f.user_id = $1
+		AND EXISTS (SELECT 1 FROM user_favorites t WHERE t.user_id = $2 AND t.product_id = f.product_id)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove duplicate favorites: %w", err)
+	}
+	if result.Favorites, err = exec(`UPDATE user_favorites SET user_id = $2 WHERE user_id = $1`); err != nil {
+		return nil, fmt.Errorf("failed to reassign favorites: %w", err)
+	}
+
+	// Drop follows that would duplicate an existing one or become self-follows
+	_, err = exec(`
+		DELETE FROM user_follows f
+		WHERE (f.follower_id = $1 AND (f.following_id = $2 OR EXISTS (
+			SELECT 1 FROM user_follows t WHERE t.follower_id = $2 AND t.following_id = f.following_id)))
+		OR (f.following_id = $1 AND (f.follower_id = $2 OR EXISTS (
+			SELECT 1 FROM user_follows t WHERE t.following_id = $2 AND t.follower_id = f.follower_id)))`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove duplicate follows: %w", err)
+	}
+	asFollower, err := exec(`UPDATE user_follows SET follower_id = $2 WHERE follower_id = $1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign follows: %w", err)
+	}
+	asFollowing, err := exec(`UPDATE user_follows SET following_id = $2 WHERE following_id = $1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign followers: %w", err)
+	}
+	result.Follows = asFollower + asFollowing
+
+	fromLinks, err := exec(`UPDATE whatsapp_links SET from_user_id = $2 WHERE from_user_id = $1 AND to_user_id <> $2`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign sent WhatsApp links: %w", err)
+	}
+	toLinks, err := exec(`UPDATE whatsapp_links SET to_user_id = $2 WHERE to_user_id = $1 AND from_user_id <> $2`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign received WhatsApp links: %w", err)
+	}
+	result.WhatsAppLinks = fromLinks + toLinks
+
+	if _, err = exec(`UPDATE product_views SET viewer_id = $2 WHERE viewer_id = $1`); err != nil {
+		return nil, fmt.Errorf("failed to reassign product views: %w", err)
+	}
+
+	// Fold the denormalized statistics of the source into the target
+	_, err = exec(`
+		UPDATE users t SET
+			rating = CASE WHEN t.total_reviews + s.total_reviews > 0
+				THEN (t.rating * t.total_reviews + s.rating * s.total_reviews) / (t.total_reviews + s.total_reviews)
+				ELSE t.rating END,
+			total_sales = t.total_sales + s.total_sales,
+			total_purchases = t.total_purchases + s.total_purchases,
+			total_reviews = t.total_reviews + s.total_reviews,
+			updated_at = NOW()
+		FROM users s
+		WHERE s.id = $1 AND t.id = $2`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge user statistics: %w", err)
+	}
+
+	// Moved listings show the target's seller details, read after the
+	// statistics were folded in
+	result.Products, err = exec(`
+		UPDATE products p SET
+			user_id = $2,
+			seller_name = COALESCE(NULLIF(t.business_name, ''), t.first_name || ' ' || t.last_name),
+			seller_phone = t.phone,
+			seller_rating = t.rating,
+			seller_verification_level = t.verification_level,
+			updated_at = NOW()
+		FROM users t
+		WHERE p.user_id = $1 AND t.id = $2`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign products: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE users SET is_active = false, updated_at = NOW() WHERE id = $1`, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deactivate source user: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	summaryJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal merge summary: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO user_merges (id, source_user_id, target_user_id, merged_by, summary)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`,
+		result.MergeID, sourceID, targetID, mergedBy, string(summaryJSON)).Scan(&result.MergedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record user merge: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit user merge: %w", err)
+	}
+
+	return result, nil
+}
+
 type UserFilters struct {
 	Role              string `json:"role"`
 	Province          string `json:"province"`
 	VerificationLevel int    `json:"verification_level"`
 	IsVerified        *bool  `json:"is_verified"`
-}
\ No newline at end of file
+	Query             string `json:"q"`
+}
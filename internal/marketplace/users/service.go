@@ -2,22 +2,41 @@ package users
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"agro-mas-backend/internal/audit"
 	"agro-mas-backend/internal/auth"
+	"agro-mas-backend/internal/marketplace/products"
+	"agro-mas-backend/pkg/gcloud"
+	"agro-mas-backend/pkg/logger"
+	"agro-mas-backend/pkg/mailer"
 	"github.com/google/uuid"
 )
 
 var (
-	ErrUserNotFound     = errors.New("user not found")
-	ErrUserExists       = errors.New("user already exists")
-	ErrInvalidPassword  = errors.New("invalid password")
-	ErrUserNotActive    = errors.New("user account is not active")
-	ErrInvalidRole      = errors.New("invalid user role")
-	ErrCUITExists       = errors.New("CUIT already registered")
+	ErrUserNotFound               = errors.New("user not found")
+	ErrUserExists                 = errors.New("user already exists")
+	ErrInvalidPassword            = errors.New("invalid password")
+	ErrUserNotActive              = errors.New("user account is not active")
+	ErrInvalidRole                = errors.New("invalid user role")
+	ErrCUITExists                 = errors.New("CUIT already registered")
+	ErrInvalidNotificationSetting = errors.New("invalid notification event or channel")
+	ErrMergeSameUser              = errors.New("source and target user must be different")
+	ErrMergeConflict              = errors.New("source user has conflicting active state")
+	ErrInvalidVerificationLevel   = errors.New("verification level must be between 0 and 4")
+	ErrInvalidRefreshToken        = errors.New("refresh token is invalid, expired or revoked")
+	ErrInvalidVerificationToken   = errors.New("email verification token is invalid or already used")
+	ErrVerificationTokenExpired   = errors.New("email verification token has expired, request a new one")
+	ErrInvalidResetToken          = errors.New("password reset token is invalid, expired or already used")
+	ErrWeakPassword               = errors.New("password does not meet strength requirements")
+	ErrInvalidWebhookURL          = errors.New("webhook URL must be an absolute https URL on a public host")
+	ErrWebhookLimitReached        = errors.New("webhook limit reached")
+	ErrWebhookNotFound            = errors.New("webhook not found")
+	ErrActiveTransactions         = errors.New("account has in-progress or disputed transactions")
 )
 
 type Service struct {
@@ -25,6 +44,17 @@ type Service struct {
 	passwordManager *auth.PasswordManager
 	jwtManager      *auth.JWTManager
 	cuitValidator   *auth.CUITValidator
+	tierThresholds  *SellerTierThresholds
+
+	mailer               mailer.Mailer
+	appBaseURL           string
+	emailVerificationTTL time.Duration
+	passwordResetTTL     time.Duration
+
+	audit *audit.Recorder
+
+	// Holds verification documents, deleted with the account
+	storageClient *gcloud.StorageClient
 }
 
 func NewService(repo *Repository, passwordManager *auth.PasswordManager, jwtManager *auth.JWTManager) *Service {
@@ -33,9 +63,37 @@ func NewService(repo *Repository, passwordManager *auth.PasswordManager, jwtMana
 		passwordManager: passwordManager,
 		jwtManager:      jwtManager,
 		cuitValidator:   auth.NewCUITValidator(),
+
+		emailVerificationTTL: DefaultEmailVerificationTTL,
+		passwordResetTTL:     DefaultPasswordResetTTL,
 	}
 }
 
+// SetAuditRecorder records admin actions taken through this service
+func (s *Service) SetAuditRecorder(recorder *audit.Recorder) {
+	s.audit = recorder
+}
+
+// SetStorageClient lets account deletion remove the user's stored documents
+func (s *Service) SetStorageClient(client *gcloud.StorageClient) {
+	s.storageClient = client
+}
+
+// SetSellerTierThresholds sets the minimums for each seller tier. Until it is
+// called no tiers are computed.
+func (s *Service) SetSellerTierThresholds(thresholds SellerTierThresholds) {
+	s.tierThresholds = &thresholds
+}
+
+// ComputeSellerTier returns the trust tier of a seller, or "" for non-sellers
+// and when no thresholds are set
+func (s *Service) ComputeSellerTier(user *User) string {
+	if user.Role != "seller" || s.tierThresholds == nil {
+		return ""
+	}
+	return s.tierThresholds.Tier(user.TotalSales, user.Rating, user.VerificationLevel, user.CreatedAt)
+}
+
 // CreateUser creates a new user account with validation
 func (s *Service) CreateUser(ctx context.Context, req *CreateUserRequest) (*User, error) {
 	// Validate password strength
@@ -48,6 +106,12 @@ func (s *Service) CreateUser(ctx context.Context, req *CreateUserRequest) (*User
 		return nil, ErrInvalidRole
 	}
 
+	if req.Coordinates != nil {
+		if err := products.ValidateCoordinates(req.Coordinates.Lat, req.Coordinates.Lng); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check if email already exists
 	existingUser, err := s.repo.GetUserByEmail(ctx, req.Email)
 	if err != nil {
@@ -133,6 +197,11 @@ func (s *Service) CreateUser(ctx context.Context, req *CreateUserRequest) (*User
 		return nil, fmt.Errorf("failed to create user in database: %w", err)
 	}
 
+	// Registration succeeds even if the email cannot be sent; the user can ask for a resend
+	if err := s.sendEmailVerification(ctx, user); err != nil {
+		logger.FromContext(ctx).Warn("failed to send verification email", "user_id", user.ID, "error", err)
+	}
+
 	return user, nil
 }
 
@@ -152,16 +221,84 @@ func (s *Service) Authenticate(ctx context.Context, req *LoginRequest) (*auth.To
 		return nil, nil, ErrUserNotActive
 	}
 
-	// Verify password
-	isValid, err := s.passwordManager.ComparePasswordAndHash(req.Password, user.PasswordHash)
-	if err != nil {
+	// Verify password, upgrading hashes made with weaker parameters than the current config
+	isValid, upgradedHash, err := s.passwordManager.ComparePasswordAndRehash(req.Password, user.PasswordHash)
+	if err != nil && !isValid {
 		return nil, nil, fmt.Errorf("failed to verify password: %w", err)
 	}
 	if !isValid {
 		return nil, nil, ErrInvalidPassword
 	}
+	if err != nil {
+		// The password is correct, so a failed rehash must not block the login
+		logger.FromContext(ctx).Warn("failed to rehash password", "user_id", user.ID, "error", err)
+	} else if upgradedHash != "" {
+		if err := s.repo.UpdateUser(ctx, user.ID, map[string]interface{}{"password_hash": upgradedHash}); err != nil {
+			logger.FromContext(ctx).Warn("failed to persist rehashed password", "user_id", user.ID, "error", err)
+		} else {
+			user.PasswordHash = upgradedHash
+		}
+	}
+
+	tokenResponse, err := s.issueTokens(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Update last login
+	if err := s.repo.UpdateLastLogin(ctx, user.ID); err != nil {
+		// Log error but don't fail authentication
+		logger.FromContext(ctx).Warn("failed to update last login", "user_id", user.ID, "error", err)
+	}
+
+	return tokenResponse, user, nil
+}
+
+// RefreshAccessToken rotates a refresh token, issuing a new access and refresh
+// token pair built from the user's current data
+func (s *Service) RefreshAccessToken(ctx context.Context, refreshToken string) (*auth.TokenResponse, *User, error) {
+	userID, err := s.repo.ConsumeRefreshToken(ctx, auth.HashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, nil, err
+	}
+	if userID == nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// Inactive users are not returned, so a deactivated account cannot refresh
+	user, err := s.repo.GetUserByID(ctx, *userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	tokenResponse, err := s.issueTokens(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tokenResponse, user, nil
+}
+
+// RevokeAccessToken revokes an access token until it expires, e.g. on logout.
+// Tokens that no longer verify have nothing left to revoke.
+func (s *Service) RevokeAccessToken(ctx context.Context, accessToken string) error {
+	claims, err := s.jwtManager.VerifyToken(accessToken)
+	if err != nil {
+		return nil
+	}
+	return s.jwtManager.RevokeToken(ctx, claims)
+}
+
+// RevokeRefreshToken invalidates a refresh token, e.g. on logout
+func (s *Service) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	return s.repo.RevokeRefreshToken(ctx, auth.HashRefreshToken(refreshToken))
+}
 
-	// Generate JWT token
+// issueTokens generates a token pair for the user and stores the refresh token hash
+func (s *Service) issueTokens(ctx context.Context, user *User) (*auth.TokenResponse, error) {
 	tokenResponse, err := s.jwtManager.GenerateToken(
 		user.ID,
 		user.Email,
@@ -172,17 +309,29 @@ func (s *Service) Authenticate(ctx context.Context, req *LoginRequest) (*auth.To
 		user.IsVerified,
 	)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	// Update last login
-	if err := s.repo.UpdateLastLogin(ctx, user.ID); err != nil {
-		// Log error but don't fail authentication
-		// In production, you'd use a proper logger
-		fmt.Printf("Failed to update last login for user %s: %v\n", user.ID, err)
+	if err := s.repo.CreateRefreshToken(ctx, user.ID, auth.HashRefreshToken(tokenResponse.RefreshToken), tokenResponse.RefreshTokenExpiresAt); err != nil {
+		return nil, err
 	}
 
-	return tokenResponse, user, nil
+	return tokenResponse, nil
+}
+
+// GetUsersByIDs loads several users at once, keyed by ID. Duplicate IDs are
+// fetched once; unknown or inactive ones are missing from the map.
+func (s *Service) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*User, error) {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return s.repo.GetUsersByIDs(ctx, unique)
 }
 
 // GetUserByID retrieves a user by their ID
@@ -206,7 +355,10 @@ func (s *Service) GetPublicUserByID(ctx context.Context, id uuid.UUID) (*PublicU
 	if user == nil {
 		return nil, ErrUserNotFound
 	}
-	return user.ToPublicResponse(), nil
+
+	response := user.ToPublicResponse()
+	response.SellerTier = s.ComputeSellerTier(user)
+	return response, nil
 }
 
 // UpdateUser updates user information
@@ -248,6 +400,9 @@ func (s *Service) UpdateUser(ctx context.Context, id uuid.UUID, req *UpdateUserR
 		updates["address"] = *req.Address
 	}
 	if req.Coordinates != nil {
+		if err := products.ValidateCoordinates(req.Coordinates.Lat, req.Coordinates.Lng); err != nil {
+			return nil, err
+		}
 		updates["coordinates"] = req.Coordinates
 	}
 
@@ -304,7 +459,7 @@ func (s *Service) ChangePassword(ctx context.Context, userID uuid.UUID, currentP
 }
 
 // ListUsers retrieves users with filtering and pagination
-func (s *Service) ListUsers(ctx context.Context, filters UserFilters, page, pageSize int) ([]*User, int, error) {
+func (s *Service) ListUsers(ctx context.Context, filters UserFilters, page, pageSize int) (*UserListResponse, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -316,34 +471,350 @@ func (s *Service) ListUsers(ctx context.Context, filters UserFilters, page, page
 
 	users, totalCount, err := s.repo.ListUsers(ctx, filters, pageSize, offset)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
 
-	return users, totalCount, nil
+	// Convert to response format, never exposing sensitive fields
+	userList := make([]UserResponse, len(users))
+	for i, u := range users {
+		userList[i] = *u.ToResponse()
+	}
+
+	totalPages := (totalCount + pageSize - 1) / pageSize
+
+	return &UserListResponse{
+		Users:      userList,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
 }
 
-// UpdateVerificationLevel updates the verification level of a user (admin only)
-func (s *Service) UpdateVerificationLevel(ctx context.Context, userID uuid.UUID, level int, isVerified bool) error {
+// UpdateVerificationLevel updates the verification level of a user (admin only),
+// recording the acting admin for the KYC audit trail
+func (s *Service) UpdateVerificationLevel(ctx context.Context, adminID, userID uuid.UUID, level int, isVerified bool) (*VerificationUpdate, error) {
 	if level < 0 || level > 4 {
-		return errors.New("verification level must be between 0 and 4")
+		return nil, ErrInvalidVerificationLevel
 	}
 
-	updates := map[string]interface{}{
-		"verification_level": level,
-		"is_verified":        isVerified,
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
 	}
 
-	if err := s.repo.UpdateUser(ctx, userID, updates); err != nil {
-		return fmt.Errorf("failed to update verification level: %w", err)
+	verifiedAt, err := s.repo.UpdateVerification(ctx, userID, level, isVerified, adminID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update verification level: %w", err)
+	}
+	if verifiedAt == nil {
+		return nil, ErrUserNotFound
 	}
 
-	return nil
+	update := &VerificationUpdate{
+		UserID:            userID,
+		VerificationLevel: level,
+		IsVerified:        isVerified,
+		VerifiedBy:        adminID,
+		VerifiedAt:        *verifiedAt,
+	}
+
+	s.audit.Record(ctx, adminID, audit.ActionVerificationUpdated, audit.EntityUser, userID, map[string]interface{}{
+		"verification_level": user.VerificationLevel,
+		"is_verified":        user.IsVerified,
+	}, update)
+
+	return update, nil
 }
 
-// DeactivateUser deactivates a user account
+// GetUserStats returns user counts by role for the admin dashboard
+func (s *Service) GetUserStats(ctx context.Context) (*UserStats, error) {
+	return s.repo.GetUserStats(ctx)
+}
+
+// DeactivateUser deactivates a user account and revokes the access tokens
+// already issued to it
 func (s *Service) DeactivateUser(ctx context.Context, userID uuid.UUID) error {
 	if err := s.repo.DeleteUser(ctx, userID); err != nil {
 		return fmt.Errorf("failed to deactivate user: %w", err)
 	}
+	if err := s.jwtManager.RevokeUserTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
 	return nil
-}
\ No newline at end of file
+}
+
+// AccountDeletionReason is recorded on transactions cancelled by an account deletion
+const AccountDeletionReason = "Cancelled by the system: account deleted"
+
+// DeleteAccount lets a user delete their own account after confirming their
+// password. Open pending or confirmed transactions are cancelled, listings
+// unpublished, every token revoked and verification documents deleted from
+// storage. In-progress and disputed transactions
+// must be settled first and fail with ErrActiveTransactions.
+func (s *Service) DeleteAccount(ctx context.Context, userID uuid.UUID, req *DeleteAccountRequest) (*AccountDeletionResult, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrUserNotFound
+	}
+
+	isValid, err := s.passwordManager.ComparePasswordAndHash(req.Password, user.PasswordHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify password: %w", err)
+	}
+	if !isValid {
+		return nil, ErrInvalidPassword
+	}
+
+	result, err := s.repo.DeleteAccount(ctx, userID, req.Anonymize, AccountDeletionReason)
+	if err != nil {
+		return nil, err
+	}
+	if result.BlockingTransactions > 0 {
+		return result, ErrActiveTransactions
+	}
+
+	if err := s.jwtManager.RevokeUserTokens(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	// The account is already deleted, so a storage failure is only logged
+	if s.storageClient != nil {
+		prefix := "documents/" + userID.String() + "/"
+		if _, err := s.storageClient.DeleteFiles(ctx, prefix); err != nil {
+			logger.FromContext(ctx).Warn("failed to delete verification documents", "user_id", userID, "prefix", prefix, "error", err)
+		}
+	}
+
+	return result, nil
+}
+
+// MergeUsers merges a duplicate source account into the target account (admin only)
+func (s *Service) MergeUsers(ctx context.Context, adminID uuid.UUID, req *MergeUsersRequest) (*MergeUsersResult, error) {
+	if req.SourceUserID == req.TargetUserID {
+		return nil, ErrMergeSameUser
+	}
+
+	source, err := s.repo.GetUserByID(ctx, req.SourceUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source user: %w", err)
+	}
+	if source == nil {
+		return nil, ErrUserNotFound
+	}
+
+	target, err := s.repo.GetUserByID(ctx, req.TargetUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get target user: %w", err)
+	}
+	if target == nil {
+		return nil, ErrUserNotFound
+	}
+
+	// The role and open-deal checks run under lock inside the repository
+	result, err := s.repo.MergeUsers(ctx, source.ID, target.ID, adminID)
+	if err != nil {
+		if err == ErrUserNotFound || err == ErrMergeConflict {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to merge users: %w", err)
+	}
+
+	// The source can no longer sign in, so its access tokens go too
+	if err := s.jwtManager.RevokeUserTokens(ctx, source.ID); err != nil {
+		return nil, fmt.Errorf("failed to revoke source user tokens: %w", err)
+	}
+
+	logger.FromContext(ctx).Info("users merged",
+		"merge_id", result.MergeID,
+		"admin_id", adminID,
+		"source_user_id", source.ID,
+		"target_user_id", target.ID,
+		"products", result.Products,
+		"transactions", result.Transactions,
+		"inquiries", result.Inquiries,
+		"favorites", result.Favorites,
+		"whatsapp_links", result.WhatsAppLinks,
+	)
+
+	s.audit.Record(ctx, adminID, audit.ActionUsersMerged, audit.EntityUser, source.ID, map[string]interface{}{
+		"source_user_id": source.ID,
+		"source_email":   source.Email,
+		"source_active":  source.IsActive,
+		"target_user_id": target.ID,
+	}, result)
+
+	return result, nil
+}
+
+// GetNotificationPreferences returns the effective notification settings for a user
+func (s *Service) GetNotificationPreferences(ctx context.Context, userID uuid.UUID) (*NotificationPreferencesResponse, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return buildNotificationPreferences(user.Preferences), nil
+}
+
+// UpdateNotificationPreferences merges the given per-event channel toggles into the user preferences
+func (s *Service) UpdateNotificationPreferences(ctx context.Context, userID uuid.UUID, req *UpdateNotificationPreferencesRequest) (*NotificationPreferencesResponse, error) {
+	// Validate all events and channels before touching the stored preferences
+	for event, channels := range req.Settings {
+		if !isValidNotificationEvent(event) {
+			return nil, ErrInvalidNotificationSetting
+		}
+		for channel := range channels {
+			if !isValidNotificationChannel(channel) {
+				return nil, ErrInvalidNotificationSetting
+			}
+		}
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	preferences := user.Preferences
+	if preferences == nil {
+		preferences = &UserPreferences{}
+	}
+	if preferences.Notifications == nil {
+		preferences.Notifications = make(NotificationSettings)
+	}
+
+	for event, channels := range req.Settings {
+		if preferences.Notifications[event] == nil {
+			preferences.Notifications[event] = make(map[string]bool)
+		}
+		for channel, enabled := range channels {
+			preferences.Notifications[event][channel] = enabled
+		}
+	}
+
+	preferencesJSON, err := json.Marshal(preferences)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal preferences: %w", err)
+	}
+
+	updates := map[string]interface{}{
+		"preferences": string(preferencesJSON),
+	}
+	if err := s.repo.UpdateUser(ctx, userID, updates); err != nil {
+		return nil, fmt.Errorf("failed to update notification preferences: %w", err)
+	}
+
+	return buildNotificationPreferences(preferences), nil
+}
+
+// UpdatePreferences merges the given fields into the user preferences, leaving
+// per-event notification settings untouched
+func (s *Service) UpdatePreferences(ctx context.Context, userID uuid.UUID, req *UpdatePreferencesRequest) (*UserPreferences, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	preferences := user.Preferences
+	if preferences == nil {
+		preferences = &UserPreferences{}
+	}
+
+	if req.NotificationEmail != nil {
+		preferences.NotificationEmail = *req.NotificationEmail
+	}
+	if req.NotificationWhatsApp != nil {
+		preferences.NotificationWhatsApp = *req.NotificationWhatsApp
+	}
+	if req.SearchRadius != nil {
+		preferences.SearchRadius = *req.SearchRadius
+	}
+	if req.PreferredCategories != nil {
+		preferences.PreferredCategories = req.PreferredCategories
+	}
+	if req.Language != nil {
+		preferences.Language = *req.Language
+	}
+
+	preferencesJSON, err := json.Marshal(preferences)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal preferences: %w", err)
+	}
+
+	updates := map[string]interface{}{
+		"preferences": string(preferencesJSON),
+	}
+	if err := s.repo.UpdateUser(ctx, userID, updates); err != nil {
+		return nil, fmt.Errorf("failed to update preferences: %w", err)
+	}
+
+	return preferences, nil
+}
+
+// AcceptedChannels returns the channels on which a notification for the event
+// may be sent, reading the user's preferences once for all of them.
+// Notification dispatchers must call this before sending anything to a user.
+// Unknown users accept nothing.
+func (s *Service) AcceptedChannels(ctx context.Context, userID uuid.UUID, event string, channels []string) ([]string, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	accepted := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		if user.Preferences.NotificationEnabled(event, channel) {
+			accepted = append(accepted, channel)
+		}
+	}
+	return accepted, nil
+}
+
+func buildNotificationPreferences(preferences *UserPreferences) *NotificationPreferencesResponse {
+	settings := make(NotificationSettings, len(NotificationEvents))
+	for _, event := range NotificationEvents {
+		settings[event] = make(map[string]bool, len(NotificationChannels))
+		for _, channel := range NotificationChannels {
+			settings[event][channel] = preferences.NotificationEnabled(event, channel)
+		}
+	}
+
+	return &NotificationPreferencesResponse{Settings: settings}
+}
+
+func isValidNotificationEvent(event string) bool {
+	for _, valid := range NotificationEvents {
+		if event == valid {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidNotificationChannel(channel string) bool {
+	for _, valid := range NotificationChannels {
+		if channel == valid {
+			return true
+		}
+	}
+	return false
+}
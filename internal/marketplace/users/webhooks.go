@@ -0,0 +1,87 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"agro-mas-backend/pkg/safehttp"
+	"github.com/google/uuid"
+)
+
+// MaxWebhooksPerUser caps the endpoints one user may register
+const MaxWebhooksPerUser = 5
+
+// Webhook is an endpoint notified about the user's transactions. The secret
+// is only returned when the webhook is created.
+type Webhook struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required"`
+}
+
+// CreateWebhook registers an HTTPS endpoint for the user's transaction events
+// and returns it with the secret deliveries are signed with. The host must
+// resolve to public addresses only; the dispatcher checks again when it connects.
+func (s *Service) CreateWebhook(ctx context.Context, userID uuid.UUID, req *CreateWebhookRequest) (*Webhook, error) {
+	endpoint := strings.TrimSpace(req.URL)
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Scheme != "https" || parsed.Hostname() == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	if err := safehttp.CheckHost(ctx, parsed.Hostname()); err != nil {
+		return nil, ErrInvalidWebhookURL
+	}
+
+	count, err := s.repo.CountWebhooks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxWebhooksPerUser {
+		return nil, ErrWebhookLimitReached
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	webhook := &Webhook{
+		ID:     uuid.New(),
+		UserID: userID,
+		URL:    endpoint,
+		Secret: hex.EncodeToString(secret),
+	}
+	if err := s.repo.CreateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// ListWebhooks returns the user's webhooks without their secrets
+func (s *Service) ListWebhooks(ctx context.Context, userID uuid.UUID) ([]*Webhook, error) {
+	return s.repo.ListWebhooks(ctx, userID)
+}
+
+// DeleteWebhook removes one of the user's webhooks along with its pending deliveries
+func (s *Service) DeleteWebhook(ctx context.Context, userID, webhookID uuid.UUID) error {
+	deleted, err := s.repo.DeleteWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
@@ -16,16 +16,40 @@ type Database struct {
 	db *sql.DB
 }
 
-func NewDatabase(databaseURL string) (*Database, error) {
+// PoolConfig bounds the connection pool. Every Cloud Run instance opens its
+// own pool, so MaxOpenConns times the instance count must stay under the
+// Cloud SQL connection limit.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration // recycles connections so Cloud SQL restarts don't leave stale ones
+	ConnMaxIdleTime time.Duration
+}
+
+// PoolStats is a snapshot of the connection pool for the admin dashboard
+type PoolStats struct {
+	MaxOpenConnections int           `json:"max_open_connections"`
+	OpenConnections    int           `json:"open_connections"`
+	InUse              int           `json:"in_use"`
+	Idle               int           `json:"idle"`
+	WaitCount          int64         `json:"wait_count"`
+	WaitDuration       time.Duration `json:"wait_duration_ns"`
+	MaxIdleClosed      int64         `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64         `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64         `json:"max_lifetime_closed"`
+}
+
+func NewDatabase(databaseURL string, pool PoolConfig) (*Database, error) {
 	db, err := sql.Open("postgres", databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	// Configure connection pool
-	db.SetMaxOpenConns(10)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
@@ -43,6 +67,22 @@ func (d *Database) GetDB() *sql.DB {
 	return d.db
 }
 
+// PoolStats reports the current state of the connection pool
+func (d *Database) PoolStats() *PoolStats {
+	stats := d.db.Stats()
+	return &PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration,
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+}
+
 func (d *Database) RunMigrations(migrationsPath string) error {
 	driver, err := postgres.WithInstance(d.db, &postgres.Config{})
 	if err != nil {
@@ -65,11 +105,8 @@ func (d *Database) RunMigrations(migrationsPath string) error {
 	return nil
 }
 
-// Health check for database connectivity
-func (d *Database) HealthCheck() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	
+// Health check for database connectivity, bounded by ctx
+func (d *Database) HealthCheck(ctx context.Context) error {
 	return d.db.PingContext(ctx)
 }
 
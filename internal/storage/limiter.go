@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// QueryLimiter caps how many database queries a single request may run
+// concurrently, so one heavy request cannot monopolize the connection pool.
+type QueryLimiter struct {
+	sem chan struct{}
+}
+
+type queryLimiterKey struct{}
+
+func NewQueryLimiter(maxConcurrent int) *QueryLimiter {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &QueryLimiter{sem: make(chan struct{}, maxConcurrent)}
+}
+
+// WithQueryLimiter attaches the limiter to the request context
+func WithQueryLimiter(ctx context.Context, limiter *QueryLimiter) context.Context {
+	return context.WithValue(ctx, queryLimiterKey{}, limiter)
+}
+
+// QueryLimiterFromContext returns the request's limiter, or nil if none was attached
+func QueryLimiterFromContext(ctx context.Context) *QueryLimiter {
+	limiter, _ := ctx.Value(queryLimiterKey{}).(*QueryLimiter)
+	return limiter
+}
+
+// Acquire blocks until a query slot is free or the context is done
+func (l *QueryLimiter) Acquire(ctx context.Context) error {
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire
+func (l *QueryLimiter) Release() {
+	<-l.sem
+}
+
+// RunLimited calls fn for every index in [0, n), running calls concurrently up to
+// the request's query limit. Without a limiter in the context calls run sequentially.
+// fn must not call RunLimited itself, since it already holds a slot.
+func RunLimited(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	limiter := QueryLimiterFromContext(ctx)
+	if limiter == nil {
+		for i := 0; i < n; i++ {
+			if err := fn(ctx, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	var acquireErr error
+	for i := 0; i < n; i++ {
+		if acquireErr = limiter.Acquire(gctx); acquireErr != nil {
+			break
+		}
+		i := i
+		g.Go(func() error {
+			defer limiter.Release()
+			return fn(gctx, i)
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	return acquireErr
+}
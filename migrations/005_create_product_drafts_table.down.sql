@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS update_product_drafts_updated_at ON product_drafts;
+DROP TABLE IF EXISTS product_drafts;
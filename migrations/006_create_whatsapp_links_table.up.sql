@@ -0,0 +1,28 @@
+-- Create whatsapp_links table for tracking generated WhatsApp links
+CREATE TABLE IF NOT EXISTS whatsapp_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID REFERENCES products(id) ON DELETE SET NULL,
+    transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    inquiry_id UUID REFERENCES product_inquiries(id) ON DELETE SET NULL,
+    from_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    phone_number VARCHAR(20) NOT NULL,
+    message TEXT NOT NULL,
+    whatsapp_url TEXT NOT NULL,
+    deep_link TEXT NOT NULL,
+    web_link TEXT NOT NULL,
+    link_type VARCHAR(20) NOT NULL CHECK (link_type IN ('inquiry', 'transaction', 'business')),
+    status VARCHAR(20) DEFAULT 'created' CHECK (status IN ('created', 'clicked', 'expired')),
+    click_count INTEGER DEFAULT 0,
+    last_clicked_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_whatsapp_links_from_user ON whatsapp_links(from_user_id);
+CREATE INDEX IF NOT EXISTS idx_whatsapp_links_to_user ON whatsapp_links(to_user_id);
+CREATE INDEX IF NOT EXISTS idx_whatsapp_links_product ON whatsapp_links(product_id);
+CREATE INDEX IF NOT EXISTS idx_whatsapp_links_transaction ON whatsapp_links(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_whatsapp_links_created_at ON whatsapp_links(created_at);
+CREATE INDEX IF NOT EXISTS idx_whatsapp_links_expires_at ON whatsapp_links(expires_at) WHERE expires_at IS NOT NULL;
@@ -0,0 +1,18 @@
+-- Create user_merges table as an audit trail of admin account merges
+CREATE TABLE user_merges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    source_user_id UUID NOT NULL REFERENCES users(id),
+    target_user_id UUID NOT NULL REFERENCES users(id),
+    merged_by UUID NOT NULL REFERENCES users(id),
+    
+    -- Number of rows reassigned per table
+    summary JSONB DEFAULT '{}',
+    
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    
+    CHECK (source_user_id != target_user_id)
+);
+
+CREATE INDEX idx_user_merges_source_user_id ON user_merges(source_user_id);
+CREATE INDEX idx_user_merges_target_user_id ON user_merges(target_user_id);
+CREATE INDEX idx_user_merges_created_at ON user_merges(created_at);
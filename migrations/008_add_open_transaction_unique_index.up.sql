@@ -0,0 +1,3 @@
+-- Allow only one open (non-terminal) transaction per buyer and product
+CREATE UNIQUE INDEX idx_transactions_open_product_buyer ON transactions(product_id, buyer_id)
+WHERE status NOT IN ('completed', 'cancelled');
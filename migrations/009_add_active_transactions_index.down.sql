@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_transactions_buyer_active;
@@ -0,0 +1,3 @@
+-- Speed up the buyer's active purchases view
+CREATE INDEX idx_transactions_buyer_active ON transactions(buyer_id, updated_at DESC)
+WHERE status IN ('pending', 'confirmed', 'in_progress', 'disputed');
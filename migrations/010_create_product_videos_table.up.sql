@@ -0,0 +1,16 @@
+-- Create product_videos table for the optional short video per product
+CREATE TABLE product_videos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    video_url TEXT NOT NULL,
+    cloud_storage_path TEXT NOT NULL,
+    poster_url TEXT,
+    poster_storage_path TEXT,
+    duration_seconds INTEGER,
+    file_size BIGINT, -- in bytes
+    mime_type VARCHAR(100),
+    uploaded_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    
+    -- Only one video per product
+    UNIQUE(product_id)
+);
@@ -0,0 +1,11 @@
+-- Create product_events table to track listing lifecycle changes for auditing
+CREATE TABLE product_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    event_type VARCHAR(50) NOT NULL, -- created, updated, published, unpublished, deleted, image_added, ...
+    details JSONB DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_product_events_product_id ON product_events(product_id, created_at);
@@ -0,0 +1,14 @@
+-- Create product_availability_slots table for bookable transport/service calendars
+CREATE TABLE product_availability_slots (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'blocked' CHECK (status IN ('available', 'blocked', 'booked')),
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    
+    CHECK (start_date <= end_date)
+);
+
+CREATE INDEX idx_product_availability_slots_product_dates ON product_availability_slots(product_id, start_date, end_date);
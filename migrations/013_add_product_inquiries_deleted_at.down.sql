@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_product_inquiries_not_deleted;
+ALTER TABLE product_inquiries DROP COLUMN IF EXISTS deleted_at;
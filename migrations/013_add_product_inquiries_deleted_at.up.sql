@@ -0,0 +1,4 @@
+-- Allow buyers to retract unresponded inquiries without losing the record
+ALTER TABLE product_inquiries ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_product_inquiries_not_deleted ON product_inquiries(product_id) WHERE deleted_at IS NULL;
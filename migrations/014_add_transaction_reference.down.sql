@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_reference;
+ALTER TABLE transactions DROP COLUMN IF EXISTS reference;
+DROP SEQUENCE IF EXISTS transaction_reference_seq;
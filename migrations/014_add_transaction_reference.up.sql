@@ -0,0 +1,15 @@
+-- Human-readable transaction references (AGR-YYYY-NNNNNN) for support and receipts
+CREATE SEQUENCE transaction_reference_seq;
+
+ALTER TABLE transactions ADD COLUMN reference VARCHAR(32);
+
+-- Six digits is a minimum width: lpad would truncate larger numbers into duplicates
+UPDATE transactions t
+SET reference = 'AGR-' || to_char(t.created_at, 'YYYY') || '-' ||
+    CASE WHEN seq.n < 1000000 THEN lpad(seq.n::text, 6, '0') ELSE seq.n::text END
+FROM (SELECT id, nextval('transaction_reference_seq') AS n FROM transactions WHERE reference IS NULL) seq
+WHERE t.id = seq.id;
+
+ALTER TABLE transactions ALTER COLUMN reference SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_transactions_reference ON transactions(reference);
@@ -0,0 +1,2 @@
+-- Sellers that only sell in bulk can require a minimum quantity per order
+ALTER TABLE products ADD COLUMN min_order_quantity INTEGER CHECK (min_order_quantity > 0);
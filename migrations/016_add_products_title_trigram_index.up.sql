@@ -0,0 +1,5 @@
+-- Trigram index for substring autocomplete on listing titles
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_products_title_trgm ON products USING GIN (title gin_trgm_ops)
+WHERE is_active = true AND published_at IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS verified_at,
+    DROP COLUMN IF EXISTS verified_by;
@@ -0,0 +1,4 @@
+-- Audit trail for KYC: which admin last changed a user's verification and when
+ALTER TABLE users
+    ADD COLUMN verified_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN verified_at TIMESTAMP WITH TIME ZONE;
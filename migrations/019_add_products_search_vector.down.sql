@@ -0,0 +1,4 @@
+CREATE INDEX idx_products_search ON products USING GIN(to_tsvector('spanish', title || ' ' || COALESCE(description, '') || ' ' || COALESCE(search_keywords, '')));
+
+DROP INDEX IF EXISTS idx_products_search_vector;
+ALTER TABLE products DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,10 @@
+-- Store the Spanish search document instead of rebuilding it on every search.
+-- A stored generated column is filled for existing rows when it is added.
+ALTER TABLE products ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('spanish', title || ' ' || COALESCE(description, '') || ' ' || COALESCE(search_keywords, ''))
+) STORED;
+
+CREATE INDEX idx_products_search_vector ON products USING GIN(search_vector);
+
+-- Superseded by the column index above
+DROP INDEX IF EXISTS idx_products_search;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transactions_whatsapp_thread;
+DROP TABLE IF EXISTS whatsapp_messages;
@@ -0,0 +1,16 @@
+-- Delivery and read receipts received through the WhatsApp webhook
+CREATE TABLE whatsapp_messages (
+    id VARCHAR(128) PRIMARY KEY, -- WhatsApp message ID (wamid)
+    transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    conversation_id VARCHAR(128),
+    recipient_id VARCHAR(20),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('sent', 'delivered', 'read', 'failed')),
+    status_rank SMALLINT NOT NULL,
+    status_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    error_message TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_whatsapp_messages_transaction ON whatsapp_messages(transaction_id) WHERE transaction_id IS NOT NULL;
+CREATE INDEX idx_transactions_whatsapp_thread ON transactions(whatsapp_thread_id) WHERE whatsapp_thread_id IS NOT NULL;
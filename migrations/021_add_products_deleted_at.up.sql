@@ -0,0 +1,6 @@
+-- Soft-delete timestamp so deleted listings can be restored within a retention
+-- window and purged once it has passed. Expired listings are inactive too but
+-- keep deleted_at NULL.
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_products_deleted_at ON products(user_id, deleted_at) WHERE deleted_at IS NOT NULL;
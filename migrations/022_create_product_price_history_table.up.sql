@@ -0,0 +1,11 @@
+-- Price history so buyers can see trends and listings can show price drops
+CREATE TABLE product_price_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    price DECIMAL(12,2),
+    price_type VARCHAR(20) NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_product_price_history_product_id ON product_price_history(product_id, changed_at);
@@ -0,0 +1,28 @@
+-- Saved product searches; notify subscribes the user to newly published matches
+CREATE TABLE saved_searches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    criteria JSONB NOT NULL DEFAULT '{}',
+    notify BOOLEAN NOT NULL DEFAULT true,
+    last_matched_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_saved_searches_user_id ON saved_searches(user_id);
+CREATE INDEX idx_saved_searches_notify ON saved_searches(last_matched_at) WHERE notify = true;
+
+-- Pending new-match notifications, one per saved search and product, picked up
+-- by the delivery channels the user had enabled when the match was found
+CREATE TABLE saved_search_notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    saved_search_id UUID NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    channels TEXT[] NOT NULL DEFAULT '{}',
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (saved_search_id, product_id)
+);
+
+CREATE INDEX idx_saved_search_notifications_pending ON saved_search_notifications(created_at) WHERE sent_at IS NULL;
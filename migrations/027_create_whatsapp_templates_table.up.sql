@@ -0,0 +1,14 @@
+-- Message copy for WhatsApp links, editable without a deploy. Bodies use
+-- {{placeholders}}; links fall back to the built-in copy when no template is active.
+CREATE TABLE whatsapp_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    link_type VARCHAR(20) NOT NULL CHECK (link_type IN ('inquiry', 'transaction', 'business')),
+    language VARCHAR(5) NOT NULL DEFAULT 'es',
+    body TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- At most one active template per link type and language
+CREATE UNIQUE INDEX idx_whatsapp_templates_active ON whatsapp_templates(link_type, language) WHERE is_active;
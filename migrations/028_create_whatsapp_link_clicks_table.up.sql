@@ -0,0 +1,12 @@
+-- Every tracked click on a WhatsApp link. Clicks repeated from the same IP
+-- within the dedup window are recorded but not counted on the link.
+CREATE TABLE whatsapp_link_clicks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    link_id UUID NOT NULL REFERENCES whatsapp_links(id) ON DELETE CASCADE,
+    ip_hash VARCHAR(64) NOT NULL, -- SHA-256 of the client IP, the IP itself is not stored
+    user_agent TEXT,
+    counted BOOLEAN NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_whatsapp_link_clicks_link_ip ON whatsapp_link_clicks(link_id, ip_hash, created_at);
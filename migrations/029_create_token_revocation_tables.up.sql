@@ -0,0 +1,17 @@
+-- Access tokens revoked before expiry, e.g. on logout. Rows can be dropped
+-- once the token would have expired anyway.
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+-- Revokes every token issued to a user up to issued_before, e.g. when the
+-- account is deactivated
+CREATE TABLE user_token_revocations (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    issued_before TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,2 @@
+-- Record when users delete their own account, as opposed to an admin deactivation
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
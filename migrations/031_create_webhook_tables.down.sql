@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS transaction_events;
+DROP TABLE IF EXISTS user_webhooks;
@@ -0,0 +1,30 @@
+-- Endpoints users register to hear about their transactions. The secret
+-- signs each delivery so the receiver can verify it came from us.
+CREATE TABLE user_webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_webhooks_user_id ON user_webhooks(user_id);
+
+-- Transactional outbox: one row per event and webhook, written in the same
+-- database transaction as the change it reports and delivered afterwards
+CREATE TABLE transaction_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    webhook_id UUID NOT NULL REFERENCES user_webhooks(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    delivered BOOLEAN NOT NULL DEFAULT false,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_error TEXT
+);
+
+CREATE INDEX idx_transaction_events_pending ON transaction_events(next_attempt_at) WHERE delivered = false;
+CREATE INDEX idx_transaction_events_transaction_id ON transaction_events(transaction_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS transaction_receipts;
+DROP SEQUENCE IF EXISTS transaction_receipt_number_seq;
@@ -0,0 +1,11 @@
+-- Generated receipt PDFs. The number is assigned once and kept when the PDF
+-- is regenerated after the transaction changes.
+CREATE SEQUENCE transaction_receipt_number_seq;
+
+CREATE TABLE transaction_receipts (
+    transaction_id UUID PRIMARY KEY REFERENCES transactions(id) ON DELETE CASCADE,
+    receipt_number VARCHAR(32) NOT NULL UNIQUE,
+    storage_path TEXT NOT NULL,
+    transaction_updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    generated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,16 @@
+-- Trail of admin actions. before/after hold JSON snapshots of the entity
+-- around mutations; actions that only create or only delete leave one empty.
+CREATE TABLE audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id UUID NOT NULL,
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at DESC);
+CREATE INDEX idx_audit_log_actor_id ON audit_log(actor_id, created_at DESC);
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id, created_at DESC);
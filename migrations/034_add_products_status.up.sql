@@ -0,0 +1,12 @@
+-- Listing lifecycle. Drafts are stored without the full validation and must be
+-- published individually; archived listings are the soft-deleted ones.
+ALTER TABLE products ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'unpublished'
+    CHECK (status IN ('draft', 'published', 'unpublished', 'archived'));
+
+UPDATE products SET status = CASE
+    WHEN deleted_at IS NOT NULL THEN 'archived'
+    WHEN published_at IS NOT NULL THEN 'published'
+    ELSE 'unpublished'
+END;
+
+CREATE INDEX idx_products_status ON products(user_id, status);
@@ -0,0 +1,4 @@
+-- Set when a purchase takes a published listing's stock to zero and it is
+-- unpublished automatically. Restocking a marked listing publishes it again;
+-- any explicit publish or unpublish by the seller clears the mark.
+ALTER TABLE products ADD COLUMN sold_out_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,20 @@
+-- Draft products created from wizard drafts stay products
+CREATE TABLE product_drafts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL, -- Reserved ID for the product created on publish
+    
+    -- Partial CreateProductRequest payload, stored without validation
+    data JSONB NOT NULL DEFAULT '{}',
+    
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    
+    UNIQUE(user_id, product_id)
+);
+
+CREATE INDEX idx_product_drafts_user_id ON product_drafts(user_id);
+CREATE INDEX idx_product_drafts_updated_at ON product_drafts(updated_at);
+
+CREATE TRIGGER update_product_drafts_updated_at BEFORE UPDATE ON product_drafts
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
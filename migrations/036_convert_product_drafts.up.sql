@@ -0,0 +1,23 @@
+-- Wizard drafts become products with status 'draft', the same drafts the
+-- create endpoint saves. Drafts with a title, category and price type carry
+-- over; category details are left for the seller to fill in before
+-- publishing, as for any draft.
+INSERT INTO products (
+    id, user_id, title, description, category, subcategory, price, price_type,
+    unit, quantity, province, city, seller_name, seller_phone, seller_rating,
+    seller_verification_level, status, created_at, updated_at
+)
+SELECT d.product_id, d.user_id, d.data->>'title', d.data->>'description',
+    d.data->>'category', d.data->>'subcategory', (d.data->>'price')::DECIMAL(12,2),
+    d.data->>'price_type', d.data->>'unit', (d.data->>'quantity')::INTEGER,
+    d.data->>'province', d.data->>'city',
+    COALESCE(NULLIF(u.business_name, ''), u.first_name || ' ' || u.last_name),
+    u.phone, u.rating, u.verification_level, 'draft', d.created_at, d.updated_at
+FROM product_drafts d
+JOIN users u ON u.id = d.user_id
+WHERE COALESCE(d.data->>'title', '') <> ''
+  AND d.data->>'category' IN ('transport', 'livestock', 'supplies')
+  AND d.data->>'price_type' IN ('fixed', 'negotiable', 'per_unit', 'quote')
+ON CONFLICT (id) DO NOTHING;
+
+DROP TABLE product_drafts;
@@ -0,0 +1,4 @@
+-- SHA-256 of the request a key was first used with; a retry with a different
+-- body is rejected instead of replaying the original transaction. Keys stored
+-- before this column existed have an empty hash and are not checked.
+ALTER TABLE idempotency_keys ADD COLUMN request_hash VARCHAR(64) NOT NULL DEFAULT '';
@@ -1,10 +1,18 @@
 package gcloud
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
+	"mime"
 	"mime/multipart"
+	"net/http"
 	"path/filepath"
 	"strings"
 	"time"
@@ -62,6 +70,15 @@ func (sc *StorageClient) Close() error {
 	return sc.client.Close()
 }
 
+// HealthCheck fetches the bucket metadata, a cheap call that fails when
+// storage is unreachable or the credentials lost access
+func (sc *StorageClient) HealthCheck(ctx context.Context) error {
+	if _, err := sc.client.Bucket(sc.bucket).Attrs(ctx); err != nil {
+		return fmt.Errorf("failed to get bucket attributes: %w", err)
+	}
+	return nil
+}
+
 // UploadFile uploads a file to Google Cloud Storage
 func (sc *StorageClient) UploadFile(ctx context.Context, file multipart.File, header *multipart.FileHeader, options UploadOptions) (*UploadResult, error) {
 	// Generate storage path
@@ -186,6 +203,23 @@ func (sc *StorageClient) DeleteFile(ctx context.Context, storagePath string) err
 	return nil
 }
 
+// DeleteFiles deletes every file under prefix and returns how many were deleted
+func (sc *StorageClient) DeleteFiles(ctx context.Context, prefix string) (int, error) {
+	objects, err := sc.ListFiles(ctx, prefix, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, object := range objects {
+		if err := sc.DeleteFile(ctx, object.Name); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
 // GetFileURL generates a signed URL for private files or public URL for public files
 func (sc *StorageClient) GetFileURL(ctx context.Context, storagePath string, expiration time.Duration) (string, error) {
 	obj := sc.client.Bucket(sc.bucket).Object(storagePath)
@@ -328,6 +362,8 @@ func (sc *StorageClient) detectContentType(fileName string) string {
 		".png":  "image/png",
 		".gif":  "image/gif",
 		".webp": "image/webp",
+		".mp4":  "video/mp4",
+		".webm": "video/webm",
 		".pdf":  "application/pdf",
 		".doc":  "application/msword",
 		".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
@@ -343,10 +379,91 @@ func (sc *StorageClient) detectContentType(fileName string) string {
 	return "application/octet-stream"
 }
 
-// ValidateImageFile validates if the uploaded file is a valid image
+// ErrFileContentMismatch is returned when an upload's bytes don't match the
+// Content-Type the client declared, e.g. an executable sent as image/png
+var ErrFileContentMismatch = errors.New("file content does not match its declared type")
+
+// sniffLength is how much of a file http.DetectContentType looks at
+const sniffLength = 512
+
+// oleSignature starts legacy Office files such as .doc, which
+// http.DetectContentType does not recognize
+var oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// signatureTypes maps each allowed declared type to the types sniffing may
+// detect for a genuine file of that type
+var signatureTypes = map[string][]string{
+	"image/jpeg":         {"image/jpeg"},
+	"image/jpg":          {"image/jpeg"},
+	"image/png":          {"image/png"},
+	"image/gif":          {"image/gif"},
+	"image/webp":         {"image/webp"},
+	"application/pdf":    {"application/pdf"},
+	"application/msword": {"application/msword"},
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": {"application/zip"},
+	"text/plain":       {"text/plain"},
+	"text/csv":         {"text/plain"},
+	"application/json": {"text/plain"},
+	"video/mp4":        {"video/mp4"},
+	"video/webm":       {"video/webm"},
+}
+
+// verifyFileSignature checks the file's magic bytes against its declared
+// type. It reads through a fresh reader from header.Open, so the file later
+// handed to UploadFile still starts at offset 0.
+func verifyFileSignature(header *multipart.FileHeader, declaredType string) error {
+	detected, err := sniffContentType(header)
+	if err != nil {
+		return err
+	}
+
+	for _, accepted := range signatureTypes[declaredType] {
+		if detected == accepted {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: declared %s, detected %s", ErrFileContentMismatch, declaredType, detected)
+}
+
+// sniffContentType detects a file's type from its first sniffLength bytes,
+// without parameters such as charset
+func sniffContentType(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	buf := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	buf = buf[:n]
+
+	if bytes.HasPrefix(buf, oleSignature) {
+		return "application/msword", nil
+	}
+
+	detected := http.DetectContentType(buf)
+	if mediaType, _, err := mime.ParseMediaType(detected); err == nil {
+		detected = mediaType
+	}
+	return detected, nil
+}
+
+// DefaultMaxImageSize is the largest image accepted unless configured otherwise
+const DefaultMaxImageSize = 10 << 20 // 10MB
+
+// ValidateImageFile validates if the uploaded file is a valid image, checking
+// both the declared Content-Type and the file's magic bytes
 func ValidateImageFile(header *multipart.FileHeader) error {
-	// Check file size (max 10MB for images)
-	const maxSize = 10 * 1024 * 1024 // 10MB
+	return ValidateImageFileWithLimit(header, DefaultMaxImageSize)
+}
+
+// ValidateImageFileWithLimit is ValidateImageFile with a custom maximum size in bytes
+func ValidateImageFileWithLimit(header *multipart.FileHeader, maxSize int64) error {
 	if header.Size > maxSize {
 		return fmt.Errorf("file size exceeds maximum allowed size of %d bytes", maxSize)
 	}
@@ -363,14 +480,53 @@ func ValidateImageFile(header *multipart.FileHeader) error {
 
 	for _, allowedType := range allowedTypes {
 		if contentType == allowedType {
-			return nil
+			if err := verifyFileSignature(header, contentType); err != nil {
+				return err
+			}
+			return verifyImageDecodes(header, contentType)
 		}
 	}
 
 	return fmt.Errorf("invalid image type: %s. Allowed types: %v", contentType, allowedTypes)
 }
 
-// ValidateDocumentFile validates if the uploaded file is a valid document
+// MaxImagePixels bounds the decoded size of an uploaded image, so a small
+// file cannot expand into a huge bitmap while it is checked
+const MaxImagePixels = 50_000_000
+
+// verifyImageDecodes decodes the image to reject files that carry a valid
+// signature but are truncated or corrupt. WebP has no decoder in the standard
+// library and is only checked by its signature.
+func verifyImageDecodes(header *multipart.FileHeader, contentType string) error {
+	if contentType == "image/webp" {
+		return nil
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return fmt.Errorf("%w: unreadable image header: %v", ErrFileContentMismatch, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxImagePixels {
+		return fmt.Errorf("%w: image dimensions %dx%d are not allowed", ErrFileContentMismatch, cfg.Width, cfg.Height)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if _, _, err := image.Decode(file); err != nil {
+		return fmt.Errorf("%w: image could not be decoded: %v", ErrFileContentMismatch, err)
+	}
+	return nil
+}
+
+// ValidateDocumentFile validates if the uploaded file is a valid document,
+// checking both the declared Content-Type and the file's magic bytes
 func ValidateDocumentFile(header *multipart.FileHeader) error {
 	// Check file size (max 50MB for documents)
 	const maxSize = 50 * 1024 * 1024 // 50MB
@@ -391,9 +547,40 @@ func ValidateDocumentFile(header *multipart.FileHeader) error {
 
 	for _, allowedType := range allowedTypes {
 		if contentType == allowedType {
-			return nil
+			return verifyFileSignature(header, contentType)
 		}
 	}
 
 	return fmt.Errorf("invalid document type: %s. Allowed types: %v", contentType, allowedTypes)
-}
\ No newline at end of file
+}
+
+// DefaultMaxVideoSize is the largest product video accepted unless configured otherwise
+const DefaultMaxVideoSize = 50 << 20 // 50MB
+
+// ValidateVideoFile validates if the uploaded file is a valid product video,
+// checking both the declared Content-Type and the file's magic bytes
+func ValidateVideoFile(header *multipart.FileHeader) error {
+	return ValidateVideoFileWithLimit(header, DefaultMaxVideoSize)
+}
+
+// ValidateVideoFileWithLimit is ValidateVideoFile with a custom maximum size in bytes
+func ValidateVideoFileWithLimit(header *multipart.FileHeader, maxSize int64) error {
+	if header.Size > maxSize {
+		return fmt.Errorf("file size exceeds maximum allowed size of %d bytes", maxSize)
+	}
+
+	// Check content type
+	contentType := header.Header.Get("Content-Type")
+	allowedTypes := []string{
+		"video/mp4",
+		"video/webm",
+	}
+
+	for _, allowedType := range allowedTypes {
+		if contentType == allowedType {
+			return verifyFileSignature(header, contentType)
+		}
+	}
+
+	return fmt.Errorf("invalid video type: %s. Allowed types: %v", contentType, allowedTypes)
+}
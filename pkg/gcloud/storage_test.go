@@ -0,0 +1,115 @@
+package gcloud
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/textproto"
+	"testing"
+)
+
+// newFileHeader builds the header gin would hand to a handler for an upload
+// with the given declared type and content
+func newFileHeader(t *testing.T, fileName, contentType string, content []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	partHeader := textproto.MIMEHeader{}
+	partHeader.Set("Content-Disposition", `form-data; name="file"; filename="`+fileName+`"`)
+	partHeader.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(partHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return form.File["file"][0]
+}
+
+func pngBytes(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestValidateImageFileChecksMagicBytes(t *testing.T) {
+	valid := pngBytes(t)
+	executable := append([]byte("MZ\x90\x00\x03\x00\x00\x00"), make([]byte, 64)...)
+
+	tests := []struct {
+		name     string
+		content  []byte
+		mismatch bool
+	}{
+		{name: "genuine png", content: valid},
+		{name: "renamed binary", content: executable, mismatch: true},
+		{name: "truncated image", content: valid[:len(valid)/2], mismatch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := newFileHeader(t, "photo.png", "image/png", tt.content)
+
+			err := ValidateImageFile(header)
+			if tt.mismatch && !errors.Is(err, ErrFileContentMismatch) {
+				t.Fatalf("expected content mismatch, got %v", err)
+			}
+			if !tt.mismatch && err != nil {
+				t.Fatalf("expected valid image, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateImageFileLeavesUploadAtStart(t *testing.T) {
+	content := pngBytes(t)
+	header := newFileHeader(t, "photo.png", "image/png", content)
+
+	file, err := header.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if err := ValidateImageFile(header); err != nil {
+		t.Fatalf("expected valid image, got %v", err)
+	}
+
+	head := make([]byte, 8)
+	if _, err := file.Read(head); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(head, content[:8]) {
+		t.Error("expected the upload to still start at offset 0 after validation")
+	}
+}
+
+func TestValidateDocumentFileChecksMagicBytes(t *testing.T) {
+	pdf := []byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	if err := ValidateDocumentFile(newFileHeader(t, "cuit.pdf", "application/pdf", pdf)); err != nil {
+		t.Errorf("expected valid pdf, got %v", err)
+	}
+
+	executable := append([]byte("\x7fELF\x02\x01\x01"), make([]byte, 64)...)
+	err := ValidateDocumentFile(newFileHeader(t, "cuit.pdf", "application/pdf", executable))
+	if !errors.Is(err, ErrFileContentMismatch) {
+		t.Errorf("expected content mismatch for renamed binary, got %v", err)
+	}
+
+	doc := append(append([]byte{}, oleSignature...), make([]byte, 64)...)
+	if err := ValidateDocumentFile(newFileHeader(t, "dni.doc", "application/msword", doc)); err != nil {
+		t.Errorf("expected valid legacy word document, got %v", err)
+	}
+}
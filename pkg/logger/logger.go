@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type contextKey struct{}
+
+// New creates a JSON logger writing to stdout at the given level ("debug",
+// "info", "warn" or "error"). Unknown levels fall back to info.
+func New(level string) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: ParseLevel(level),
+	}))
+}
+
+// Init installs a logger at the given level as the process-wide default
+func Init(level string) *slog.Logger {
+	l := New(level)
+	slog.SetDefault(l)
+	return l
+}
+
+// ParseLevel maps a configured level name to a slog level
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithContext returns a copy of ctx carrying l
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the request-scoped logger stored in ctx, or the default
+// logger when there is none
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return l
+		}
+	}
+	return slog.Default()
+}
+
+// Fatal logs msg at error level and exits the process
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,80 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends transactional emails such as verification links
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPMailer sends mail through an SMTP relay, authenticating with PLAIN auth
+// when a username is configured. from may carry a display name
+// ("Agro Mas <no-reply@agromas.com.ar>"); only the address is used as the
+// envelope sender.
+type SMTPMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	from, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address %q: %w", m.from, err)
+	}
+
+	if err := smtp.SendMail(m.addr, auth, from.Address, []string{msg.To}, buildMessage(from, msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// buildMessage renders the headers and body. The subject and sender name are
+// MIME-encoded so accented characters survive.
+func buildMessage(from *mail.Address, msg Message) []byte {
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", from.String())
+	fmt.Fprintf(&body, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n\r\n")
+	body.WriteString(msg.Body)
+	return []byte(body.String())
+}
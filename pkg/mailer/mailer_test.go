@@ -0,0 +1,36 @@
+package mailer
+
+import (
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestBuildMessageEncodesHeaders(t *testing.T) {
+	from, err := mail.ParseAddress("Agro Más <no-reply@agromas.com.ar>")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := string(buildMessage(from, Message{
+		To:      "vendedor@example.com",
+		Subject: "Verificá tu dirección",
+		Body:    "Hola",
+	}))
+
+	parsed, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "Verificá tu dirección" {
+		t.Errorf("expected the accented subject to round-trip, got %q (%v)", subject, err)
+	}
+
+	sender, err := parsed.Header.AddressList("From")
+	if err != nil || len(sender) != 1 || sender[0].Name != "Agro Más" || sender[0].Address != "no-reply@agromas.com.ar" {
+		t.Errorf("expected the display name and address in From, got %v (%v)", sender, err)
+	}
+}
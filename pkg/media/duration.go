@@ -0,0 +1,305 @@
+// Package media reads metadata from uploaded media files without decoding them.
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+var (
+	// ErrUnknownDuration is returned when the container does not state its
+	// duration, as in WebM recorded live by browsers
+	ErrUnknownDuration = errors.New("video duration is not stated in the file")
+	ErrMalformedVideo  = errors.New("video container is malformed")
+)
+
+// VideoDuration reads the duration stated in an MP4 or WebM container
+func VideoDuration(r io.ReaderAt, size int64, contentType string) (time.Duration, error) {
+	switch contentType {
+	case "video/mp4":
+		return mp4Duration(r, size)
+	case "video/webm":
+		return webmDuration(r, size)
+	}
+	return 0, fmt.Errorf("unsupported video type: %s", contentType)
+}
+
+// mp4Duration reads the duration from the movie header box (moov/mvhd)
+func mp4Duration(r io.ReaderAt, size int64) (time.Duration, error) {
+	moovStart, moovEnd, err := findMP4Box(r, 0, size, "moov")
+	if err != nil {
+		return 0, err
+	}
+	mvhdStart, mvhdEnd, err := findMP4Box(r, moovStart, moovEnd, "mvhd")
+	if err != nil {
+		return 0, err
+	}
+
+	// version(1) flags(3), then creation and modification times, timescale
+	// and duration; times and duration are 64-bit in version 1
+	header := make([]byte, 32)
+	n, _ := r.ReadAt(header, mvhdStart)
+	header = header[:n]
+
+	var timescale, duration uint64
+	switch {
+	case len(header) >= 20 && header[0] == 0 && mvhdEnd-mvhdStart >= 20:
+		timescale = uint64(binary.BigEndian.Uint32(header[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(header[16:20]))
+		if duration == math.MaxUint32 {
+			return 0, ErrUnknownDuration
+		}
+	case len(header) >= 32 && header[0] == 1 && mvhdEnd-mvhdStart >= 32:
+		timescale = uint64(binary.BigEndian.Uint32(header[20:24]))
+		duration = binary.BigEndian.Uint64(header[24:32])
+		if duration == math.MaxUint64 {
+			return 0, ErrUnknownDuration
+		}
+	default:
+		return 0, ErrMalformedVideo
+	}
+
+	if timescale == 0 {
+		return 0, ErrMalformedVideo
+	}
+	return secondsDuration(float64(duration) / float64(timescale))
+}
+
+// findMP4Box returns the payload bounds of the first box of the given type
+// among the boxes laid out between start and end
+func findMP4Box(r io.ReaderAt, start, end int64, boxType string) (int64, int64, error) {
+	header := make([]byte, 16)
+	for offset := start; offset+8 <= end; {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return 0, 0, ErrMalformedVideo
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		headerSize := int64(8)
+
+		switch boxSize {
+		case 0:
+			// The box extends to the end of its parent
+			boxSize = end - offset
+		case 1:
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return 0, 0, ErrMalformedVideo
+			}
+			largeSize := binary.BigEndian.Uint64(header[8:16])
+			if largeSize > math.MaxInt64 {
+				return 0, 0, ErrMalformedVideo
+			}
+			boxSize = int64(largeSize)
+			headerSize = 16
+		}
+		if boxSize < headerSize || boxSize > end-offset {
+			return 0, 0, ErrMalformedVideo
+		}
+
+		if string(header[4:8]) == boxType {
+			return offset + headerSize, offset + boxSize, nil
+		}
+		offset += boxSize
+	}
+	return 0, 0, ErrUnknownDuration
+}
+
+// EBML element IDs on the path to the WebM duration
+const (
+	ebmlHeaderID    = 0x1A45DFA3
+	segmentID       = 0x18538067
+	infoID          = 0x1549A966
+	clusterID       = 0x1F43B675
+	timecodeScaleID = 0x2AD7B1
+	durationID      = 0x4489
+
+	// Nanoseconds per timecode unit unless the file says otherwise
+	defaultTimecodeScale = 1000000
+)
+
+// webmDuration reads Segment/Info/Duration, scaled by the TimecodeScale
+func webmDuration(r io.ReaderAt, size int64) (time.Duration, error) {
+	reader := &ebmlReader{r: r, end: size}
+
+	for reader.offset < size {
+		id, elementSize, err := reader.element()
+		if err != nil {
+			return 0, err
+		}
+
+		switch id {
+		case ebmlHeaderID:
+			if elementSize < 0 {
+				return 0, ErrMalformedVideo
+			}
+			reader.offset += elementSize
+		case segmentID:
+			// Segments written live have an unknown size; read to the end
+			end := size
+			if elementSize >= 0 && reader.offset+elementSize < size {
+				end = reader.offset + elementSize
+			}
+			return webmSegmentDuration(&ebmlReader{r: r, offset: reader.offset, end: end})
+		default:
+			return 0, ErrMalformedVideo
+		}
+	}
+	return 0, ErrMalformedVideo
+}
+
+func webmSegmentDuration(reader *ebmlReader) (time.Duration, error) {
+	for reader.offset < reader.end {
+		id, elementSize, err := reader.element()
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case id == infoID && elementSize >= 0:
+			return webmInfoDuration(&ebmlReader{r: reader.r, offset: reader.offset, end: reader.offset + elementSize})
+		case id == clusterID || elementSize < 0:
+			// Media data started without an Info element stating the duration
+			return 0, ErrUnknownDuration
+		}
+		reader.offset += elementSize
+	}
+	return 0, ErrUnknownDuration
+}
+
+func webmInfoDuration(reader *ebmlReader) (time.Duration, error) {
+	timecodeScale := uint64(defaultTimecodeScale)
+	var duration float64
+	hasDuration := false
+
+	for reader.offset < reader.end {
+		id, elementSize, err := reader.element()
+		if err != nil {
+			return 0, err
+		}
+		if elementSize < 0 || (elementSize > 8 && (id == timecodeScaleID || id == durationID)) {
+			return 0, ErrMalformedVideo
+		}
+
+		switch id {
+		case timecodeScaleID:
+			value, err := reader.bytes(elementSize)
+			if err != nil {
+				return 0, err
+			}
+			timecodeScale = 0
+			for _, b := range value {
+				timecodeScale = timecodeScale<<8 | uint64(b)
+			}
+		case durationID:
+			value, err := reader.bytes(elementSize)
+			if err != nil {
+				return 0, err
+			}
+			switch len(value) {
+			case 4:
+				duration = float64(math.Float32frombits(binary.BigEndian.Uint32(value)))
+			case 8:
+				duration = math.Float64frombits(binary.BigEndian.Uint64(value))
+			default:
+				return 0, ErrMalformedVideo
+			}
+			hasDuration = true
+		}
+		reader.offset += elementSize
+	}
+
+	if !hasDuration {
+		return 0, ErrUnknownDuration
+	}
+	if timecodeScale == 0 {
+		return 0, ErrMalformedVideo
+	}
+	return secondsDuration(duration * float64(timecodeScale) / float64(time.Second))
+}
+
+// ebmlReader walks EBML elements between offset and end
+type ebmlReader struct {
+	r      io.ReaderAt
+	offset int64
+	end    int64
+}
+
+// element reads an element header and leaves offset at its data. A size of
+// -1 means the size is unknown.
+func (e *ebmlReader) element() (uint64, int64, error) {
+	id, idLength, err := e.vint(true)
+	if err != nil {
+		return 0, 0, err
+	}
+	e.offset += idLength
+
+	size, sizeLength, err := e.vint(false)
+	if err != nil {
+		return 0, 0, err
+	}
+	e.offset += sizeLength
+
+	// All value bits set marks an unknown size
+	if size == 1<<(7*uint(sizeLength))-1 {
+		return id, -1, nil
+	}
+	if size > uint64(e.end-e.offset) {
+		return 0, 0, ErrMalformedVideo
+	}
+	return id, int64(size), nil
+}
+
+// vint reads a variable-length integer, keeping the length marker for IDs
+func (e *ebmlReader) vint(keepMarker bool) (uint64, int64, error) {
+	first, err := e.bytesAt(e.offset, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	length := int64(1)
+	for mask := byte(0x80); first[0]&mask == 0; mask >>= 1 {
+		length++
+		if length > 8 {
+			return 0, 0, ErrMalformedVideo
+		}
+	}
+
+	data, err := e.bytesAt(e.offset, length)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	value := uint64(data[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> uint(length))
+	}
+	for _, b := range data[1:] {
+		value = value<<8 | uint64(b)
+	}
+	return value, length, nil
+}
+
+func (e *ebmlReader) bytes(n int64) ([]byte, error) {
+	return e.bytesAt(e.offset, n)
+}
+
+func (e *ebmlReader) bytesAt(offset, n int64) ([]byte, error) {
+	if offset+n > e.end {
+		return nil, ErrMalformedVideo
+	}
+	data := make([]byte, n)
+	if read, _ := e.r.ReadAt(data, offset); int64(read) < n {
+		return nil, ErrMalformedVideo
+	}
+	return data, nil
+}
+
+func secondsDuration(seconds float64) (time.Duration, error) {
+	if math.IsNaN(seconds) || seconds < 0 || seconds > float64(math.MaxInt64)/float64(time.Second) {
+		return 0, ErrMalformedVideo
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
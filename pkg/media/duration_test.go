@@ -0,0 +1,157 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func mp4Box(boxType string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	box := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(box, uint32(8+len(body)))
+	copy(box[4:], boxType)
+	return append(box, body...)
+}
+
+func mvhdV0(timescale, duration uint32) []byte {
+	payload := make([]byte, 100)
+	binary.BigEndian.PutUint32(payload[12:], timescale)
+	binary.BigEndian.PutUint32(payload[16:], duration)
+	return mp4Box("mvhd", payload)
+}
+
+func mvhdV1(timescale uint32, duration uint64) []byte {
+	payload := make([]byte, 112)
+	payload[0] = 1
+	binary.BigEndian.PutUint32(payload[20:], timescale)
+	binary.BigEndian.PutUint64(payload[24:], duration)
+	return mp4Box("mvhd", payload)
+}
+
+// ebml encodes an element with a one-byte size, or with an unknown size
+func ebml(id []byte, data []byte, unknownSize bool) []byte {
+	element := append([]byte{}, id...)
+	if unknownSize {
+		element = append(element, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF)
+	} else {
+		element = append(element, 0x80|byte(len(data)))
+	}
+	return append(element, data...)
+}
+
+func webmFile(info []byte, unknownSegmentSize bool) []byte {
+	header := ebml([]byte{0x1A, 0x45, 0xDF, 0xA3}, ebml([]byte{0x42, 0x82}, []byte("webm"), false), false)
+	segment := bytes.Join([][]byte{
+		ebml([]byte{0x11, 0x4D, 0x9B, 0x74}, []byte{0, 0}, false),
+		info,
+		ebml([]byte{0x1F, 0x43, 0xB6, 0x75}, []byte{0xE7, 0x81, 0x00}, false),
+	}, nil)
+	return append(header, ebml([]byte{0x18, 0x53, 0x80, 0x67}, segment, unknownSegmentSize)...)
+}
+
+func webmInfo(scale []byte, duration []byte) []byte {
+	var data []byte
+	if scale != nil {
+		data = append(data, ebml([]byte{0x2A, 0xD7, 0xB1}, scale, false)...)
+	}
+	if duration != nil {
+		data = append(data, ebml([]byte{0x44, 0x89}, duration, false)...)
+	}
+	return ebml([]byte{0x15, 0x49, 0xA9, 0x66}, data, false)
+}
+
+func float64Bytes(f float64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, math.Float64bits(f))
+	return b
+}
+
+func float32Bytes(f float32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, math.Float32bits(f))
+	return b
+}
+
+func TestVideoDuration(t *testing.T) {
+	ftyp := mp4Box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2"))
+	mdat := mp4Box("mdat", make([]byte, 64))
+
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+		want        time.Duration
+		wantErr     error
+	}{
+		{
+			name:        "mp4 version 0 with moov at the end",
+			contentType: "video/mp4",
+			data:        bytes.Join([][]byte{ftyp, mdat, mp4Box("moov", mvhdV0(1000, 95500))}, nil),
+			want:        95500 * time.Millisecond,
+		},
+		{
+			name:        "mp4 version 1",
+			contentType: "video/mp4",
+			data:        bytes.Join([][]byte{ftyp, mp4Box("moov", mvhdV1(90000, 90000*30)), mdat}, nil),
+			want:        30 * time.Second,
+		},
+		{
+			name:        "mp4 without moov",
+			contentType: "video/mp4",
+			data:        bytes.Join([][]byte{ftyp, mdat}, nil),
+			wantErr:     ErrUnknownDuration,
+		},
+		{
+			name:        "mp4 with a box overrunning the file",
+			contentType: "video/mp4",
+			data:        append(ftyp, 0x00, 0x00, 0xFF, 0xFF, 'm', 'o', 'o', 'v'),
+			wantErr:     ErrMalformedVideo,
+		},
+		{
+			name:        "webm with default timecode scale",
+			contentType: "video/webm",
+			data:        webmFile(webmInfo(nil, float64Bytes(12345)), false),
+			want:        12345 * time.Millisecond,
+		},
+		{
+			name:        "webm with custom scale and float32 duration",
+			contentType: "video/webm",
+			data:        webmFile(webmInfo([]byte{0x01, 0x86, 0xA0}, float32Bytes(20000)), true),
+			want:        2 * time.Second,
+		},
+		{
+			name:        "webm recorded live without duration",
+			contentType: "video/webm",
+			data:        webmFile(webmInfo(nil, nil), true),
+			wantErr:     ErrUnknownDuration,
+		},
+		{
+			name:        "not a webm file",
+			contentType: "video/webm",
+			data:        []byte("definitely not a video"),
+			wantErr:     ErrMalformedVideo,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VideoDuration(bytes.NewReader(tt.data), int64(len(tt.data)), tt.contentType)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v (duration %v)", tt.wantErr, err, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
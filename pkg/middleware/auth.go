@@ -1,11 +1,13 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"agro-mas-backend/internal/auth"
+	"agro-mas-backend/pkg/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -23,8 +25,19 @@ func AuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
 			return
 		}
 
-		claims, err := jwtManager.VerifyToken(token)
+		claims, err := jwtManager.Authenticate(c.Request.Context(), token)
+		if errors.Is(err, auth.ErrRevocationUnavailable) {
+			logger.FromContext(c.Request.Context()).Error("failed to check token revocation", "error", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Authentication temporarily unavailable",
+				"code":  "AUTH_UNAVAILABLE",
+			})
+			c.Abort()
+			return
+		}
 		if err != nil {
+			// The reason is only logged; clients get the same generic error
+			logger.FromContext(c.Request.Context()).Warn("rejected access token", "reason", err)
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid or expired token",
 				"code":  "AUTH_TOKEN_INVALID",
@@ -52,7 +65,7 @@ func OptionalAuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := extractTokenFromHeader(c.GetHeader("Authorization"))
 		if token != "" {
-			claims, err := jwtManager.VerifyToken(token)
+			claims, err := jwtManager.Authenticate(c.Request.Context(), token)
 			if err == nil {
 				// Set user context if token is valid
 				c.Set("user_id", claims.UserID)
@@ -1,9 +1,12 @@
 package middleware
 
 import (
-	"fmt"
+	"log/slog"
+	"net/http"
 	"time"
 
+	"agro-mas-backend/internal/storage"
+	"agro-mas-backend/pkg/logger"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -36,6 +39,7 @@ func CORSMiddleware() gin.HandlerFunc {
 			"origin",
 			"Cache-Control",
 			"X-Requested-With",
+			"Idempotency-Key",
 		},
 		ExposeHeaders: []string{
 			"Content-Length",
@@ -67,22 +71,41 @@ func SecurityHeadersMiddleware() gin.HandlerFunc {
 	}
 }
 
-// LoggerMiddleware configures structured logging
+// LoggerMiddleware attaches a request-scoped logger carrying the trace ID to
+// the request context and emits one JSON line per request once it completes
 func LoggerMiddleware() gin.HandlerFunc {
-	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		return fmt.Sprintf(`{"time":"%s","method":"%s","path":"%s","protocol":"%s","status":%d,"latency":"%s","client_ip":"%s","user_agent":"%s","errors":"%s"}%s`,
-			param.TimeStamp.Format(time.RFC3339),
-			param.Method,
-			param.Path,
-			param.Request.Proto,
-			param.StatusCode,
-			param.Latency,
-			param.ClientIP,
-			param.Request.UserAgent(),
-			param.ErrorMessage,
-			"\n",
-		)
-	})
+	return func(c *gin.Context) {
+		start := time.Now()
+		traceID := traceIDFor(c)
+
+		requestLogger := slog.Default().With("trace_id", traceID)
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), requestLogger))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+			"user_agent", c.Request.UserAgent(),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+
+		requestLogger.Log(c.Request.Context(), level, "request completed", attrs...)
+	}
 }
 
 // APIVersionMiddleware sets API version header
@@ -100,3 +123,13 @@ func ContentTypeMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// QueryConcurrencyMiddleware gives each request its own query limiter so batch
+// loaders cannot open more than maxConcurrent connections for one request
+func QueryConcurrencyMiddleware(maxConcurrent int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := storage.WithQueryLimiter(c.Request.Context(), storage.NewQueryLimiter(maxConcurrent))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
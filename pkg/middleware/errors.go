@@ -1,15 +1,23 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
+	"agro-mas-backend/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
+// Context key under which the request's trace ID is stored
+const traceIDKey = "trace_id"
+
 // ErrorResponse represents a standardized error response
 type ErrorResponse struct {
 	Error   string                 `json:"error"`
@@ -38,15 +46,16 @@ func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
-		// Process any errors that occurred during request processing
-		if len(c.Errors) > 0 {
+		// Process any errors that occurred during request processing, unless a
+		// more specific handler already responded
+		if len(c.Errors) > 0 && !c.Writer.Written() {
 			err := c.Errors.Last()
 			
-			// Generate trace ID for debugging
-			traceID := generateTraceID()
+			// Reuse the request's trace ID so the error matches its log lines
+			traceID := traceIDFor(c)
 			
 			// Log the error for monitoring
-			logError(c, err, traceID)
+			logError(c, err)
 			
 			// Convert error to appropriate HTTP response
 			statusCode, errorResponse := convertErrorToResponse(err, traceID)
@@ -60,13 +69,13 @@ func ErrorHandler() gin.HandlerFunc {
 // RecoveryHandler handles panics and converts them to errors
 func RecoveryHandler() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, err interface{}) {
-		traceID := generateTraceID()
+		traceID := traceIDFor(c)
 		
 		// Log panic details
 		stack := make([]byte, 4096)
 		length := runtime.Stack(stack, false)
 		
-		logPanic(c, err, string(stack[:length]), traceID)
+		logPanic(c, err, string(stack[:length]))
 		
 		// Return standardized error response
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -126,12 +135,19 @@ func RateLimitErrorHandler() gin.HandlerFunc {
 		
 		for _, err := range c.Errors {
 			if isRateLimitError(err.Err) {
+				retryAfter := 60
+				var limitErr *RateLimitError
+				if errors.As(err.Err, &limitErr) {
+					retryAfter = retryAfterSeconds(limitErr.RetryAfter)
+				}
+
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
 				c.JSON(http.StatusTooManyRequests, ErrorResponse{
 					Error:   "Rate limit exceeded",
 					Code:    "RATE_LIMIT_EXCEEDED",
 					Message: "Too many requests. Please try again later.",
 					Details: map[string]interface{}{
-						"retry_after": "60s",
+						"retry_after": fmt.Sprintf("%ds", retryAfter),
 					},
 				})
 				return
@@ -143,32 +159,42 @@ func RateLimitErrorHandler() gin.HandlerFunc {
 // Helper functions
 
 func generateTraceID() string {
-	// In a real implementation, you'd use a proper trace ID generation
-	// This is a simplified version
-	return fmt.Sprintf("trace_%d", time.Now().UnixNano())
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("trace_%d", time.Now().UnixNano())
+	}
+	return "trace_" + hex.EncodeToString(buf)
+}
+
+// traceIDFor returns the trace ID assigned to the request, assigning one on
+// first use so every log line and error response for a request shares it
+func traceIDFor(c *gin.Context) string {
+	if traceID := c.GetString(traceIDKey); traceID != "" {
+		return traceID
+	}
+	traceID := generateTraceID()
+	c.Set(traceIDKey, traceID)
+	return traceID
 }
 
-func logError(c *gin.Context, err *gin.Error, traceID string) {
-	// In production, you'd use structured logging (e.g., logrus, zap)
-	fmt.Printf("[ERROR] %s | %s %s | %s | TraceID: %s | Error: %v\n",
-		time.Now().Format("2006/01/02 - 15:04:05"),
-		c.Request.Method,
-		c.Request.RequestURI,
-		c.ClientIP(),
-		traceID,
-		err.Err,
+// logError and logPanic log through the request-scoped logger, which already
+// carries the trace ID
+func logError(c *gin.Context, err *gin.Error) {
+	logger.FromContext(c.Request.Context()).Error("request failed",
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"client_ip", c.ClientIP(),
+		"error", err.Err,
 	)
 }
 
-func logPanic(c *gin.Context, err interface{}, stack string, traceID string) {
-	fmt.Printf("[PANIC] %s | %s %s | %s | TraceID: %s | Panic: %v\nStack:\n%s\n",
-		time.Now().Format("2006/01/02 - 15:04:05"),
-		c.Request.Method,
-		c.Request.RequestURI,
-		c.ClientIP(),
-		traceID,
-		err,
-		stack,
+func logPanic(c *gin.Context, err interface{}, stack string) {
+	logger.FromContext(c.Request.Context()).Error("panic recovered",
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"client_ip", c.ClientIP(),
+		"panic", fmt.Sprint(err),
+		"stack", stack,
 	)
 }
 
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// How long a client may stay silent before its bucket is dropped
+const rateLimitIdleTTL = 10 * time.Minute
+
+// RateLimitError is attached to the context when a client exceeds its limit
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s", e.RetryAfter)
+}
+
+// RouteRateLimit overrides the default limit for a single route, identified by
+// its registered path (e.g. "/api/v1/auth/login")
+type RouteRateLimit struct {
+	Path  string
+	Limit rate.Limit
+	Burst int
+}
+
+// PerMinute converts a per-minute limit into a rate. Zero or less disables the
+// limit instead of dividing by zero.
+func PerMinute(n int) rate.Limit {
+	if n <= 0 {
+		return rate.Inf
+	}
+	return rate.Every(time.Minute / time.Duration(n))
+}
+
+type rateLimitClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiter keeps one token bucket per client key
+type rateLimiter struct {
+	limit     rate.Limit
+	burst     int
+	mu        sync.Mutex
+	clients   map[string]*rateLimitClient
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func newRateLimiter(limit rate.Limit, burst int) *rateLimiter {
+	return &rateLimiter{
+		limit:     limit,
+		burst:     burst,
+		clients:   make(map[string]*rateLimitClient),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// allow takes a token for the key, returning how long to wait when none is left
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	client, ok := l.clients[key]
+	if !ok {
+		client = &rateLimitClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = client
+	}
+	client.lastSeen = now
+
+	reservation := client.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, rateLimitIdleTTL
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// sweep drops clients that have been idle for longer than the TTL. It runs at
+// most once per TTL so the map stays bounded without a background goroutine.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitIdleTTL {
+		return
+	}
+	for key, client := range l.clients {
+		if now.Sub(client.lastSeen) >= rateLimitIdleTTL {
+			delete(l.clients, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// RateLimitMiddleware limits each client IP with a token bucket refilled at rps
+// requests per second; rps of zero or less disables the default limit. Routes
+// listed in overrides get their own, separate bucket, so login can be stricter
+// than search. Rejected requests are aborted with a RateLimitError for
+// RateLimitErrorHandler to turn into a 429. The client IP comes from
+// c.ClientIP, so the router's trusted proxies must be set.
+func RateLimitMiddleware(rps int, burst int, overrides ...RouteRateLimit) gin.HandlerFunc {
+	limit := rate.Limit(rps)
+	if rps <= 0 {
+		limit = rate.Inf
+	}
+	defaultLimiter := newRateLimiter(limit, burst)
+
+	routeLimiters := make(map[string]*rateLimiter, len(overrides))
+	for _, override := range overrides {
+		routeLimiters[override.Path] = newRateLimiter(override.Limit, override.Burst)
+	}
+
+	return func(c *gin.Context) {
+		limiter, ok := routeLimiters[c.FullPath()]
+		if !ok {
+			limiter = defaultLimiter
+		}
+
+		if allowed, retryAfter := limiter.allow(c.ClientIP()); !allowed {
+			c.Error(&RateLimitError{RetryAfter: retryAfter})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// retryAfterSeconds rounds a wait up to whole seconds for the Retry-After header
+func retryAfterSeconds(d time.Duration) int {
+	return int(math.Max(1, math.Ceil(d.Seconds())))
+}
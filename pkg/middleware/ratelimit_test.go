@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+func newRateLimitedRouter(rps, burst int, overrides ...RouteRateLimit) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RateLimitErrorHandler())
+	router.Use(RateLimitMiddleware(rps, burst, overrides...))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/search", ok)
+	router.POST("/login", ok)
+	return router
+}
+
+func doRequest(router *gin.Engine, method, path, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = ip + ":1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitMiddlewareBurstFromSingleIP(t *testing.T) {
+	router := newRateLimitedRouter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		if w := doRequest(router, http.MethodGet, "/search", "10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200 within burst, got %d", i+1, w.Code)
+		}
+	}
+
+	w := doRequest(router, http.MethodGet, "/search", "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after burst, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("expected Retry-After 1, got %q", got)
+	}
+
+	// Other clients keep their own bucket
+	if w := doRequest(router, http.MethodGet, "/search", "10.0.0.2"); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a different IP, got %d", w.Code)
+	}
+}
+
+func TestRateLimitMiddlewareRouteOverride(t *testing.T) {
+	router := newRateLimitedRouter(100, 100, RouteRateLimit{
+		Path:  "/login",
+		Limit: rate.Every(time.Minute / 5),
+		Burst: 2,
+	})
+
+	for i := 0; i < 2; i++ {
+		if w := doRequest(router, http.MethodPost, "/login", "10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("login %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+
+	w := doRequest(router, http.MethodPost, "/login", "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected login to be limited, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "12" {
+		t.Errorf("expected Retry-After 12, got %q", got)
+	}
+
+	// The stricter login bucket does not consume the default one
+	if w := doRequest(router, http.MethodGet, "/search", "10.0.0.1"); w.Code != http.StatusOK {
+		t.Errorf("expected search to remain available, got %d", w.Code)
+	}
+}
+
+func TestRateLimiterSweepsIdleClients(t *testing.T) {
+	limiter := newRateLimiter(1, 1)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	limiter.allow("10.0.0.1")
+	now = now.Add(rateLimitIdleTTL)
+	limiter.allow("10.0.0.2")
+
+	if _, ok := limiter.clients["10.0.0.1"]; ok {
+		t.Error("expected idle client to be swept")
+	}
+	if len(limiter.clients) != 1 {
+		t.Errorf("expected 1 tracked client, got %d", len(limiter.clients))
+	}
+}
+
+func TestPerMinuteZeroDisablesLimit(t *testing.T) {
+	if got := PerMinute(0); got != rate.Inf {
+		t.Errorf("expected no limit for 0, got %v", got)
+	}
+	if got := PerMinute(6); got != rate.Every(10*time.Second) {
+		t.Errorf("expected one token every 10s, got %v", got)
+	}
+}
+
+func TestRateLimitMiddlewareIgnoresSpoofedForwardedFor(t *testing.T) {
+	router := newRateLimitedRouter(1, 1)
+	if err := router.SetTrustedProxies([]string{"169.254.0.0/16"}); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/search", nil)
+		req.RemoteAddr = "169.254.1.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// The front end appends the real client IP; the spoofed left entries
+	// must not give the client a fresh bucket
+	if code := request("1.1.1.1, 203.0.113.7"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := request("2.2.2.2, 203.0.113.7"); code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 for the same client with a spoofed header, got %d", code)
+	}
+}
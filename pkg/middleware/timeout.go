@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"agro-mas-backend/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// RouteTimeout overrides the default timeout for the requests it matches. An
+// empty Method or Path matches any; Path is the registered route path (e.g.
+// "/api/v1/products/images").
+type RouteTimeout struct {
+	Method  string
+	Path    string
+	Timeout time.Duration
+}
+
+func (r RouteTimeout) matches(method, path string) bool {
+	return (r.Method == "" || r.Method == method) && (r.Path == "" || r.Path == path)
+}
+
+// TimeoutMiddleware bounds each request's context to d, or to the timeout of
+// the first matching override, so uploads can run longer and reads shorter.
+// Database and storage calls made with the request context are cancelled
+// once it expires. The handler's response is held back until it returns: if
+// the deadline passed and the handler failed or never answered, the client
+// gets a 504 instead of the 500 its cancelled calls produced.
+func TimeoutMiddleware(d time.Duration, overrides ...RouteTimeout) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := d
+		for _, override := range overrides {
+			if override.matches(c.Request.Method, c.FullPath()) {
+				timeout = override.Timeout
+				break
+			}
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		original := c.Writer
+		header := original.Header().Clone()
+		buffered := &timeoutWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = buffered
+
+		c.Next()
+
+		c.Writer = original
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && (!buffered.wrote || buffered.status >= http.StatusInternalServerError) {
+			logger.FromContext(ctx).Warn("request timed out", "timeout", timeout.String())
+
+			// Drop headers the handler set for the response that is discarded
+			for key := range original.Header() {
+				delete(original.Header(), key)
+			}
+			for key, values := range header {
+				original.Header()[key] = values
+			}
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{
+				"error": "Request timed out",
+				"code":  "REQUEST_TIMEOUT",
+			})
+			return
+		}
+
+		// Handlers that only set a status (e.g. 304) leave gin to flush it
+		original.WriteHeader(buffered.status)
+		if buffered.wrote {
+			original.WriteHeaderNow()
+			original.Write(buffered.body.Bytes())
+		}
+	}
+}
+
+// timeoutWriter holds the handler's response in memory so TimeoutMiddleware
+// can still replace it once the handler returns
+type timeoutWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+	wrote  bool
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if code > 0 && !w.wrote {
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.wrote = true
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.wrote = true
+	return w.body.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	w.wrote = true
+	return w.body.WriteString(s)
+}
+
+func (w *timeoutWriter) Status() int {
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	if !w.wrote {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	return w.wrote
+}
+
+// Flush is a no-op; the response is sent when the handler returns
+func (w *timeoutWriter) Flush() {}
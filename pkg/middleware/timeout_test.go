@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTimeoutRouter(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TimeoutMiddleware(20 * time.Millisecond))
+	router.GET("/slow", handler)
+	return router
+}
+
+func TestTimeoutMiddlewareReplacesFailureAfterDeadline(t *testing.T) {
+	router := newTimeoutRouter(func(c *gin.Context) {
+		// A database call failing because the context was cancelled
+		<-c.Request.Context().Done()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": c.Request.Context().Err().Error()})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestTimeoutMiddlewarePassesResponseThrough(t *testing.T) {
+	router := newTimeoutRouter(func(c *gin.Context) {
+		c.Header("ETag", `W/"1"`)
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusCreated || w.Body.String() != `{"ok":true}` {
+		t.Fatalf("expected the handler's response, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("ETag") != `W/"1"` {
+		t.Errorf("expected handler headers to be kept, got %v", w.Header())
+	}
+}
+
+func TestTimeoutMiddlewareKeepsStatusWithoutBody(t *testing.T) {
+	router := newTimeoutRouter(func(c *gin.Context) {
+		c.Status(http.StatusNotModified)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", w.Code)
+	}
+}
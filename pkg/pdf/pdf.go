@@ -0,0 +1,162 @@
+// Package pdf writes simple single-page PDF documents: text in the standard
+// Helvetica fonts, lines and a JPEG image. It covers generated paperwork such
+// as receipts without pulling in a layout engine.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Document is a single A4 page. Coordinates are in points from the top-left
+// corner; text is positioned by its baseline.
+type Document struct {
+	content bytes.Buffer
+	image   *jpegImage
+}
+
+type jpegImage struct {
+	data          []byte
+	width, height int
+	gray          bool
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+// Text draws s at (x, y). Characters outside Windows-1252 are replaced with '?'.
+func (d *Document) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&d.content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, PageHeight-y, escape(s))
+}
+
+// TextRight draws s so that it ends at x
+func (d *Document) TextRight(x, y, size float64, bold bool, s string) {
+	d.Text(x-TextWidth(s, size, bold), y, size, bold, s)
+}
+
+// Line draws a thin line from (x1, y1) to (x2, y2)
+func (d *Document) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&d.content, "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// JPEG draws a JPEG image with its top-left corner at (x, y), scaled to
+// width and keeping its aspect ratio. It returns the drawn height. A page
+// holds one image; later calls replace it.
+func (d *Document) JPEG(data []byte, x, y, width float64) (float64, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read jpeg: %w", err)
+	}
+	if cfg.Width == 0 || cfg.Height == 0 {
+		return 0, fmt.Errorf("jpeg has no size")
+	}
+	if cfg.ColorModel == color.CMYKModel {
+		return 0, fmt.Errorf("CMYK jpegs are not supported")
+	}
+
+	d.image = &jpegImage{
+		data:   data,
+		width:  cfg.Width,
+		height: cfg.Height,
+		gray:   cfg.ColorModel == color.GrayModel,
+	}
+
+	height := width * float64(cfg.Height) / float64(cfg.Width)
+	fmt.Fprintf(&d.content, "q %.2f 0 0 %.2f %.2f %.2f cm /Im1 Do Q\n", width, height, x, PageHeight-y-height)
+	return height, nil
+}
+
+// Bytes renders the document
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	stream := func(dict string, data []byte) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n<< %s /Length %d >>\nstream\n", len(offsets), dict, len(data))
+		out.Write(data)
+		out.WriteString("\nendstream\nendobj\n")
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	resources := "/Font << /F1 4 0 R /F2 5 0 R >>"
+	if d.image != nil {
+		resources += " /XObject << /Im1 7 0 R >>"
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << %s >> /Contents 6 0 R >>",
+		PageWidth, PageHeight, resources))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	stream("", d.content.Bytes())
+	if d.image != nil {
+		colorSpace := "/DeviceRGB"
+		if d.image.gray {
+			colorSpace = "/DeviceGray"
+		}
+		stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode",
+			d.image.width, d.image.height, colorSpace), d.image.data)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// TextWidth approximates the width of s in points. Helvetica averages about
+// half an em per character, a little more in bold.
+func TextWidth(s string, size float64, bold bool) float64 {
+	factor := 0.5
+	if bold {
+		factor = 0.55
+	}
+	return float64(len([]rune(s))) * size * factor
+}
+
+// escape encodes s as a PDF literal string in Windows-1252
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7F:
+			b.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			// Latin-1 supplement (accents, ñ) matches Windows-1252
+			fmt.Fprintf(&b, "\\%03o", r)
+		case r == '€':
+			b.WriteString("\\200")
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
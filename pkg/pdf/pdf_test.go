@@ -0,0 +1,181 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// parsed is a document read back through its cross-reference table
+type parsed struct {
+	objects map[int]string
+	streams map[int][]byte
+}
+
+// parse reads doc the way a viewer does: it follows startxref to the
+// cross-reference table and loads every object from its recorded offset
+func parse(t *testing.T, doc []byte) *parsed {
+	t.Helper()
+
+	if !bytes.HasPrefix(doc, []byte("%PDF-1.4\n")) {
+		t.Fatalf("missing PDF header")
+	}
+	if !bytes.HasSuffix(doc, []byte("%%EOF\n")) {
+		t.Fatalf("missing EOF marker")
+	}
+
+	trailer := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(doc)
+	if trailer == nil {
+		t.Fatalf("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(trailer[1]))
+	if !bytes.HasPrefix(doc[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+
+	lines := strings.Split(string(doc[xref:]), "\n")
+	var first, count int
+	if _, err := fmt.Sscan(lines[1], &first, &count); err != nil || first != 0 {
+		t.Fatalf("bad xref subsection %q", lines[1])
+	}
+	size := regexp.MustCompile(`/Size (\d+)`).FindStringSubmatch(string(doc[xref:]))
+	if size == nil || size[1] != strconv.Itoa(count) {
+		t.Fatalf("trailer size %v does not match %d xref entries", size, count)
+	}
+
+	p := &parsed{objects: map[int]string{}, streams: map[int][]byte{}}
+	for n := 1; n < count; n++ {
+		entry := lines[2+n]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("bad xref entry %d: %q", n, entry)
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+
+		header := strconv.Itoa(n) + " 0 obj\n"
+		if !bytes.HasPrefix(doc[offset:], []byte(header)) {
+			t.Fatalf("xref offset %d of object %d does not point at it", offset, n)
+		}
+		body := doc[offset+len(header):]
+
+		if m := regexp.MustCompile(`^<< .*/Length (\d+) >>\nstream\n`).FindSubmatch(body); m != nil {
+			length, _ := strconv.Atoi(string(m[1]))
+			data := body[len(m[0]):]
+			if !bytes.HasPrefix(data[length:], []byte("\nendstream\nendobj\n")) {
+				t.Fatalf("stream %d does not end after its /Length of %d bytes", n, length)
+			}
+			p.objects[n] = string(m[0])
+			p.streams[n] = data[:length]
+			continue
+		}
+
+		end := bytes.Index(body, []byte("\nendobj\n"))
+		if end < 0 {
+			t.Fatalf("object %d is not terminated", n)
+		}
+		p.objects[n] = string(body[:end])
+	}
+
+	return p
+}
+
+func TestBytesProducesReadableDocument(t *testing.T) {
+	doc := New()
+	doc.Text(50, 100, 12, false, "Comprobante N° 1 (copia) a\\b")
+	doc.TextRight(545, 120, 10, true, "Total € 100")
+	doc.Line(50, 130, 545, 130)
+
+	p := parse(t, doc.Bytes())
+
+	if len(p.objects) != 6 {
+		t.Fatalf("got %d objects, want 6 without an image", len(p.objects))
+	}
+	if !strings.Contains(p.objects[1], "/Type /Catalog /Pages 2 0 R") {
+		t.Errorf("catalog = %q", p.objects[1])
+	}
+	if !strings.Contains(p.objects[3], "/Contents 6 0 R") || strings.Contains(p.objects[3], "/XObject") {
+		t.Errorf("page = %q", p.objects[3])
+	}
+
+	content := string(p.streams[6])
+	wants := []string{
+		`BT /F1 12.00 Tf 50.00 741.89 Td (Comprobante N\260 1 \(copia\) a\\b) Tj ET`,
+		`/F2 10.00 Tf`,
+		`(Total \200 100) Tj`,
+		`0.5 w 50.00 711.89 m 545.00 711.89 l S`,
+	}
+	for _, want := range wants {
+		if !strings.Contains(content, want) {
+			t.Errorf("content stream missing %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestJPEGEmbedsImage(t *testing.T) {
+	var logo bytes.Buffer
+	if err := jpeg.Encode(&logo, image.NewGray(image.Rect(0, 0, 40, 20)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := New()
+	height, err := doc.JPEG(logo.Bytes(), 50, 50, 120)
+	if err != nil {
+		t.Fatalf("JPEG() error = %v", err)
+	}
+	if height != 60 {
+		t.Errorf("drawn height = %v, want 60", height)
+	}
+
+	p := parse(t, doc.Bytes())
+
+	if !strings.Contains(p.objects[3], "/XObject << /Im1 7 0 R >>") {
+		t.Errorf("page does not reference the image: %q", p.objects[3])
+	}
+	for _, want := range []string{"/Width 40", "/Height 20", "/ColorSpace /DeviceGray", "/Filter /DCTDecode"} {
+		if !strings.Contains(p.objects[7], want) {
+			t.Errorf("image dictionary missing %q: %q", want, p.objects[7])
+		}
+	}
+	if !bytes.Equal(p.streams[7], logo.Bytes()) {
+		t.Errorf("image stream does not hold the JPEG data")
+	}
+	if !strings.Contains(string(p.streams[6]), "q 120.00 0 0 60.00 50.00 731.89 cm /Im1 Do Q") {
+		t.Errorf("content stream does not draw the image: %s", p.streams[6])
+	}
+}
+
+func TestJPEGRejectsInvalidData(t *testing.T) {
+	doc := New()
+	if _, err := doc.JPEG([]byte("not a jpeg"), 0, 0, 100); err == nil {
+		t.Fatal("JPEG() accepted invalid data")
+	}
+
+	p := parse(t, doc.Bytes())
+	if _, ok := p.objects[7]; ok {
+		t.Error("document embeds an image after a failed JPEG()")
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Agro Mas", "Agro Mas"},
+		{"(a)", `\(a\)`},
+		{`c:\x`, `c:\\x`},
+		{"Ñandú", `\321and\372`},
+		{"€", `\200`},
+		{"日本", "??"},
+	}
+
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
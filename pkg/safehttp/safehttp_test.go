@@ -0,0 +1,52 @@
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00:ec2::254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPublicIP(net.ParseIP(tt.ip)); got != tt.public {
+			t.Errorf("IsPublicIP(%s) = %v, want %v", tt.ip, got, tt.public)
+		}
+	}
+}
+
+func TestCheckHostRejectsInternalLiterals(t *testing.T) {
+	for _, host := range []string{"169.254.169.254", "127.0.0.1", "10.0.0.5", "::1"} {
+		if err := CheckHost(context.Background(), host); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("expected %s to be rejected, got %v", host, err)
+		}
+	}
+}
+
+func TestDialControlRejectsInternalAddresses(t *testing.T) {
+	if err := dialControl("tcp", "169.254.169.254:443", nil); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("expected metadata address to be refused, got %v", err)
+	}
+	if err := dialControl("tcp", "8.8.8.8:443", nil); err != nil {
+		t.Errorf("expected public address to be allowed, got %v", err)
+	}
+}
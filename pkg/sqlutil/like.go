@@ -0,0 +1,12 @@
+// Package sqlutil holds helpers for building SQL from user input
+package sqlutil
+
+import "strings"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// EscapeLike escapes the LIKE wildcards and the escape character itself, so
+// user text matches literally in LIKE and ILIKE patterns
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,21 @@
+package sqlutil
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"soja", "soja"},
+		{"100%", `100\%`},
+		{"a_b", `a\_b`},
+		{`c:\tmp`, `c:\\tmp`},
+	}
+
+	for _, tt := range tests {
+		if got := EscapeLike(tt.in); got != tt.want {
+			t.Errorf("EscapeLike(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
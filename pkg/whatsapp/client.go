@@ -8,8 +8,9 @@ import (
 )
 
 type Client struct {
-	apiURL         string
-	businessNumber string
+	apiURL          string
+	businessNumber  string
+	provinceNumbers map[string]string
 }
 
 type MessageTemplate struct {
@@ -20,6 +21,7 @@ type MessageTemplate struct {
 	BuyerName       string
 	InquiryType     string
 	CustomMessage   string
+	Language        string // selects the stored template, defaults to DefaultTemplateLanguage
 }
 
 type ClickToCallConfig struct {
@@ -29,11 +31,29 @@ type ClickToCallConfig struct {
 
 func NewClient(apiURL, businessNumber string) *Client {
 	return &Client{
-		apiURL:         apiURL,
-		businessNumber: businessNumber,
+		apiURL:          apiURL,
+		businessNumber:  businessNumber,
+		provinceNumbers: make(map[string]string),
 	}
 }
 
+// SetProvinceNumbers configures region-specific business numbers keyed by province name
+func (c *Client) SetProvinceNumbers(numbers map[string]string) {
+	c.provinceNumbers = make(map[string]string, len(numbers))
+	for province, number := range numbers {
+		c.provinceNumbers[normalizeProvince(province)] = number
+	}
+}
+
+// BusinessNumberFor returns the business number that serves a province,
+// falling back to the global business number when no mapping exists
+func (c *Client) BusinessNumberFor(province string) string {
+	if number, exists := c.provinceNumbers[normalizeProvince(province)]; exists && number != "" {
+		return number
+	}
+	return c.businessNumber
+}
+
 // GenerateWhatsAppURL generates a WhatsApp click-to-chat URL
 func (c *Client) GenerateWhatsAppURL(phoneNumber, message string) (string, error) {
 	// Clean and validate phone number
@@ -156,6 +176,10 @@ func (c *Client) buildBusinessContactMessage(businessName, inquiryType string) s
 }
 
 // Helper functions
+func normalizeProvince(province string) string {
+	return strings.ToLower(strings.TrimSpace(province))
+}
+
 func (c *Client) cleanPhoneNumber(phoneNumber string) (string, error) {
 	// Remove all non-digit characters
 	reg := regexp.MustCompile(`\D`)
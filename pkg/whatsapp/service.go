@@ -2,16 +2,40 @@ package whatsapp
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
+	"agro-mas-backend/internal/audit"
+	"agro-mas-backend/pkg/logger"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrProductNotOwned is returned when a bulk request lists another seller's product
+	ErrProductNotOwned = errors.New("product does not belong to the user")
+	// ErrSellerHasNoPhone is returned when a product link should reach the
+	// seller directly but the seller has no phone on file
+	ErrSellerHasNoPhone = errors.New("seller has no phone number; use the platform number instead")
 )
 
 type Service struct {
 	client *Client
 	db     *sql.DB
+
+	// Webhook credentials, see SetWebhookCredentials
+	webhookVerifyToken string
+	webhookAppSecret   string
+
+	// Key for click IP hashes, see SetClickHashSecret
+	clickHashKey []byte
+
+	audit *audit.Recorder
 }
 
 type WhatsAppLink struct {
@@ -35,14 +59,40 @@ type WhatsAppLink struct {
 }
 
 type CreateLinkRequest struct {
-	ProductID       *uuid.UUID `json:"product_id,omitempty"`
-	TransactionID   *uuid.UUID `json:"transaction_id,omitempty"`
-	InquiryID       *uuid.UUID `json:"inquiry_id,omitempty"`
-	ToUserID        uuid.UUID  `json:"to_user_id"`
-	PhoneNumber     string     `json:"phone_number"`
-	LinkType        string     `json:"link_type"`
-	MessageTemplate MessageTemplate `json:"message_template"`
-	ExpirationHours int        `json:"expiration_hours,omitempty"` // 0 means no expiration
+	ProductID         *uuid.UUID      `json:"product_id,omitempty"`
+	TransactionID     *uuid.UUID      `json:"transaction_id,omitempty"`
+	InquiryID         *uuid.UUID      `json:"inquiry_id,omitempty"`
+	ToUserID          uuid.UUID       `json:"to_user_id"`
+	PhoneNumber       string          `json:"phone_number"`
+	Province          string          `json:"province,omitempty"`            // Selects the regional business number
+	UsePlatformNumber bool            `json:"use_platform_number,omitempty"` // Route through the platform instead of the seller's own number
+	LinkType          string          `json:"link_type"`
+	MessageTemplate   MessageTemplate `json:"message_template"`
+	ExpirationHours   int             `json:"expiration_hours,omitempty"` // 0 means no expiration
+}
+
+// MaxBulkLinks caps how many links a single bulk request may create
+const MaxBulkLinks = 50
+
+// BulkCreateLinksRequest creates one link per product sharing the same target and template
+type BulkCreateLinksRequest struct {
+	ProductIDs        []uuid.UUID     `json:"product_ids" binding:"required,min=1"`
+	ToUserID          uuid.UUID       `json:"to_user_id"`
+	PhoneNumber       string          `json:"phone_number"`
+	Province          string          `json:"province,omitempty"`
+	UsePlatformNumber bool            `json:"use_platform_number,omitempty"`
+	LinkType          string          `json:"link_type"`
+	MessageTemplate   MessageTemplate `json:"message_template"`
+	ExpirationHours   int             `json:"expiration_hours,omitempty"`
+}
+
+// LinkPreview is the fully rendered content of a link that has not been saved
+type LinkPreview struct {
+	PhoneNumber string `json:"phone_number"`
+	Message     string `json:"message"`
+	WhatsAppURL string `json:"whatsapp_url"`
+	DeepLink    string `json:"deep_link"`
+	WebLink     string `json:"web_link"`
 }
 
 func NewService(client *Client, db *sql.DB) *Service {
@@ -52,42 +102,22 @@ func NewService(client *Client, db *sql.DB) *Service {
 	}
 }
 
-// CreateWhatsAppLink creates a new WhatsApp communication link
-func (s *Service) CreateWhatsAppLink(ctx context.Context, fromUserID uuid.UUID, req CreateLinkRequest) (*WhatsAppLink, error) {
-	// Validate phone number
-	if err := s.client.ValidatePhoneNumber(req.PhoneNumber); err != nil {
-		return nil, fmt.Errorf("invalid phone number: %w", err)
-	}
-
-	// Build message based on link type
-	var message string
-	var err error
-
-	switch req.LinkType {
-	case "inquiry":
-		message = s.client.buildProductInquiryMessage(req.MessageTemplate)
-	case "transaction":
-		message = s.client.buildTransactionMessage(req.MessageTemplate)
-	case "business":
-		message = s.client.buildBusinessContactMessage(req.MessageTemplate.SellerName, req.MessageTemplate.InquiryType)
-	default:
-		return nil, fmt.Errorf("invalid link type: %s", req.LinkType)
-	}
-
-	// Generate WhatsApp URLs
-	whatsappURL, err := s.client.GenerateWhatsAppURL(req.PhoneNumber, message)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate WhatsApp URL: %w", err)
-	}
+// SetAuditRecorder records template changes made by admins
+func (s *Service) SetAuditRecorder(recorder *audit.Recorder) {
+	s.audit = recorder
+}
 
-	deepLink, err := s.client.GenerateDeepLink(req.PhoneNumber, message)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate deep link: %w", err)
-	}
+// SetClickHashSecret sets the key click IPs are hashed with, so stored hashes
+// cannot be reversed by hashing every address
+func (s *Service) SetClickHashSecret(secret string) {
+	s.clickHashKey = []byte(secret)
+}
 
-	webLink, err := s.client.GenerateWebLink(req.PhoneNumber, message)
+// CreateWhatsAppLink creates a new WhatsApp communication link
+func (s *Service) CreateWhatsAppLink(ctx context.Context, fromUserID uuid.UUID, req CreateLinkRequest) (*WhatsAppLink, error) {
+	preview, err := s.PreviewLink(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate web link: %w", err)
+		return nil, err
 	}
 
 	// Calculate expiration
@@ -105,11 +135,11 @@ func (s *Service) CreateWhatsAppLink(ctx context.Context, fromUserID uuid.UUID,
 		InquiryID:     req.InquiryID,
 		FromUserID:    fromUserID,
 		ToUserID:      req.ToUserID,
-		PhoneNumber:   req.PhoneNumber,
-		Message:       message,
-		WhatsAppURL:   whatsappURL,
-		DeepLink:      deepLink,
-		WebLink:       webLink,
+		PhoneNumber:   preview.PhoneNumber,
+		Message:       preview.Message,
+		WhatsAppURL:   preview.WhatsAppURL,
+		DeepLink:      preview.DeepLink,
+		WebLink:       preview.WebLink,
 		LinkType:      req.LinkType,
 		Status:        "created",
 		ClickCount:    0,
@@ -118,13 +148,170 @@ func (s *Service) CreateWhatsAppLink(ctx context.Context, fromUserID uuid.UUID,
 	}
 
 	// Save to database
-	if err := s.saveWhatsAppLink(ctx, link); err != nil {
+	if err := saveWhatsAppLink(ctx, s.db, link); err != nil {
 		return nil, fmt.Errorf("failed to save WhatsApp link: %w", err)
 	}
 
 	return link, nil
 }
 
+// CreateBulkWhatsAppLinks validates and creates a link per product inside one
+// transaction. Every product must belong to fromUserID.
+func (s *Service) CreateBulkWhatsAppLinks(ctx context.Context, fromUserID uuid.UUID, req BulkCreateLinksRequest) ([]*WhatsAppLink, error) {
+	if len(req.ProductIDs) == 0 {
+		return nil, fmt.Errorf("at least one product ID is required")
+	}
+	if len(req.ProductIDs) > MaxBulkLinks {
+		return nil, fmt.Errorf("too many products: maximum is %d per request", MaxBulkLinks)
+	}
+
+	var expiresAt *time.Time
+	if req.ExpirationHours > 0 {
+		expiry := time.Now().Add(time.Duration(req.ExpirationHours) * time.Hour)
+		expiresAt = &expiry
+	}
+
+	owned, err := s.ownedProducts(ctx, fromUserID, req.ProductIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Render every link first so a single invalid product fails the whole batch
+	seen := make(map[uuid.UUID]bool, len(req.ProductIDs))
+	links := make([]*WhatsAppLink, 0, len(req.ProductIDs))
+	for _, productID := range req.ProductIDs {
+		if seen[productID] {
+			continue
+		}
+		seen[productID] = true
+
+		if !owned[productID] {
+			return nil, fmt.Errorf("product %s: %w", productID, ErrProductNotOwned)
+		}
+
+		// Product fields come from each product, not from the shared template
+		template := req.MessageTemplate
+		template.ProductTitle = ""
+		template.ProductCategory = ""
+		template.ProductPrice = ""
+
+		productID := productID
+		preview, err := s.PreviewLink(ctx, CreateLinkRequest{
+			ProductID:         &productID,
+			ToUserID:          req.ToUserID,
+			PhoneNumber:       req.PhoneNumber,
+			Province:          req.Province,
+			UsePlatformNumber: req.UsePlatformNumber,
+			LinkType:          req.LinkType,
+			MessageTemplate:   template,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("product %s: %w", productID, err)
+		}
+
+		links = append(links, &WhatsAppLink{
+			ID:          uuid.New(),
+			ProductID:   &productID,
+			FromUserID:  fromUserID,
+			ToUserID:    req.ToUserID,
+			PhoneNumber: preview.PhoneNumber,
+			Message:     preview.Message,
+			WhatsAppURL: preview.WhatsAppURL,
+			DeepLink:    preview.DeepLink,
+			WebLink:     preview.WebLink,
+			LinkType:    req.LinkType,
+			Status:      "created",
+			ClickCount:  0,
+			ExpiresAt:   expiresAt,
+			CreatedAt:   time.Now(),
+		})
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, link := range links {
+		if err := saveWhatsAppLink(ctx, tx, link); err != nil {
+			return nil, fmt.Errorf("failed to save WhatsApp link: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit WhatsApp links: %w", err)
+	}
+
+	return links, nil
+}
+
+// PreviewLink renders the message and URLs a link would have, without
+// persisting anything. Product links are routed by the product's province and,
+// unless the platform number is requested, go to the seller's own phone.
+func (s *Service) PreviewLink(ctx context.Context, req CreateLinkRequest) (*LinkPreview, error) {
+	// Fill in real product data for anything the template leaves empty
+	var product *linkProduct
+	if req.ProductID != nil {
+		var err error
+		product, err = s.fillProductData(ctx, *req.ProductID, &req.MessageTemplate)
+		if err != nil {
+			return nil, err
+		}
+		if product.Province != "" {
+			req.Province = product.Province
+		}
+	}
+
+	switch {
+	case req.UsePlatformNumber || (req.PhoneNumber == "" && product == nil):
+		req.PhoneNumber = s.client.BusinessNumberFor(req.Province)
+		if req.PhoneNumber == "" {
+			return nil, fmt.Errorf("no WhatsApp business number configured")
+		}
+	case req.PhoneNumber == "":
+		if product.SellerPhone == "" {
+			return nil, ErrSellerHasNoPhone
+		}
+		req.PhoneNumber = product.SellerPhone
+	}
+
+	// Validate phone number
+	if err := s.client.ValidatePhoneNumber(req.PhoneNumber); err != nil {
+		return nil, fmt.Errorf("invalid phone number: %w", err)
+	}
+
+	// Build message from the configured template for the link type, or the built-in copy
+	message, err := s.renderMessage(ctx, req.LinkType, req.MessageTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate WhatsApp URLs
+	whatsappURL, err := s.client.GenerateWhatsAppURL(req.PhoneNumber, message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate WhatsApp URL: %w", err)
+	}
+
+	deepLink, err := s.client.GenerateDeepLink(req.PhoneNumber, message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate deep link: %w", err)
+	}
+
+	webLink, err := s.client.GenerateWebLink(req.PhoneNumber, message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate web link: %w", err)
+	}
+
+	return &LinkPreview{
+		PhoneNumber: req.PhoneNumber,
+		Message:     message,
+		WhatsAppURL: whatsappURL,
+		DeepLink:    deepLink,
+		WebLink:     webLink,
+	}, nil
+}
+
 // GetWhatsAppLink retrieves a WhatsApp link by ID
 func (s *Service) GetWhatsAppLink(ctx context.Context, linkID uuid.UUID) (*WhatsAppLink, error) {
 	query := `
@@ -152,8 +339,23 @@ func (s *Service) GetWhatsAppLink(ctx context.Context, linkID uuid.UUID) (*Whats
 	return link, nil
 }
 
-// TrackLinkClick tracks when a WhatsApp link is clicked
-func (s *Service) TrackLinkClick(ctx context.Context, linkID uuid.UUID) error {
+// ClickDedupWindow is how long repeated clicks from the same IP on a link are
+// treated as one, so prefetching browsers and bots do not inflate analytics
+const ClickDedupWindow = 10 * time.Minute
+
+// maxClickUserAgentLength bounds the user agent stored with each click
+const maxClickUserAgentLength = 512
+
+// ClickInfo is the coarse information recorded about whoever clicked a link
+type ClickInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// TrackLinkClick records a click on a WhatsApp link. Every click is stored
+// with a hash of the client IP, but the link's click count only grows once
+// per IP within ClickDedupWindow.
+func (s *Service) TrackLinkClick(ctx context.Context, linkID uuid.UUID, info ClickInfo) error {
 	// Check if link exists and is not expired
 	link, err := s.GetWhatsAppLink(ctx, linkID)
 	if err != nil {
@@ -169,15 +371,29 @@ func (s *Service) TrackLinkClick(ctx context.Context, linkID uuid.UUID) error {
 		return fmt.Errorf("WhatsApp link has expired")
 	}
 
-	// Update click count and timestamp
+	userAgent := info.UserAgent
+	if len(userAgent) > maxClickUserAgentLength {
+		userAgent = userAgent[:maxClickUserAgentLength]
+	}
+
+	// Record the click, counting it only if the IP has no counted click in the window
 	query := `
+		WITH click AS (
+			INSERT INTO whatsapp_link_clicks (link_id, ip_hash, user_agent, counted)
+			SELECT $1, $2, NULLIF($3, ''), NOT EXISTS (
+				SELECT 1 FROM whatsapp_link_clicks
+				WHERE link_id = $1 AND ip_hash = $2 AND counted
+				AND created_at > NOW() - make_interval(secs => $4)
+			)
+			RETURNING counted
+		)
 		UPDATE whatsapp_links 
 		SET click_count = click_count + 1, 
 			last_clicked_at = NOW(),
 			status = CASE WHEN status = 'created' THEN 'clicked' ELSE status END
-		WHERE id = $1`
+		WHERE id = $1 AND (SELECT counted FROM click)`
 
-	_, err = s.db.ExecContext(ctx, query, linkID)
+	_, err = s.db.ExecContext(ctx, query, linkID, s.hashClickIP(info.IP), userAgent, ClickDedupWindow.Seconds())
 	if err != nil {
 		return fmt.Errorf("failed to track link click: %w", err)
 	}
@@ -185,6 +401,13 @@ func (s *Service) TrackLinkClick(ctx context.Context, linkID uuid.UUID) error {
 	return nil
 }
 
+// hashClickIP keeps clicks from the same address comparable without storing the address
+func (s *Service) hashClickIP(ip string) string {
+	mac := hmac.New(sha256.New, s.clickHashKey)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 // GetUserWhatsAppLinks retrieves WhatsApp links for a user
 func (s *Service) GetUserWhatsAppLinks(ctx context.Context, userID uuid.UUID, linkType string, limit int) ([]*WhatsAppLink, error) {
 	query := `
@@ -254,7 +477,7 @@ func (s *Service) ExpireOldLinks(ctx context.Context) error {
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected > 0 {
-		fmt.Printf("Expired %d WhatsApp links\n", rowsAffected)
+		logger.FromContext(ctx).Info("expired whatsapp links", "count", rowsAffected)
 	}
 
 	return nil
@@ -281,14 +504,18 @@ func (s *Service) GetLinkStats(ctx context.Context, userID *uuid.UUID, fromDate,
 			COUNT(CASE WHEN link_type = 'transaction' THEN 1 END) as transaction_links,
 			COUNT(CASE WHEN link_type = 'business' THEN 1 END) as business_links,
 			COALESCE(SUM(click_count), 0) as total_clicks,
-			COALESCE(AVG(click_count), 0) as avg_clicks_per_link
-		FROM whatsapp_links %s`, whereClause)
+			COALESCE(AVG(click_count), 0) as avg_clicks_per_link,
+			(
+				SELECT COUNT(DISTINCT (link_id, ip_hash)) FROM whatsapp_link_clicks
+				WHERE link_id IN (SELECT id FROM whatsapp_links %s)
+			) as unique_clicks
+		FROM whatsapp_links %s`, whereClause, whereClause)
 
 	stats := &LinkStats{}
 	err := s.db.QueryRowContext(ctx, query, args...).Scan(
 		&stats.TotalLinks, &stats.ClickedLinks, &stats.ExpiredLinks,
 		&stats.InquiryLinks, &stats.TransactionLinks, &stats.BusinessLinks,
-		&stats.TotalClicks, &stats.AvgClicksPerLink)
+		&stats.TotalClicks, &stats.AvgClicksPerLink, &stats.UniqueClicks)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get link stats: %w", err)
@@ -302,8 +529,70 @@ func (s *Service) GetLinkStats(ctx context.Context, userID *uuid.UUID, fromDate,
 	return stats, nil
 }
 
+// GetSellerLinkLeaderboard ranks the seller's products by the WhatsApp contact
+// they generated over the date range. Clicks are the counted clicks made in
+// the range, whenever their link was created; the click rate is over the links
+// created or clicked in the range. sortBy is "clicks" (default) or "ctr".
+func (s *Service) GetSellerLinkLeaderboard(ctx context.Context, sellerID uuid.UUID, fromDate, toDate time.Time, sortBy string, limit int) ([]*ProductLinkPerformance, error) {
+	orderBy := "total_clicks DESC, click_rate DESC"
+	if sortBy == "ctr" {
+		orderBy = "click_rate DESC, total_clicks DESC"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT product_id, title, links_created, links_clicked, total_clicks, click_rate, last_clicked_at
+		FROM (
+			SELECT 
+				p.id as product_id,
+				p.title,
+				COUNT(*) FILTER (WHERE l.created_at >= $2 AND l.created_at <= $3) as links_created,
+				COUNT(lc.link_id) as links_clicked,
+				COALESCE(SUM(lc.clicks), 0) as total_clicks,
+				COUNT(lc.link_id)::float / COUNT(*) * 100 as click_rate,
+				MAX(lc.last_clicked_at) as last_clicked_at
+			FROM whatsapp_links l
+			JOIN products p ON p.id = l.product_id
+			LEFT JOIN (
+				SELECT link_id, COUNT(*) as clicks, MAX(created_at) as last_clicked_at
+				FROM whatsapp_link_clicks
+				WHERE counted AND created_at >= $2 AND created_at <= $3
+				GROUP BY link_id
+			) lc ON lc.link_id = l.id
+			WHERE p.user_id = $1
+			AND ((l.created_at >= $2 AND l.created_at <= $3) OR lc.link_id IS NOT NULL)
+			GROUP BY p.id, p.title
+		) ranked
+		ORDER BY %s
+		LIMIT $4`, orderBy)
+
+	rows, err := s.db.QueryContext(ctx, query, sellerID, fromDate, toDate, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	leaderboard := make([]*ProductLinkPerformance, 0)
+	for rows.Next() {
+		entry := &ProductLinkPerformance{}
+		if err := rows.Scan(&entry.ProductID, &entry.Title, &entry.LinksCreated, &entry.LinksClicked,
+			&entry.TotalClicks, &entry.ClickRate, &entry.LastClickedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan link leaderboard: %w", err)
+		}
+		entry.Rank = len(leaderboard) + 1
+		leaderboard = append(leaderboard, entry)
+	}
+
+	return leaderboard, rows.Err()
+}
+
 // Helper functions
-func (s *Service) saveWhatsAppLink(ctx context.Context, link *WhatsAppLink) error {
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func saveWhatsAppLink(ctx context.Context, db execer, link *WhatsAppLink) error {
 	query := `
 		INSERT INTO whatsapp_links (
 			id, product_id, transaction_id, inquiry_id, from_user_id, to_user_id,
@@ -311,7 +600,7 @@ func (s *Service) saveWhatsAppLink(ctx context.Context, link *WhatsAppLink) erro
 			status, click_count, expires_at, created_at
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
 
-	_, err := s.db.ExecContext(ctx, query,
+	_, err := db.ExecContext(ctx, query,
 		link.ID, link.ProductID, link.TransactionID, link.InquiryID,
 		link.FromUserID, link.ToUserID, link.PhoneNumber, link.Message,
 		link.WhatsAppURL, link.DeepLink, link.WebLink, link.LinkType,
@@ -320,6 +609,71 @@ func (s *Service) saveWhatsAppLink(ctx context.Context, link *WhatsAppLink) erro
 	return err
 }
 
+// linkProduct is the routing information of the product a link is about
+type linkProduct struct {
+	Province    string
+	SellerPhone string
+}
+
+// fillProductData completes empty template fields with the product's current
+// data and returns where links about it should be routed
+func (s *Service) fillProductData(ctx context.Context, productID uuid.UUID, template *MessageTemplate) (*linkProduct, error) {
+	query := `
+		SELECT p.title, p.category, p.price, p.currency, COALESCE(p.seller_name, ''),
+			   COALESCE(p.province, ''), COALESCE(NULLIF(u.phone, ''), p.seller_phone, '')
+		FROM products p
+		LEFT JOIN users u ON u.id = p.user_id
+		WHERE p.id = $1`
+
+	var title, category, currency, sellerName string
+	var price sql.NullFloat64
+	product := &linkProduct{}
+	err := s.db.QueryRowContext(ctx, query, productID).Scan(&title, &category, &price, &currency, &sellerName,
+		&product.Province, &product.SellerPhone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product not found")
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	if template.ProductTitle == "" {
+		template.ProductTitle = title
+	}
+	if template.ProductCategory == "" {
+		template.ProductCategory = category
+	}
+	if template.ProductPrice == "" && price.Valid {
+		template.ProductPrice = fmt.Sprintf("%s %.2f", currency, price.Float64)
+	}
+	if template.SellerName == "" {
+		template.SellerName = sellerName
+	}
+
+	return product, nil
+}
+
+// ownedProducts returns which of productIDs belong to userID and are not deleted
+func (s *Service) ownedProducts(ctx context.Context, userID uuid.UUID, productIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id FROM products
+		WHERE id = ANY($1) AND user_id = $2 AND deleted_at IS NULL`, pq.Array(productIDs), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check product ownership: %w", err)
+	}
+	defer rows.Close()
+
+	owned := make(map[uuid.UUID]bool, len(productIDs))
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		owned[id] = true
+	}
+	return owned, rows.Err()
+}
+
 func (s *Service) updateLinkStatus(ctx context.Context, linkID uuid.UUID, status string) error {
 	query := `UPDATE whatsapp_links SET status = $1 WHERE id = $2`
 	_, err := s.db.ExecContext(ctx, query, status, linkID)
@@ -368,7 +722,20 @@ type LinkStats struct {
 	InquiryLinks       int     `json:"inquiry_links"`
 	TransactionLinks   int     `json:"transaction_links"`
 	BusinessLinks      int     `json:"business_links"`
-	TotalClicks        int     `json:"total_clicks"`
+	TotalClicks        int     `json:"total_clicks"`  // clicks counted after deduplication
+	UniqueClicks       int     `json:"unique_clicks"` // distinct visitors per link
 	AvgClicksPerLink   float64 `json:"avg_clicks_per_link"`
 	ClickRate          float64 `json:"click_rate"` // percentage
-}
\ No newline at end of file
+}
+
+// ProductLinkPerformance is a seller's product ranked by WhatsApp contact
+type ProductLinkPerformance struct {
+	Rank          int        `json:"rank"`
+	ProductID     uuid.UUID  `json:"product_id"`
+	Title         string     `json:"title"`
+	LinksCreated  int        `json:"links_created"`
+	LinksClicked  int        `json:"links_clicked"`
+	TotalClicks   int        `json:"total_clicks"`
+	ClickRate     float64    `json:"click_rate"` // percentage of links clicked
+	LastClickedAt *time.Time `json:"last_clicked_at,omitempty"`
+}
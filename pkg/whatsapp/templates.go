@@ -0,0 +1,323 @@
+package whatsapp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"agro-mas-backend/internal/audit"
+	"agro-mas-backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("WhatsApp template not found")
+	ErrInvalidTemplate  = errors.New("invalid WhatsApp template")
+)
+
+// DefaultTemplateLanguage is used when a link does not ask for a language
+const DefaultTemplateLanguage = "es"
+
+// templateLanguages are the locales templates may be written in, matching
+// the languages users can choose in their preferences
+var templateLanguages = []string{DefaultTemplateLanguage, "en"}
+
+// Placeholders a template body may use, e.g. "Hola {{seller_name}}"
+var templatePlaceholders = []string{
+	"product_title", "product_category", "product_price", "seller_name",
+	"buyer_name", "inquiry_type", "custom_message",
+}
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+
+// Template is stored message copy for one link type and language
+type Template struct {
+	ID        uuid.UUID `json:"id"`
+	LinkType  string    `json:"link_type"`
+	Language  string    `json:"language"`
+	Body      string    `json:"body"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateTemplateRequest struct {
+	LinkType string `json:"link_type" binding:"required,oneof=inquiry transaction business"`
+	Language string `json:"language,omitempty"`
+	Body     string `json:"body" binding:"required"`
+	IsActive *bool  `json:"is_active,omitempty"` // defaults to true
+}
+
+type UpdateTemplateRequest struct {
+	Body     *string `json:"body,omitempty"`
+	IsActive *bool   `json:"is_active,omitempty"`
+}
+
+// ValidateTemplateBody rejects bodies that use placeholders the renderer does not know
+func ValidateTemplateBody(body string) error {
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("%w: body is required", ErrInvalidTemplate)
+	}
+	for _, match := range placeholderPattern.FindAllStringSubmatch(body, -1) {
+		if !isTemplatePlaceholder(match[1]) {
+			return fmt.Errorf("%w: unknown placeholder {{%s}}", ErrInvalidTemplate, match[1])
+		}
+	}
+	return nil
+}
+
+// validateTemplateLanguage rejects languages no user can ask for
+func validateTemplateLanguage(language string) error {
+	for _, supported := range templateLanguages {
+		if supported == language {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unsupported language %q, expected one of %s", ErrInvalidTemplate, language, strings.Join(templateLanguages, ", "))
+}
+
+func isTemplatePlaceholder(name string) bool {
+	for _, placeholder := range templatePlaceholders {
+		if placeholder == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderTemplate substitutes placeholders in a single pass, so values that
+// themselves look like placeholders are inserted verbatim rather than expanded
+func (c *Client) RenderTemplate(body, language string, data MessageTemplate) string {
+	category, inquiryType := data.ProductCategory, data.InquiryType
+	if language == DefaultTemplateLanguage {
+		if category != "" {
+			category = c.translateCategory(category)
+		}
+		if inquiryType != "" {
+			inquiryType = c.translateInquiryType(inquiryType)
+		}
+	}
+
+	values := map[string]string{
+		"product_title":    data.ProductTitle,
+		"product_category": category,
+		"product_price":    data.ProductPrice,
+		"seller_name":      data.SellerName,
+		"buyer_name":       data.BuyerName,
+		"inquiry_type":     inquiryType,
+		"custom_message":   data.CustomMessage,
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(body, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// renderMessage builds a link's message from the active template for its
+// type and language, falling back to the built-in copy when none is configured
+func (s *Service) renderMessage(ctx context.Context, linkType string, data MessageTemplate) (string, error) {
+	language := data.Language
+	if language == "" {
+		language = DefaultTemplateLanguage
+	}
+
+	template, err := s.getActiveTemplate(ctx, linkType, language)
+	if err != nil {
+		// Links keep working with the built-in copy if templates cannot be read
+		logger.FromContext(ctx).Warn("failed to load WhatsApp template", "link_type", linkType, "language", language, "error", err)
+	}
+	if template != nil {
+		return s.client.RenderTemplate(template.Body, language, data), nil
+	}
+
+	switch linkType {
+	case "inquiry":
+		return s.client.buildProductInquiryMessage(data), nil
+	case "transaction":
+		return s.client.buildTransactionMessage(data), nil
+	case "business":
+		return s.client.buildBusinessContactMessage(data.SellerName, data.InquiryType), nil
+	}
+	return "", fmt.Errorf("invalid link type: %s", linkType)
+}
+
+const templateColumns = `id, link_type, language, body, is_active, created_at, updated_at`
+
+func scanTemplate(row interface{ Scan(...interface{}) error }) (*Template, error) {
+	template := &Template{}
+	err := row.Scan(&template.ID, &template.LinkType, &template.Language, &template.Body,
+		&template.IsActive, &template.CreatedAt, &template.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// getActiveTemplate returns the active template for a link type and language, or nil
+func (s *Service) getActiveTemplate(ctx context.Context, linkType, language string) (*Template, error) {
+	query := `SELECT ` + templateColumns + ` FROM whatsapp_templates
+		WHERE link_type = $1 AND language = $2 AND is_active`
+
+	template, err := scanTemplate(s.db.QueryRowContext(ctx, query, linkType, language))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get WhatsApp template: %w", err)
+	}
+	return template, nil
+}
+
+// ListTemplates returns every stored template, active ones first
+func (s *Service) ListTemplates(ctx context.Context) ([]*Template, error) {
+	query := `SELECT ` + templateColumns + ` FROM whatsapp_templates
+		ORDER BY link_type, language, is_active DESC, updated_at DESC`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list WhatsApp templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := make([]*Template, 0)
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan WhatsApp template: %w", err)
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, rows.Err()
+}
+
+// CreateTemplate stores a template. An active template replaces the one
+// currently active for the same link type and language.
+func (s *Service) CreateTemplate(ctx context.Context, actorID uuid.UUID, req CreateTemplateRequest) (*Template, error) {
+	if err := ValidateTemplateBody(req.Body); err != nil {
+		return nil, err
+	}
+
+	language := strings.ToLower(strings.TrimSpace(req.Language))
+	if language == "" {
+		language = DefaultTemplateLanguage
+	}
+	if err := validateTemplateLanguage(language); err != nil {
+		return nil, err
+	}
+	isActive := req.IsActive == nil || *req.IsActive
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if isActive {
+		if err := deactivateTemplates(ctx, tx, req.LinkType, language); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+		INSERT INTO whatsapp_templates (link_type, language, body, is_active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + templateColumns
+
+	template, err := scanTemplate(tx.QueryRowContext(ctx, query, req.LinkType, language, req.Body, isActive))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create WhatsApp template: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit WhatsApp template: %w", err)
+	}
+
+	s.audit.Record(ctx, actorID, audit.ActionTemplateCreated, audit.EntityWhatsAppTemplate, template.ID, nil, template)
+
+	return template, nil
+}
+
+// UpdateTemplate changes a template's body or active flag. Activating it
+// deactivates the other template for the same link type and language.
+func (s *Service) UpdateTemplate(ctx context.Context, actorID, id uuid.UUID, req UpdateTemplateRequest) (*Template, error) {
+	if req.Body != nil {
+		if err := ValidateTemplateBody(*req.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := scanTemplate(tx.QueryRowContext(ctx,
+		`SELECT `+templateColumns+` FROM whatsapp_templates WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, fmt.Errorf("failed to get WhatsApp template: %w", err)
+	}
+
+	if req.IsActive != nil && *req.IsActive && !current.IsActive {
+		if err := deactivateTemplates(ctx, tx, current.LinkType, current.Language); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+		UPDATE whatsapp_templates
+		SET body = COALESCE($2, body), is_active = COALESCE($3, is_active), updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + templateColumns
+
+	template, err := scanTemplate(tx.QueryRowContext(ctx, query, id, req.Body, req.IsActive))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update WhatsApp template: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit WhatsApp template: %w", err)
+	}
+
+	s.audit.Record(ctx, actorID, audit.ActionTemplateUpdated, audit.EntityWhatsAppTemplate, id, current, template)
+
+	return template, nil
+}
+
+// DeleteTemplate removes a template; links of its type fall back to the built-in copy
+func (s *Service) DeleteTemplate(ctx context.Context, actorID, id uuid.UUID) error {
+	deleted, err := scanTemplate(s.db.QueryRowContext(ctx,
+		`DELETE FROM whatsapp_templates WHERE id = $1 RETURNING `+templateColumns, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrTemplateNotFound
+		}
+		return fmt.Errorf("failed to delete WhatsApp template: %w", err)
+	}
+
+	s.audit.Record(ctx, actorID, audit.ActionTemplateDeleted, audit.EntityWhatsAppTemplate, id, deleted, nil)
+
+	return nil
+}
+
+func deactivateTemplates(ctx context.Context, tx *sql.Tx, linkType, language string) error {
+	query := `
+		UPDATE whatsapp_templates SET is_active = false, updated_at = NOW()
+		WHERE link_type = $1 AND language = $2 AND is_active`
+	if _, err := tx.ExecContext(ctx, query, linkType, language); err != nil {
+		return fmt.Errorf("failed to deactivate WhatsApp templates: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package whatsapp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidWebhookPayload = errors.New("invalid webhook payload")
+
+// Message delivery states reported by WhatsApp status callbacks, in the
+// order they progress
+const (
+	MessageStatusSent      = "sent"
+	MessageStatusDelivered = "delivered"
+	MessageStatusRead      = "read"
+	MessageStatusFailed    = "failed"
+)
+
+// WebhookPayload is the envelope Meta posts to the webhook
+type WebhookPayload struct {
+	Object string         `json:"object"`
+	Entry  []WebhookEntry `json:"entry"`
+}
+
+type WebhookEntry struct {
+	ID      string          `json:"id"`
+	Changes []WebhookChange `json:"changes"`
+}
+
+type WebhookChange struct {
+	Field string `json:"field"`
+	Value struct {
+		Statuses []WebhookStatus `json:"statuses"`
+	} `json:"value"`
+}
+
+// WebhookStatus is a delivery or read receipt for one outgoing message
+type WebhookStatus struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	Timestamp    string `json:"timestamp"` // unix seconds
+	RecipientID  string `json:"recipient_id"`
+	Conversation *struct {
+		ID string `json:"id"`
+	} `json:"conversation,omitempty"`
+	Errors []struct {
+		Code  int    `json:"code"`
+		Title string `json:"title"`
+	} `json:"errors,omitempty"`
+}
+
+// SetWebhookCredentials configures the token Meta echoes when subscribing the
+// webhook and the app secret it signs callbacks with
+func (s *Service) SetWebhookCredentials(verifyToken, appSecret string) {
+	s.webhookVerifyToken = verifyToken
+	s.webhookAppSecret = appSecret
+}
+
+// VerifySubscription checks the hub.mode and hub.verify_token of Meta's
+// subscription handshake
+func (s *Service) VerifySubscription(mode, token string) bool {
+	if s.webhookVerifyToken == "" || mode != "subscribe" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.webhookVerifyToken)) == 1
+}
+
+// VerifySignature checks the X-Hub-Signature-256 header ("sha256=<hex>")
+// against the HMAC of the raw body. Without a configured secret nothing is trusted.
+func (s *Service) VerifySignature(body []byte, signatureHeader string) bool {
+	if s.webhookAppSecret == "" {
+		return false
+	}
+
+	encoded, ok := strings.CutPrefix(signatureHeader, "sha256=")
+	if !ok {
+		return false
+	}
+	signature, err := hex.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.webhookAppSecret))
+	mac.Write(body)
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+// ProcessWebhook records the message statuses of a verified callback. Statuses
+// only move forward, so late or repeated receipts never downgrade a message.
+func (s *Service) ProcessWebhook(ctx context.Context, body []byte) (int, error) {
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidWebhookPayload, err)
+	}
+
+	processed := 0
+	for _, entry := range payload.Entry {
+		for _, change := range entry.Changes {
+			for _, status := range change.Value.Statuses {
+				if status.ID == "" || messageStatusRank(status.Status) == 0 {
+					continue
+				}
+				if err := s.saveMessageStatus(ctx, status); err != nil {
+					return processed, err
+				}
+				processed++
+			}
+		}
+	}
+
+	return processed, nil
+}
+
+func (s *Service) saveMessageStatus(ctx context.Context, status WebhookStatus) error {
+	statusAt := time.Now()
+	if seconds, err := strconv.ParseInt(status.Timestamp, 10, 64); err == nil {
+		statusAt = time.Unix(seconds, 0)
+	}
+
+	var conversationID *string
+	if status.Conversation != nil && status.Conversation.ID != "" {
+		conversationID = &status.Conversation.ID
+	}
+
+	var errorMessage *string
+	if len(status.Errors) > 0 {
+		message := fmt.Sprintf("%d: %s", status.Errors[0].Code, status.Errors[0].Title)
+		errorMessage = &message
+	}
+
+	query := `
+		INSERT INTO whatsapp_messages (
+			id, transaction_id, conversation_id, recipient_id, status, status_rank,
+			status_at, error_message
+		) VALUES (
+			$1, (SELECT id FROM transactions WHERE whatsapp_thread_id = $2 LIMIT 1),
+			$2, $3, $4, $5, $6, $7
+		)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			status_rank = EXCLUDED.status_rank,
+			status_at = EXCLUDED.status_at,
+			error_message = COALESCE(EXCLUDED.error_message, whatsapp_messages.error_message),
+			updated_at = NOW()
+		WHERE whatsapp_messages.status_rank < EXCLUDED.status_rank`
+
+	_, err := s.db.ExecContext(ctx, query,
+		status.ID, conversationID, status.RecipientID, status.Status,
+		messageStatusRank(status.Status), statusAt, errorMessage)
+	if err != nil {
+		return fmt.Errorf("failed to save message status: %w", err)
+	}
+
+	return nil
+}
+
+// messageStatusRank orders statuses so updates only move forward; unknown
+// statuses rank 0 and are ignored
+func messageStatusRank(status string) int {
+	switch status {
+	case MessageStatusSent:
+		return 1
+	case MessageStatusDelivered:
+		return 2
+	case MessageStatusRead:
+		return 3
+	case MessageStatusFailed:
+		return 4
+	}
+	return 0
+}